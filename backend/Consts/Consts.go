@@ -21,6 +21,7 @@ var (
 	MaxTitleLen = 50
 	MaxContentLen = 300
 	MaxDescriptionLen = 150
+	MaxMarkdownSourceLen = 2000 // 註釋跟敘述含Markdown符號的原文上限(byte)，MaxDefinitionLen/MaxDescriptionLen算的是顯示出來的字數
 	MaxVocabularyLen = 100
 	MaxDefinitionLen = 300
 	MaxUploadSize = 10 << 20 // 10 MB
@@ -32,6 +33,64 @@ var (
 	ActivatedEmailRegisterExpire = 5 * 60 // 5分鐘內完成該email註冊
 )
 
+// 單字的詞性
+const (
+	PartOfSpeechNoun         = "noun"
+	PartOfSpeechVerb         = "verb"
+	PartOfSpeechAdjective    = "adjective"
+	PartOfSpeechAdverb       = "adverb"
+	PartOfSpeechPronoun      = "pronoun"
+	PartOfSpeechPreposition  = "preposition"
+	PartOfSpeechConjunction  = "conjunction"
+	PartOfSpeechInterjection = "interjection"
+	PartOfSpeechPhrase       = "phrase" // 片語、慣用語
+	PartOfSpeechOther        = "other"
+)
+
+var PartsOfSpeech = []string{PartOfSpeechNoun, PartOfSpeechVerb, PartOfSpeechAdjective, PartOfSpeechAdverb, PartOfSpeechPronoun,
+	PartOfSpeechPreposition, PartOfSpeechConjunction, PartOfSpeechInterjection, PartOfSpeechPhrase, PartOfSpeechOther}
+
+var (
+	MaxPronunciationLen = 100
+	MaxMeanings = 10 // 每個單字最多幾個其他意思
+	MaxExamples = 5 // 每個單字最多幾個例句
+	MaxExampleLen = 300 // 例句以及翻譯的字數
+)
+
+// 卡片模板的內建欄位，每個模板都一定要有
+const (
+	TemplateFieldVocabulary = "vocabulary"
+	TemplateFieldDefinition = "definition"
+)
+
+var (
+	MaxTemplateFields = 8 // 含內建欄位
+	MaxTemplateLabelLen = 20
+	MaxFieldValueLen = 300 // 自訂欄位的值
+)
+
+// 單字圖片以及上傳檔案的存放位置
+var MediaDir = os.Getenv("go_quizlet_media_dir") // 本地硬碟的資料夾，沒設定的話是./media
+var MediaBaseURL = os.Getenv("go_quizlet_media_url") // 檔案網址的前綴，沒設定的話是後端的/media
+
+var (
+	MaxWordImageSize = 5 << 20 // 5 MB
+	MaxImageDimension = 6000 // 圖片長寬上限(px)，避免解碼時吃掉太多記憶體
+	MaxImagePixels = 24_000_000 // 圖片總像素上限，解碼後的RGBA大約是4倍的byte數
+	ThumbnailSize = 320 // 縮圖最長邊(px)
+	MaxWordAudioSize = 3 << 20 // 3 MB
+	MaxAudioDuration = 15.0 // 秒，單字發音只要短短的一段
+)
+
+// 單字發音音檔屬於單字的哪一面
+const (
+	AudioSideVocabulary = "vocabulary"
+	AudioSideDefinition = "definition"
+)
+
+// 語言設定檔(JSON陣列)，沒設定的話用內建的語言
+var LanguagesFile = os.Getenv("go_quizlet_languages_file")
+
 var APILimit rate.Limit = 35;
 var APIBurst = 40
 
@@ -39,4 +98,168 @@ var JWTClientID = os.Getenv("JWTClientID")
 
 var ImgurUploadURL = os.Getenv("imgur_upload_img_url")
 var ImgurClientID = os.Getenv("imgur-go-quizlet-clientID")
-var ImgurAccessToken, present = os.LookupEnv("imgur_go_quizlet_accessToken") // 一個月要用postman去更新他
\ No newline at end of file
+var ImgurAccessToken, present = os.LookupEnv("imgur_go_quizlet_accessToken") // 一個月要用postman去更新他
+
+// 個人API token
+const AccessTokenPrefix = "qzp_"
+
+// API token的權限範圍
+const (
+	ScopeWordSetsRead  = "wordsets:read"  // 讀取自己或被邀請協作的非公開單字集
+	ScopeWordSetsWrite = "wordsets:write" // 新增/更改/刪除單字集和單字、收藏、複製(包含wordsets:read)
+	ScopeAccountRead   = "account:read"   // 讀取自己的帳號資料、信件、最近查看
+	ScopeAccountWrite  = "account:write"  // 更改自己的帳號設定、標記信件已讀
+)
+
+var AccessTokenScopes = []string{ScopeWordSetsRead, ScopeWordSetsWrite, ScopeAccountRead, ScopeAccountWrite}
+
+var (
+	MaxAccessTokens = 20 // 每個使用者最多幾個token
+	MaxAccessTokenDays = 365 // token最長有效天數
+	AccessTokenLastUsedInterval = 60 // 秒，lastUsedAt最多隔這麼久才寫一次DB
+)
+
+// Webhook事件
+const (
+	WebhookEventWordSetCreated = "wordset.created"
+	WebhookEventWordSetUpdated = "wordset.updated"
+	WebhookEventWordSetForked  = "wordset.forked"
+	WebhookEventWordSetLiked   = "wordset.liked"
+	WebhookEventWordSetUnliked = "wordset.unliked"
+	WebhookEventUserRegistered = "user.registered" // 只有管理員可以訂閱
+)
+
+var WebhookEvents = []string{WebhookEventWordSetCreated, WebhookEventWordSetUpdated, WebhookEventWordSetForked,
+	WebhookEventWordSetLiked, WebhookEventWordSetUnliked, WebhookEventUserRegistered}
+var AdminWebhookEvents = []string{WebhookEventUserRegistered}
+
+var (
+	MaxWebhooks = 10 // 每個使用者最多幾個webhook
+	WebhookMaxAttempts = 5 // 含第一次，最多送幾次
+	WebhookRetryBaseDelay = 2 * time.Second // 第n次重送前等待 base * 2^(n-1)
+	WebhookTimeout = 10 * time.Second // 單次送出的timeout
+	WebhookAllowPrivateTarget = os.Getenv("go_quizlet_webhook_allow_private") == "true" // 本地開發時允許送到localhost/內網
+)
+
+// 單字集版本紀錄的動作
+const (
+	RevisionActionCreate   = "create"
+	RevisionActionFork     = "fork"
+	RevisionActionEdit     = "edit"
+	RevisionActionRestore  = "restore"
+	RevisionActionPull     = "pull" // 從原始單字集同步變更
+	RevisionActionTransfer = "transfer" // 從別的單字集複製/移動單字進來，或單字被移到別的單字集
+	RevisionActionSplit    = "split" // 拆分單字集，原本的單字集以及拆出來的新單字集都會記錄
+	RevisionActionMerge    = "merge" // 合併多個單字集產生的新單字集
+	RevisionActionBaseline = "baseline" // 開始記錄版本前就存在的單字集，第一次變更前先存一份原本的內容
+)
+
+// 單字集遇到重複單字時的處理方式
+const (
+	DuplicatePolicyWarn   = "warn"   // 預設，照常寫入並在回應附上重複的單字
+	DuplicatePolicyReject = "reject" // 新增/修改後跟其他單字完全相同(正規化後)就拒絕，拼字相近的只提醒
+)
+
+var DuplicatePolicies = []string{DuplicatePolicyWarn, DuplicatePolicyReject}
+
+var MaxFuzzyDuplicateWords = 2000 // 單字數超過這個數量只比對完全相同的單字，不算編輯距離
+
+// 整理單字集(複製/移動單字、拆分、合併)
+var (
+	MaxReorganizeWordSets = 20 // 一次最多從幾個單字集複製/移動單字，或合併幾個單字集
+	MaxSplitParts = 20 // 一個單字集最多拆成幾份
+)
+
+// 跨單字集的複習牌組
+const (
+	ReviewDeckStarred = "starred" // 所有加星號的單字
+	ReviewDeckMissed  = "missed"  // 最近幾天答錯的單字
+	ReviewDeckDue     = "due"     // 到了該複習時間的單字
+)
+
+var ReviewDeckRules = []string{ReviewDeckStarred, ReviewDeckMissed, ReviewDeckDue}
+
+var (
+	DefaultReviewMissedDays = 7 // 沒指定天數時看最近幾天答錯的單字
+	MaxReviewMissedDays = 365
+	MaxReviewDeckWords = 500 // 一個複習牌組最多幾個單字
+	MaxReviewDeckWordSets = 100 // 最多指定幾個單字集
+	MaxStudyResults = 200 // 一次最多回報幾個作答結果
+)
+
+// 答對後隔多久再複習，依答對的次數(盒子)決定，答錯回到第0盒
+var ReviewIntervals = []time.Duration{0, 24 * time.Hour, 3 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// 打字作答的判定結果
+const (
+	GradeCorrect = "correct" // 正規化後跟其中一個答案相同
+	GradeAlmost  = "almost"  // 有容許範圍內的錯字，算對但要提醒
+	GradeWrong   = "wrong"
+)
+
+// 打字作答容許錯字的程度
+const (
+	GradeLeniencyStrict  = "strict"  // 正規化後要完全相同
+	GradeLeniencyNormal  = "normal"  // 預設，5個字元以上容許1個錯字、9個以上容許2個
+	GradeLeniencyLenient = "lenient" // 3個字元以上容許1個錯字、6個以上2個、10個以上3個
+)
+
+var GradeLeniencies = []string{GradeLeniencyStrict, GradeLeniencyNormal, GradeLeniencyLenient}
+
+// Learn模式，單字從選擇題(recognition)答對後升到打字作答(recall)，再答對就算學會(mastered)
+const (
+	LearnStageRecognition = "recognition"
+	LearnStageRecall      = "recall"
+	LearnStageMastered    = "mastered"
+)
+
+var (
+	LearnChoices = 4 // 選擇題的選項數
+	LearnRequeueGap = 3 // 答錯的單字隔幾題再考一次
+)
+
+var MaxWordSetRevisions = 100 // 每個單字集最多保留幾個版本，超過的從最舊的開始刪
+
+// 垃圾桶
+var (
+	TrashRetentionDays = 30 // 刪除的單字集在垃圾桶保留幾天
+	TrashPurgeInterval = time.Hour // 背景清除過期單字集以及失效引用的間隔
+)
+
+// 單字集的權限角色 owner > editor > viewer
+const (
+	WordSetRoleOwner  = "owner"  // 作者本人，只有作者可以管理協作者、刪除單字集、更改公開設定
+	WordSetRoleEditor = "editor" // 可以編輯單字集內容
+	WordSetRoleViewer = "viewer" // 只能查看，包含版本紀錄
+)
+
+var CollaboratorRoles = []string{WordSetRoleEditor, WordSetRoleViewer}
+
+var MaxCollaborators = 20 // 每個單字集最多幾個協作者
+
+// 單字集的公開範圍
+const (
+	VisibilityPrivate  = "private"  // 只有作者跟協作者看得到
+	VisibilityUnlisted = "unlisted" // 作者、協作者以及拿到有效分享連結的人
+	VisibilityPublic   = "public"   // 任何人，會出現在搜尋以及首頁
+)
+
+var Visibilities = []string{VisibilityPrivate, VisibilityUnlisted, VisibilityPublic}
+
+// 分享連結
+const ShareTokenPrefix = "qzs_"
+
+var (
+	MaxShareLinks    = 20  // 每個單字集最多幾個分享連結
+	MaxShareLinkDays = 365 // 分享連結最長有效天數
+)
+
+// 單字集即時協作(WebSocket)
+var (
+	LiveMaxConnections = 50 // 每個單字集同時最多幾條連線
+	LiveReadTimeout = 60 * time.Second // 這段時間內沒收到任何訊息(client要定期送ping)就斷線
+	LiveWriteTimeout = 10 * time.Second
+	LiveMaxMessageSize = 64 << 10 // 64 KB
+	LiveMessageLimit rate.Limit = 20 // 每條連線每秒最多幾則訊息
+	LiveMessageBurst = 40
+)
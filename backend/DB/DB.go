@@ -2,8 +2,8 @@ package DB
 
 import (
 	"context"
-	"fmt"
 	"go-quizlet/Consts"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -28,15 +28,15 @@ func InitDB() {
 		panic(err)
 	}
 	// Send a ping to confirm a successful connection
-	if err := Client.Database("admin").RunCommand(context.Background(), bson.D{{"ping", 1}}).Err(); err != nil {
+	if err := Client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
 		panic(err)
 	}
-	fmt.Println("Pinged your deployment. You successfully connected to MongoDB!")
+	slog.Info("Pinged your deployment. You successfully connected to MongoDB!")
 }
 
 func DisconnectDB() {
 	if err := Client.Disconnect(context.Background()); err != nil {
 		panic(err)
 	}
-	fmt.Println("disconnect DB")
+	slog.Info("disconnect DB")
 }
\ No newline at end of file
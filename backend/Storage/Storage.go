@@ -0,0 +1,58 @@
+package Storage
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"io"
+	"log/slog"
+	"time"
+)
+
+/*
+--------------------------------------------------------------
+檔案儲存(單字圖片等上傳的檔案)
+handler只透過Backend存取，之後要換成S3之類的物件儲存只要再實作一個Backend
+key是類似路徑的字串(例如words/{wordSetID}/{id}.png)，由呼叫端產生且不重複
+--------------------------------------------------------------
+*/
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// 檔案的資訊
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+type Backend interface {
+	// 寫入檔案，同一個key會被覆蓋
+	Put(ctx context.Context, key string, contentType string, body io.Reader) error
+	// 讀取檔案，回傳的reader可以Seek(給http.ServeContent處理Range)，用完要Close
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error)
+	// 刪除檔案，檔案不存在不算錯誤
+	Delete(ctx context.Context, key string) error
+	// 檔案對外的網址
+	URL(key string) string
+}
+
+var Client Backend
+
+func InitStorage() {
+	dir := Consts.MediaDir
+	if dir == "" {
+		dir = "media"
+	}
+	baseURL := Consts.MediaBaseURL
+	if baseURL == "" {
+		baseURL = "/media"
+	}
+	local, err := NewLocal(dir, baseURL)
+	if err != nil {
+		panic(err)
+	}
+	Client = local
+	slog.Info("media storage ready", "dir", dir, "baseURL", baseURL)
+}
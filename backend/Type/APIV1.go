@@ -0,0 +1,230 @@
+package Type
+
+// /api/v1 使用的request/response type
+// v1的回應不包Response envelope，成功直接回資源本身，失敗回MessageDisplayError並帶對應的HTTP status
+// 指標欄位代表PATCH時可以不給(nil就不更新)
+
+// 新增單字時送的格式，id由後端產生
+type V1WordInput struct {
+	Order           int               `json:"order"` // 沒給的話排在最後面
+	Vocabulary      string            `json:"vocabulary" validate:"required"`
+	Definition      string            `json:"definition" validate:"required"`
+	VocabularySound string            `json:"vocabularySound" validate:"required"`
+	DefinitionSound string            `json:"definitionSound" validate:"required"`
+	Star            bool              `json:"star"`
+	Pronunciation   string            `json:"pronunciation"`
+	PartOfSpeech    string            `json:"partOfSpeech"`
+	Meanings        []WordMeaning     `json:"meanings"`
+	Examples        []WordExample     `json:"examples"`
+	Fields          map[string]string `json:"fields"` // 卡片模板自訂欄位的值
+}
+
+// POST /api/v1/wordsets
+type V1CreateWordSetRequest struct {
+	Title           string        `json:"title" validate:"required"`
+	Description     string        `json:"description"`
+	Words           []V1WordInput `json:"words" validate:"required,min=1,dive"`
+	ShouldSwap      bool          `json:"shouldSwap"`
+	AllowCopy       bool          `json:"allowCopy"`
+	IsPublic        bool          `json:"isPublic"`
+	Visibility      string        `json:"visibility" validate:"omitempty,oneof=private unlisted public"` // 沒給的話isPublic為true是public，否則private
+	DuplicatePolicy string        `json:"duplicatePolicy" validate:"omitempty,oneof=warn reject"`        // 沒給是warn
+	Template        *CardTemplate `json:"template"`                                                      // 沒給是預設模板
+}
+
+// PATCH /api/v1/wordsets/{wordSetID}
+type V1UpdateWordSetRequest struct {
+	Title           *string `json:"title,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	ShouldSwap      *bool   `json:"shouldSwap,omitempty"`
+	AllowCopy       *bool   `json:"allowCopy,omitempty"`
+	IsPublic        *bool   `json:"isPublic,omitempty"` // 舊欄位，true是public，false是private
+	Visibility      *string `json:"visibility,omitempty" validate:"omitempty,oneof=private unlisted public"`
+	DuplicatePolicy *string `json:"duplicatePolicy,omitempty" validate:"omitempty,oneof=warn reject"`
+}
+
+// PATCH /api/v1/wordsets/{wordSetID}/words/{wordID}
+type V1UpdateWordRequest struct {
+	Vocabulary      *string           `json:"vocabulary,omitempty"`
+	Definition      *string           `json:"definition,omitempty"`
+	VocabularySound *string           `json:"vocabularySound,omitempty"`
+	DefinitionSound *string           `json:"definitionSound,omitempty"`
+	Order           *int              `json:"order,omitempty"`
+	Star            *bool             `json:"star,omitempty"` // 呼叫者自己的星號
+	Pronunciation   *string           `json:"pronunciation,omitempty"`
+	PartOfSpeech    *string           `json:"partOfSpeech,omitempty"`
+	Meanings        *[]WordMeaning    `json:"meanings,omitempty"` // 給空陣列是清除
+	Examples        *[]WordExample    `json:"examples,omitempty"`
+	Fields          map[string]string `json:"fields,omitempty"` // 有給的自訂欄位才更新，空字串是清除
+}
+
+// PATCH /api/v1/wordsets/{wordSetID}/words，一次設定所有單字的星號(呼叫者自己的)
+type V1UpdateAllWordsRequest struct {
+	Star bool `json:"star"`
+}
+
+// PATCH /api/v1/me
+type V1UpdateMeRequest struct {
+	Name   *string `json:"name,omitempty"`
+	Locale *string `json:"locale,omitempty"` // zh-TW、en，空字串代表依Accept-Language
+}
+
+// POST /api/v1/me/recent-visits
+type V1AddRecentVisitRequest struct {
+	WordSetID string `json:"wordSetID" validate:"required"`
+}
+
+// 新建資源後回傳的ID
+type V1CreatedResponse struct {
+	ID string `json:"id"`
+}
+
+// GET /api/v1/wordsets/{wordSetID}/words
+type V1WordPage struct {
+	Words    []Word `json:"words"`
+	HaveMore bool   `json:"haveMore"`
+}
+
+// GET /api/v1/mails/unread-count
+type V1UnreadCountResponse struct {
+	Count int `json:"count"`
+}
+
+// POST /api/v1/me/tokens
+type V1CreateAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays"` // 0代表不會過期
+}
+
+// 建立token後回傳，token明碼只會出現這一次
+type V1CreateAccessTokenResponse struct {
+	Token       string              `json:"token"`
+	AccessToken PersonalAccessToken `json:"accessToken"`
+}
+
+// POST /api/v1/me/webhooks
+type V1CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required"`
+	Events []string `json:"events" validate:"required,min=1"`
+}
+
+// PATCH /api/v1/me/webhooks/{webhookID}
+type V1UpdateWebhookRequest struct {
+	URL    *string   `json:"url,omitempty"`
+	Events *[]string `json:"events,omitempty"`
+	Active *bool     `json:"active,omitempty"`
+}
+
+// 建立webhook後回傳，secret只會出現這一次
+type V1CreateWebhookResponse struct {
+	Secret  string  `json:"secret"`
+	Webhook Webhook `json:"webhook"`
+}
+
+// GET /api/v1/me/trash
+type V1TrashWordSet struct {
+	ID        string `json:"id" bson:"id"`
+	Title     string `json:"title" bson:"title"`
+	WordCnt   int    `json:"wordCnt" bson:"wordCnt"`
+	DeletedAt int64  `json:"deletedAt" bson:"deletedAt"`
+	PurgeAt   int64  `json:"purgeAt" bson:"purgeAt"`
+}
+
+// PUT /api/v1/wordsets/{wordSetID}/collaborators/{userID}
+type V1PutCollaboratorRequest struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+// GET /api/v1/me/shared-wordsets
+type V1SharedWordSet struct {
+	ID        string `json:"id" bson:"id"`
+	Title     string `json:"title" bson:"title"`
+	AuthorID  string `json:"authorID" bson:"authorID"`
+	WordCnt   int    `json:"wordCnt" bson:"wordCnt"`
+	UpdatedAt int64  `json:"updatedAt" bson:"updatedAt"`
+	Role      string `json:"role" bson:"-"` // 自己在這個單字集的角色
+}
+
+// POST /api/v1/wordsets/{wordSetID}/share-links
+type V1CreateShareLinkRequest struct {
+	ExpiresInDays int `json:"expiresInDays"` // 0代表不會過期
+}
+
+// 建立分享連結的回應，token明碼只會出現這一次
+type V1CreateShareLinkResponse struct {
+	Token     string           `json:"token"`
+	URL       string           `json:"url"`
+	ShareLink WordSetShareLink `json:"shareLink"`
+}
+
+// POST /api/v1/wordsets/{wordSetID}/upstream/pull
+type V1PullUpstreamRequest struct {
+	Resolutions map[string]string `json:"resolutions" validate:"dive,oneof=upstream local"` // 衝突的wordID對應upstream或local，每個衝突都要給
+}
+
+// 複製/移動單字時的一個來源單字集
+type V1WordTransferSource struct {
+	WordSetID string   `json:"wordSetID" validate:"required"`
+	WordIDs   []string `json:"wordIDs" validate:"required,min=1,unique"`
+	Version   *int     `json:"version,omitempty"` // move時必填，來源單字集目前的版本號
+}
+
+// POST /api/v1/wordsets/{wordSetID}/words/transfer
+type V1TransferWordsRequest struct {
+	Mode           string                 `json:"mode" validate:"required,oneof=copy move"`
+	Sources        []V1WordTransferSource `json:"sources" validate:"required,min=1,dive"`
+	SkipDuplicates bool                   `json:"skipDuplicates"` // true的話跟目標重複的單字不會加進去，move時也會留在來源
+}
+
+type V1TransferWordsResponse struct {
+	WordSet     WordSet         `json:"wordSet"`     // 目標單字集
+	Transferred int             `json:"transferred"` // 實際加進目標的單字數
+	Duplicates  []DuplicateWord `json:"duplicates"`
+}
+
+// POST /api/v1/wordsets/{wordSetID}/split
+type V1SplitWordSetRequest struct {
+	Parts int `json:"parts" validate:"required,min=2"` // 依order平均拆成幾份，原本的單字集留下第一份
+}
+
+type V1SplitWordSetResponse struct {
+	WordSet WordSet  `json:"wordSet"` // 留下第一份的原本單字集
+	Parts   []string `json:"parts"`   // 拆出來的新單字集ID，依順序
+}
+
+// POST /api/v1/wordsets/merge
+type V1MergeWordSetsRequest struct {
+	Title          string   `json:"title" validate:"required"`
+	Description    string   `json:"description"`
+	WordSetIDs     []string `json:"wordSetIDs" validate:"required,min=2,unique"` // 單字依這個順序接在一起
+	AllowCopy      bool     `json:"allowCopy"`
+	Visibility     string   `json:"visibility" validate:"omitempty,oneof=private unlisted public"` // 沒給是private
+	SkipDuplicates bool     `json:"skipDuplicates"`
+	TrashSources   bool     `json:"trashSources"` // 合併後把來源單字集移到垃圾桶，必須是所有來源的作者
+}
+
+type V1MergeWordSetsResponse struct {
+	ID         string          `json:"id"`
+	WordCnt    int             `json:"wordCnt"`
+	Duplicates []DuplicateWord `json:"duplicates"`
+}
+
+// POST /api/v1/me/study-results
+type V1RecordStudyResultsRequest struct {
+	Results []StudyResult `json:"results" validate:"required,min=1,max=200,dive"`
+}
+
+// POST /api/v1/wordsets/{wordSetID}/words/{wordID}/grade
+type V1GradeAnswerRequest struct {
+	Answer      string `json:"answer" validate:"max=300"`
+	AnswerField string `json:"answerField" validate:"omitempty,oneof=vocabulary definition"` // 要打出來的是單字還是註釋，預設vocabulary
+	Leniency    string `json:"leniency" validate:"omitempty,oneof=strict normal lenient"`    // 預設normal
+}
+
+// POST /api/v1/wordsets/{wordSetID}/learn/answer
+type V1AnswerLearnQuestionRequest struct {
+	QuestionID  string `json:"questionID" validate:"required"`
+	ChoiceIndex *int   `json:"choiceIndex"`               // 選擇題
+	Answer      string `json:"answer" validate:"max=300"` // 打字作答
+}
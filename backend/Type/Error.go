@@ -1,11 +1,138 @@
 package Type
 
+import (
+	"fmt"
+	"net/http"
+)
+
 // 處裡所有error response payload type
 type MessageDisplayError struct {
-	Message string `json:"message"`
+	Message string    `json:"message"`
+	Code    ErrorCode `json:"code,omitempty"`
+	Details any       `json:"details,omitempty"` // 例如版本衝突時附上伺服器目前的資料
 }
 
 type PageDisplayError struct {
-	StatusCode int `json:"statusCode"`
-	Message string `json:"message"`
+	StatusCode int       `json:"statusCode"`
+	Message    string    `json:"message"`
+	Code       ErrorCode `json:"code,omitempty"`
+}
+
+// 機器可讀的錯誤代碼，讓前端以外的client也能判斷錯誤種類
+type ErrorCode string
+
+const (
+	CodeBadRequest      ErrorCode = "BAD_REQUEST"
+	CodeUnauthorized    ErrorCode = "UNAUTHORIZED"
+	CodeForbidden       ErrorCode = "FORBIDDEN"
+	CodeNotFound        ErrorCode = "NOT_FOUND"
+	CodeConflict        ErrorCode = "CONFLICT"
+	CodeDuplicateWords  ErrorCode = "DUPLICATE_WORDS"
+	CodePrecondition    ErrorCode = "PRECONDITION_REQUIRED"
+	CodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	CodeTimeout         ErrorCode = "TIMEOUT"
+	CodeInternal        ErrorCode = "INTERNAL"
+)
+
+// 後端統一的錯誤type
+// Message是給使用者看的訊息(繁中原文，回應時會依語系翻譯)，Args是Message裡格式化字元的參數
+// Err是內部錯誤(例如Mongo回傳的錯誤)，只會寫進log不會回給client
+// Details會原樣回給client
+type AppError struct {
+	Status  int
+	Code    ErrorCode
+	Message string
+	Args    []any
+	Details any
+	Err     error
+}
+
+func (e *AppError) Error() string {
+	message := e.Message
+	if len(e.Args) > 0 {
+		message = fmt.Sprintf(message, e.Args...)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// 附上內部錯誤，回傳新的AppError
+func (e *AppError) Wrap(err error) *AppError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// 附上Message的格式化參數，回傳新的AppError
+func (e *AppError) WithArgs(args ...any) *AppError {
+	withArgs := *e
+	withArgs.Args = args
+	return &withArgs
+}
+
+// 附上要回給client的資料，回傳新的AppError
+func (e *AppError) WithDetails(details any) *AppError {
+	withDetails := *e
+	withDetails.Details = details
+	return &withDetails
+}
+
+func NewAppError(status int, code ErrorCode, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+// 400 請求格式或欄位錯誤
+func BadRequest(message string) *AppError {
+	return NewAppError(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// 401 未登入或憑證無效
+func Unauthorized(message string) *AppError {
+	return NewAppError(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// 403 已登入但沒有權限
+func Forbidden(message string) *AppError {
+	return NewAppError(http.StatusForbidden, CodeForbidden, message)
+}
+
+// 404 查無資料
+func NotFound(message string) *AppError {
+	return NewAppError(http.StatusNotFound, CodeNotFound, message)
+}
+
+// 409 資料已存在或狀態衝突
+func Conflict(message string) *AppError {
+	return NewAppError(http.StatusConflict, CodeConflict, message)
+}
+
+// 409 單字集不允許重複單字，新增/修改的單字跟其他單字重複
+func DuplicateWords(message string) *AppError {
+	return NewAppError(http.StatusConflict, CodeDuplicateWords, message)
+}
+
+// 428 缺少必要的前置條件(例如版本號)
+func PreconditionRequired(message string) *AppError {
+	return NewAppError(http.StatusPreconditionRequired, CodePrecondition, message)
+}
+
+// 429 請求太頻繁
+func TooManyRequests(message string) *AppError {
+	return NewAppError(http.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+// 500 資料庫等操作超時，client可以重試
+func Timeout(message string) *AppError {
+	return NewAppError(http.StatusInternalServerError, CodeTimeout, message)
+}
+
+// 500 伺服器內部錯誤
+func Internal(message string) *AppError {
+	return NewAppError(http.StatusInternalServerError, CodeInternal, message)
 }
@@ -0,0 +1,48 @@
+package Type
+
+// WebSocket即時協作(/api/v1/wordsets/{wordSetID}/live)的訊息，用type區分
+
+// client -> server
+type LiveClientMessage struct {
+	Type        string               `json:"type"`                  // add、update、remove、reorder、presence、sync、ping
+	ClientOpID  string               `json:"clientOpID,omitempty"`  // client自己產生的ID，對應的op或error會原封不動帶回去
+	WordID      string               `json:"wordID,omitempty"`      // update、remove
+	Word        *V1WordInput         `json:"word,omitempty"`        // add
+	Fields      *V1UpdateWordRequest `json:"fields,omitempty"`      // update，只送有變的欄位
+	Order       []string             `json:"order,omitempty"`       // reorder，所有單字ID的新順序
+	FocusWordID string               `json:"focusWordID,omitempty"` // presence，正在編輯的單字
+	Field       string               `json:"field,omitempty"`       // presence，正在編輯的欄位(vocabulary/definition)
+	Draft       string               `json:"draft,omitempty"`       // presence，還沒送出的輸入內容
+}
+
+// server -> client
+type LiveServerMessage struct {
+	Type       string               `json:"type"`          // welcome、snapshot、op、presence、error、pong
+	Seq        int                  `json:"seq,omitempty"` // 單字集版本號，op的seq一定是上一個+1，跳號代表錯過了變更要送sync
+	ClientOpID string               `json:"clientOpID,omitempty"`
+	UserID     string               `json:"userID,omitempty"` // op是誰做的
+	ConnID     string               `json:"connID,omitempty"` // welcome，自己這條連線的ID
+	Op         *LiveOp              `json:"op,omitempty"`
+	WordSet    *WordSet             `json:"wordSet,omitempty"`  // welcome、snapshot
+	Presence   []LivePresence       `json:"presence,omitempty"` // welcome、presence，目前在線上的所有人
+	Error      *MessageDisplayError `json:"error,omitempty"`
+}
+
+// 已經寫進DB的單字變更
+type LiveOp struct {
+	Type   string   `json:"type"` // add、update、remove、reorder
+	WordID string   `json:"wordID,omitempty"`
+	Word   *Word    `json:"word,omitempty"`  // add、update之後完整的單字
+	Order  []string `json:"order,omitempty"` // reorder
+}
+
+// 線上的協作者以及他們正在編輯的位置
+type LivePresence struct {
+	ConnID      string `json:"connID"`
+	UserID      string `json:"userID"`
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+	FocusWordID string `json:"focusWordID,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Draft       string `json:"draft,omitempty"`
+}
@@ -60,6 +60,15 @@ func (c ChangeUserNameRequest) GetUserID() string {
 	return c.UserID
 }
 
+// Change User Locale
+type ChangeUserLocaleRequest struct {
+	UserID string `json:"userID" validate:"required"`
+	Locale string `json:"locale"` // zh-TW、en，空字串代表依Accept-Language
+}
+func (c ChangeUserLocaleRequest) GetUserID() string {
+	return c.UserID
+}
+
 // Change User Email
 type ChangeUserEmailRequest struct {
 	UserID string `json:"userID" validate:"required"`
@@ -73,6 +82,7 @@ func (c ChangeUserEmailRequest) GetUserID() string {
 type ToggleLikeWordSetRequest struct {
 	UserID string `json:"userID" validate:"required"`
 	WordSetID string `json:"wordSetID" validate:"required"`
+	ShareToken string `json:"shareToken,omitempty"` // 透過分享連結看到的單字集要帶上
 }
 func (s ToggleLikeWordSetRequest) GetUserID() string {
 	return s.UserID
@@ -81,6 +91,7 @@ func (s ToggleLikeWordSetRequest) GetUserID() string {
 type ForkWordSetRequest struct {
 	UserID string `json:"userID" validate:"required"`
 	WordSetID string `json:"wordSetID" validate:"required"`
+	ShareToken string `json:"shareToken,omitempty"` // 透過分享連結看到的單字集要帶上
 }
 func (f ForkWordSetRequest) GetUserID() string {
 	return f.UserID
@@ -95,6 +106,7 @@ type WordSetsRelatedRequest interface {
 
 // editWordSet頁面送的request格式
 type EditWordSetRequest struct {
+	Version *int `json:"version" validate:"required"` // 編輯前拿到的版本號
 	AddWords []Word `json:"addWords" validate:"required,dive"`
 	WordSet EditWordSet `json:"wordSet" validate:"required"`
 	RemoveWords []string `json:"removeWords" validate:"required"`
@@ -114,33 +126,72 @@ func (d DeleteWordSetRequest) GetWordSetID() string {
 // delete one word from a wordSet
 type DeleteWordRequest struct {
 	WordSetID string `json:"wordSetID" validate:"required"`
+	Version *int `json:"version" validate:"required"`
 	WordID string `json:"wordID" validate:"required"`
 }
 func (d DeleteWordRequest) GetWordSetID() string {
 	return d.WordSetID
 }
 
-// toggle star of a word in a wordSet
+// toggle star of a word in a wordSet，星號是每個使用者自己的
 type ToggleWordStarRequest struct {
+	UserID    string `json:"userID" validate:"required"`
 	WordSetID string `json:"wordSetID" validate:"required"`
 	WordID    string `json:"wordID" validate:"required"`
+	ShareToken string `json:"shareToken,omitempty"` // 透過分享連結看到的單字集要帶上
 }
-func (d ToggleWordStarRequest) GetWordSetID() string {
-	return d.WordSetID
+func (d ToggleWordStarRequest) GetUserID() string {
+	return d.UserID
 }
 
 // toggle star of all words in a wordSet
 type ToggleAllWordStarRequest struct {
+	UserID    string `json:"userID" validate:"required"`
 	WordSetID string `json:"wordSetID" validate:"required"`
 	NewStar   bool   `json:"newStar"`
+	ShareToken string `json:"shareToken,omitempty"`
 }
-func (d ToggleAllWordStarRequest) GetWordSetID() string {
-	return d.WordSetID
+func (d ToggleAllWordStarRequest) GetUserID() string {
+	return d.UserID
+}
+
+// 判定打字作答，不用登入
+type GradeAnswerRequest struct {
+	Expected string `json:"expected" validate:"required,max=300"` // 題目的答案，可以用/或;分隔多個
+	Answer   string `json:"answer" validate:"max=300"`
+	Leniency string `json:"leniency" validate:"omitempty,oneof=strict normal lenient"`
+}
+
+// 開始或接著上次的Learn進度，restart為true時重新開始
+type StartLearnSessionRequest struct {
+	WordSetID  string `json:"wordSetID" validate:"required"`
+	Restart    bool   `json:"restart"`
+	ShareToken string `json:"shareToken,omitempty"`
+}
+
+// 回答Learn模式目前的題目，選擇題帶choiceIndex，打字作答帶answer
+type AnswerLearnQuestionRequest struct {
+	WordSetID   string `json:"wordSetID" validate:"required"`
+	QuestionID  string `json:"questionID" validate:"required"`
+	ChoiceIndex *int   `json:"choiceIndex"`
+	Answer      string `json:"answer" validate:"max=300"`
+	ShareToken  string `json:"shareToken,omitempty"`
+}
+
+// 回報練習時的作答結果
+type RecordStudyResultsRequest struct {
+	UserID     string        `json:"userID" validate:"required"`
+	ShareToken string        `json:"shareToken,omitempty"`
+	Results    []StudyResult `json:"results" validate:"required,min=1,max=200,dive"`
+}
+func (d RecordStudyResultsRequest) GetUserID() string {
+	return d.UserID
 }
 
 // add a word to a wordSet
 type AddWordRequest struct {
 	WordSetID string `json:"wordSetID" validate:"required"`
+	Version   *int   `json:"version" validate:"required"`
 	Word 	  Word   `json:"word" validate:"required"`
 }
 func (d AddWordRequest) GetWordSetID() string {
@@ -150,6 +201,7 @@ func (d AddWordRequest) GetWordSetID() string {
 // inline update the word in a wordSet
 type InlineUpdateWordRequest struct {
 	WordSetID string `json:"wordSetID" validate:"required"`
+	Version *int `json:"version" validate:"required"`
 	WordID string `json:"wordID" validate:"required"`
 	NewVocabulary string `json:"newVocabulary" validate:"required"`
 	NewDefinition string `json:"newDefinition" validate:"required"`
@@ -160,11 +212,17 @@ func (d InlineUpdateWordRequest) GetWordSetID() string {
 // inline update the word in a wordSet
 type BigWordCardUpdateWordRequest struct {
 	WordSetID string `json:"wordSetID" validate:"required"`
+	Version *int `json:"version" validate:"required"`
 	WordID string `json:"wordID" validate:"required"`
 	NewVocabulary string `json:"newVocabulary" validate:"required"`
 	NewDefinition string `json:"newDefinition" validate:"required"`
 	NewVocabularySound string `json:"newVocabularySound" validate:"required"`
 	NewDefinitionSound string `json:"newDefinitionSound" validate:"required"`
+	NewPronunciation *string `json:"newPronunciation"` // 以下沒給(nil)代表不變
+	NewPartOfSpeech *string `json:"newPartOfSpeech"`
+	NewMeanings *[]WordMeaning `json:"newMeanings"`
+	NewExamples *[]WordExample `json:"newExamples"`
+	NewFields map[string]string `json:"newFields"` // 有給的自訂欄位才更新
 }
 func (d BigWordCardUpdateWordRequest) GetWordSetID() string {
 	return d.WordSetID
@@ -193,6 +251,9 @@ type ToggleAllowCopyRequest struct {
 func (t ToggleAllowCopyRequest) GetUserID() string {
 	return t.UserID
 }
+func (t ToggleAllowCopyRequest) GetWordSetID() string {
+	return t.WordSetID
+}
 
 type ToggleIsPublicRequest struct {
 	UserID string `json:"userID"` 
@@ -201,6 +262,78 @@ type ToggleIsPublicRequest struct {
 func (t ToggleIsPublicRequest) GetUserID() string {
 	return t.UserID
 }
+func (t ToggleIsPublicRequest) GetWordSetID() string {
+	return t.WordSetID
+}
+
+// 設定單字集的公開範圍(private、unlisted、public)
+type SetWordSetVisibilityRequest struct {
+	UserID string `json:"userID"`
+	WordSetID string `json:"wordSetID" validate:"required"`
+	Visibility string `json:"visibility" validate:"required,oneof=private unlisted public"`
+}
+func (s SetWordSetVisibilityRequest) GetWordSetID() string {
+	return s.WordSetID
+}
+
+// 設定單字集遇到重複單字時要提醒(warn)還是拒絕(reject)
+type SetDuplicatePolicyRequest struct {
+	UserID string `json:"userID"`
+	WordSetID string `json:"wordSetID" validate:"required"`
+	DuplicatePolicy string `json:"duplicatePolicy" validate:"required,oneof=warn reject"`
+}
+func (s SetDuplicatePolicyRequest) GetWordSetID() string {
+	return s.WordSetID
+}
+
+// 設定卡片模板，template是null代表改回預設模板
+type SetCardTemplateRequest struct {
+	WordSetID string `json:"wordSetID" validate:"required"`
+	Version *int `json:"version" validate:"required"`
+	Template *CardTemplate `json:"template"`
+}
+func (s SetCardTemplateRequest) GetWordSetID() string {
+	return s.WordSetID
+}
+
+// 刪除單字的圖片
+type RemoveWordImageRequest struct {
+	WordSetID string `json:"wordSetID" validate:"required"`
+	WordID string `json:"wordID" validate:"required"`
+	Version *int `json:"version" validate:"required"`
+}
+func (s RemoveWordImageRequest) GetWordSetID() string {
+	return s.WordSetID
+}
+
+// 刪除單字某一面的發音音檔，side是vocabulary或definition
+type RemoveWordAudioRequest struct {
+	WordSetID string `json:"wordSetID" validate:"required"`
+	WordID string `json:"wordID" validate:"required"`
+	Side string `json:"side" validate:"required"`
+	Version *int `json:"version" validate:"required"`
+}
+func (s RemoveWordAudioRequest) GetWordSetID() string {
+	return s.WordSetID
+}
+
+// 建立分享連結，成功時回傳完整的連結
+type CreateShareLinkRequest struct {
+	UserID string `json:"userID"`
+	WordSetID string `json:"wordSetID" validate:"required"`
+}
+func (c CreateShareLinkRequest) GetWordSetID() string {
+	return c.WordSetID
+}
+
+// 撤銷該單字集所有的分享連結
+type RevokeShareLinksRequest struct {
+	UserID string `json:"userID"`
+	WordSetID string `json:"wordSetID" validate:"required"`
+}
+func (r RevokeShareLinksRequest) GetWordSetID() string {
+	return r.WordSetID
+}
 
 type CreateFeedbackRequest struct {
 	AuthorID  string `json:"authorID" bson:"authorID" validate:"required"`
@@ -239,4 +372,16 @@ type SendActivateEmailRequest struct {
 
 type ActivateEmailRequest struct {
 	Token string `json:"token"`
-}
\ No newline at end of file
+}
+
+// 從原始單字集同步變更
+type PullUpstreamRequest struct {
+	Version *int `json:"version" validate:"required"`
+	UserID string `json:"userID"`
+	WordSetID string `json:"wordSetID" validate:"required"`
+	Resolutions map[string]string `json:"resolutions" validate:"dive,oneof=upstream local"` // 衝突的wordID對應upstream或local
+	ShareToken string `json:"shareToken,omitempty"` // 來源是透過分享連結看到的要帶上
+}
+func (p PullUpstreamRequest) GetWordSetID() string {
+	return p.WordSetID
+}
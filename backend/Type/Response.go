@@ -10,6 +10,20 @@ type Response struct {
 
 type MessageDisplaySuccess struct {
 	Message string `json:"message"`
+	Version int    `json:"version,omitempty"` // 變更單字集內容後的新版本號
+	Duplicates []DuplicateCluster `json:"duplicates,omitempty"` // 新增/修改單字後跟其他單字重複或相近的單字
+}
+
+// 上傳單字圖片成功
+type UploadWordImageResponse struct {
+	Image   WordImage `json:"image"`
+	Version int       `json:"version"`
+}
+
+// 上傳單字發音成功
+type UploadWordAudioResponse struct {
+	Audio   WordAudio `json:"audio"`
+	Version int       `json:"version"`
 }
 
 // 在userLink component中會要的使用者資訊
@@ -28,6 +42,7 @@ type FrontEndUser struct {
 	Email         string   `json:"email"`
 	Img           string   `json:"img"`
 	LikedWordSets []string `json:"likedWordSets"` // 別人的
+	Locale        string   `json:"locale"`
 }
 
 // 給front end的Lib page
@@ -38,10 +53,12 @@ type LibPage struct {
 }
 
 type FullWordCardType struct {
-	ID         string `json:"id"`
-	Title      string `json:"title"`
-	Words      []Word `json:"words"`
-	ShouldSwap bool   `json:"shouldSwap"` // 用來給前端展示是否要swap
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Words      []Word            `json:"words"`
+	ShouldSwap bool              `json:"shouldSwap"`           // 用來給前端展示是否要swap
+	Template   CardTemplate      `json:"template"`             // 卡片正反面要顯示的欄位，沒設定模板的話是預設模板(已依shouldSwap換好)
+	WordSetIDs map[string]string `json:"wordSetIDs,omitempty"` // 複習牌組的單字來自不同單字集，wordID對應來源單字集
 }
 
 type SearchWordSetResponse struct {
@@ -69,4 +86,20 @@ type PopularWordSetResponse struct {
 type FeedbackResponse struct {
 	Feedbacks []Feedback `json:"feedbacks"`
 	HaveMore  bool       `json:"haveMore"`
-}
\ No newline at end of file
+}
+// 打字作答的判定
+type GradeAnswerResponse struct {
+	Result   string       `json:"result"`   // correct、almost或wrong
+	Answer   string       `json:"answer"`   // 最接近作答的答案，答案用/或;分隔多個時是其中一個
+	Answers  []string     `json:"answers"`  // 所有可接受的答案
+	Distance int          `json:"distance"` // 正規化後跟answer的編輯距離
+	Diff     []AnswerDiff `json:"diff"`     // 正規化後answer跟作答的差異
+}
+
+// Learn模式作答的結果
+type LearnAnswerResponse struct {
+	Correct bool                 `json:"correct"`
+	Answer  string               `json:"answer"`          // 正確答案
+	Grade   *GradeAnswerResponse `json:"grade,omitempty"` // 打字作答的判定
+	Session LearnSession         `json:"session"`         // 作答後的進度以及下一題
+}
@@ -1,5 +1,10 @@
 package Type
 
+import (
+	"encoding/json"
+	"go-quizlet/markdown"
+)
+
 type Account struct {
 	ID       string `json:"id"`
 	UserID   string `json:"userID"`
@@ -21,6 +26,7 @@ type User struct {
 	CreatedAt       string   `json:"createdAt" bson:"createdAt"`
 	LikedCnt        int      `json:"likedCnt" bson:"likedCnt"`   // 單字集被收藏次數
 	ForkedCnt       int      `json:"forkedCnt" bson:"forkedCnt"` // 單字集被複製次數
+	Locale          string   `json:"locale" bson:"locale,omitempty"` // 介面/郵件語系(zh-TW、en)，空值代表依Accept-Language
 }
 
 // 在Lib Page顯示wordSet的type
@@ -59,10 +65,56 @@ type Word struct {
 	ID              string `json:"id" bson:"id"`
 	Order           int    `json:"order" bson:"order" validate:"required"`
 	Vocabulary      string `json:"vocabulary" bson:"vocabulary" validate:"required"`
-	Definition      string `json:"definition" bson:"definition" validate:"required"`
+	Definition      string `json:"definition" bson:"definition" validate:"required"` // 可以用Markdown，字數算顯示出來的文字
+	DefinitionHTML  string `json:"definitionHtml" bson:"-"` // 回傳時由definition轉成的安全HTML，不存DB
 	VocabularySound string `json:"vocabularySound" bson:"vocabularySound" validate:"required"`
 	DefinitionSound string `json:"definitionSound" bson:"definitionSound" validate:"required"`
-	Star            bool   `json:"star" bson:"star"`
+	Star            bool   `json:"star" bson:"star"` // 回傳時是檢視者自己的星號，DB裡的是作者以前的星號(還沒有WordStars時沿用)
+	Pronunciation   string `json:"pronunciation,omitempty" bson:"pronunciation,omitempty"` // 音標(IPA)或拼音/羅馬拼音
+	PartOfSpeech    string `json:"partOfSpeech,omitempty" bson:"partOfSpeech,omitempty"`   // 詞性，有多個意思時是主要的詞性
+	Meanings        []WordMeaning `json:"meanings,omitempty" bson:"meanings,omitempty"` // 其他意思，依序編號，definition是主要的意思
+	Examples        []WordExample `json:"examples,omitempty" bson:"examples,omitempty"` // 例句，填充題會把例句裡的單字挖空
+	Fields          map[string]string `json:"fields,omitempty" bson:"fields,omitempty"` // 卡片模板自訂欄位的值，key是欄位的key
+	Image           *WordImage `json:"image,omitempty" bson:"image,omitempty"` // 只能透過上傳圖片的route設定
+	VocabularyAudio *WordAudio `json:"vocabularyAudio,omitempty" bson:"vocabularyAudio,omitempty"` // 錄好的發音，有的話優先於VocabularySound的TTS
+	DefinitionAudio *WordAudio `json:"definitionAudio,omitempty" bson:"definitionAudio,omitempty"`
+}
+
+// JSON回傳時附上definition轉好的HTML，client送來的definitionHtml不會被使用
+func (w Word) MarshalJSON() ([]byte, error) {
+	type plainWord Word
+	w.DefinitionHTML = markdown.Render(w.Definition)
+	return json.Marshal(plainWord(w))
+}
+
+// 單字的圖片，key是Storage裡的位置，複製/合併單字集時會共用同一份檔案
+type WordImage struct {
+	Key          string `json:"-" bson:"key"`
+	ThumbnailKey string `json:"-" bson:"thumbnailKey"`
+	URL          string `json:"url" bson:"url"`
+	ThumbnailURL string `json:"thumbnailUrl" bson:"thumbnailUrl"`
+	Width        int    `json:"width" bson:"width"`
+	Height       int    `json:"height" bson:"height"`
+}
+
+// 單字某一面的發音音檔，跟圖片一樣複製單字集時共用檔案
+type WordAudio struct {
+	Key         string  `json:"-" bson:"key"`
+	URL         string  `json:"url" bson:"url"`
+	ContentType string  `json:"contentType" bson:"contentType"`
+	Duration    float64 `json:"duration" bson:"duration"` // 秒
+}
+
+// 單字的其中一個意思
+type WordMeaning struct {
+	PartOfSpeech string `json:"partOfSpeech,omitempty" bson:"partOfSpeech,omitempty"`
+	Definition   string `json:"definition" bson:"definition"`
+}
+
+// 例句以及翻譯
+type WordExample struct {
+	Sentence    string `json:"sentence" bson:"sentence"`
+	Translation string `json:"translation,omitempty" bson:"translation,omitempty"`
 }
 
 /*
@@ -77,7 +129,8 @@ validate:"required,min=1,dive,required"
 type WordSet struct {
 	ID          string   `json:"id" bson:"id"`
 	Title       string   `json:"title" bson:"title" validate:"required"`
-	Description string   `json:"description" bson:"description"`
+	Description string   `json:"description" bson:"description"` // 可以用Markdown
+	DescriptionHTML string `json:"descriptionHtml" bson:"-"` // 回傳時由description轉成的安全HTML
 	AuthorID    string   `json:"authorID" bson:"authorID" validate:"required"` // 原作者
 	CreatedAt   string   `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   int64    `json:"updatedAt" bson:"updatedAt"` // 存成int方便比大小，在前端自行format就好
@@ -87,7 +140,101 @@ type WordSet struct {
 	Likes       int      `json:"likes" bson:"likes"`           // 讚數
 	WordCnt     int      `json:"wordCnt" bson:"wordCnt"`       // 字數統計
 	AllowCopy   bool     `json:"allowCopy" bson:"allowCopy"`   // 允許他人複製/衍生
-	IsPublic    bool     `json:"isPublic" bson:"isPublic"`     // 允許發布在首頁(最新/熱門單字集)
+	IsPublic    bool     `json:"isPublic" bson:"isPublic"`     // 等同visibility為public，保留給舊的前端
+	Visibility  string   `json:"visibility" bson:"visibility"` // private、unlisted或public
+	Version     int      `json:"version" bson:"version"`       // 每次內容變更+1，編輯時要帶上目前的版本號
+	DeletedAt   int64    `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"` // 移到垃圾桶的時間，0代表沒被刪除
+	PurgeAt     int64    `json:"purgeAt,omitempty" bson:"purgeAt,omitempty"`     // 超過這個時間會被永久刪除
+	UpdatedBy   string   `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"` // 最後修改內容的使用者(作者或協作者)
+	Collaborators []Collaborator `json:"collaborators,omitempty" bson:"collaborators,omitempty"` // 作者邀請的協作者
+	ForkedFrom  *ForkSource `json:"forkedFrom,omitempty" bson:"forkedFrom,omitempty"` // 複製來源，不是複製來的為nil
+	DuplicatePolicy string `json:"duplicatePolicy,omitempty" bson:"duplicatePolicy,omitempty"` // warn或reject，空字串等同warn
+	Template    *CardTemplate `json:"template,omitempty" bson:"template,omitempty"` // 卡片模板，nil是預設模板(vocabulary/definition)
+	RevisionCnt int      `json:"-" bson:"revisionCnt"` // 已經配發出去的版本編號，跟變更在同一個寫入裡+1
+}
+
+// JSON回傳時附上description轉好的HTML
+func (s WordSet) MarshalJSON() ([]byte, error) {
+	type plainWordSet WordSet
+	s.DescriptionHTML = markdown.Render(s.Description)
+	return json.Marshal(plainWordSet(s))
+}
+
+// 單字集的卡片模板，定義有哪些欄位以及卡片正反面要顯示哪些欄位
+// vocabulary跟definition是內建欄位，值在Word.Vocabulary/Definition，其他欄位的值在Word.Fields
+type CardTemplate struct {
+	Fields []TemplateField `json:"fields" bson:"fields" validate:"required,dive"`
+	Front  []string        `json:"front" bson:"front" validate:"required"` // 正面顯示的欄位key，依序
+	Back   []string        `json:"back" bson:"back" validate:"required"`   // 背面顯示的欄位key，依序
+}
+
+type TemplateField struct {
+	Key   string `json:"key" bson:"key" validate:"required"`
+	Label string `json:"label" bson:"label" validate:"required"`
+	Sound string `json:"sound,omitempty" bson:"sound,omitempty"` // 自訂欄位的發音，空字串不發音；內建欄位用單字各自的vocabularySound/definitionSound
+}
+
+// 複製來源，同步上游變更時用Base當三方合併的共同祖先
+type ForkSource struct {
+	WordSetID string `json:"wordSetID" bson:"wordSetID"`
+	AuthorID  string `json:"authorID" bson:"authorID"`
+	Title     string `json:"title" bson:"title"`     // 複製當下的標題，來源被刪除後還能顯示
+	Version   int    `json:"version" bson:"version"` // 上次同步時來源的版本
+	ForkedAt  int64  `json:"forkedAt" bson:"forkedAt"`
+	SyncedAt  int64  `json:"syncedAt" bson:"syncedAt"` // 上次同步的時間，還沒同步過等於forkedAt
+	Base      []Word `json:"-" bson:"base"`            // 上次同步時來源的單字
+}
+
+// 原始單字集跟fork之間一個單字的差異
+type UpstreamWordChange struct {
+	WordID   string `json:"wordID"`
+	Kind     string `json:"kind"`     // added、updated、removed(以原始單字集的角度)
+	Conflict bool   `json:"conflict"` // fork這邊也改過，要選擇保留哪一邊
+	Base     *Word  `json:"base,omitempty"`
+	Upstream *Word  `json:"upstream,omitempty"`
+	Local    *Word  `json:"local,omitempty"`
+}
+
+// 同步上游變更前的預覽
+type UpstreamChanges struct {
+	SourceWordSetID string               `json:"sourceWordSetID"`
+	BaseVersion     int                  `json:"baseVersion"`   // 上次同步時來源的版本
+	SourceVersion   int                  `json:"sourceVersion"` // 來源目前的版本
+	Changes         []UpstreamWordChange `json:"changes"`
+}
+
+// 搬移或合併單字時偵測到的重複單字(單字不分大小寫以及多餘空白)
+type DuplicateWord struct {
+	WordSetID   string `json:"wordSetID"` // 重複的單字來自哪個單字集
+	WordID      string `json:"wordID"`
+	Vocabulary  string `json:"vocabulary"`
+	DuplicateOf string `json:"duplicateOf"` // 結果中已經有的同一個單字的wordID
+	Skipped     bool   `json:"skipped"`     // 有沒有被略過
+}
+
+// 一組重複或拼字相近的單字
+type DuplicateCluster struct {
+	Exact bool   `json:"exact"` // 所有單字正規化後(全半形、大小寫、繁簡、空白)完全相同，false代表有些只是拼字相近
+	Words []Word `json:"words"` // 依order排序
+}
+
+// 單字集的協作者
+type Collaborator struct {
+	UserID  string `json:"userID" bson:"userID"`
+	Role    string `json:"role" bson:"role"` // editor或viewer
+	AddedBy string `json:"addedBy" bson:"addedBy"`
+	AddedAt int64  `json:"addedAt" bson:"addedAt"`
+}
+
+// 單字集的分享連結，DB只存token的SHA-256，明碼只在建立時回傳一次
+type WordSetShareLink struct {
+	ID        string `json:"id" bson:"id"`
+	WordSetID string `json:"wordSetID" bson:"wordSetID"`
+	TokenHash string `json:"-" bson:"tokenHash"`
+	Prefix    string `json:"prefix" bson:"prefix"` // 明碼的前幾碼，讓作者辨識是哪個連結
+	CreatedBy string `json:"createdBy" bson:"createdBy"`
+	CreatedAt int64  `json:"createdAt" bson:"createdAt"`
+	ExpiresAt int64  `json:"expiresAt" bson:"expiresAt"` // 0代表不會過期
 }
 
 // editWordSet request中的editWord格式
@@ -98,6 +245,11 @@ type EditWord struct {
 	Definition      string `json:"definition" bson:"definition"`
 	VocabularySound string `json:"vocabularySound" bson:"vocabularySound"`
 	DefinitionSound string `json:"definitionSound" bson:"definitionSound"`
+	Fields          map[string]string `json:"fields,omitempty" bson:"fields"` // 有給的自訂欄位才更新，空字串是清除
+	Pronunciation   *string        `json:"pronunciation,omitempty" bson:"pronunciation"` // 以下欄位沒給(nil)代表不變，給空字串或空陣列是清除
+	PartOfSpeech    *string        `json:"partOfSpeech,omitempty" bson:"partOfSpeech"`
+	Meanings        *[]WordMeaning `json:"meanings,omitempty" bson:"meanings"`
+	Examples        *[]WordExample `json:"examples,omitempty" bson:"examples"`
 }
 
 // editWordSet request中的EditWordSet格式
@@ -199,4 +351,196 @@ type ActivateEmail struct {
 	Token     string `json:"token" bson:"token"`
 	Expire    int64  `json:"expire" bson:"expire"` // 開通跟註冊的時間是共用的
 	Activated bool   `json:"activated" bson:"activated"`
-}
\ No newline at end of file
+}
+// 個人API token，DB只存hash，明碼只在建立時回傳一次
+type PersonalAccessToken struct {
+	ID         string   `json:"id" bson:"id"`
+	UserID     string   `json:"userID" bson:"userID"`
+	Name       string   `json:"name" bson:"name"`
+	TokenHash  string   `json:"-" bson:"tokenHash"`
+	Prefix     string   `json:"prefix" bson:"prefix"` // 明碼的前幾碼，讓使用者辨識是哪個token
+	Scopes     []string `json:"scopes" bson:"scopes"`
+	CreatedAt  int64    `json:"createdAt" bson:"createdAt"`
+	ExpiresAt  int64    `json:"expiresAt" bson:"expiresAt"`   // 0代表不會過期
+	LastUsedAt int64    `json:"lastUsedAt" bson:"lastUsedAt"` // 0代表還沒用過
+}
+
+// 使用者/管理員註冊的webhook，secret用來簽HMAC，只在建立時回傳一次
+type Webhook struct {
+	ID        string   `json:"id" bson:"id"`
+	OwnerID   string   `json:"ownerID" bson:"ownerID"`
+	URL       string   `json:"url" bson:"url"`
+	Secret    string   `json:"-" bson:"secret"`
+	Events    []string `json:"events" bson:"events"`
+	Admin     bool     `json:"admin" bson:"admin"` // 管理員的webhook會收到所有人的事件
+	Active    bool     `json:"active" bson:"active"`
+	CreatedAt int64    `json:"createdAt" bson:"createdAt"`
+}
+
+// 送給webhook的payload
+type WebhookPayload struct {
+	ID        string `json:"id"` // 事件ID，重送時不變，接收端可以用來去重
+	Event     string `json:"event"`
+	CreatedAt int64  `json:"createdAt"`
+	Data      any    `json:"data"`
+}
+
+// wordset.*事件的data
+type WebhookWordSetData struct {
+	WordSetID       string `json:"wordSetID"`
+	Title           string `json:"title,omitempty"`
+	AuthorID        string `json:"authorID"`
+	ActorID         string `json:"actorID"`                   // 觸發事件的使用者
+	SourceWordSetID string `json:"sourceWordSetID,omitempty"` // wordset.forked的來源單字集
+}
+
+// user.registered事件的data
+type WebhookUserData struct {
+	UserID   string `json:"userID"`
+	Name     string `json:"name"`
+	IsGoogle bool   `json:"isGoogle"`
+}
+
+// 一次事件送給一個webhook的紀錄，每次嘗試都記在Attempts
+type WebhookDelivery struct {
+	ID        string           `json:"id" bson:"id"`
+	WebhookID string           `json:"webhookID" bson:"webhookID"`
+	OwnerID   string           `json:"ownerID" bson:"ownerID"`
+	EventID   string           `json:"eventID" bson:"eventID"`
+	Event     string           `json:"event" bson:"event"`
+	Payload   string           `json:"payload" bson:"payload"`
+	Status    string           `json:"status" bson:"status"` // pending、succeeded、failed
+	Attempts  []WebhookAttempt `json:"attempts" bson:"attempts"`
+	CreatedAt int64            `json:"createdAt" bson:"createdAt"`
+}
+
+type WebhookAttempt struct {
+	Attempt    int    `json:"attempt" bson:"attempt"`
+	StatusCode int    `json:"statusCode" bson:"statusCode"` // 0代表沒有收到回應
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64  `json:"durationMs" bson:"durationMs"`
+	SentAt     int64  `json:"sentAt" bson:"sentAt"`
+}
+
+// 單字集內容的快照，還原時整份蓋回去
+type WordSetSnapshot struct {
+	Title       string `json:"title" bson:"title"`
+	Description string `json:"description" bson:"description"`
+	ShouldSwap  bool   `json:"shouldSwap" bson:"shouldSwap"`
+	Words       []Word `json:"words" bson:"words"`
+	Template    *CardTemplate `json:"template,omitempty" bson:"template,omitempty"`
+}
+
+// 單字集的一個版本，Changes是跟上一個版本的差異，Snapshot是變更後的內容
+type WordSetRevision struct {
+	ID           string           `json:"id" bson:"id"`
+	WordSetID    string           `json:"wordSetID" bson:"wordSetID"`
+	Number       int              `json:"number" bson:"number"` // 從1開始遞增
+	EditorID     string           `json:"editorID" bson:"editorID"`
+	Action       string           `json:"action" bson:"action"`                                 // create、fork、edit、restore、pull、transfer、split、merge、baseline
+	RestoredFrom int              `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"` // restore時還原的版本
+	CreatedAt    int64            `json:"createdAt" bson:"createdAt"`
+	Changes      WordSetChanges   `json:"changes" bson:"changes"`
+	Snapshot     *WordSetSnapshot `json:"snapshot,omitempty" bson:"snapshot,omitempty"` // 列表不回傳
+}
+
+type WordSetChanges struct {
+	Title        *FieldChange `json:"title,omitempty" bson:"title,omitempty"`
+	Description  *FieldChange `json:"description,omitempty" bson:"description,omitempty"`
+	AddedWords   []Word       `json:"addedWords" bson:"addedWords"`
+	RemovedWords []Word       `json:"removedWords" bson:"removedWords"`
+	ChangedWords []WordChange `json:"changedWords" bson:"changedWords"`
+	Template     bool         `json:"template,omitempty" bson:"template,omitempty"` // 卡片模板有變更
+}
+
+type FieldChange struct {
+	Before string `json:"before" bson:"before"`
+	After  string `json:"after" bson:"after"`
+}
+
+// 同一個wordID前後的差異，Fields是有變的欄位名稱(json名稱)
+type WordChange struct {
+	WordID string   `json:"wordID" bson:"wordID"`
+	Fields []string `json:"fields" bson:"fields"`
+	Before Word     `json:"before" bson:"before"`
+	After  Word     `json:"after" bson:"after"`
+}
+
+// 使用者在一個單字集中加星號的單字，每個使用者每個單字集一筆
+type WordStars struct {
+	UserID    string   `json:"userID" bson:"userID"`
+	WordSetID string   `json:"wordSetID" bson:"wordSetID"`
+	WordIDs   []string `json:"wordIDs" bson:"wordIDs"`
+	UpdatedAt int64    `json:"updatedAt" bson:"updatedAt"`
+}
+
+// 使用者對一個單字的作答紀錄，用來組複習牌組(答錯的、該複習的單字)
+type WordReview struct {
+	UserID         string `json:"userID" bson:"userID"`
+	WordSetID      string `json:"wordSetID" bson:"wordSetID"`
+	WordID         string `json:"wordID" bson:"wordID"`
+	Box            int    `json:"box" bson:"box"` // 連續答對幾次，決定下次複習的間隔
+	CorrectCnt     int    `json:"correctCnt" bson:"correctCnt"`
+	MissedCnt      int    `json:"missedCnt" bson:"missedCnt"`
+	LastReviewedAt int64  `json:"lastReviewedAt" bson:"lastReviewedAt"`
+	LastMissedAt   int64  `json:"lastMissedAt" bson:"lastMissedAt"`
+	DueAt          int64  `json:"dueAt" bson:"dueAt"`
+}
+
+// 一個單字在Learn模式中的進度
+type LearnWord struct {
+	WordID    string `json:"wordID" bson:"wordID"`
+	Stage     string `json:"stage" bson:"stage"` // recognition、recall或mastered
+	MissedCnt int    `json:"missedCnt" bson:"missedCnt"`
+}
+
+// Learn模式目前的題目，題目是註釋、要答出單字(單字集shouldSwap時相反)
+type LearnQuestion struct {
+	ID          string   `json:"id" bson:"id"`
+	WordID      string   `json:"wordID" bson:"wordID"`
+	Stage       string   `json:"stage" bson:"stage"` // recognition是選擇題，recall要打字作答
+	Prompt      string   `json:"prompt" bson:"prompt"`
+	PromptSound string   `json:"promptSound" bson:"promptSound"`
+	AnswerSound string   `json:"answerSound" bson:"answerSound"`
+	Choices     []string `json:"choices,omitempty" bson:"choices,omitempty"` // 選擇題的選項
+	AnswerIndex int      `json:"-" bson:"answerIndex"`                       // 作答前不能讓使用者知道
+}
+
+// 使用者在一個單字集的Learn進度，每個使用者每個單字集一筆，換裝置也能接著學
+type LearnSession struct {
+	ID          string         `json:"id" bson:"id"`
+	UserID      string         `json:"userID" bson:"userID"`
+	WordSetID   string         `json:"wordSetID" bson:"wordSetID"`
+	Words       []LearnWord    `json:"words" bson:"words"`
+	Queue       []string       `json:"-" bson:"queue"`                             // 接下來要考的wordID，第一個是目前的題目
+	Current     *LearnQuestion `json:"current,omitempty" bson:"current,omitempty"` // 全部學會後為nil
+	Progress    LearnProgress  `json:"progress" bson:"-"`
+	Answered    int            `json:"answered" bson:"answered"`
+	CorrectCnt  int            `json:"correctCnt" bson:"correctCnt"`
+	Version     int            `json:"version" bson:"version"` // 每次作答+1，避免兩個裝置同時作答互相覆蓋
+	CreatedAt   int64          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   int64          `json:"updatedAt" bson:"updatedAt"`
+	CompletedAt int64          `json:"completedAt,omitempty" bson:"completedAt,omitempty"` // 所有單字都學會的時間
+}
+
+// 各階段的單字數
+type LearnProgress struct {
+	Total       int  `json:"total"`
+	Recognition int  `json:"recognition"`
+	Recall      int  `json:"recall"`
+	Mastered    int  `json:"mastered"`
+	Completed   bool `json:"completed"`
+}
+
+// 打字作答跟答案的差異，以正規化後的字元計
+type AnswerDiff struct {
+	Op   string `json:"op"` // equal、missing(答案有但作答沒打)、extra(作答多打的)
+	Text string `json:"text"`
+}
+
+// 一個單字的作答結果
+type StudyResult struct {
+	WordSetID string `json:"wordSetID" validate:"required"`
+	WordID    string `json:"wordID" validate:"required"`
+	Correct   bool   `json:"correct"`
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+個人API token
+使用者登入後可以建立有名稱、權限範圍(scope)、可撤銷的token
+script/CLI用Authorization: Bearer <token>呼叫/api/v1，DB只存token的SHA-256
+--------------------------------------------------------------
+*/
+
+// 用Bearer token找出對應的token，並更新lastUsedAt
+func lookupAccessToken(ctx context.Context, token string) (*Type.PersonalAccessToken, error) {
+	if !strings.HasPrefix(token, Consts.AccessTokenPrefix) {
+		return nil, Type.Unauthorized("API token無效或已被撤銷")
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("personalAccessTokens")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var accessToken Type.PersonalAccessToken
+	err := coll.FindOne(findingContext, bson.M{"tokenHash": utils.HashAccessToken(token)}).Decode(&accessToken)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.Unauthorized("API token無效或已被撤銷")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+
+	now := utils.GetNow()
+	if accessToken.ExpiresAt != 0 && accessToken.ExpiresAt < now {
+		return nil, Type.Unauthorized("API token已過期")
+	}
+	// 不用每個request都寫DB，隔一段時間才更新一次
+	if now-accessToken.LastUsedAt >= int64(Consts.AccessTokenLastUsedInterval) {
+		_, err := coll.UpdateOne(findingContext, bson.M{"id": accessToken.ID}, bson.M{"$set": bson.M{"lastUsedAt": now}})
+		if err != nil {
+			loggerFromContext(ctx).Warn("update access token lastUsedAt failed", "tokenID", accessToken.ID, "error", err)
+		}
+		accessToken.LastUsedAt = now
+	}
+	return &accessToken, nil
+}
+
+// wordsets:write包含wordsets:read，加入讀取權限之前建立的token不用重新建立
+func accessTokenHasScope(accessToken *Type.PersonalAccessToken, scope string) bool {
+	if slices.Contains(accessToken.Scopes, scope) {
+		return true
+	}
+	return scope == Consts.ScopeWordSetsRead && slices.Contains(accessToken.Scopes, Consts.ScopeWordSetsWrite)
+}
+
+func v1ListAccessTokens(r *http.Request) (any, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("personalAccessTokens")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userID": userIDFromContext(r.Context())}
+	cursor, err := coll.Find(findingContext, filter, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	accessTokens := make([]Type.PersonalAccessToken, 0)
+	if err := cursor.All(findingContext, &accessTokens); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return accessTokens, nil
+}
+
+func v1CreateAccessToken(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1CreateAccessTokenRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(request.Name)
+	if len(name) == 0 || len(name) > Consts.MaxTitleLen {
+		return nil, Type.BadRequest("token名稱不得為空且不得超過50字元")
+	}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(Consts.AccessTokenScopes, scope) {
+			return nil, Type.BadRequest("未知的權限範圍(%s)").WithArgs(scope)
+		}
+	}
+	scopes := slices.Compact(slices.Sorted(slices.Values(request.Scopes)))
+	if request.ExpiresInDays < 0 || request.ExpiresInDays > Consts.MaxAccessTokenDays {
+		return nil, Type.BadRequest("有效天數須為0至%d天").WithArgs(Consts.MaxAccessTokenDays)
+	}
+
+	userID := userIDFromContext(r.Context())
+	coll := DB.Client.Database("go-quizlet").Collection("personalAccessTokens")
+	writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cnt, err := coll.CountDocuments(writingContext, bson.M{"userID": userID})
+	if err != nil {
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if cnt >= int64(Consts.MaxAccessTokens) {
+		return nil, Type.Conflict("API token數量已達上限(%d個)").WithArgs(Consts.MaxAccessTokens)
+	}
+
+	token, err := utils.GenerateAccessToken()
+	if err != nil {
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	now := utils.GetNow()
+	accessToken := Type.PersonalAccessToken{
+		ID:        utils.GenerateID(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: utils.HashAccessToken(token),
+		Prefix:    token[:len(Consts.AccessTokenPrefix)+6],
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if request.ExpiresInDays > 0 {
+		accessToken.ExpiresAt = now + int64(request.ExpiresInDays)*24*60*60
+	}
+	if _, err := coll.InsertOne(writingContext, accessToken); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+
+	loggerFromContext(r.Context()).Info("access token created", "tokenID", accessToken.ID, "scopes", scopes)
+	return Type.V1CreateAccessTokenResponse{Token: token, AccessToken: accessToken}, nil
+}
+
+// 撤銷就直接刪掉，之後用這個token的request都會是401
+func v1RevokeAccessToken(r *http.Request) (any, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("personalAccessTokens")
+	deletingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tokenID := r.PathValue("tokenID")
+	res, err := coll.DeleteOne(deletingContext, bson.M{"id": tokenID, "userID": userIDFromContext(r.Context())})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	if res.DeletedCount == 0 {
+		return nil, Type.NotFound("查無此API token")
+	}
+	loggerFromContext(r.Context()).Info("access token revoked", "tokenID", tokenID)
+	return nil, nil
+}
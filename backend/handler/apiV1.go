@@ -0,0 +1,910 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/language"
+	"go-quizlet/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+--------------------------------------------------------------
+/api/v1 resource-oriented API
+同一份route table用來註冊mux以及產生OpenAPI文件(GET /api/v1/openapi.json)
+舊的RPC-style route保留給現有前端，兩邊共用同一份邏輯function
+--------------------------------------------------------------
+*/
+
+const apiV1Prefix = "/api/v1"
+
+// 單字列表分頁的預設/最大筆數
+const (
+	defaultWordPageSize = 100
+	maxWordPageSize     = 500
+)
+
+type apiParam struct {
+	Name        string
+	Type        string // string、integer、boolean
+	Required    bool
+	Description string
+}
+
+type apiRoute struct {
+	Name         string // OpenAPI的operationId
+	Method       string
+	Path         string // 相對於/api/v1，path params用{name}
+	Summary      string
+	Tag          string
+	Auth         bool       // 需要登入(JWT cookie或API token)，handler可以用userIDFromContext拿到userID
+	OptionalAuth bool       // 不登入也可以呼叫，有登入的話userIDFromContext拿得到userID(例如讀取非公開的單字集)
+	Scope        string     // 用API token呼叫時需要的權限範圍
+	SessionOnly  bool       // 只接受JWT cookie，不接受API token(例如管理token本身)
+	Query        []apiParam // query params
+	Request      any        // request body的型別，nil代表沒有body
+	Upload       string     // request body直接是檔案時的MIME type(例如image/*)，跟Request擇一
+	Response     any        // 成功時回傳的型別，nil代表回204 No Content
+	Status       int        // 成功時的status code，0代表200
+	Handle       func(r *http.Request) (any, error)
+}
+
+func apiV1Routes() []apiRoute {
+	return []apiRoute{
+		// users
+		{Name: "getMe", Method: "GET", Path: "/me", Tag: "users", Auth: true, Scope: Consts.ScopeAccountRead,
+			Summary: "取得目前登入的使用者", Response: Type.FrontEndUser{}, Handle: v1GetMe},
+		{Name: "updateMe", Method: "PATCH", Path: "/me", Tag: "users", Auth: true, Scope: Consts.ScopeAccountWrite,
+			Summary: "更改使用者名稱或語系", Request: Type.V1UpdateMeRequest{}, Response: Type.FrontEndUser{}, Handle: v1UpdateMe},
+		{Name: "listRecentVisits", Method: "GET", Path: "/me/recent-visits", Tag: "users", Auth: true, Scope: Consts.ScopeAccountRead,
+			Summary: "最近查看的單字集", Response: []Type.HomePageWordSet{}, Handle: v1ListRecentVisits},
+		{Name: "addRecentVisit", Method: "POST", Path: "/me/recent-visits", Tag: "users", Auth: true, Scope: Consts.ScopeAccountWrite,
+			Summary: "新增最近查看的單字集", Request: Type.V1AddRecentVisitRequest{}, Handle: v1AddRecentVisit},
+		{Name: "getUser", Method: "GET", Path: "/users/{userID}", Tag: "users",
+			Summary: "取得使用者的公開資訊", Response: Type.UserLink{}, Handle: v1GetUser},
+		{Name: "getUserLibrary", Method: "GET", Path: "/users/{userID}/library", Tag: "users", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "使用者自創以及收藏的單字集，只列出呼叫者看得到的", Response: Type.LibPage{}, Handle: v1GetUserLibrary},
+
+		// wordsets
+		{Name: "searchWordSets", Method: "GET", Path: "/wordsets", Tag: "wordsets",
+			Summary: "用標題前綴搜尋公開的單字集",
+			Query: []apiParam{
+				{Name: "query", Type: "string", Required: true, Description: "標題前綴"},
+				{Name: "offset", Type: "integer", Description: "略過前幾筆，預設0"},
+			},
+			Response: Type.SearchWordSetResponse{}, Handle: v1SearchWordSets},
+		{Name: "createWordSet", Method: "POST", Path: "/wordsets", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "新建單字集", Request: Type.V1CreateWordSetRequest{}, Response: Type.V1CreatedResponse{}, Status: http.StatusCreated, Handle: v1CreateWordSet},
+		{Name: "listNewWordSets", Method: "GET", Path: "/explore/new", Tag: "wordsets",
+			Summary: "最新的公開單字集", Response: []Type.HomePageWordSet{}, Handle: v1ListNewWordSets},
+		{Name: "listPopularWordSets", Method: "GET", Path: "/explore/popular", Tag: "wordsets",
+			Summary: "最熱門的公開單字集", Response: []Type.HomePageWordSet{}, Handle: v1ListPopularWordSets},
+		{Name: "getWordSet", Method: "GET", Path: "/wordsets/{wordSetID}", Tag: "wordsets", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary:  "取得單字集，非公開的單字集需要是作者、協作者或帶著分享連結的token",
+			Query:    []apiParam{{Name: "share", Type: "string", Description: "分享連結的token，也可以放在X-Share-Token header"}},
+			Response: Type.WordSet{}, Handle: v1GetWordSet},
+		{Name: "updateWordSet", Method: "PATCH", Path: "/wordsets/{wordSetID}", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "更改單字集內容(作者或editor協作者)，公開範圍、複製設定以及重複單字設定僅限作者", Request: Type.V1UpdateWordSetRequest{}, Response: Type.WordSet{}, Handle: v1UpdateWordSet},
+		{Name: "deleteWordSet", Method: "DELETE", Path: "/wordsets/{wordSetID}", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "把單字集移到垃圾桶(僅限作者)", Handle: v1DeleteWordSet},
+		{Name: "likeWordSet", Method: "PUT", Path: "/wordsets/{wordSetID}/like", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "收藏單字集", Query: []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}}, Handle: v1LikeWordSet},
+		{Name: "unlikeWordSet", Method: "DELETE", Path: "/wordsets/{wordSetID}/like", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "取消收藏單字集", Handle: v1UnlikeWordSet},
+		{Name: "forkWordSet", Method: "POST", Path: "/wordsets/{wordSetID}/forks", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "複製單字集到自己的單字集", Query: []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Response: Type.V1CreatedResponse{}, Status: http.StatusCreated, Handle: v1ForkWordSet},
+
+		{Name: "splitWordSet", Method: "POST", Path: "/wordsets/{wordSetID}/split", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "依單字順序把單字集拆成好幾份，原本的單字集留下第一份，If-Match帶版本號(僅限作者)",
+			Request: Type.V1SplitWordSetRequest{}, Response: Type.V1SplitWordSetResponse{}, Status: http.StatusCreated, Handle: v1SplitWordSet},
+		{Name: "mergeWordSets", Method: "POST", Path: "/wordsets/merge", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "把多個單字集合併成一個新的單字集並標出重複的單字，別人的單字集要允許複製，trashSources需要是所有來源的作者",
+			Request: Type.V1MergeWordSetsRequest{}, Response: Type.V1MergeWordSetsResponse{}, Status: http.StatusCreated, Handle: v1MergeWordSets},
+		{Name: "listWordSetForks", Method: "GET", Path: "/wordsets/{wordSetID}/forks", Tag: "wordsets", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "從這個單字集複製出去的單字集，只列出呼叫者看得到的",
+			Query: []apiParam{
+				{Name: "offset", Type: "integer", Description: "略過前幾筆，預設0"},
+				{Name: "share", Type: "string", Description: "分享連結的token"},
+			},
+			Response: []Type.LibWordSetDisplay{}, Handle: v1ListWordSetForks},
+		{Name: "getUpstreamChanges", Method: "GET", Path: "/wordsets/{wordSetID}/upstream", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "複製來的單字集跟原始單字集之間的單字差異，conflict代表兩邊都改過(作者或editor協作者)", Response: Type.UpstreamChanges{},
+			Query: []apiParam{{Name: "share", Type: "string", Description: "原始單字集的分享連結token，原始單字集不公開時要帶"}}, Handle: v1GetUpstreamChanges},
+		{Name: "pullUpstream", Method: "POST", Path: "/wordsets/{wordSetID}/upstream/pull", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "把原始單字集的變更合併進來，衝突的單字要在resolutions選擇upstream或local(作者或editor協作者)", Request: Type.V1PullUpstreamRequest{}, Response: Type.WordSet{},
+			Query: []apiParam{{Name: "share", Type: "string", Description: "原始單字集的分享連結token，原始單字集不公開時要帶"}}, Handle: v1PullUpstream},
+
+		{Name: "listDuplicateWords", Method: "GET", Path: "/wordsets/{wordSetID}/duplicates", Tag: "wordsets", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary:  "單字集中重複(exact)或拼字相近的單字群組，權限同getWordSet",
+			Query:    []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Response: []Type.DuplicateCluster{}, Handle: v1ListDuplicateWords},
+
+		{Name: "getCardTemplate", Method: "GET", Path: "/wordsets/{wordSetID}/template", Tag: "wordsets", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary:  "單字集的卡片模板(欄位以及正反面)，沒設定的話回傳依shouldSwap換好的預設模板，權限同getWordSet",
+			Query:    []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Response: Type.CardTemplate{}, Handle: v1GetCardTemplate},
+		{Name: "putCardTemplate", Method: "PUT", Path: "/wordsets/{wordSetID}/template", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "設定卡片模板，一定要有vocabulary跟definition欄位，拿掉的自訂欄位會從所有單字刪除，If-Match帶版本號(作者或editor協作者)",
+			Request: Type.CardTemplate{}, Response: Type.WordSet{}, Handle: v1PutCardTemplate},
+		{Name: "deleteCardTemplate", Method: "DELETE", Path: "/wordsets/{wordSetID}/template", Tag: "wordsets", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "改回預設模板並刪除所有自訂欄位，If-Match帶版本號(作者或editor協作者)", Response: Type.WordSet{}, Handle: v1DeleteCardTemplate},
+
+		// share links
+		{Name: "listShareLinks", Method: "GET", Path: "/wordsets/{wordSetID}/share-links", Tag: "share-links", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "單字集的分享連結(僅限作者)，只有公開範圍為unlisted時連結才有效", Response: []Type.WordSetShareLink{}, Handle: v1ListShareLinks},
+		{Name: "createShareLink", Method: "POST", Path: "/wordsets/{wordSetID}/share-links", Tag: "share-links", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "建立分享連結(僅限作者)，token明碼只會回傳這一次", Request: Type.V1CreateShareLinkRequest{}, Response: Type.V1CreateShareLinkResponse{}, Status: http.StatusCreated, Handle: v1CreateShareLink},
+		{Name: "revokeShareLink", Method: "DELETE", Path: "/wordsets/{wordSetID}/share-links/{linkID}", Tag: "share-links", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "撤銷分享連結(僅限作者)", Handle: v1RevokeShareLink},
+
+		// words
+		{Name: "listWords", Method: "GET", Path: "/wordsets/{wordSetID}/words", Tag: "words", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "分頁取得單字集中的單字，權限同getWordSet，star是呼叫者自己的星號",
+			Query: []apiParam{
+				{Name: "offset", Type: "integer", Description: "略過前幾個單字，預設0"},
+				{Name: "limit", Type: "integer", Description: "一次最多拿幾個單字，預設100，最多500"},
+				{Name: "share", Type: "string", Description: "分享連結的token"},
+			},
+			Response: Type.V1WordPage{}, Handle: v1ListWords},
+		{Name: "addWord", Method: "POST", Path: "/wordsets/{wordSetID}/words", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "新增單字(作者或editor協作者)", Request: Type.V1WordInput{}, Response: Type.Word{}, Status: http.StatusCreated, Handle: v1AddWord},
+		{Name: "transferWords", Method: "POST", Path: "/wordsets/{wordSetID}/words/transfer", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "從其他單字集複製(copy)或移動(move)單字到這個單字集，If-Match帶目標的版本號(目標以及move的來源需要是作者或editor協作者，copy別人的單字集要允許複製)",
+			Request: Type.V1TransferWordsRequest{}, Response: Type.V1TransferWordsResponse{}, Handle: v1TransferWords},
+		{Name: "updateAllWords", Method: "PATCH", Path: "/wordsets/{wordSetID}/words", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "一次設定所有單字自己的星號，看得到單字集就可以設定", Query: []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Request: Type.V1UpdateAllWordsRequest{}, Handle: v1UpdateAllWords},
+		{Name: "updateWord", Method: "PATCH", Path: "/wordsets/{wordSetID}/words/{wordID}", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "更改單字(作者或editor協作者)，star是呼叫者自己的星號", Request: Type.V1UpdateWordRequest{}, Response: Type.Word{}, Handle: v1UpdateWord},
+		{Name: "starWord", Method: "PUT", Path: "/wordsets/{wordSetID}/words/{wordID}/star", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "幫單字加上自己的星號，看得到單字集就可以加", Query: []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}}, Handle: v1StarWord},
+		{Name: "unstarWord", Method: "DELETE", Path: "/wordsets/{wordSetID}/words/{wordID}/star", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "取消單字自己的星號", Query: []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}}, Handle: v1UnstarWord},
+		{Name: "gradeAnswer", Method: "POST", Path: "/wordsets/{wordSetID}/words/{wordID}/grade", Tag: "words", OptionalAuth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "判定打字作答，忽略大小寫、空白、標點、全形以及重音，答案可用/或;分隔多個，容許的錯字依leniency回傳almost",
+			Query:   []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Request: Type.V1GradeAnswerRequest{}, Response: Type.GradeAnswerResponse{}, Handle: v1GradeAnswer},
+		{Name: "putWordImage", Method: "PUT", Path: "/wordsets/{wordSetID}/words/{wordID}/image", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "上傳或更換單字的圖片(jpeg、png、gif，最多5MB)，body直接放圖片檔，If-Match帶版本號(作者或editor協作者)",
+			Upload:  "image/*", Response: Type.WordImage{}, Handle: v1PutWordImage},
+		{Name: "deleteWordImage", Method: "DELETE", Path: "/wordsets/{wordSetID}/words/{wordID}/image", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "刪除單字的圖片，If-Match帶版本號(作者或editor協作者)", Handle: v1DeleteWordImage},
+		{Name: "putWordAudio", Method: "PUT", Path: "/wordsets/{wordSetID}/words/{wordID}/audio/{side}", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "上傳或更換單字vocabulary或definition的發音(mp3、wav、ogg、m4a，最多3MB、15秒)，播放時優先於TTS，body直接放音檔，If-Match帶版本號(作者或editor協作者)",
+			Upload:  "audio/*", Response: Type.WordAudio{}, Handle: v1PutWordAudio},
+		{Name: "deleteWordAudio", Method: "DELETE", Path: "/wordsets/{wordSetID}/words/{wordID}/audio/{side}", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "刪除單字vocabulary或definition的發音，之後改回TTS，If-Match帶版本號(作者或editor協作者)", Handle: v1DeleteWordAudio},
+		{Name: "deleteWord", Method: "DELETE", Path: "/wordsets/{wordSetID}/words/{wordID}", Tag: "words", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "刪除單字(作者或editor協作者)", Handle: v1DeleteWord},
+
+		// learn
+		{Name: "getLearnSession", Method: "GET", Path: "/wordsets/{wordSetID}/learn", Tag: "learn", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary:  "Learn模式目前的進度以及題目，跟單字集同步(新增的單字從選擇題開始)，還沒開始過回404",
+			Query:    []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Response: Type.LearnSession{}, Handle: v1GetLearnSession},
+		{Name: "startLearnSession", Method: "POST", Path: "/wordsets/{wordSetID}/learn", Tag: "learn", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "開始Learn模式，已經有進度就接著學",
+			Query: []apiParam{
+				{Name: "restart", Type: "boolean", Description: "true的話放棄目前的進度重新開始"},
+				{Name: "share", Type: "string", Description: "分享連結的token"},
+			},
+			Response: Type.LearnSession{}, Handle: v1StartLearnSession},
+		{Name: "answerLearnQuestion", Method: "POST", Path: "/wordsets/{wordSetID}/learn/answer", Tag: "learn", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "回答目前的題目，選擇題帶choiceIndex、打字作答帶answer，答對升一個階段，答錯隔幾題再考；questionID不是目前的題目回409",
+			Query:   []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Request: Type.V1AnswerLearnQuestionRequest{}, Response: Type.LearnAnswerResponse{}, Handle: v1AnswerLearnQuestion},
+		{Name: "deleteLearnSession", Method: "DELETE", Path: "/wordsets/{wordSetID}/learn", Tag: "learn", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "放棄Learn模式的進度", Handle: v1DeleteLearnSession},
+
+		// review
+		{Name: "recordStudyResults", Method: "POST", Path: "/me/study-results", Tag: "review", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "回報練習時每個單字答對或答錯，用來組missed/due複習牌組",
+			Query:   []apiParam{{Name: "share", Type: "string", Description: "分享連結的token"}},
+			Request: Type.V1RecordStudyResultsRequest{}, Handle: v1RecordStudyResults},
+		{Name: "getReviewDeck", Method: "GET", Path: "/me/review-deck", Tag: "review", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "跨單字集的複習牌組，單字已依各自單字集的shouldSwap換好，wordSetIDs對應每個單字的來源單字集",
+			Query: []apiParam{
+				{Name: "rule", Type: "string", Required: true, Description: "starred、missed或due"},
+				{Name: "days", Type: "integer", Description: "missed看最近幾天，預設7"},
+				{Name: "wordSetIDs", Type: "string", Description: "只從這些單字集挑，用逗號分隔"},
+			},
+			Response: Type.FullWordCardType{}, Handle: v1GetReviewDeck},
+
+		// trash
+		{Name: "listTrash", Method: "GET", Path: "/me/trash", Tag: "trash", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "垃圾桶中的單字集，超過purgeAt會被永久刪除", Response: []Type.V1TrashWordSet{}, Handle: v1ListTrash},
+		{Name: "restoreFromTrash", Method: "POST", Path: "/me/trash/{wordSetID}/restore", Tag: "trash", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "從垃圾桶還原單字集", Response: Type.WordSet{}, Handle: v1RestoreFromTrash},
+		{Name: "purgeFromTrash", Method: "DELETE", Path: "/me/trash/{wordSetID}", Tag: "trash", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "永久刪除垃圾桶中的單字集", Handle: v1PurgeFromTrash},
+
+		// revisions
+		{Name: "listWordSetRevisions", Method: "GET", Path: "/wordsets/{wordSetID}/revisions", Tag: "revisions", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary:  "單字集的版本紀錄以及每個版本新增/刪除/修改的單字，由新到舊(作者或協作者)",
+			Query:    []apiParam{{Name: "offset", Type: "integer", Description: "略過前幾筆，預設0"}},
+			Response: []Type.WordSetRevision{}, Handle: v1ListWordSetRevisions},
+		{Name: "getWordSetRevision", Method: "GET", Path: "/wordsets/{wordSetID}/revisions/{number}", Tag: "revisions", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "取得某個版本，包含當時的完整內容(作者或協作者)", Response: Type.WordSetRevision{}, Handle: v1GetWordSetRevision},
+		{Name: "restoreWordSetRevision", Method: "POST", Path: "/wordsets/{wordSetID}/revisions/{number}/restore", Tag: "revisions", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "把單字集還原成某個版本的內容，還原本身也會產生新版本(作者或editor協作者)", Response: Type.WordSet{}, Handle: v1RestoreWordSetRevision},
+
+		// collaborators
+		{Name: "listCollaborators", Method: "GET", Path: "/wordsets/{wordSetID}/collaborators", Tag: "collaborators", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "單字集的協作者(作者或協作者)", Response: []Type.Collaborator{}, Handle: v1ListCollaborators},
+		{Name: "putCollaborator", Method: "PUT", Path: "/wordsets/{wordSetID}/collaborators/{userID}", Tag: "collaborators", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "邀請協作者或更改協作者的角色(editor/viewer)，新邀請的協作者會收到通知信(僅限作者)", Request: Type.V1PutCollaboratorRequest{}, Response: Type.Collaborator{}, Handle: v1PutCollaborator},
+		{Name: "removeCollaborator", Method: "DELETE", Path: "/wordsets/{wordSetID}/collaborators/{userID}", Tag: "collaborators", Auth: true, Scope: Consts.ScopeWordSetsWrite,
+			Summary: "移除協作者(作者)，或協作者自己退出", Handle: v1RemoveCollaborator},
+		{Name: "listSharedWordSets", Method: "GET", Path: "/me/shared-wordsets", Tag: "collaborators", Auth: true, Scope: Consts.ScopeWordSetsRead,
+			Summary: "別人邀請自己協作的單字集", Response: []Type.V1SharedWordSet{}, Handle: v1ListSharedWordSets},
+
+		// mails
+		{Name: "listMails", Method: "GET", Path: "/mails", Tag: "mails", Auth: true, Scope: Consts.ScopeAccountRead,
+			Summary: "取得信件", Response: []Type.MailViewType{}, Handle: v1ListMails},
+		{Name: "getUnreadMailCount", Method: "GET", Path: "/mails/unread-count", Tag: "mails", Auth: true, Scope: Consts.ScopeAccountRead,
+			Summary: "未讀信件數量", Response: Type.V1UnreadCountResponse{}, Handle: v1GetUnreadMailCount},
+		{Name: "readMail", Method: "PUT", Path: "/mails/{mailID}/read", Tag: "mails", Auth: true, Scope: Consts.ScopeAccountWrite,
+			Summary: "標記信件為已讀", Handle: v1ReadMail},
+
+		// tokens
+		{Name: "listAccessTokens", Method: "GET", Path: "/me/tokens", Tag: "tokens", Auth: true, SessionOnly: true,
+			Summary: "列出個人API token", Response: []Type.PersonalAccessToken{}, Handle: v1ListAccessTokens},
+		{Name: "createAccessToken", Method: "POST", Path: "/me/tokens", Tag: "tokens", Auth: true, SessionOnly: true,
+			Summary: "建立個人API token，token明碼只會回傳這一次", Request: Type.V1CreateAccessTokenRequest{}, Response: Type.V1CreateAccessTokenResponse{}, Status: http.StatusCreated, Handle: v1CreateAccessToken},
+		{Name: "revokeAccessToken", Method: "DELETE", Path: "/me/tokens/{tokenID}", Tag: "tokens", Auth: true, SessionOnly: true,
+			Summary: "撤銷個人API token", Handle: v1RevokeAccessToken},
+
+		// webhooks
+		{Name: "listWebhooks", Method: "GET", Path: "/me/webhooks", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary: "列出webhook", Response: []Type.Webhook{}, Handle: v1ListWebhooks},
+		{Name: "createWebhook", Method: "POST", Path: "/me/webhooks", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary: "註冊webhook，簽章用的secret只會回傳這一次", Request: Type.V1CreateWebhookRequest{}, Response: Type.V1CreateWebhookResponse{}, Status: http.StatusCreated, Handle: v1CreateWebhook},
+		{Name: "updateWebhook", Method: "PATCH", Path: "/me/webhooks/{webhookID}", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary: "更改webhook網址、訂閱的事件或停用", Request: Type.V1UpdateWebhookRequest{}, Response: Type.Webhook{}, Handle: v1UpdateWebhook},
+		{Name: "deleteWebhook", Method: "DELETE", Path: "/me/webhooks/{webhookID}", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary: "刪除webhook以及送出紀錄", Handle: v1DeleteWebhook},
+		{Name: "listWebhookDeliveries", Method: "GET", Path: "/me/webhooks/{webhookID}/deliveries", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary:  "webhook送出紀錄，由新到舊",
+			Query:    []apiParam{{Name: "offset", Type: "integer", Description: "略過前幾筆，預設0"}},
+			Response: []Type.WebhookDelivery{}, Handle: v1ListWebhookDeliveries},
+		{Name: "redeliverWebhook", Method: "POST", Path: "/me/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", Tag: "webhooks", Auth: true, SessionOnly: true,
+			Summary: "用同一個事件重送一次", Handle: v1RedeliverWebhook},
+
+		// languages
+		{Name: "listLanguages", Method: "GET", Path: "/languages", Tag: "languages",
+			Summary: "單字、註釋以及模板欄位可以使用的語言，sound欄位填tag", Response: []language.Language{}, Handle: v1ListLanguages},
+	}
+}
+
+// 把v1 route註冊到mux，並提供OpenAPI文件
+func registerAPIV1(mux *http.ServeMux) {
+	routes := apiV1Routes()
+	for _, route := range routes {
+		if (route.Auth || route.OptionalAuth) && !route.SessionOnly && route.Scope == "" {
+			panic("api route " + route.Name + " accepts API tokens but has no scope")
+		}
+		mux.HandleFunc(route.Method+" "+apiV1Prefix+route.Path, serveAPIRoute(route))
+	}
+	// WebSocket不是JSON request/response，不放進route table(也不會出現在OpenAPI文件)
+	mux.HandleFunc("GET "+apiV1Prefix+"/wordsets/{wordSetID}/live", serveWordSetLive)
+
+	spec, err := json.Marshal(buildOpenAPI(routes))
+	if err != nil {
+		panic("build openapi spec: " + err.Error())
+	}
+	mux.HandleFunc("GET "+apiV1Prefix+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.Write(spec)
+	})
+}
+
+func serveAPIRoute(route apiRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if route.Auth {
+			userID, err := authenticateAPIRequest(r, route)
+			if err != nil {
+				writeAPIError(w, r, err)
+				return
+			}
+			r = r.WithContext(contextWithUserID(r.Context(), userID))
+		} else if route.OptionalAuth {
+			userID, err := optionalAPIUser(r, route)
+			if err != nil {
+				writeAPIError(w, r, err)
+				return
+			}
+			r = r.WithContext(contextWithUserID(r.Context(), userID))
+		}
+
+		ctx, version := contextWithVersionSink(r.Context())
+		ctx, duplicates := contextWithDuplicateSink(ctx)
+		data, err := route.Handle(r.WithContext(ctx))
+		if err != nil {
+			writeAPIError(w, r, err)
+			return
+		}
+		// 重複單字只提醒組數，詳細內容用GET /wordsets/{wordSetID}/duplicates查
+		if len(*duplicates) > 0 {
+			w.Header().Set(duplicateWordsHeader, strconv.Itoa(len(*duplicates)))
+		}
+		// 變更單字集內容後回傳新的版本號，下一次編輯放在If-Match
+		if *version > 0 {
+			w.Header().Set("ETag", strconv.Quote(strconv.Itoa(*version)))
+			notifyLiveRoom(ctx, r.PathValue("wordSetID"))
+		}
+		if route.Response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		writeAPIJson(w, status, data)
+	}
+}
+
+// 有Authorization: Bearer就用個人API token驗證並檢查scope，否則從JWT cookie取出userID
+func authenticateAPIRequest(r *http.Request, route apiRoute) (string, error) {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		if !ok {
+			return "", Type.Unauthorized("憑證錯誤")
+		}
+		if route.SessionOnly {
+			return "", Type.Forbidden("此操作不接受API token 請登入後操作")
+		}
+		accessToken, err := lookupAccessToken(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			return "", err
+		}
+		if !accessTokenHasScope(accessToken, route.Scope) {
+			return "", Type.Forbidden("API token缺少%s權限").WithArgs(route.Scope)
+		}
+		loggerFromContext(r.Context()).Debug("authenticated by access token", "tokenID", accessToken.ID, "userID", accessToken.UserID)
+		return accessToken.UserID, nil
+	}
+
+	userID := requestUserID(r)
+	if userID == "" {
+		return "", Type.Unauthorized("使用者未登入! 或憑證已過期!")
+	}
+	return userID, nil
+}
+
+// OptionalAuth的route：有帶API token就照常驗證(錯的token一樣是401)，否則看JWT cookie，沒登入回傳空字串
+func optionalAPIUser(r *http.Request, route apiRoute) (string, error) {
+	if r.Header.Get("Authorization") != "" {
+		return authenticateAPIRequest(r, route)
+	}
+	return requestUserID(r), nil
+}
+
+// v1不包Response envelope，直接回傳資料
+func writeAPIJson(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// v1的錯誤回應，HTTP status跟code由AppError決定
+func writeAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := toAppError(err)
+	logAppError(r, appErr)
+	writeAPIJson(w, appErr.Status, Type.MessageDisplayError{Message: translate(r, appErr.Message, appErr.Args...), Code: appErr.Code, Details: appErr.Details})
+}
+
+// 解析並驗證request body
+func decodeAPIBody[T any](r *http.Request) (T, error) {
+	var request T
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return request, Type.BadRequest("請求格式錯誤").Wrap(err)
+	}
+	if err := validate.Struct(request); err != nil {
+		return request, Type.BadRequest("請求缺少必要欄位").Wrap(err)
+	}
+	return request, nil
+}
+
+// 拿非負整數的query param，沒給就用預設值
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, Type.BadRequest("搜尋值錯誤")
+	}
+	return number, nil
+}
+
+// 驗證單字集標題跟敘述
+func validateWordSetText(title string, description string) error {
+	if len(strings.TrimSpace(title)) == 0 || len(title) > Consts.MaxTitleLen {
+		return Type.BadRequest("標題字數不得為0或超過50字元")
+	}
+	if !markdownLenOK(description, Consts.MaxDescriptionLen) {
+		return Type.BadRequest("敘述字數不得超過150字元")
+	}
+	return nil
+}
+
+/* ---------------- users ---------------- */
+
+func v1GetMe(r *http.Request) (any, error) {
+	user, err := getUserByID(userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return frontEndUser(user), nil
+}
+
+func v1UpdateMe(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1UpdateMeRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	ctx, userID := r.Context(), userIDFromContext(r.Context())
+	// 先確認語系合法，避免名稱改了語系卻失敗
+	if request.Locale != nil && strings.TrimSpace(*request.Locale) != "" {
+		if _, ok := i18n.Parse(*request.Locale); !ok {
+			return nil, Type.BadRequest("語系格式錯誤(zh-TW, en)")
+		}
+	}
+	if request.Name != nil {
+		if _, err := changeUserName(ctx, Type.ChangeUserNameRequest{UserID: userID, NewName: *request.Name}); err != nil {
+			return nil, err
+		}
+	}
+	if request.Locale != nil {
+		if _, err := changeUserLocale(ctx, Type.ChangeUserLocaleRequest{UserID: userID, Locale: *request.Locale}); err != nil {
+			return nil, err
+		}
+	}
+	return v1GetMe(r)
+}
+
+func v1ListRecentVisits(r *http.Request) (any, error) {
+	userID := userIDFromContext(r.Context())
+	return getRecentVisitWordSets(r.Context(), userID, userID)
+}
+
+func v1AddRecentVisit(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1AddRecentVisitRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	_, err = addRecentVisit(r.Context(), Type.AddRecentVisitRequest{UserID: userIDFromContext(r.Context()), WordSetID: request.WordSetID})
+	return nil, err
+}
+
+func v1GetUser(r *http.Request) (any, error) {
+	return getUserLink(r.Context(), r.PathValue("userID"))
+}
+
+func v1GetUserLibrary(r *http.Request) (any, error) {
+	return getLibPage(r.Context(), r.PathValue("userID"), userIDFromContext(r.Context()))
+}
+
+/* ---------------- wordsets ---------------- */
+
+func v1SearchWordSets(r *http.Request) (any, error) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		return nil, Type.BadRequest("搜尋值為空")
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	return searchWordSetCards(r.Context(), query, offset)
+}
+
+func v1ListNewWordSets(r *http.Request) (any, error) {
+	return getHomePageWordSets(r.Context(), "new")
+}
+
+func v1ListPopularWordSets(r *http.Request) (any, error) {
+	return getHomePageWordSets(r.Context(), "popular")
+}
+
+func v1CreateWordSet(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1CreateWordSetRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	userID := userIDFromContext(r.Context())
+	words := make([]Type.Word, len(request.Words))
+	for i, input := range request.Words {
+		words[i] = wordFromInput(input, i+1)
+	}
+	id, err := handleCreateWordSet(r.Context(), Type.CreateWordSetRequest{
+		UserID: userID,
+		WordSet: Type.WordSet{
+			Title:       strings.TrimSpace(request.Title),
+			Description: request.Description,
+			AuthorID:    userID,
+			Words:       words,
+			ShouldSwap:  request.ShouldSwap,
+			LikedUsers:  []string{},
+			AllowCopy:   request.AllowCopy,
+			IsPublic:    request.IsPublic,
+			Visibility:  request.Visibility,
+
+			DuplicatePolicy: request.DuplicatePolicy,
+			Template:        request.Template,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Type.V1CreatedResponse{ID: id}, nil
+}
+
+func v1GetWordSet(r *http.Request) (any, error) {
+	return getStarredWordSet(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r))
+}
+
+func v1UpdateWordSet(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	wordSet, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWordSetVersion(wordSet, version); err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1UpdateWordSetRequest](r)
+	if err != nil {
+		return nil, err
+	}
+
+	// wordSet是變更前的內容，版本紀錄要用，不能直接改
+	setFields := bson.M{}
+	title, description := wordSet.Title, wordSet.Description
+	if request.Title != nil {
+		title = strings.TrimSpace(*request.Title)
+		setFields["title"] = title
+	}
+	if request.Description != nil {
+		description = *request.Description
+		setFields["description"] = description
+	}
+	if err := validateWordSetText(title, description); err != nil {
+		return nil, err
+	}
+	if request.ShouldSwap != nil {
+		setFields["shouldSwap"] = *request.ShouldSwap
+	}
+	// 公開範圍以及複製設定只有作者可以更改
+	if (request.AllowCopy != nil || request.IsPublic != nil || request.Visibility != nil || request.DuplicatePolicy != nil) && wordSet.AuthorID != userIDFromContext(r.Context()) {
+		return nil, Type.Forbidden("只有作者可以更改公開設定")
+	}
+	if request.AllowCopy != nil {
+		setFields["allowCopy"] = *request.AllowCopy
+	}
+	// 改成reject不會檢查既有的單字，只影響之後的新增/修改
+	if request.DuplicatePolicy != nil {
+		setFields["duplicatePolicy"] = *request.DuplicatePolicy
+	}
+	// 舊的isPublic只有public跟private，同時給的話以visibility為準
+	if request.Visibility != nil || request.IsPublic != nil {
+		visibility := Consts.VisibilityPrivate
+		if request.Visibility != nil {
+			visibility = *request.Visibility
+		} else if *request.IsPublic {
+			visibility = Consts.VisibilityPublic
+		}
+		setFields["visibility"] = visibility
+		setFields["isPublic"] = visibility == Consts.VisibilityPublic
+	}
+	if len(setFields) > 0 {
+		setFields["updatedAt"] = utils.GetNow()
+		after, err := updateWordSetFields(r.Context(), wordSet, version, bson.M{"id": wordSetID}, setFields)
+		if err != nil {
+			return nil, err
+		}
+		recordWordSetRevisionOrLog(r.Context(), wordSet, after, Consts.RevisionActionEdit)
+		emitWordSetEvent(r.Context(), Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+			WordSetID: wordSetID, Title: after.Title, AuthorID: after.AuthorID, ActorID: userIDFromContext(r.Context()),
+		})
+		return after, nil
+	}
+	return wordSet, nil
+}
+
+func v1DeleteWordSet(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetAuthor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	_, err := deleteWordSet(r.Context(), Type.DeleteWordSetRequest{WordSetID: wordSetID})
+	return nil, err
+}
+
+func v1LikeWordSet(r *http.Request) (any, error) {
+	return nil, setWordSetLiked(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"), shareTokenFromRequest(r), true)
+}
+
+func v1UnlikeWordSet(r *http.Request) (any, error) {
+	return nil, setWordSetLiked(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"), shareTokenFromRequest(r), false)
+}
+
+// 舊的toggleLikeWordSet不是idempotent，v1先確認目前狀態，不一樣才toggle
+func setWordSetLiked(ctx context.Context, userID string, wordSetID string, shareToken string, liked bool) error {
+	user, err := getUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(user.LikedWordSets, wordSetID) == liked {
+		return nil
+	}
+	_, err = toggleLikeWordSet(ctx, Type.ToggleLikeWordSetRequest{UserID: userID, WordSetID: wordSetID, ShareToken: shareToken})
+	return err
+}
+
+func v1ForkWordSet(r *http.Request) (any, error) {
+	id, err := ForkWordSet(r.Context(), Type.ForkWordSetRequest{UserID: userIDFromContext(r.Context()), WordSetID: r.PathValue("wordSetID"), ShareToken: shareTokenFromRequest(r)})
+	if err != nil {
+		return nil, err
+	}
+	return Type.V1CreatedResponse{ID: id}, nil
+}
+
+/* ---------------- words ---------------- */
+
+func v1ListWords(r *http.Request) (any, error) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(r, "limit", defaultWordPageSize)
+	if err != nil {
+		return nil, err
+	}
+	limit = min(limit, maxWordPageSize)
+	wordSet, err := getStarredWordSet(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	start := min(offset, len(wordSet.Words))
+	end := min(start+limit, len(wordSet.Words))
+	return Type.V1WordPage{
+		Words:    wordSet.Words[start:end],
+		HaveMore: end < len(wordSet.Words),
+	}, nil
+}
+
+func v1AddWord(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	wordSet, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	input, err := decodeAPIBody[Type.V1WordInput](r)
+	if err != nil {
+		return nil, err
+	}
+	word := wordFromInput(input, len(wordSet.Words)+1)
+	word.Star = false
+	word.ID, err = addWord(r.Context(), Type.AddWordRequest{WordSetID: wordSetID, Version: &version, Word: word})
+	if err != nil {
+		return nil, err
+	}
+	// star是呼叫者自己的星號
+	if input.Star {
+		wordSet.Words = append(wordSet.Words, word)
+		if err := setWordStars(r.Context(), userIDFromContext(r.Context()), wordSet, []string{word.ID}, true); err != nil {
+			return nil, err
+		}
+		word.Star = true
+	}
+	return word, nil
+}
+
+func v1UpdateAllWords(r *http.Request) (any, error) {
+	userID := userIDFromContext(r.Context())
+	wordSet, err := getReadableWordSet(r.Context(), r.PathValue("wordSetID"), userID, shareTokenFromRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1UpdateAllWordsRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, setWordStars(r.Context(), userID, wordSet, wordIDsOf(wordSet.Words), request.Star)
+}
+
+func v1UpdateWord(r *http.Request) (any, error) {
+	wordSetID, wordID := r.PathValue("wordSetID"), r.PathValue("wordID")
+	wordSet, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1UpdateWordRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	word, err := applyWordUpdate(r.Context(), wordSet, wordID, version, request)
+	if err != nil {
+		return nil, err
+	}
+	// star是呼叫者自己的星號，不會寫進單字集
+	userID := userIDFromContext(r.Context())
+	if request.Star != nil {
+		if err := setWordStars(r.Context(), userID, wordSet, []string{wordID}, *request.Star); err != nil {
+			return nil, err
+		}
+	}
+	starred, err := loadStarredWords(r.Context(), userID, wordSet)
+	if err != nil {
+		return nil, err
+	}
+	word.Star = starred[wordID]
+	return word, nil
+}
+
+// 把request裡有給的欄位套用到單字上並寫回DB，v1 API以及即時協作共用，star是個人的星號不在這裡處理
+func applyWordUpdate(ctx context.Context, wordSet *Type.WordSet, wordID string, version int, request Type.V1UpdateWordRequest) (Type.Word, error) {
+	if err := checkWordSetVersion(wordSet, version); err != nil {
+		return Type.Word{}, err
+	}
+	index := slices.IndexFunc(wordSet.Words, func(word Type.Word) bool { return word.ID == wordID })
+	if index == -1 {
+		return Type.Word{}, Type.NotFound("查無此單字或單字集")
+	}
+
+	word := wordSet.Words[index]
+	if request.Vocabulary != nil {
+		word.Vocabulary = strings.TrimSpace(*request.Vocabulary)
+	}
+	if request.Definition != nil {
+		word.Definition = strings.TrimSpace(*request.Definition)
+	}
+	if request.VocabularySound != nil {
+		word.VocabularySound = *request.VocabularySound
+	}
+	if request.DefinitionSound != nil {
+		word.DefinitionSound = *request.DefinitionSound
+	}
+	if request.Order != nil {
+		word.Order = *request.Order
+	}
+	if request.Pronunciation != nil {
+		word.Pronunciation = strings.TrimSpace(*request.Pronunciation)
+	}
+	if request.PartOfSpeech != nil {
+		word.PartOfSpeech = *request.PartOfSpeech
+	}
+	if request.Meanings != nil {
+		word.Meanings = *request.Meanings
+	}
+	if request.Examples != nil {
+		word.Examples = *request.Examples
+	}
+	if err := validateWord(word); err != nil {
+		return Type.Word{}, err
+	}
+	if err := validateWordFields(wordSet.Template, request.Fields); err != nil {
+		return Type.Word{}, err
+	}
+	if request.Fields != nil {
+		word.Fields = mergeWordFields(word.Fields, request.Fields)
+	}
+	if request.Vocabulary != nil {
+		if err := checkEditedVocabulary(ctx, wordSet, wordID, word.Vocabulary); err != nil {
+			return Type.Word{}, err
+		}
+	}
+
+	setFields := bson.M{
+		"words.$.vocabulary":      word.Vocabulary,
+		"words.$.definition":      word.Definition,
+		"words.$.vocabularySound": word.VocabularySound,
+		"words.$.definitionSound": word.DefinitionSound,
+		"words.$.order":           word.Order,
+		"words.$.pronunciation":   word.Pronunciation,
+		"words.$.partOfSpeech":    word.PartOfSpeech,
+		"words.$.meanings":        word.Meanings,
+		"words.$.examples":        word.Examples,
+		"words.$.fields":          word.Fields,
+		"updatedAt":               utils.GetNow(),
+	}
+	after, err := updateWordSetFields(ctx, wordSet, version, bson.M{"id": wordSet.ID, "words.id": wordID}, setFields)
+	if err != nil {
+		return Type.Word{}, err
+	}
+	recordWordSetRevisionOrLog(ctx, wordSet, after, Consts.RevisionActionEdit)
+	return word, nil
+}
+
+func v1DeleteWord(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	_, err = deleteWord(r.Context(), Type.DeleteWordRequest{WordSetID: wordSetID, Version: &version, WordID: r.PathValue("wordID")})
+	return nil, err
+}
+
+// V1WordInput轉成Word，order沒給就用defaultOrder
+func wordFromInput(input Type.V1WordInput, defaultOrder int) Type.Word {
+	order := input.Order
+	if order == 0 {
+		order = defaultOrder
+	}
+	return Type.Word{
+		Order:           order,
+		Vocabulary:      strings.TrimSpace(input.Vocabulary),
+		Definition:      strings.TrimSpace(input.Definition),
+		VocabularySound: input.VocabularySound,
+		DefinitionSound: input.DefinitionSound,
+		Star:            input.Star,
+		Pronunciation:   strings.TrimSpace(input.Pronunciation),
+		PartOfSpeech:    input.PartOfSpeech,
+		Meanings:        input.Meanings,
+		Examples:        input.Examples,
+		Fields:          input.Fields,
+	}
+}
+
+// 用$set更新一個wordSet，version對不上回409，回傳更新後的單字集給版本紀錄用
+func updateWordSetFields(ctx context.Context, before *Type.WordSet, version int, filter bson.M, setFields bson.M) (*Type.WordSet, error) {
+	setFields["updatedBy"] = userIDFromContext(ctx)
+	return updateWordSetWithRevision(ctx, before, version, filter, bson.M{"$set": setFields}, Type.NotFound("查無此單字或單字集"))
+}
+
+/* ---------------- mails ---------------- */
+
+func v1ListMails(r *http.Request) (any, error) {
+	return getMails(r.Context(), userIDFromContext(r.Context()))
+}
+
+func v1GetUnreadMailCount(r *http.Request) (any, error) {
+	count, err := getUnreadMailsCnt(r.Context(), userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return Type.V1UnreadCountResponse{Count: count}, nil
+}
+
+func v1ReadMail(r *http.Request) (any, error) {
+	userID, mailID := userIDFromContext(r.Context()), r.PathValue("mailID")
+	user, err := getUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// 只能標記自己的信件
+	if !slices.Contains(user.Mails, mailID) {
+		return nil, Type.NotFound("查無該信件")
+	}
+	_, err = readMail(r.Context(), Type.ReadMailRequest{UserID: userID, MailID: mailID})
+	return nil, err
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/utils"
+	"html"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+單字集協作者
+作者可以邀請其他使用者成為editor(可編輯內容)或viewer(只能查看)
+權限檢查統一走checkWordSetRole，誰做了哪個變更記在wordSet.updatedBy以及版本紀錄的editorID
+--------------------------------------------------------------
+*/
+
+// 角色的權限等級，數字越大權限越多
+var wordSetRoleRank = map[string]int{
+	Consts.WordSetRoleViewer: 1,
+	Consts.WordSetRoleEditor: 2,
+	Consts.WordSetRoleOwner:  3,
+}
+
+// userID在這個wordSet的角色，沒有任何權限回傳空字串
+func wordSetRoleOf(wordSet *Type.WordSet, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	if wordSet.AuthorID == userID {
+		return Consts.WordSetRoleOwner
+	}
+	for _, collaborator := range wordSet.Collaborators {
+		if collaborator.UserID == userID {
+			return collaborator.Role
+		}
+	}
+	return ""
+}
+
+// userID在這個wordSet的權限是否至少有role
+func hasWordSetRole(wordSet *Type.WordSet, userID string, role string) bool {
+	current, ok := wordSetRoleRank[wordSetRoleOf(wordSet, userID)]
+	return ok && current >= wordSetRoleRank[role]
+}
+
+// 通知信裡顯示的角色名稱
+func collaboratorRoleName(locale i18n.Locale, role string) string {
+	if role == Consts.WordSetRoleEditor {
+		return i18n.T(locale, "編輯者")
+	}
+	return i18n.T(locale, "檢視者")
+}
+
+func v1ListCollaborators(r *http.Request) (any, error) {
+	wordSet, err := checkWordSetRole(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), Consts.WordSetRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if wordSet.Collaborators == nil {
+		return []Type.Collaborator{}, nil
+	}
+	return wordSet.Collaborators, nil
+}
+
+// 新增協作者或更改既有協作者的角色，新加入的協作者會收到一封通知信
+func v1PutCollaborator(r *http.Request) (any, error) {
+	wordSetID, targetID := r.PathValue("wordSetID"), r.PathValue("userID")
+	userID := userIDFromContext(r.Context())
+	wordSet, err := checkWordSetAuthor(r.Context(), wordSetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if targetID == wordSet.AuthorID {
+		return nil, Type.BadRequest("作者不需要加入協作者")
+	}
+	request, err := decodeAPIBody[Type.V1PutCollaboratorRequest](r)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	index := slices.IndexFunc(wordSet.Collaborators, func(c Type.Collaborator) bool { return c.UserID == targetID })
+	if index != -1 {
+		collaborator := wordSet.Collaborators[index]
+		if collaborator.Role == request.Role {
+			return collaborator, nil
+		}
+		writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		filter := bson.M{"id": wordSetID, "collaborators.userID": targetID}
+		res, err := coll.UpdateOne(writingContext, filter, bson.M{"$set": bson.M{"collaborators.$.role": request.Role}})
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, Type.Timeout("超時錯誤 請重試")
+			}
+			return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+		}
+		if res.MatchedCount == 0 {
+			return nil, Type.NotFound("查無此協作者")
+		}
+		loggerFromContext(r.Context()).Info("collaborator role changed", "wordSetID", wordSetID, "collaboratorID", targetID, "role", request.Role)
+		collaborator.Role = request.Role
+		return collaborator, nil
+	}
+
+	if len(wordSet.Collaborators) >= Consts.MaxCollaborators {
+		return nil, Type.BadRequest("每個單字集最多%d位協作者").WithArgs(Consts.MaxCollaborators)
+	}
+	invitee, err := getUserByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	inviter, err := getUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	collaborator := Type.Collaborator{
+		UserID:  targetID,
+		Role:    request.Role,
+		AddedBy: userID,
+		AddedAt: utils.GetNow(),
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	session, err := DB.Client.StartSession()
+	if err != nil {
+		return nil, Type.Internal("無法啟動資料庫會話，請重試")
+	}
+	defer session.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("無法啟動交易 請重試")
+		}
+		// 同時確認對方還不在名單裡、名單還沒滿，避免兩個request同時邀請
+		filter := bson.M{
+			"id":                   wordSetID,
+			"collaborators.userID": bson.M{"$ne": targetID},
+			"collaborators." + strconv.Itoa(Consts.MaxCollaborators-1): bson.M{"$exists": false},
+		}
+		res, err := coll.UpdateOne(sc, filter, bson.M{"$push": bson.M{"collaborators": collaborator}})
+		if err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
+		}
+		if res.MatchedCount == 0 {
+			session.AbortTransaction(sc)
+			return Type.Conflict("協作者名單已被更改 請重新整理")
+		}
+
+		locale, ok := i18n.Parse(invitee.Locale)
+		if !ok {
+			locale = i18n.DefaultLocale
+		}
+		mailID := utils.GenerateID()
+		// 信件內容會被當成HTML顯示，使用者輸入的名字以及標題要先跳脫
+		_, err = DB.Client.Database("go-quizlet").Collection("mails").InsertOne(sc, Type.MailViewType{
+			ID:         mailID,
+			Title:      i18n.T(locale, "單字集協作邀請"),
+			Content:    i18n.T(locale, "<b>%s</b> 邀請您成為單字集「%s」的%s", html.EscapeString(inviter.Name), html.EscapeString(wordSet.Title), collaboratorRoleName(locale, request.Role)),
+			Date:       utils.GetNow(),
+			ReceiverID: targetID,
+			Read:       false,
+		})
+		if err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
+		}
+		_, err = DB.Client.Database("go-quizlet").Collection("users").UpdateOne(sc, bson.M{"id": targetID}, bson.M{"$push": bson.M{"mails": mailID}})
+		if err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
+		}
+
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	loggerFromContext(r.Context()).Info("collaborator added", "wordSetID", wordSetID, "collaboratorID", targetID, "role", request.Role)
+	return collaborator, nil
+}
+
+// 作者可以移除任何協作者，協作者也可以自己退出
+func v1RemoveCollaborator(r *http.Request) (any, error) {
+	wordSetID, targetID := r.PathValue("wordSetID"), r.PathValue("userID")
+	userID := userIDFromContext(r.Context())
+	role := Consts.WordSetRoleOwner
+	if targetID == userID {
+		role = Consts.WordSetRoleViewer
+	}
+	if _, err := checkWordSetRole(r.Context(), wordSetID, userID, role); err != nil {
+		return nil, err
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	res, err := coll.UpdateOne(writingContext, bson.M{"id": wordSetID}, bson.M{"$pull": bson.M{"collaborators": bson.M{"userID": targetID}}})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.ModifiedCount == 0 {
+		return nil, Type.NotFound("查無此協作者")
+	}
+	loggerFromContext(r.Context()).Info("collaborator removed", "wordSetID", wordSetID, "collaboratorID", targetID)
+	return nil, nil
+}
+
+func v1ListSharedWordSets(r *http.Request) (any, error) {
+	userID := userIDFromContext(r.Context())
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.M{"updatedAt": -1}).
+		SetProjection(bson.M{"id": 1, "title": 1, "authorID": 1, "wordCnt": 1, "updatedAt": 1, "collaborators": 1})
+	cursor, err := coll.Find(findingContext, liveWordSetFilter(bson.M{"collaborators.userID": userID}), opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	var wordSets []Type.WordSet
+	if err := cursor.All(findingContext, &wordSets); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	shared := make([]Type.V1SharedWordSet, 0, len(wordSets))
+	for _, wordSet := range wordSets {
+		shared = append(shared, Type.V1SharedWordSet{
+			ID:        wordSet.ID,
+			Title:     wordSet.Title,
+			AuthorID:  wordSet.AuthorID,
+			WordCnt:   wordSet.WordCnt,
+			UpdatedAt: wordSet.UpdatedAt,
+			Role:      wordSetRoleOf(&wordSet, userID),
+		})
+	}
+	return shared, nil
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+--------------------------------------------------------------
+重複單字偵測
+完全相同：正規化(全形轉半形、大小寫、繁轉簡、空白)後一樣
+拼字相近：正規化後編輯距離很小，只比較夠長的單字，只會提醒不會拒絕
+單字集的duplicatePolicy為reject時，新增/修改的單字跟其他單字完全相同就拒絕(409 DUPLICATE_WORDS)
+warn(預設)照常寫入，重複的單字透過sink交給wrapper放進回應
+--------------------------------------------------------------
+*/
+
+// v1 API寫入後偵測到重複單字時，回應header帶重複的組數
+const duplicateWordsHeader = "X-Duplicate-Words"
+
+// 找出重複或相近的單字群組，changed不是nil時只回傳包含changed單字的群組
+func findDuplicateClusters(words []Type.Word, changed map[string]bool) []Type.DuplicateCluster {
+	keys := make([]string, len(words))
+	parent := make([]int, len(words))
+	for i, word := range words {
+		keys[i] = utils.NormalizeVocabulary(word.Vocabulary)
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i int, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	// 完全相同
+	first := map[string]int{}
+	for i, key := range keys {
+		if j, ok := first[key]; ok {
+			union(i, j)
+		} else {
+			first[key] = i
+		}
+	}
+	// 拼字相近，只需要比對跟changed有關的組合
+	if len(words) <= Consts.MaxFuzzyDuplicateWords {
+		for i := range words {
+			for j := i + 1; j < len(words); j++ {
+				if keys[i] == keys[j] || find(i) == find(j) {
+					continue
+				}
+				if changed != nil && !changed[words[i].ID] && !changed[words[j].ID] {
+					continue
+				}
+				if utils.IsSimilarVocabulary(keys[i], keys[j]) {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	groups := map[int][]int{}
+	for i := range words {
+		groups[find(i)] = append(groups[find(i)], i)
+	}
+	clusters := []Type.DuplicateCluster{}
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		if changed != nil && !slices.ContainsFunc(members, func(i int) bool { return changed[words[i].ID] }) {
+			continue
+		}
+		cluster := Type.DuplicateCluster{Exact: true, Words: make([]Type.Word, 0, len(members))}
+		for _, i := range members {
+			cluster.Exact = cluster.Exact && keys[i] == keys[members[0]]
+			cluster.Words = append(cluster.Words, words[i])
+		}
+		cluster.Words = sortedByOrder(cluster.Words)
+		clusters = append(clusters, cluster)
+	}
+	slices.SortFunc(clusters, func(a Type.DuplicateCluster, b Type.DuplicateCluster) int {
+		return a.Words[0].Order - b.Words[0].Order
+	})
+	return clusters
+}
+
+// 新增/修改單字後檢查重複，words是寫入後的完整單字列表，changed是這次新增或改過單字的wordID
+// reject的單字集有changed單字跟其他單字完全相同就回錯誤，否則回報給wrapper
+func checkDuplicateWords(ctx context.Context, policy string, words []Type.Word, changed map[string]bool) error {
+	clusters := findDuplicateClusters(words, changed)
+	if len(clusters) == 0 {
+		return nil
+	}
+	if policy == Consts.DuplicatePolicyReject {
+		counts := map[string]int{}
+		for _, word := range words {
+			counts[utils.NormalizeVocabulary(word.Vocabulary)]++
+		}
+		rejected := slices.DeleteFunc(slices.Clone(clusters), func(cluster Type.DuplicateCluster) bool {
+			return !slices.ContainsFunc(cluster.Words, func(word Type.Word) bool {
+				return (changed == nil || changed[word.ID]) && counts[utils.NormalizeVocabulary(word.Vocabulary)] > 1
+			})
+		})
+		if len(rejected) > 0 {
+			return Type.DuplicateWords("有%d組重複的單字 此單字集不允許重複").WithArgs(len(rejected)).WithDetails(rejected)
+		}
+	}
+	reportDuplicateWords(ctx, clusters)
+	return nil
+}
+
+// 單獨修改一個單字的vocabulary前檢查重複
+func checkEditedVocabulary(ctx context.Context, wordSet *Type.WordSet, wordID string, vocabulary string) error {
+	words := slices.Clone(wordSet.Words)
+	for i := range words {
+		if words[i].ID == wordID {
+			words[i].Vocabulary = vocabulary
+		}
+	}
+	return checkDuplicateWords(ctx, wordSet.DuplicatePolicy, words, map[string]bool{wordID: true})
+}
+
+// 讓wrapper可以拿到邏輯function偵測到的重複單字，放進回應
+func contextWithDuplicateSink(ctx context.Context) (context.Context, *[]Type.DuplicateCluster) {
+	duplicates := new([]Type.DuplicateCluster)
+	return context.WithValue(ctx, duplicateWordsKey, duplicates), duplicates
+}
+
+// 回報重複的單字，沒有sink(例如即時協作)就忽略
+func reportDuplicateWords(ctx context.Context, clusters []Type.DuplicateCluster) {
+	if sink, ok := ctx.Value(duplicateWordsKey).(*[]Type.DuplicateCluster); ok {
+		*sink = clusters
+	}
+}
+
+func updateDuplicatePolicy(ctx context.Context, wordSetID string, policy string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := coll.UpdateOne(writingContext, liveWordSetFilter(bson.M{"id": wordSetID}), bson.M{"$set": bson.M{"duplicatePolicy": policy}})
+	if err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return Type.NotFound("查無單字集")
+	}
+	loggerFromContext(ctx).Info("duplicate policy updated", "wordSetID", wordSetID, "policy", policy)
+	return nil
+}
+
+func getDuplicateClusters(ctx context.Context, wordSetID string, userID string, shareToken string) ([]Type.DuplicateCluster, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	return findDuplicateClusters(wordSet.Words, nil), nil
+}
+
+/* ---------------- handlers ---------------- */
+
+func setDuplicatePolicy(ctx context.Context, request Type.SetDuplicatePolicyRequest) (string, error) {
+	return "", updateDuplicatePolicy(ctx, request.WordSetID, request.DuplicatePolicy)
+}
+
+func handleGetDuplicateWords(w http.ResponseWriter, r *http.Request) {
+	clusters, err := getDuplicateClusters(r.Context(), r.PathValue("wordSetID"), requestUserID(r), shareTokenFromRequest(r))
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, clusters); err != nil {
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func v1ListDuplicateWords(r *http.Request) (any, error) {
+	return getDuplicateClusters(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r))
+}
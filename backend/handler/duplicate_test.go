@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"go-quizlet/Type"
+	"slices"
+	"testing"
+)
+
+func TestFindDuplicateClusters(t *testing.T) {
+	vocabularies := []string{"apple", "Apple ", "receive", "recieve", "頭髮", "頭發", "cat", "cot", "裡面", "裏面"}
+	words := make([]Type.Word, len(vocabularies))
+	for i, vocabulary := range vocabularies {
+		words[i] = Type.Word{ID: string(rune('a' + i)), Vocabulary: vocabulary, Order: i + 1}
+	}
+
+	type cluster struct {
+		exact bool
+		ids   []string
+	}
+	tests := []struct {
+		name    string
+		changed map[string]bool
+		want    []cluster
+	}{
+		{
+			name: "全部",
+			want: []cluster{
+				{exact: true, ids: []string{"a", "b"}},
+				{exact: false, ids: []string{"c", "d"}},
+				{exact: true, ids: []string{"e", "f"}},
+				{exact: true, ids: []string{"i", "j"}},
+			},
+		},
+		{
+			name:    "只看改過的單字",
+			changed: map[string]bool{"d": true},
+			want:    []cluster{{exact: false, ids: []string{"c", "d"}}},
+		},
+		{
+			name:    "改過的單字沒有重複",
+			changed: map[string]bool{"g": true},
+			want:    []cluster{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clusters := findDuplicateClusters(words, test.changed)
+			got := make([]cluster, len(clusters))
+			for i, c := range clusters {
+				got[i].exact = c.Exact
+				for _, word := range c.Words {
+					got[i].ids = append(got[i].ids, word.ID)
+				}
+			}
+			if !slices.EqualFunc(got, test.want, func(a cluster, b cluster) bool {
+				return a.exact == b.exact && slices.Equal(a.ids, b.ids)
+			}) {
+				t.Errorf("findDuplicateClusters() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
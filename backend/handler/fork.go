@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+複製來源(fork lineage)
+複製出來的單字集記著來源以及當時來源的單字(base)，單字ID跟來源相同
+同步上游變更時用base、來源目前的單字、fork目前的單字做三方合併，兩邊都改過的單字算衝突，要選擇保留哪一邊
+只同步單字，標題跟敘述是fork自己的
+--------------------------------------------------------------
+*/
+
+const forkPageSize = 20
+
+const (
+	upstreamAdded   = "added"
+	upstreamUpdated = "updated"
+	upstreamRemoved = "removed"
+)
+
+// 只比較內容，順序跟星號是各自的
+func sameWordContent(a Type.Word, b Type.Word) bool {
+	return a.Vocabulary == b.Vocabulary && a.Definition == b.Definition &&
+		a.VocabularySound == b.VocabularySound && a.DefinitionSound == b.DefinitionSound &&
+		sameWordDetails(a, b)
+}
+
+// 音標、詞性、意思、例句以及圖片跟發音是否相同
+func sameWordDetails(a Type.Word, b Type.Word) bool {
+	return a.Pronunciation == b.Pronunciation && a.PartOfSpeech == b.PartOfSpeech &&
+		slices.Equal(a.Meanings, b.Meanings) && slices.Equal(a.Examples, b.Examples) && maps.Equal(a.Fields, b.Fields) && sameWordImage(a.Image, b.Image) &&
+		sameWordAudio(a.VocabularyAudio, b.VocabularyAudio) && sameWordAudio(a.DefinitionAudio, b.DefinitionAudio)
+}
+
+func indexWords(words []Type.Word) map[string]Type.Word {
+	index := make(map[string]Type.Word, len(words))
+	for _, word := range words {
+		index[word.ID] = word
+	}
+	return index
+}
+
+// 複製單字列表，圖片、音檔、意思、例句以及自訂欄位都另外複製一份，改其中一邊不會動到另一邊
+// 圖片跟音檔的key不變，兩邊共用Storage裡同一份檔案
+func cloneWords(words []Type.Word) []Type.Word {
+	cloned := slices.Clone(words)
+	for i := range cloned {
+		word := &cloned[i]
+		if word.Image != nil {
+			image := *word.Image
+			word.Image = &image
+		}
+		if word.VocabularyAudio != nil {
+			audio := *word.VocabularyAudio
+			word.VocabularyAudio = &audio
+		}
+		if word.DefinitionAudio != nil {
+			audio := *word.DefinitionAudio
+			word.DefinitionAudio = &audio
+		}
+		word.Meanings = slices.Clone(word.Meanings)
+		word.Examples = slices.Clone(word.Examples)
+		word.Fields = maps.Clone(word.Fields)
+	}
+	return cloned
+}
+
+func wordRef(word Type.Word) *Type.Word {
+	return &word
+}
+
+// 三方合併：base是上次同步時來源的單字，upstream是來源現在的單字，local是fork現在的單字
+func diffUpstream(base []Type.Word, upstream []Type.Word, local []Type.Word) []Type.UpstreamWordChange {
+	baseWords, upstreamWords, localWords := indexWords(base), indexWords(upstream), indexWords(local)
+	changes := []Type.UpstreamWordChange{}
+
+	// 來源新增或修改的單字
+	for _, up := range upstream {
+		b, inBase := baseWords[up.ID]
+		l, inLocal := localWords[up.ID]
+		change := Type.UpstreamWordChange{WordID: up.ID, Upstream: wordRef(up)}
+		if inBase {
+			change.Base = wordRef(b)
+		}
+		if inLocal {
+			change.Local = wordRef(l)
+		}
+		switch {
+		case !inBase && !inLocal:
+			change.Kind = upstreamAdded
+		case inBase && sameWordContent(b, up):
+			continue // 來源沒改
+		case inLocal && sameWordContent(l, up):
+			continue // 兩邊改成一樣
+		case inBase && inLocal && sameWordContent(l, b):
+			change.Kind = upstreamUpdated
+		default:
+			// 兩邊都改過，或來源改了但fork已經刪掉
+			change.Kind = upstreamUpdated
+			change.Conflict = true
+		}
+		changes = append(changes, change)
+	}
+
+	// 來源刪除的單字
+	for _, b := range base {
+		if _, ok := upstreamWords[b.ID]; ok {
+			continue
+		}
+		l, inLocal := localWords[b.ID]
+		if !inLocal {
+			continue // 兩邊都刪了
+		}
+		changes = append(changes, Type.UpstreamWordChange{
+			WordID:   b.ID,
+			Kind:     upstreamRemoved,
+			Conflict: !sameWordContent(l, b), // fork改過又被來源刪掉
+			Base:     wordRef(b),
+			Local:    wordRef(l),
+		})
+	}
+	return changes
+}
+
+// 把變更套用到fork的單字上，沒衝突的直接採用來源，有衝突的照resolutions
+func applyUpstreamChanges(local []Type.Word, changes []Type.UpstreamWordChange, resolutions map[string]string) ([]Type.Word, error) {
+	unresolved := []string{}
+	for _, change := range changes {
+		if change.Conflict && resolutions[change.WordID] == "" {
+			unresolved = append(unresolved, change.WordID)
+		}
+	}
+	if len(unresolved) > 0 {
+		return nil, Type.Conflict("有%d個單字與原始單字集衝突 請選擇要保留的版本").WithArgs(len(unresolved)).WithDetails(unresolved)
+	}
+
+	merged := make([]Type.Word, len(local))
+	copy(merged, local)
+	position := make(map[string]int, len(merged))
+	for i, word := range merged {
+		position[word.ID] = i
+	}
+	removed := map[string]bool{}
+	for _, change := range changes {
+		if change.Conflict && resolutions[change.WordID] == "local" {
+			continue
+		}
+		switch change.Kind {
+		case upstreamRemoved:
+			removed[change.WordID] = true
+		default:
+			i, ok := position[change.WordID]
+			if !ok {
+				// 新增的單字，或fork刪掉後選擇用來源的版本
+				word := *change.Upstream
+				word.Star = false
+				merged = append(merged, word)
+				continue
+			}
+			merged[i].Vocabulary = change.Upstream.Vocabulary
+			merged[i].Definition = change.Upstream.Definition
+			merged[i].VocabularySound = change.Upstream.VocabularySound
+			merged[i].DefinitionSound = change.Upstream.DefinitionSound
+			merged[i].Pronunciation = change.Upstream.Pronunciation
+			merged[i].PartOfSpeech = change.Upstream.PartOfSpeech
+			merged[i].Meanings = change.Upstream.Meanings
+			merged[i].Examples = change.Upstream.Examples
+			merged[i].Fields = change.Upstream.Fields
+			merged[i].Image = change.Upstream.Image
+			merged[i].VocabularyAudio = change.Upstream.VocabularyAudio
+			merged[i].DefinitionAudio = change.Upstream.DefinitionAudio
+		}
+	}
+
+	words := make([]Type.Word, 0, len(merged))
+	for _, word := range merged {
+		if removed[word.ID] {
+			continue
+		}
+		word.Order = len(words) + 1
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return nil, Type.BadRequest("單字集至少要有一個單字")
+	}
+	return words, nil
+}
+
+// 拿fork跟來源，確認使用者可以編輯fork而且讀得到來源
+// 來源不公開時要帶上當初複製用的分享連結token
+func getForkAndSource(ctx context.Context, wordSetID string, userID string, shareToken string) (*Type.WordSet, *Type.WordSet, error) {
+	fork, err := checkWordSetEditor(ctx, wordSetID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fork.ForkedFrom == nil {
+		return nil, nil, Type.BadRequest("此單字集不是複製來的")
+	}
+	source, err := getReadableWordSet(ctx, fork.ForkedFrom.WordSetID, userID, shareToken)
+	if err != nil {
+		var appErr *Type.AppError
+		if errors.As(err, &appErr) && appErr.Status == http.StatusNotFound {
+			return nil, nil, Type.NotFound("原始單字集已不存在或無法查看")
+		}
+		return nil, nil, err
+	}
+	return fork, source, nil
+}
+
+func getUpstreamChanges(ctx context.Context, wordSetID string, userID string, shareToken string) (*Type.UpstreamChanges, error) {
+	fork, source, err := getForkAndSource(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	return &Type.UpstreamChanges{
+		SourceWordSetID: source.ID,
+		BaseVersion:     fork.ForkedFrom.Version,
+		SourceVersion:   source.Version,
+		Changes:         diffUpstream(fork.ForkedFrom.Base, source.Words, fork.Words),
+	}, nil
+}
+
+// 把來源的變更合併進fork，base更新成來源目前的單字，同步本身也會產生一個新版本
+func pullUpstreamChanges(ctx context.Context, wordSetID string, shareToken string, version int, resolutions map[string]string) (*Type.WordSet, error) {
+	userID := userIDFromContext(ctx)
+	fork, source, err := getForkAndSource(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	if fork.Version != version {
+		return nil, versionConflictOr(ctx, wordSetID, version, Type.NotFound("查無此單字集"))
+	}
+	changes := diffUpstream(fork.ForkedFrom.Base, source.Words, fork.Words)
+	if len(changes) == 0 && fork.ForkedFrom.Version == source.Version {
+		return fork, nil
+	}
+	words, err := applyUpstreamChanges(fork.Words, changes, resolutions)
+	if err != nil {
+		return nil, err
+	}
+	// 來源的卡片模板可能跟fork不同，只保留fork模板有的自訂欄位
+	for i := range words {
+		words[i] = fitWordFields(fork.Template, words[i])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	session, err := DB.Client.StartSession()
+	if err != nil {
+		return nil, Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	defer session.EndSession(ctx)
+
+	var pulled Type.WordSet
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+		filter := versionFilter(liveWordSetFilter(bson.M{"id": wordSetID}), version)
+		now := utils.GetNow()
+		update := bson.M{"$set": bson.M{
+			"words":               words,
+			"wordCnt":             len(words),
+			"forkedFrom.version":  source.Version,
+			"forkedFrom.base":     source.Words,
+			"forkedFrom.syncedAt": now,
+			"updatedAt":           now,
+			"updatedBy":           userID,
+		}, "$inc": bson.M{"version": 1}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		if err := coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&pulled); err != nil {
+			session.AbortTransaction(sc)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return versionConflictOr(ctx, wordSetID, version, Type.NotFound("查無此單字集"))
+			}
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if err := recordWordSetRevision(sc, wordSetID, fork, Consts.RevisionActionPull, 0); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, err
+	}
+
+	reportWordSetVersion(ctx, pulled.Version)
+	loggerFromContext(ctx).Info("upstream pulled", "wordSetID", wordSetID, "sourceWordSetID", source.ID, "changes", len(changes))
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: pulled.Title, AuthorID: pulled.AuthorID, ActorID: userID,
+	})
+	return &pulled, nil
+}
+
+// 某個單字集被複製出去的單字集，只列出viewerID看得到的
+func listWordSetForks(ctx context.Context, wordSetID string, viewerID string, shareToken string, offset int) ([]Type.LibWordSetDisplay, error) {
+	if _, err := getReadableWordSet(ctx, wordSetID, viewerID, shareToken); err != nil {
+		return nil, err
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := readableWordSetFilter(liveWordSetFilter(bson.M{"forkedFrom.wordSetID": wordSetID}), viewerID)
+	opts := options.Find().
+		SetSort(bson.M{"updatedAt": -1}).
+		SetSkip(int64(offset)).
+		SetLimit(forkPageSize).
+		SetProjection(bson.M{"id": 1, "title": 1, "authorID": 1, "wordCnt": 1, "createdAt": 1, "updatedAt": 1})
+	cursor, err := coll.Find(findingContext, filter, opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	forks := make([]Type.LibWordSetDisplay, 0)
+	if err := cursor.All(findingContext, &forks); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return forks, nil
+}
+
+// 以前複製時寫錯欄位(forkCnt)，把累積的次數併回forkedCnt
+func MigrateForkCounts(ctx context.Context) {
+	coll := DB.Client.Database("go-quizlet").Collection("users")
+	migratingContext, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"forkedCnt": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$forkedCnt", 0}}, "$forkCnt"}}}}},
+		{{Key: "$unset", Value: "forkCnt"}},
+	}
+	res, err := coll.UpdateMany(migratingContext, bson.M{"forkCnt": bson.M{"$exists": true}}, update)
+	if err != nil {
+		slog.Error("migrate fork counts failed", "error", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		slog.Info("fork counts migrated", "users", res.ModifiedCount)
+	}
+}
+
+/* ---------------- handlers ---------------- */
+
+func handleGetWordSetForks(w http.ResponseWriter, r *http.Request) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	forks, err := listWordSetForks(r.Context(), r.PathValue("wordSetID"), requestUserID(r), shareTokenFromRequest(r), offset)
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, forks); err != nil {
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func handleGetUpstreamChanges(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
+		return
+	}
+	changes, err := getUpstreamChanges(r.Context(), r.PathValue("wordSetID"), userID, shareTokenFromRequest(r))
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, changes); err != nil {
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func pullUpstream(ctx context.Context, request Type.PullUpstreamRequest) (string, error) {
+	_, err := pullUpstreamChanges(ctx, request.WordSetID, request.ShareToken, *request.Version, request.Resolutions)
+	return "", err
+}
+
+func v1ListWordSetForks(r *http.Request) (any, error) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	return listWordSetForks(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r), offset)
+}
+
+func v1GetUpstreamChanges(r *http.Request) (any, error) {
+	return getUpstreamChanges(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r))
+}
+
+func v1PullUpstream(r *http.Request) (any, error) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1PullUpstreamRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return pullUpstreamChanges(r.Context(), r.PathValue("wordSetID"), shareTokenFromRequest(r), version, request.Resolutions)
+}
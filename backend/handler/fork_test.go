@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"go-quizlet/Type"
+	"slices"
+	"testing"
+)
+
+func testWord(id string, vocabulary string, definition string) Type.Word {
+	return Type.Word{ID: id, Vocabulary: vocabulary, Definition: definition}
+}
+
+func TestDiffUpstream(t *testing.T) {
+	base := []Type.Word{testWord("a", "apple", "蘋果"), testWord("b", "banana", "香蕉"), testWord("c", "cherry", "櫻桃")}
+
+	tests := []struct {
+		name     string
+		upstream []Type.Word
+		local    []Type.Word
+		want     []Type.UpstreamWordChange // 只比WordID、Kind、Conflict
+	}{
+		{
+			name:     "沒有變更",
+			upstream: base,
+			local:    base,
+			want:     []Type.UpstreamWordChange{},
+		},
+		{
+			name:     "來源新增",
+			upstream: append(slices.Clone(base), testWord("d", "date", "棗子")),
+			local:    base,
+			want:     []Type.UpstreamWordChange{{WordID: "d", Kind: upstreamAdded}},
+		},
+		{
+			name:     "來源修改，fork沒改",
+			upstream: []Type.Word{testWord("a", "apple", "蘋果樹"), base[1], base[2]},
+			local:    base,
+			want:     []Type.UpstreamWordChange{{WordID: "a", Kind: upstreamUpdated}},
+		},
+		{
+			name:     "fork修改，來源沒改",
+			upstream: base,
+			local:    []Type.Word{testWord("a", "apple", "我的蘋果"), base[1], base[2]},
+			want:     []Type.UpstreamWordChange{},
+		},
+		{
+			name:     "兩邊改成一樣",
+			upstream: []Type.Word{testWord("a", "apple", "蘋果樹"), base[1], base[2]},
+			local:    []Type.Word{testWord("a", "apple", "蘋果樹"), base[1], base[2]},
+			want:     []Type.UpstreamWordChange{},
+		},
+		{
+			name:     "兩邊都改過",
+			upstream: []Type.Word{testWord("a", "apple", "蘋果樹"), base[1], base[2]},
+			local:    []Type.Word{testWord("a", "apple", "我的蘋果"), base[1], base[2]},
+			want:     []Type.UpstreamWordChange{{WordID: "a", Kind: upstreamUpdated, Conflict: true}},
+		},
+		{
+			name:     "來源修改但fork已經刪掉",
+			upstream: []Type.Word{testWord("a", "apple", "蘋果樹"), base[1], base[2]},
+			local:    []Type.Word{base[1], base[2]},
+			want:     []Type.UpstreamWordChange{{WordID: "a", Kind: upstreamUpdated, Conflict: true}},
+		},
+		{
+			name:     "來源刪除，fork沒改",
+			upstream: []Type.Word{base[0], base[1]},
+			local:    base,
+			want:     []Type.UpstreamWordChange{{WordID: "c", Kind: upstreamRemoved}},
+		},
+		{
+			name:     "來源刪除，fork改過",
+			upstream: []Type.Word{base[0], base[1]},
+			local:    []Type.Word{base[0], base[1], testWord("c", "cherry", "我的櫻桃")},
+			want:     []Type.UpstreamWordChange{{WordID: "c", Kind: upstreamRemoved, Conflict: true}},
+		},
+		{
+			name:     "兩邊都刪了",
+			upstream: []Type.Word{base[0], base[1]},
+			local:    []Type.Word{base[0], base[1]},
+			want:     []Type.UpstreamWordChange{},
+		},
+		{
+			name:     "fork自己新增的單字不算",
+			upstream: base,
+			local:    append(slices.Clone(base), testWord("x", "extra", "額外")),
+			want:     []Type.UpstreamWordChange{},
+		},
+		{
+			name:     "順序跟星號不算修改",
+			upstream: []Type.Word{{ID: "a", Vocabulary: "apple", Definition: "蘋果", Order: 3, Star: true}, base[1], base[2]},
+			local:    base,
+			want:     []Type.UpstreamWordChange{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := diffUpstream(base, test.upstream, test.local)
+			got := make([]Type.UpstreamWordChange, len(changes))
+			for i, change := range changes {
+				got[i] = Type.UpstreamWordChange{WordID: change.WordID, Kind: change.Kind, Conflict: change.Conflict}
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("diffUpstream() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpstreamChanges(t *testing.T) {
+	base := []Type.Word{testWord("a", "apple", "蘋果"), testWord("b", "banana", "香蕉"), testWord("c", "cherry", "櫻桃")}
+	upstream := []Type.Word{testWord("a", "apple", "蘋果樹"), testWord("b", "banana", "芭蕉"), testWord("d", "date", "棗子")}
+	local := []Type.Word{testWord("b", "banana", "我的香蕉"), testWord("c", "cherry", "櫻桃"), testWord("x", "extra", "額外")}
+	local[1].Star = true
+	changes := diffUpstream(base, upstream, local)
+
+	tests := []struct {
+		name        string
+		resolutions map[string]string
+		want        []Type.Word // 只比ID、Definition、Order
+		conflict    bool
+	}{
+		{
+			name:     "衝突沒有選擇",
+			conflict: true,
+		},
+		{
+			name:        "衝突選local",
+			resolutions: map[string]string{"a": "local", "b": "local"},
+			want: []Type.Word{
+				{ID: "b", Definition: "我的香蕉", Order: 1},
+				{ID: "x", Definition: "額外", Order: 2},
+				{ID: "d", Definition: "棗子", Order: 3},
+			},
+		},
+		{
+			name:        "衝突選upstream",
+			resolutions: map[string]string{"a": "upstream", "b": "upstream"},
+			want: []Type.Word{
+				{ID: "b", Definition: "芭蕉", Order: 1},
+				{ID: "x", Definition: "額外", Order: 2},
+				{ID: "a", Definition: "蘋果樹", Order: 3},
+				{ID: "d", Definition: "棗子", Order: 4},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := applyUpstreamChanges(local, changes, test.resolutions)
+			if test.conflict {
+				if err == nil || toAppError(err).Code != Type.CodeConflict {
+					t.Fatalf("applyUpstreamChanges() error = %v, want conflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyUpstreamChanges() error = %v", err)
+			}
+			got := make([]Type.Word, len(merged))
+			for i, word := range merged {
+				got[i] = Type.Word{ID: word.ID, Definition: word.Definition, Order: word.Order}
+			}
+			if !slices.EqualFunc(got, test.want, func(a Type.Word, b Type.Word) bool {
+				return a.ID == b.ID && a.Definition == b.Definition && a.Order == b.Order
+			}) {
+				t.Errorf("applyUpstreamChanges() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("來源新增的單字不帶星號", func(t *testing.T) {
+		starred := []Type.UpstreamWordChange{{WordID: "d", Kind: upstreamAdded, Upstream: &Type.Word{ID: "d", Star: true}}}
+		merged, err := applyUpstreamChanges(local, starred, nil)
+		if err != nil {
+			t.Fatalf("applyUpstreamChanges() error = %v", err)
+		}
+		if merged[len(merged)-1].Star {
+			t.Errorf("added word kept upstream star")
+		}
+	})
+
+	t.Run("全部刪光", func(t *testing.T) {
+		removed := []Type.UpstreamWordChange{{WordID: "only", Kind: upstreamRemoved}}
+		_, err := applyUpstreamChanges([]Type.Word{testWord("only", "one", "一")}, removed, nil)
+		if err == nil || toAppError(err).Code != Type.CodeBadRequest {
+			t.Errorf("applyUpstreamChanges() error = %v, want bad request", err)
+		}
+	})
+}
+
+// 複製出來的單字跟來源共用圖片跟音檔，不能被當成fork自己改過
+func TestDiffUpstreamWithMedia(t *testing.T) {
+	base := []Type.Word{testWord("a", "apple", "蘋果"), testWord("b", "banana", "香蕉")}
+	base[0].Image = &Type.WordImage{Key: "words/s/1.png", ThumbnailKey: "words/s/1_thumb.png", URL: "/media/words/s/1.png"}
+	base[1].VocabularyAudio = &Type.WordAudio{Key: "words/s/2.mp3", URL: "/media/words/s/2.mp3"}
+	local := cloneWords(base)
+
+	if changes := diffUpstream(base, base, local); len(changes) != 0 {
+		t.Errorf("diffUpstream() = %+v, want no changes", changes)
+	}
+
+	// 來源換了圖片，fork沒動過，不是衝突
+	upstream := cloneWords(base)
+	upstream[0].Image = &Type.WordImage{Key: "words/s/3.png", ThumbnailKey: "words/s/3_thumb.png", URL: "/media/words/s/3.png"}
+	changes := diffUpstream(base, upstream, local)
+	if len(changes) != 1 || changes[0].WordID != "a" || changes[0].Kind != upstreamUpdated || changes[0].Conflict {
+		t.Errorf("diffUpstream() = %+v, want non-conflicting update of a", changes)
+	}
+}
+
+func TestCloneWords(t *testing.T) {
+	words := []Type.Word{testWord("a", "apple", "蘋果")}
+	words[0].Image = &Type.WordImage{Key: "words/s/1.png"}
+	words[0].DefinitionAudio = &Type.WordAudio{Key: "words/s/1.mp3"}
+	words[0].Fields = map[string]string{"note": "n"}
+
+	cloned := cloneWords(words)
+	if cloned[0].Image.Key != "words/s/1.png" || cloned[0].DefinitionAudio.Key != "words/s/1.mp3" {
+		t.Fatalf("cloneWords() lost media keys: %+v", cloned[0])
+	}
+	cloned[0].Image.Key = "changed"
+	cloned[0].DefinitionAudio.Key = "changed"
+	cloned[0].Fields["note"] = "changed"
+	if words[0].Image.Key != "words/s/1.png" || words[0].DefinitionAudio.Key != "words/s/1.mp3" || words[0].Fields["note"] != "n" {
+		t.Errorf("cloneWords() shares state with the source: %+v", words[0])
+	}
+}
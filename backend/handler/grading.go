@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"net/http"
+	"strings"
+)
+
+/*
+--------------------------------------------------------------
+打字作答的判定(填充題)
+作答跟答案都先正規化(大小寫、空白、標點、全形、重音)，正規化後相同就是correct
+答案可以用/或;分隔多個，跟任何一個相同都算對
+錯字在leniency容許的編輯距離內是almost(算對但提醒)，其他是wrong
+--------------------------------------------------------------
+*/
+
+// 依答案長度(正規化後的字元數)容許的錯字數
+func allowedTypos(length int, leniency string) int {
+	switch leniency {
+	case Consts.GradeLeniencyStrict:
+		return 0
+	case Consts.GradeLeniencyLenient:
+		switch {
+		case length >= 10:
+			return 3
+		case length >= 6:
+			return 2
+		case length >= 3:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case length >= 9:
+		return 2
+	case length >= 5:
+		return 1
+	}
+	return 0
+}
+
+// 判定打字作答，expected是單字或註釋原本的內容
+func gradeTypedAnswer(expected string, leniency string, answer string) Type.GradeAnswerResponse {
+	given := utils.NormalizeAnswer(answer)
+	if given == "" {
+		// 作答只有標點之類的，跟答案一樣比原本的字
+		given = strings.TrimSpace(answer)
+	}
+	response := Type.GradeAnswerResponse{Result: Consts.GradeWrong, Answers: utils.SplitAnswers(expected), Distance: -1}
+	var best string
+	for _, candidate := range response.Answers {
+		normalized := utils.NormalizeAnswer(candidate)
+		if normalized == "" {
+			// 答案只有標點之類的，就比原本的字
+			normalized = strings.TrimSpace(candidate)
+		}
+		limit := max(len([]rune(normalized)), len([]rune(given)))
+		distance := utils.EditDistance(normalized, given, limit)
+		if response.Distance == -1 || distance < response.Distance {
+			response.Answer, response.Distance, best = candidate, distance, normalized
+		}
+		if distance == 0 {
+			break
+		}
+	}
+	if response.Distance == -1 {
+		// 答案是空的，只有空白的作答算對
+		response.Distance = len([]rune(given))
+	}
+	switch {
+	case response.Distance == 0:
+		response.Result = Consts.GradeCorrect
+	case given != "" && response.Distance <= allowedTypos(len([]rune(best)), leniency):
+		response.Result = Consts.GradeAlmost
+	}
+	response.Diff = utils.DiffAnswer(best, given)
+	return response
+}
+
+// 找單字並判定作答，answerField是要打出來的欄位(vocabulary或definition)，看得到單字集就可以，不用登入
+func gradeWordAnswer(ctx context.Context, wordSetID string, wordID string, userID string, shareToken string, answerField string, leniency string, answer string) (*Type.GradeAnswerResponse, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range wordSet.Words {
+		if word.ID == wordID {
+			expected := word.Vocabulary
+			if answerField == "definition" {
+				expected = word.Definition
+			}
+			response := gradeTypedAnswer(expected, leniency, answer)
+			return &response, nil
+		}
+	}
+	return nil, Type.NotFound("查無此單字或單字集")
+}
+
+/* ---------------- handlers ---------------- */
+
+func handleGradeAnswer(w http.ResponseWriter, r *http.Request) {
+	var request Type.GradeAnswerRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	defer r.Body.Close()
+	if err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
+		return
+	}
+	if err := validate.Struct(request); err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位"))
+		return
+	}
+	// 前端練習時已經有單字，可能換過單字跟註釋(或是複習牌組)，直接拿答案來判定
+	response := gradeTypedAnswer(request.Expected, request.Leniency, request.Answer)
+	if err := writeDataJson(w, response); err != nil {
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func v1GradeAnswer(r *http.Request) (any, error) {
+	input, err := decodeAPIBody[Type.V1GradeAnswerRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return gradeWordAnswer(r.Context(), r.PathValue("wordSetID"), r.PathValue("wordID"), userIDFromContext(r.Context()), shareTokenFromRequest(r),
+		input.AnswerField, input.Leniency, input.Answer)
+}
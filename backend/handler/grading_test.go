@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"go-quizlet/Consts"
+	"testing"
+)
+
+func TestGradeTypedAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		leniency string
+		answer   string
+		result   string
+		distance int
+	}{
+		{"完全相同", "apple", Consts.GradeLeniencyNormal, "apple", Consts.GradeCorrect, 0},
+		{"正規化後相同", "Café", Consts.GradeLeniencyStrict, " cafe ", Consts.GradeCorrect, 0},
+		{"多個答案其中一個", "蘋果/蘋果樹", Consts.GradeLeniencyNormal, "蘋果樹", Consts.GradeCorrect, 0},
+		{"容許的錯字", "banana", Consts.GradeLeniencyNormal, "banena", Consts.GradeAlmost, 1},
+		{"strict不容許錯字", "banana", Consts.GradeLeniencyStrict, "banena", Consts.GradeWrong, 1},
+		{"太短不容許錯字", "cat", Consts.GradeLeniencyNormal, "cot", Consts.GradeWrong, 1},
+		{"lenient短字也容許", "cat", Consts.GradeLeniencyLenient, "cot", Consts.GradeAlmost, 1},
+		{"錯太多", "banana", Consts.GradeLeniencyNormal, "orange", Consts.GradeWrong, 5},
+		{"沒作答", "apple", Consts.GradeLeniencyLenient, "", Consts.GradeWrong, 5},
+		{"答案只有標點", "!!", Consts.GradeLeniencyNormal, "!!", Consts.GradeCorrect, 0},
+		{"答案只有標點，作答不同", "!!", Consts.GradeLeniencyNormal, "?", Consts.GradeWrong, 2},
+		{"作答只有標點", "apple", Consts.GradeLeniencyNormal, "...", Consts.GradeWrong, 5},
+		{"空答案空作答", "", Consts.GradeLeniencyNormal, "  ", Consts.GradeCorrect, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := gradeTypedAnswer(test.expected, test.leniency, test.answer)
+			if got.Result != test.result || got.Distance != test.distance {
+				t.Errorf("gradeTypedAnswer(%q, %q, %q) = %s distance %d, want %s distance %d",
+					test.expected, test.leniency, test.answer, got.Result, got.Distance, test.result, test.distance)
+			}
+		})
+	}
+}
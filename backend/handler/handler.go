@@ -8,11 +8,13 @@ import (
 	"go-quizlet/Consts"
 	"go-quizlet/DB"
 	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/markdown"
 	"go-quizlet/utils"
-	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -21,6 +23,7 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator"
 	"github.com/golang-jwt/jwt/v5"
@@ -56,20 +59,35 @@ func CreateHandler() http.Handler {
 	mux.HandleFunc("GET /getPreviewWords/", handleGetPreviewWords) // handling querying words for preview
 	mux.HandleFunc("GET /getWords/{wordSetID}", handleGetWords) // handling querying words for full wordCard
 	//mux.HandleFunc("POST /updateWordElement", handleUpdateWordElement)
-	mux.HandleFunc("POST /updateWordSet", PostValidateWordSetAuthor(UpdateWordSet))
+	mux.HandleFunc("POST /updateWordSet", PostValidateWordSetEditor(UpdateWordSet))
 	mux.HandleFunc("POST /deleteWordSet", PostValidateWordSetAuthor(deleteWordSet))
-	mux.HandleFunc("POST /addWord", PostValidateWordSetAuthor(addWord))
-	mux.HandleFunc("POST /deleteWord", PostValidateWordSetAuthor(deleteWord)) // 貌似沒用到這個route
-	mux.HandleFunc("POST /toggleWordStar", PostValidateWordSetAuthor(toggleWordStar))
-	mux.HandleFunc("POST /toggleAllWordStar", PostValidateWordSetAuthor(toggleAllWordStar))
-	mux.HandleFunc("POST /inlineUpdateWord", PostValidateWordSetAuthor(inlineUpdateWord))
-	mux.HandleFunc("POST /bigWordCardUpdateWord", PostValidateWordSetAuthor(bigWordCardUpdateWord))
+	mux.HandleFunc("POST /addWord", PostValidateWordSetEditor(addWord))
+	mux.HandleFunc("POST /deleteWord", PostValidateWordSetEditor(deleteWord)) // 貌似沒用到這個route
+	mux.HandleFunc("POST /toggleWordStar", PostValidateUser(toggleWordStar))
+	mux.HandleFunc("POST /toggleAllWordStar", PostValidateUser(toggleAllWordStar))
+	mux.HandleFunc("POST /gradeAnswer", handleGradeAnswer) // 填充題判定作答，不用登入
+	mux.HandleFunc("POST /recordStudyResults", PostValidateUser(handleRecordStudyResults))
+	mux.HandleFunc("POST /startLearnSession", handleStartLearnSession) // Learn模式，要登入
+	mux.HandleFunc("POST /answerLearnQuestion", handleAnswerLearnQuestion)
+	mux.HandleFunc("GET /getReviewDeck/{rule}", handleGetReviewDeck) // 跨單字集的複習牌組，要登入
+	mux.HandleFunc("POST /inlineUpdateWord", PostValidateWordSetEditor(inlineUpdateWord))
+	mux.HandleFunc("POST /bigWordCardUpdateWord", PostValidateWordSetEditor(bigWordCardUpdateWord))
+	mux.HandleFunc("POST /uploadWordImage", handleUploadWordImage) // multipart，要登入
+	mux.HandleFunc("POST /removeWordImage", PostValidateWordSetEditor(handleRemoveWordImage))
+	mux.HandleFunc("POST /uploadWordAudio", handleUploadWordAudio) // multipart，要登入
+	mux.HandleFunc("POST /removeWordAudio", PostValidateWordSetEditor(handleRemoveWordAudio))
+	mux.HandleFunc("GET /media/{key...}", serveMedia) // 單字圖片等上傳的檔案
+	mux.HandleFunc("GET /languages", handleGetLanguages) // 可用的語言(sound)
 	mux.HandleFunc("GET /getWordSetsInLib/{userID}", getWordSetsInLib)
 	mux.HandleFunc("POST /changeUserImage", changeUserImage)
 	mux.HandleFunc("POST /changeUserName", PostValidateUser(changeUserName))
 	mux.HandleFunc("POST /changeUserEmail", PostValidateUser(changeUserEmail))
+	mux.HandleFunc("POST /changeUserLocale", PostValidateUser(changeUserLocale))
 	mux.HandleFunc("POST /toggleLikeWordSet", PostValidateUser(toggleLikeWordSet))
 	mux.HandleFunc("POST /forkWordSet", PostValidateUser(ForkWordSet))
+	mux.HandleFunc("GET /getWordSetForks/{wordSetID}", handleGetWordSetForks)
+	mux.HandleFunc("GET /getUpstreamChanges/{wordSetID}", handleGetUpstreamChanges)
+	mux.HandleFunc("POST /pullUpstream", PostValidateWordSetEditor(pullUpstream))
 	mux.HandleFunc("GET /getMails/{userID}", GetValidateUser(getMails))
 	mux.HandleFunc("GET /getUnreadMailsCnt/{userID}", GetValidateUser(getUnreadMailsCnt))
 	mux.HandleFunc("POST /readMail", PostValidateUser(readMail))
@@ -79,14 +97,21 @@ func CreateHandler() http.Handler {
 	mux.HandleFunc("GET /getPopularWordSet", getPopularWordSet)
 	mux.HandleFunc("GET /getFeedback/", getFeedback)
 	mux.HandleFunc("POST /createFeedback", PostValidateUser(createFeedback))
-	mux.HandleFunc("POST /toggleAllowCopy", PostValidateUser(toggleAllowCopy))
-	mux.HandleFunc("POST /toggleIsPublic", PostValidateUser(toggleIsPublic))
+	mux.HandleFunc("POST /toggleAllowCopy", PostValidateWordSetAuthor(toggleAllowCopy))
+	mux.HandleFunc("POST /setDuplicatePolicy", PostValidateWordSetAuthor(setDuplicatePolicy))
+	mux.HandleFunc("POST /setCardTemplate", PostValidateWordSetEditor(handleSetCardTemplate))
+	mux.HandleFunc("GET /getDuplicateWords/{wordSetID}", handleGetDuplicateWords)
+	mux.HandleFunc("POST /toggleIsPublic", PostValidateWordSetAuthor(toggleIsPublic))
+	mux.HandleFunc("POST /setWordSetVisibility", PostValidateWordSetAuthor(setWordSetVisibility))
+	mux.HandleFunc("POST /createShareLink", PostValidateWordSetAuthor(handleCreateShareLink))
+	mux.HandleFunc("POST /revokeShareLinks", PostValidateWordSetAuthor(handleRevokeShareLinks))
 	mux.HandleFunc("POST /logError", PostValidateUser(logError)) // log error sent from ErrorBoundary
 	mux.HandleFunc("POST /requestValidateCode/", requestValidateCode)
 	mux.HandleFunc("POST /resetPassword", resetPassword)
 	mux.HandleFunc("POST /sendActivationEmail", sendActivationEmail)
 	mux.HandleFunc("POST /activateEmail", activateEmail)
-	return chainMiddleware(mux, EnableCORS, RateLimit) // 用CORS middleware包裹住mux 並回傳
+	registerAPIV1(mux)
+	return chainMiddleware(mux, RequestLogger, DetectLocale, EnableCORS, RateLimit) // 用logger和CORS middleware包裹住mux 並回傳
 }
 
 // 取得用戶IP
@@ -104,17 +129,58 @@ func getIP(r *http.Request) (string, error) {
 --------------------------------------------------------------
 */
 
+// 把任何error轉成AppError，不是AppError的一律當成內部錯誤，避免把Mongo等內部訊息回給client
+func toAppError(err error) *Type.AppError {
+	var appErr *Type.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Type.Internal("未知錯誤 請重試").Wrap(err)
+}
+
+// 內部錯誤細節只寫進log
+func logAppError(r *http.Request, appErr *Type.AppError) {
+	if appErr.Status < http.StatusInternalServerError && appErr.Err == nil {
+		return
+	}
+	logger := loggerFromContext(r.Context())
+	if appErr.Status >= http.StatusInternalServerError {
+		logger.Error("request failed", "code", appErr.Code, "message", appErr.Message, "error", appErr.Err)
+	} else {
+		logger.Warn("request rejected", "code", appErr.Code, "message", appErr.Message, "error", appErr.Err)
+	}
+}
+
+// 依request的語系翻譯要回給使用者的訊息
+func translate(r *http.Request, message string, args ...any) string {
+	return i18n.T(i18n.FromContext(r.Context()), message, args...)
+}
+
 // 用來回覆要使用者登入 所以前端要判斷type是To Log In還是Error還是Success
 // 這個會強制讓前端登出使用者(如果憑證過期)/要求使用者登入(沒憑證)
 // 主要用在Post操作時驗證JWT的時候
-func CallToLogInJson(w http.ResponseWriter, errorBody Type.Payload) {
-	w.Header().Add("content-type", "application/json")
-	json.NewEncoder(w).Encode(Type.Response{Type:"To Log In", Payload: errorBody})
+func CallToLogInJson(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := toAppError(err)
+	logAppError(r, appErr)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(Type.Response{Type:"To Log In", Payload: Type.MessageDisplayError{Message: translate(r, appErr.Message, appErr.Args...), Code: Type.CodeUnauthorized}})
 }
-// 用來回覆error
-func writeErrorJson(w http.ResponseWriter, errorBody Type.Payload) {
-	w.Header().Add("content-type", "application/json")
-	json.NewEncoder(w).Encode(Type.Response{Type:"Error", Payload: errorBody})
+// 用來回覆error，HTTP status跟code由AppError決定
+func writeErrorJson(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := toAppError(err)
+	logAppError(r, appErr)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(appErr.Status)
+	json.NewEncoder(w).Encode(Type.Response{Type:"Error", Payload: Type.MessageDisplayError{Message: translate(r, appErr.Message, appErr.Args...), Code: appErr.Code, Details: appErr.Details}})
+}
+// 用來回覆需要整頁顯示的error(前端會依statusCode顯示錯誤頁面)
+func writePageErrorJson(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := toAppError(err)
+	logAppError(r, appErr)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(appErr.Status)
+	json.NewEncoder(w).Encode(Type.Response{Type:"Error", Payload: Type.PageDisplayError{StatusCode: appErr.Status, Message: translate(r, appErr.Message, appErr.Args...), Code: appErr.Code}})
 }
 // 用來回覆成功的data
 func writeDataJson(w http.ResponseWriter, data Type.Payload) error {
@@ -135,32 +201,37 @@ func getUserByID(userID string) (*Type.User, error) {
 	err := coll.FindOne(findingContext, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, errors.New("超時錯誤 請重試")
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("查無使用者")
+			return nil, Type.NotFound("查無使用者")
 		}
-		return nil, errors.New("未知錯誤 請重試")
+		return nil, Type.Internal("未知錯誤 請重試").Wrap(err)
 	}
 
 	return &user, nil
 }
+// 轉成給前端的User(不含密碼等欄位)
+func frontEndUser(user *Type.User) Type.FrontEndUser {
+	return Type.FrontEndUser{ID:user.ID, Role: user.Role, Name: user.Name, Email: user.Email,
+		Img:user.Img, LikedWordSets: user.LikedWordSets, Locale: user.Locale}
+}
 // 從DB拿一個wordSet的函數
 func getWordSetByID(wordSetID string) (*Type.WordSet, error) {
 	var wordSet Type.WordSet
 	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
 	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	filter := bson.M{"id":wordSetID}
+	filter := liveWordSetFilter(bson.M{"id":wordSetID})
 	err := coll.FindOne(findingContext, filter).Decode(&wordSet)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, errors.New("超時錯誤 請重試")
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("查無單字集")
+			return nil, Type.NotFound("查無單字集")
 		}
-		return nil, errors.New("未知錯誤 請重試")
+		return nil, Type.Internal("未知錯誤 請重試").Wrap(err)
 	}
 
 	return &wordSet, nil
@@ -175,12 +246,12 @@ func getRecentVisitByID(userID string) (*Type.RecentVisit, error) {
 	err := coll.FindOne(findingContext, filter).Decode(&recentVisit)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, errors.New("超時錯誤 請重試")
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
-		if err == mongo.ErrNilDocument {
-			return nil, errors.New("查無使用者")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("查無使用者")
 		}
-		return nil, errors.New("未知錯誤 請重試")
+		return nil, Type.Internal("未知錯誤 請重試")
 	}
 	return &recentVisit, nil
 }
@@ -198,35 +269,31 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 func handleCheckLogIn(w http.ResponseWriter, r *http.Request) {
 	token, err := utils.CheckLogIn(w, r)
 	if err != nil {
-		fmt.Println("User not log in! Exit the post handler")
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者未登入! 或憑證已過期!"})
+		writeErrorJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
 		return
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤!"})
+		writeErrorJson(w, r, Type.Unauthorized("憑證錯誤!"))
 		return
 	}
 	
 	// 確認有userID這個欄位
 	userID, ok := claims["userID"].(string) 
 	if !ok || userID == "" {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤!"})
+		writeErrorJson(w, r, Type.Unauthorized("憑證錯誤!"))
 		return
 	}
 
 	DBUser, err := getUserByID(userID)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 
-	user := Type.FrontEndUser{ID:DBUser.ID, Role: DBUser.Role, Name: DBUser.Name, Email: DBUser.Email, 
-		Img:DBUser.Img, LikedWordSets: DBUser.LikedWordSets}
-	fmt.Println("check log in from backend", user)
-	err = writeDataJson(w, user)
+	err = writeDataJson(w, frontEndUser(DBUser))
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		return
 	}
 }
@@ -238,38 +305,38 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 	// validate
 	if err := validate.Struct(request); err != nil {
 		// Handle validation error
-		writeErrorJson(w, Type.MessageDisplayError{Message: "invalid request format"})
+		writeErrorJson(w, r, Type.BadRequest("invalid request format"))
 		return
 	}
 	userName := strings.TrimSpace(request.UserName)
 	if len(userName) == 0 {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者名稱不得為空"})
+		writeErrorJson(w, r, Type.BadRequest("使用者名稱不得為空"))
 		return
 	}
 	if len(userName) > Consts.MaxNameLen {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者名稱不得超過12字元"})
+		writeErrorJson(w, r, Type.BadRequest("使用者名稱不得超過12字元"))
 		return 
 	}
 	if userName == Consts.ADMINNAME {
-		writeErrorJson(w, Type.MessageDisplayError{Message: fmt.Sprintf("使用者名稱不得為%s", Consts.ADMINNAME)})
+		writeErrorJson(w, r, Type.BadRequest("使用者名稱不得為%s").WithArgs(Consts.ADMINNAME))
 		return 
 	}
 	if !utils.IsValidName(userName) {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者名稱只能包含英文、數字、底線"})
+		writeErrorJson(w, r, Type.BadRequest("使用者名稱只能包含英文、數字、底線"))
 		return 
 	}
 	
 	if !utils.IsValidEmail(request.UserEmail) {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "帳號電子郵件格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("帳號電子郵件格式錯誤"))
 		return 
 	}
 
 	if err := utils.IsValidPassword(request.UserPassword); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return 
 	}
 	if request.UserPassword != request.ReUserPassword {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "兩次密碼輸入不一致"})
+		writeErrorJson(w, r, Type.BadRequest("兩次密碼輸入不一致"))
 		return 
 	}
 
@@ -282,29 +349,29 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 	err := activateEmailColl.FindOne(findingContext, filter).Decode(&record)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return 
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "此電子郵件尚未申請驗證"})
+			writeErrorJson(w, r, Type.BadRequest("此電子郵件尚未申請驗證"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤"))
 		return 
 	}
 	if record.Activated == false {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "此電子郵件尚未通過開通驗證"})
+		writeErrorJson(w, r, Type.BadRequest("此電子郵件尚未通過開通驗證"))
 		return 
 	}
 	// 確認是否在開通的有效期限
 	if record.Expire < utils.GetNow() {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "電子郵件超過有效註冊時間 請重新驗證"})
+		writeErrorJson(w, r, Type.BadRequest("電子郵件超過有效註冊時間 請重新驗證"))
 		return 
 	}
 
 	hashedPassword, err := utils.HashPassword(request.UserPassword)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤"))
 		return 
 	}
 	mailColl := DB.Client.Database("go-quizlet").Collection("mails")
@@ -316,14 +383,14 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 	res := userColl.FindOne(findingContext, filter)
 	if res.Err() == nil {
 		// User exists
-		writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號已註冊"})
+		writeErrorJson(w, r, Type.Conflict("此帳號已註冊"))
 		return
 	} else if res.Err() != mongo.ErrNoDocuments {
 		// Some other database error occurred
 		if res.Err() == context.DeadlineExceeded {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤"))
 		} else {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "伺服器錯誤 請重試"})
+			writeErrorJson(w, r, Type.Internal("伺服器錯誤 請重試"))
 		}
 		return
 	}
@@ -335,7 +402,7 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "無法開始會話 請重試"})
+		writeErrorJson(w, r, Type.Internal("無法開始會話 請重試"))
 		return
 	}
 	defer session.EndSession(ctx)
@@ -344,16 +411,16 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 		// 先創建歡迎信件
 		_, err = mailColl.InsertOne(ctx, Type.MailViewType{
 			ID: mailID,
-			Title: "歡迎信件",
-			Content: utils.GetWelcomeLetter(userName),
+			Title: translate(r, "歡迎信件"),
+			Content: utils.GetWelcomeLetter(i18n.FromContext(r.Context()), userName),
 			Date: utils.GetNow(),
 			ReceiverID: userID,
 			Read: false,
 		})
 		if err != nil {
 			session.AbortTransaction(sc) // 交易失敗時回滾
-			log.Println(err)
-			return errors.New("資料庫錯誤 請重試")
+			loggerFromContext(r.Context()).Error("insert welcome mail failed", "error", err)
+			return Type.Internal("資料庫錯誤 請重試")
 		}
 		// 創建使用者
 		res, err := userColl.InsertOne(ctx, Type.User{
@@ -371,8 +438,8 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 		})
 		if err != nil {
 			session.AbortTransaction(sc)
-			log.Println(err)
-			return errors.New("資料庫錯誤 請重試")
+			loggerFromContext(r.Context()).Error("insert user failed", "error", err)
+			return Type.Internal("資料庫錯誤 請重試")
 		}
 
 		//創建使用者對應的recentVisit
@@ -382,8 +449,8 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 		})
 		if err != nil {
 			session.AbortTransaction(sc)
-			log.Println(err)
-			return errors.New("資料庫錯誤 請重試")
+			loggerFromContext(r.Context()).Error("insert recentVisit failed", "error", err)
+			return Type.Internal("資料庫錯誤 請重試")
 		}
 
 		// 把電子郵件從activateEmail中移除
@@ -391,28 +458,30 @@ func handleAccountPasswordRegister(w http.ResponseWriter, r *http.Request) {
 		_, err = activateEmailColl.DeleteOne(ctx, filter)
 		if err != nil {
 			session.AbortTransaction(sc)
-			log.Println(err)
-			return errors.New("資料庫錯誤 請重試")
+			loggerFromContext(r.Context()).Error("delete activateEmail record failed", "error", err)
+			return Type.Internal("資料庫錯誤 請重試")
 		}
 
-		fmt.Println("account-password successfully register a new user", res.InsertedID)
+		loggerFromContext(r.Context()).Info("account-password successfully registered a new user", "newUserID", userID, "insertedID", res.InsertedID)
 		return nil
 	})
 	
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 
 	// 簽發JWT
 	tokenString, err := utils.SignJWT(userID, Consts.DefaultJWTExpireTime)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "JWT簽發錯誤 請重新登入"})
+		writeErrorJson(w, r, Type.Internal("JWT簽發錯誤 請重新登入"))
 		return 
 	}
 	// 設定JWT到cookie
 	utils.SetJTWCookie(w, tokenString, Consts.DefaultJWTExpireTime)
 	
+	emitWebhookEvent(r.Context(), Consts.WebhookEventUserRegistered, []string{userID}, Type.WebhookUserData{UserID: userID, Name: userName})
+
 	writeDataJson(w, Type.FrontEndUser{ID:userID, Role: "user", Name: userName, Email: request.UserEmail, Img: "", LikedWordSets: []string{}})
 }
 
@@ -420,14 +489,14 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 	var request Type.OAuthRegisterRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
 		return
 	}
 	defer r.Body.Close()
 	
 	payload, err := utils.VerifyGoogleCredential(request.Credential)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+		writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 		return
 	}
 	
@@ -443,14 +512,14 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 	res := userColl.FindOne(findingContext, filter)
 	if res.Err() == nil {
 		// User exists
-		writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號已註冊"})
+		writeErrorJson(w, r, Type.Conflict("此帳號已註冊"))
 		return
 	} else if res.Err() != mongo.ErrNoDocuments {
 		// Some other database error occurred
 		if res.Err() == context.DeadlineExceeded {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤"))
 		} else {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "伺服器錯誤 請重試"})
+			writeErrorJson(w, r, Type.Internal("伺服器錯誤 請重試"))
 		}
 		return
 	}
@@ -462,7 +531,7 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "無法開始會話 請重試"})
+		writeErrorJson(w, r, Type.Internal("無法開始會話 請重試"))
 		return
 	}
 	defer session.EndSession(ctx)
@@ -476,15 +545,15 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 		// 先創建歡迎信件
 		_, err = mailColl.InsertOne(ctx, Type.MailViewType{
 			ID: mailID,
-			Title: "歡迎信件",
-			Content: utils.GetWelcomeLetter(userName),
+			Title: translate(r, "歡迎信件"),
+			Content: utils.GetWelcomeLetter(i18n.FromContext(r.Context()), userName),
 			Date: utils.GetNow(),
 			ReceiverID: userID,
 			Read: false,
 		})
 		if err != nil {
 			session.AbortTransaction(sc) // 交易失敗時回滾
-			return errors.New("寫入錯誤 請重試")
+			return Type.Internal("寫入錯誤 請重試")
 		}
 		newUser := Type.User{
 			ID:              userID,
@@ -504,9 +573,9 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			session.AbortTransaction(sc)
 			if errors.Is(err, context.DeadlineExceeded) {
-				return errors.New("超時錯誤")
+				return Type.Timeout("超時錯誤")
 			} else {
-				return errors.New("未知錯誤 請重試")
+				return Type.Internal("未知錯誤 請重試")
 			}
 		}
 
@@ -517,15 +586,15 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 		})
 		if err != nil {
 			session.AbortTransaction(sc)
-			return errors.New("寫入錯誤 請重試")
+			return Type.Internal("寫入錯誤 請重試")
 		}
 		
-		log.Println("OAuth successfully registered a new user", res.InsertedID)
+		loggerFromContext(r.Context()).Info("OAuth successfully registered a new user", "newUserID", userID, "insertedID", res.InsertedID)
 		return nil
 	})
 
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 	
@@ -534,12 +603,14 @@ func handleOAuthRegister(w http.ResponseWriter, r *http.Request) {
 	// 簽發JWT
 	tokenString, err := utils.SignJWT(userID, Consts.DefaultJWTExpireTime)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "JWT簽發錯誤 請重新登入"})
+		writeErrorJson(w, r, Type.Internal("JWT簽發錯誤 請重新登入"))
 		return 
 	}
 	// 設定JWT到cookie
 	utils.SetJTWCookie(w, tokenString, Consts.DefaultJWTExpireTime)
 	
+	emitWebhookEvent(r.Context(), Consts.WebhookEventUserRegistered, []string{userID}, Type.WebhookUserData{UserID: userID, Name: userName, IsGoogle: true})
+
 	writeDataJson(w, Type.FrontEndUser{ID:userID, Role: "user", Name: userName, Email: email, Img: userImg, LikedWordSets: []string{}})
 }
 
@@ -551,8 +622,8 @@ func handleAccountPasswordLogIn(w http.ResponseWriter, r *http.Request) {
 	// validate
 	if err := validate.Struct(request); err != nil {
 		// Handle validation error
-		log.Println("帳密登入格式錯誤")
-		writeErrorJson(w, Type.MessageDisplayError{Message: "帳密登入格式錯誤"})
+		loggerFromContext(r.Context()).Info("帳密登入格式錯誤")
+		writeErrorJson(w, r, Type.BadRequest("帳密登入格式錯誤"))
 		return
 	}
 	// check if the account exists
@@ -564,44 +635,42 @@ func handleAccountPasswordLogIn(w http.ResponseWriter, r *http.Request) {
 	err := coll.FindOne(findingContext, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			log.Println("此帳號不存在")
-			writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號不存在"})
+			loggerFromContext(r.Context()).Info("此帳號不存在")
+			writeErrorJson(w, r, Type.NotFound("此帳號不存在"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		return
 	}
 	// 確認該帳號是否為OAuth而不是一般帳號
 	if user.IsGoogle {
-		log.Println("帳號登入錯誤")
-		writeErrorJson(w, Type.MessageDisplayError{Message: "帳號登入錯誤"})
+		loggerFromContext(r.Context()).Info("帳號登入錯誤", "userID", user.ID)
+		writeErrorJson(w, r, Type.BadRequest("帳號登入錯誤"))
 		return
 	}
 	// check if the password is correct
 	if ok := utils.CheckHashedPassword(request.UserPassword, user.Password); !ok {
-		log.Println("使用者密碼錯誤")
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者密碼錯誤"})
+		loggerFromContext(r.Context()).Info("使用者密碼錯誤", "userID", user.ID)
+		writeErrorJson(w, r, Type.Unauthorized("使用者密碼錯誤"))
 		return
 	}
 
-	log.Printf("user %s successfully log in\n", user.Email)
-
 	// sign JWT
 	tokenString, err := utils.SignJWT(user.ID, Consts.DefaultJWTExpireTime)
 	if err != nil {
-		log.Println("JWT簽發錯誤 請重試")
-		writeErrorJson(w, Type.MessageDisplayError{Message: "JWT簽發錯誤 請重試"})
+		loggerFromContext(r.Context()).Error("JWT簽發錯誤", "userID", user.ID, "error", err)
+		writeErrorJson(w, r, Type.Internal("JWT簽發錯誤 請重試"))
 		return
 	}
 	// store JWT to cookie
 	utils.SetJTWCookie(w, tokenString, Consts.DefaultJWTExpireTime)
 
-	log.Printf("user %s successfully log in then sign JWT\n", user.Email)
-	writeDataJson(w, Type.FrontEndUser{ID:user.ID, Role: user.Role, Name: user.Name, Email: user.Email, Img: user.Img, LikedWordSets: user.LikedWordSets})
+	loggerFromContext(r.Context()).Info("user successfully logged in", "userID", user.ID)
+	writeDataJson(w, Type.FrontEndUser{ID:user.ID, Role: user.Role, Name: user.Name, Email: user.Email, Img: user.Img, LikedWordSets: user.LikedWordSets, Locale: user.Locale})
 }
 
 
@@ -613,14 +682,14 @@ func handleOAuthLogIn(w http.ResponseWriter, r *http.Request) {
 	// validate
 	if err := validate.Struct(request); err != nil {
 		// Handle validation error
-		writeErrorJson(w, Type.MessageDisplayError{Message: "OAuth登入格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("OAuth登入格式錯誤"))
 		return
 	}
 
 	// check if the OAuth credential is valid
 	payload, err := utils.VerifyGoogleCredential(request.Credential)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "wrong OAuth credential"})
+		writeErrorJson(w, r, Type.Unauthorized("wrong OAuth credential"))
 		return
 	} 
 	
@@ -633,116 +702,119 @@ func handleOAuthLogIn(w http.ResponseWriter, r *http.Request) {
 	err = coll.FindOne(findingContext, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號不存在"})
+			writeErrorJson(w, r, Type.NotFound("此帳號不存在"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 	// 確認該帳號是否為一般帳號而不是OAuth
 	if !user.IsGoogle {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "帳號登入錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("帳號登入錯誤"))
 		return
 	}
 
-	log.Printf("user %s successfully log in\n", user.Email)
-
 	// sign JWT
 	tokenString, err := utils.SignJWT(user.ID, Consts.DefaultJWTExpireTime)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "JWT簽發錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("JWT簽發錯誤 請重試"))
 		return
 	}
 	// store JWT to cookie
 	utils.SetJTWCookie(w, tokenString, Consts.DefaultJWTExpireTime)
 
-	log.Printf("user %s successfully log in then sign JWT\n", user.Email)
-	writeDataJson(w, Type.FrontEndUser{ID:user.ID, Role: user.Role, Name: user.Name, Email: user.Email, Img: user.Img, LikedWordSets: user.LikedWordSets})
+	loggerFromContext(r.Context()).Info("user successfully logged in", "userID", user.ID)
+	writeDataJson(w, Type.FrontEndUser{ID:user.ID, Role: user.Role, Name: user.Name, Email: user.Email, Img: user.Img, LikedWordSets: user.LikedWordSets, Locale: user.Locale})
 }
 
 // log out
 func handleLogOut(w http.ResponseWriter, r *http.Request) {
 	utils.RemoveJWTCookie(w)
-	writeDataJson(w, Type.MessageDisplaySuccess{Message: "登出成功!"})
+	writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "登出成功!")})
 }
 
 // get UserLink(適用於需要user ID/name/img)的任何場景
 func handleGetUserLink(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("userID")
+	userLink, err := getUserLink(r.Context(), r.PathValue("userID"))
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	writeDataJson(w, userLink)
+}
+
+func getUserLink(ctx context.Context, userID string) (*Type.UserLink, error) {
 	coll := DB.Client.Database("go-quizlet").Collection("users")
-	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	var userLink Type.UserLink
 	filter := bson.M{"id":userID}
 	err := coll.FindOne(findingContext, filter).Decode(&userLink)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusNotFound, Message: "查無使用者"})
-			return
+			return nil, Type.NotFound("查無使用者")
 		}
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "伺服器錯誤 請重試"})
-		return
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
 	}
-	
-	writeDataJson(w, userLink)
+	return &userLink, nil
 }
 
-func GetValidateUser[T any](handlerFunc func(string) (T, error)) http.HandlerFunc {
+func GetValidateUser[T any](handlerFunc func(context.Context, string) (T, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 先檢查使用者是否登入以及JWT是否過期了
 		token, err := utils.CheckLogIn(w, r)
 		if err != nil {
-			fmt.Println("User not log in! Exit the post handler")
-			CallToLogInJson(w, Type.MessageDisplayError{Message: "使用者未登入! 或憑證已過期!"})
+			loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+			CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
 			return
 		}
 		
 		// 從JWT中取出userID
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
 		userID, ok := claims["userID"].(string)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
 
 		// 從path params拿userID
 		id := r.PathValue("userID")
 		if id != userID {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "使用者無權限"})
+			writeErrorJson(w, r, Type.Forbidden("使用者無權限"))
 			return
 		}
 
 		// 檢查完畢，執行邏輯function
-		data, err := handlerFunc(userID)
+		data, err := handlerFunc(contextWithUserID(r.Context(), userID), userID)
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+			writeErrorJson(w, r, err)
 			return
 		}
 		
 		err = writeDataJson(w, data)
 		
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+			writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		}
 	}
 }
 
 // 回傳MessageDisplayError的handler wrapper，這是一個很好的generic pattern in Golang
-func PostValidateUser[T Type.UserRelatedRequest](handlerFunc func(T) (string, error)) http.HandlerFunc {
+func PostValidateUser[T Type.UserRelatedRequest](handlerFunc func(context.Context, T) (string, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 先檢查使用者是否登入以及JWT是否過期了
 		token, err := utils.CheckLogIn(w, r)
 		if err != nil {
-			fmt.Println("User not log in! Exit the post handler")
-			CallToLogInJson(w, Type.MessageDisplayError{Message: "使用者未登入! 或憑證已過期!"})
+			loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+			CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
 			return
 		}
 		
@@ -753,55 +825,66 @@ func PostValidateUser[T Type.UserRelatedRequest](handlerFunc func(T) (string, er
 		// validate
 		if err := validate.Struct(request); err != nil {
 			// Handle validation error
-			writeErrorJson(w, Type.MessageDisplayError{Message: "請求缺少必要欄位"})
+			writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位"))
 			return
 		}
 		// 從JWT中取出userID
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
 		userID, ok := claims["userID"].(string)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
 
 		// 比對request的userID是否一致
 		if request.GetUserID() != userID {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "使用者無權限變更"})
+			writeErrorJson(w, r, Type.Forbidden("使用者無權限變更"))
 			return
 		}
 
 		// 檢查完畢，執行邏輯function
-		id, err := handlerFunc(request)
+		ctx, duplicates := contextWithDuplicateSink(contextWithUserID(r.Context(), userID))
+		id, err := handlerFunc(ctx, request)
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+			writeErrorJson(w, r, err)
 			return
 		}
 		
 		if id != "" {
-			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: id})
+			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: id, Duplicates: *duplicates})
 		} else {
-			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: "使用者操作成功"})
+			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "使用者操作成功"), Duplicates: *duplicates})
 		}
 
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+			writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		}
 	}
 }
 
 // 回傳MessageDisplayError的handler wrapper，這是改良上方讓他能在這裡用interface的方式去達成類似assertion的效果
 // 可以call type T struct的function
-func PostValidateWordSetAuthor[T Type.WordSetsRelatedRequest](handlerFunc func(T) (string, error)) http.HandlerFunc {
+func PostValidateWordSetAuthor[T Type.WordSetsRelatedRequest](handlerFunc func(context.Context, T) (string, error)) http.HandlerFunc {
+	return postValidateWordSetRole(Consts.WordSetRoleOwner, handlerFunc)
+}
+
+// 同PostValidateWordSetAuthor，但作者以外的editor協作者也可以通過
+func PostValidateWordSetEditor[T Type.WordSetsRelatedRequest](handlerFunc func(context.Context, T) (string, error)) http.HandlerFunc {
+	return postValidateWordSetRole(Consts.WordSetRoleEditor, handlerFunc)
+}
+
+// 確認使用者在該wordSet至少有role的權限後才執行handlerFunc
+func postValidateWordSetRole[T Type.WordSetsRelatedRequest](role string, handlerFunc func(context.Context, T) (string, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 先檢查使用者是否登入以及JWT是否過期了
 		token, err := utils.CheckLogIn(w, r)
 		if err != nil {
-			fmt.Println("User not log in! Exit the post handler")
-			CallToLogInJson(w, Type.MessageDisplayError{Message: "使用者未登入! 或憑證已過期!"})
+			loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+			CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
 			return
 		}
 		
@@ -812,78 +895,180 @@ func PostValidateWordSetAuthor[T Type.WordSetsRelatedRequest](handlerFunc func(T
 		// validate
 		if err := validate.Struct(request); err != nil {
 			// Handle validation error
-			writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+			writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位").Wrap(err))
 			return
 		}
 		
-		// 拿出wordSetID 之後並確認該user是這份wordSet的作者
-		// 查詢wordSet
-		coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-		findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		var wordSet Type.WordSet
-		filter := bson.M{"id":request.GetWordSetID()}
-		err = coll.FindOne(findingContext, filter).Decode(&wordSet)
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
-				writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
-				return
-			}
-			writeErrorJson(w, Type.MessageDisplayError{Message: "查無此單字集"})
-			return
-		}
-
 		// 從token取得userID
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
 		// 確保有userID這個欄位
 		userID, ok := claims["userID"].(string)
 		if !ok {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+			writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 			return
 		}
-		// 比對作者
-		if wordSet.AuthorID != userID {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "使用者無權限更改!"})
+		// 拿出wordSetID 之後並確認該user在這份wordSet有足夠的權限
+		if _, err := checkWordSetRole(r.Context(), request.GetWordSetID(), userID, role); err != nil {
+			writeErrorJson(w, r, err)
 			return
 		}
 
 		// 一切成功後，執行真正的邏輯function
-		id, err := handlerFunc(request)
+		ctx, version := contextWithVersionSink(contextWithUserID(r.Context(), userID))
+		ctx, duplicates := contextWithDuplicateSink(ctx)
+		id, err := handlerFunc(ctx, request)
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+			writeErrorJson(w, r, err)
 			return
 		} 
+		if *version > 0 {
+			notifyLiveRoom(ctx, request.GetWordSetID())
+		}
 		if id != "" {
-			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: id})
+			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: id, Version: *version, Duplicates: *duplicates})
 		} else {
-			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: "使用者/單字集驗證成功"})
+			err = writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "使用者/單字集驗證成功"), Version: *version, Duplicates: *duplicates})
 		}
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+			writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		}
 	}
 }
 
+// 確認userID是該wordSet的作者，並回傳該wordSet
+func checkWordSetAuthor(ctx context.Context, wordSetID string, userID string) (*Type.WordSet, error) {
+	return checkWordSetRole(ctx, wordSetID, userID, Consts.WordSetRoleOwner)
+}
+
+// 確認userID是該wordSet的作者或editor協作者，並回傳該wordSet
+func checkWordSetEditor(ctx context.Context, wordSetID string, userID string) (*Type.WordSet, error) {
+	return checkWordSetRole(ctx, wordSetID, userID, Consts.WordSetRoleEditor)
+}
+
+// 確認userID在該wordSet至少有role的權限(作者本人或協作者名單)，並回傳該wordSet
+func checkWordSetRole(ctx context.Context, wordSetID string, userID string, role string) (*Type.WordSet, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var wordSet Type.WordSet
+	err := coll.FindOne(findingContext, liveWordSetFilter(bson.M{"id":wordSetID})).Decode(&wordSet)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("查無此單字集")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	// 比對作者以及協作者名單
+	if !hasWordSetRole(&wordSet, userID, role) {
+		return nil, Type.Forbidden("使用者無權限更改!")
+	}
+	return &wordSet, nil
+}
+
+// 註釋跟敘述可以用Markdown，字數限制算的是顯示出來的文字，原文另外有上限
+func markdownLenOK(source string, limit int) bool {
+	return len(source) <= Consts.MaxMarkdownSourceLen && markdown.Len(source) <= limit
+}
+
+// 註釋不能是空的(只有Markdown符號也算空的)
+func validDefinition(definition string) bool {
+	return markdown.Len(definition) > 0 && markdownLenOK(definition, Consts.MaxDefinitionLen)
+}
+
+// 驗證單字字數跟註釋字數/Sound
+func validateWord(word Type.Word) error {
+	if len(word.Vocabulary) == 0 || len(word.Vocabulary) > Consts.MaxVocabularyLen {
+		return Type.BadRequest("單字字數不得為0或超過100字元")
+	}
+	if !validDefinition(word.Definition) {
+		return Type.BadRequest("註釋字數不得為0或超過300字元")
+	}
+	if err := utils.IsValidSound(word.VocabularySound); err != nil {
+		return err
+	}
+	if err := utils.IsValidSound(word.DefinitionSound); err != nil {
+		return err
+	}
+	return validateWordDetails(word.Pronunciation, word.PartOfSpeech, word.Meanings, word.Examples)
+}
+
+// 驗證音標、詞性、其他意思以及例句，都是選填的
+func validateWordDetails(pronunciation string, partOfSpeech string, meanings []Type.WordMeaning, examples []Type.WordExample) error {
+	if utf8.RuneCountInString(pronunciation) > Consts.MaxPronunciationLen {
+		return Type.BadRequest("音標字數不得超過%d字元").WithArgs(Consts.MaxPronunciationLen)
+	}
+	if partOfSpeech != "" && !slices.Contains(Consts.PartsOfSpeech, partOfSpeech) {
+		return Type.BadRequest("詞性格式錯誤")
+	}
+	if len(meanings) > Consts.MaxMeanings {
+		return Type.BadRequest("每個單字最多%d個意思").WithArgs(Consts.MaxMeanings)
+	}
+	for _, meaning := range meanings {
+		if !validDefinition(meaning.Definition) {
+			return Type.BadRequest("註釋字數不得為0或超過300字元")
+		}
+		if meaning.PartOfSpeech != "" && !slices.Contains(Consts.PartsOfSpeech, meaning.PartOfSpeech) {
+			return Type.BadRequest("詞性格式錯誤")
+		}
+	}
+	if len(examples) > Consts.MaxExamples {
+		return Type.BadRequest("每個單字最多%d個例句").WithArgs(Consts.MaxExamples)
+	}
+	for _, example := range examples {
+		if len(strings.TrimSpace(example.Sentence)) == 0 || utf8.RuneCountInString(example.Sentence) > Consts.MaxExampleLen ||
+			utf8.RuneCountInString(example.Translation) > Consts.MaxExampleLen {
+			return Type.BadRequest("例句字數不得為0或超過%d字元").WithArgs(Consts.MaxExampleLen)
+		}
+	}
+	return nil
+}
+
+// 驗證editWordSet有給的音標、詞性、意思以及例句，沒給的欄位不變
+func validateEditWordDetails(word Type.EditWord) error {
+	var pronunciation, partOfSpeech string
+	var meanings []Type.WordMeaning
+	var examples []Type.WordExample
+	if word.Pronunciation != nil {
+		pronunciation = *word.Pronunciation
+	}
+	if word.PartOfSpeech != nil {
+		partOfSpeech = *word.PartOfSpeech
+	}
+	if word.Meanings != nil {
+		meanings = *word.Meanings
+	}
+	if word.Examples != nil {
+		examples = *word.Examples
+	}
+	return validateWordDetails(pronunciation, partOfSpeech, meanings, examples)
+}
+
 // 處理新建wordSet
-func handleCreateWordSet(request Type.CreateWordSetRequest) (string, error) {
-	fmt.Printf("%v\n",request)
+func handleCreateWordSet(ctx context.Context, request Type.CreateWordSetRequest) (string, error) {
+	// 標題跟敘述字數
+	if err := validateWordSetText(request.WordSet.Title, request.WordSet.Description); err != nil {
+		return "", err
+	}
+	// 卡片模板，沒給是預設模板
+	if request.WordSet.Template != nil {
+		if err := validateCardTemplate(request.WordSet.Template); err != nil {
+			return "", err
+		}
+	}
 	// 驗證單字字數跟註釋字數/Sound
 	for _, word := range request.WordSet.Words {
-		if len(word.Vocabulary) == 0 || len(word.Vocabulary) > Consts.MaxVocabularyLen {
-			return "", errors.New("單字字數不得為0或超過100字元")
-		}
-		if len(word.Definition) == 0 || len(word.Definition) > Consts.MaxDefinitionLen {
-			return "", errors.New("註釋字數不得為0或超過300字元")
-		}
-		if err := utils.IsValidSound(word.VocabularySound); err != nil {
+		if err := validateWord(word); err != nil {
 			return "", err
 		}
-		if err := utils.IsValidSound(word.DefinitionSound); err != nil {
+		if err := validateWordFields(request.WordSet.Template, word.Fields); err != nil {
 			return "", err
 		}
 	}
@@ -893,44 +1078,66 @@ func handleCreateWordSet(request Type.CreateWordSetRequest) (string, error) {
 	// 在後端產生wordID
 	for i := range request.WordSet.Words {
 		request.WordSet.Words[i].ID = utils.GenerateID() // Modify the original slice element by reference
+		request.WordSet.Words[i].Fields = mergeWordFields(nil, request.WordSet.Words[i].Fields)
 	}
+	stripWordMedia(request.WordSet.Words)
 	// 後端產生字數
 	request.WordSet.WordCnt = len(request.WordSet.Words)
+	// 作者是發request的人，其他由後端管理的欄位不接受前端帶進來的值
+	request.WordSet.AuthorID = request.UserID
+	request.WordSet.LikedUsers = []string{}
+	request.WordSet.Likes = 0
+	request.WordSet.Version = 0
+	request.WordSet.UpdatedBy = ""
+	request.WordSet.Collaborators = nil
+	request.WordSet.ForkedFrom = nil
+	request.WordSet.RevisionCnt = 0
+	request.WordSet.DeletedAt, request.WordSet.PurgeAt = 0, 0
+	// 公開範圍，isPublic跟著visibility
+	visibility, err := resolveVisibility(request.WordSet.Visibility, request.WordSet.IsPublic)
+	if err != nil {
+		return "", err
+	}
+	request.WordSet.Visibility = visibility
+	request.WordSet.IsPublic = visibility == Consts.VisibilityPublic
+	// 重複單字設定，沒給就是warn
+	if request.WordSet.DuplicatePolicy != "" && !slices.Contains(Consts.DuplicatePolicies, request.WordSet.DuplicatePolicy) {
+		return "", Type.BadRequest("重複單字設定錯誤(warn, reject)")
+	}
+	if err := checkDuplicateWords(ctx, request.WordSet.DuplicatePolicy, request.WordSet.Words, nil); err != nil {
+		return "", err
+	}
 	// 後端標註createdAt跟updatedAt
 	request.WordSet.CreatedAt = utils.GetTodayFormatted()
 	request.WordSet.UpdatedAt = utils.GetNow()
-
-	fmt.Printf("%v\n",request)
 	
 	// 寫入DB，用transaction
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		return "", errors.New("資料庫錯誤 請重試")
+		return "", Type.Internal("資料庫錯誤 請重試").Wrap(err)
 	}
 	defer session.EndSession(ctx)
 	// Run transaction function
 	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
 		// Start transaction
 		if err := session.StartTransaction(); err != nil {
-			return errors.New("資料庫錯誤 請重試")
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
 		}
 		// 取得 Collection
 		wordSetColl := DB.Client.Database("go-quizlet").Collection("wordSets")
 		userColl := DB.Client.Database("go-quizlet").Collection("users")
 
 		// 插入 wordSet (使用 session context `sc`)
-		insertRes, err := wordSetColl.InsertOne(sc, request.WordSet)
+		_, err := wordSetColl.InsertOne(sc, request.WordSet)
 		if err != nil {
 			session.AbortTransaction(sc) // 交易失敗時回滾
 			if errors.Is(err, context.DeadlineExceeded) {
-				return errors.New("超時錯誤 請重試")
+				return Type.Timeout("超時錯誤 請重試")
 			}
-			return errors.New("未知錯誤 請重試")
+			return Type.Internal("未知錯誤 請重試").Wrap(err)
 		}
-		fmt.Println("HandleCreateWordSet: 成功", "ID", insertRes.InsertedID)
-
 		// wordSetID 寫入到 user 的 CreatedWordSets 陣列
 		filter := bson.M{"id": request.UserID}
 		update := bson.M{"$push": bson.M{"createdWordSets": newWordSetID}}
@@ -939,21 +1146,27 @@ func handleCreateWordSet(request Type.CreateWordSetRequest) (string, error) {
 		if err != nil {
 			session.AbortTransaction(sc) // 交易失敗時回滾
 			if errors.Is(err, context.DeadlineExceeded) {
-				return errors.New("超時錯誤 請重試")
+				return Type.Timeout("超時錯誤 請重試")
 			}
-			return errors.New("未知錯誤 請重試")
+			return Type.Internal("未知錯誤 請重試").Wrap(err)
 		}
 		if updateRes.MatchedCount == 0 {
 			session.AbortTransaction(sc)
-			return errors.New("查無使用者")
+			return Type.NotFound("查無使用者")
+		}
+
+		// 第一個版本
+		if err := recordWordSetRevision(sc, newWordSetID, nil, Consts.RevisionActionCreate, 0); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
 		}
 
 		// ✅ 提交交易
 		if err := session.CommitTransaction(sc); err != nil {
-			return errors.New("交易提交失敗 請重試")
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
 		}
 
-		log.Println("create wordSet succeeds!")
+		loggerFromContext(ctx).Info("create wordSet succeeds", "wordSetID", newWordSetID, "wordCnt", request.WordSet.WordCnt)
 
 		return nil
 	})
@@ -962,20 +1175,23 @@ func handleCreateWordSet(request Type.CreateWordSetRequest) (string, error) {
 		return "", err
 	}
 
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetCreated, Type.WebhookWordSetData{
+		WordSetID: newWordSetID, Title: request.WordSet.Title, AuthorID: request.UserID, ActorID: request.UserID,
+	})
 	return newWordSetID, nil
 }
 
 // 處理query wordSet
 func handleGetWordSet(w http.ResponseWriter, r *http.Request) {
 	wordSetID := r.PathValue("wordSetID")
-	wordSet, err := getWordSetByID(wordSetID)
+	wordSet, err := getStarredWordSet(r.Context(), wordSetID, requestUserID(r), shareTokenFromRequest(r))
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
+		writePageErrorJson(w, r, err)
 		return
 	}
 	err = writeDataJson(w, wordSet)
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "未知錯誤 請重試"})
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
@@ -983,69 +1199,78 @@ func handleGetWordSet(w http.ResponseWriter, r *http.Request) {
 func handleGetWordSetCard(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("query") // to get the query params called "query"
 	if query == "" {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "搜尋值為空"})
+		writePageErrorJson(w, r, Type.BadRequest("搜尋值為空"))
 		return
 	}
-	log.Println("wordSet card query:", query)
+	loggerFromContext(r.Context()).Debug("wordSet card query", "query", query)
 	curNumber, err := strconv.Atoi(r.URL.Query().Get("curNumber")) // to get the query params called "curNumber"
+	if err != nil || curNumber < 0 {
+		writePageErrorJson(w, r, Type.BadRequest("搜尋值錯誤"))
+		return
+	}
+	response, err := searchWordSetCards(r.Context(), query, curNumber)
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "搜尋值錯誤"})
+		writePageErrorJson(w, r, err)
 		return
 	}
+	err = writeDataJson(w, response)
+	if err != nil {
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+// 用標題前綴搜尋wordSet，從第offset筆開始一次最多拿Consts.MaxDataFetch筆
+func searchWordSetCards(ctx context.Context, query string, offset int) (*Type.SearchWordSetResponse, error) {
 	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	filter := bson.M{"title":primitive.Regex{Pattern: fmt.Sprintf("^%s", query)}}
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := publicWordSetFilter(liveWordSetFilter(bson.M{"title":primitive.Regex{Pattern: "^" + regexp.QuoteMeta(query)}}))
 	// Create options for find with sort, skip and limit
 	findOptions := options.Find()
 	findOptions.SetSort(bson.M{"updatedAt": -1})	// Sort by updatedAt in descending order
-	findOptions.SetSkip(int64(curNumber))			// Skip number of documents before curNumber
+	findOptions.SetSkip(int64(offset))			// Skip number of documents before offset
 	findOptions.SetLimit(int64(Consts.MaxDataFetch+1)) // Limit to Consts.MaxDataFetch+1 documents
-	defer cancel()
 	cursor, err := coll.Find(findingContext, filter, findOptions)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
-			return
+			return nil, Type.Timeout("超時錯誤 請重試").Wrap(err)
 		}
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
-		return
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
 	}
 	wordSetCards := make([]Type.WordSetCard, 0, Consts.MaxDataFetch+1)
 	if err = cursor.All(findingContext, &wordSetCards); err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "解析失敗"})
-    	return
+		return nil, Type.Internal("解析失敗").Wrap(err)
 	}
 	// 查詢是否還有多的資料
 	haveMore := len(wordSetCards) > Consts.MaxDataFetch
 	if haveMore {
 		wordSetCards = wordSetCards[:Consts.MaxDataFetch] // 不取最後一個
 	}
-	response := Type.SearchWordSetResponse{
+	return &Type.SearchWordSetResponse{
 		WordSetCards: wordSetCards,
 		HaveMore: haveMore,
-	}
-	fmt.Println(response)
-	err = writeDataJson(w, response)
-	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "未知錯誤 請重試"})
-	}
+	}, nil
 }
 
 // 處理請求preview words的
 func handleGetPreviewWords(w http.ResponseWriter, r *http.Request) {
 	wordSetID := r.URL.Query().Get("wordSetID") // to get the query params called "wordSetID"
 	if wordSetID == "" {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "搜尋值為空"})
+		writePageErrorJson(w, r, Type.BadRequest("搜尋值為空"))
 		return
 	}
 	curNumber, err := strconv.Atoi(r.URL.Query().Get("curNumber")) // to get the query params called "curNumber"
-	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "搜尋值為錯誤"})
+	if err != nil || curNumber < 0 {
+		writePageErrorJson(w, r, Type.BadRequest("搜尋值為錯誤"))
 		return
 	}
-	wordSet, err := getWordSetByID(wordSetID)
+	wordSet, err := getStarredWordSet(r.Context(), wordSetID, requestUserID(r), shareTokenFromRequest(r))
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
+		writePageErrorJson(w, r, err)
+		return
+	}
+	if curNumber > len(wordSet.Words) {
+		writePageErrorJson(w, r, Type.BadRequest("搜尋值為錯誤"))
 		return
 	}
 	// 查詢是否還有多的資料
@@ -1054,19 +1279,18 @@ func handleGetPreviewWords(w http.ResponseWriter, r *http.Request) {
 		Words: wordSet.Words[curNumber:min(curNumber+6, len(wordSet.Words))],
 		HaveMore: haveMore,
 	}
-	fmt.Println(response)
 	err = writeDataJson(w, response)
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "未知錯誤 請重試"})
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
 // 處理query words for full wordCard
 func handleGetWords(w http.ResponseWriter, r *http.Request) {
 	wordSetID := r.PathValue("wordSetID")
-	wordSet, err := getWordSetByID(wordSetID)
+	wordSet, err := getStarredWordSet(r.Context(), wordSetID, requestUserID(r), shareTokenFromRequest(r))
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
+		writePageErrorJson(w, r, err)
 		return
 	}
 	res := Type.FullWordCardType{
@@ -1074,35 +1298,41 @@ func handleGetWords(w http.ResponseWriter, r *http.Request) {
 		Title: wordSet.Title,
 		Words: wordSet.Words,
 		ShouldSwap: wordSet.ShouldSwap,
+		Template: effectiveCardTemplate(r.Context(), wordSet),
 	}
 	err = writeDataJson(w, res)
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "未知錯誤 請重試"})
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
 // 處理editWordSet所傳來的變更
-func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func UpdateWordSet(ctx context.Context, request Type.EditWordSetRequest) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		return "", fmt.Errorf("failed to start session: %w", err)
+		return "", Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to start session: %w", err))
 	}
 	defer session.EndSession(ctx)
 
+	var existingWordSet Type.WordSet
 	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
 		if err := session.StartTransaction(); err != nil {
-			return fmt.Errorf("failed to start transaction: %w", err)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to start transaction: %w", err))
 		}
 
 		wordSetColl := DB.Client.Database("go-quizlet").Collection("wordSets")
 		filter := bson.M{"id": request.WordSet.ID}
-		var existingWordSet Type.WordSet
-		err = wordSetColl.FindOne(sc, filter).Decode(&existingWordSet)
+		// 先用version搶下這次編輯，其他人同時的編輯會對不上version
+		claimUpdate := bson.M{"$inc": bson.M{"version": 1}}
+		err = wordSetColl.FindOneAndUpdate(sc, versionFilter(bson.M{"id": request.WordSet.ID}, *request.Version), claimUpdate).Decode(&existingWordSet)
 		if err != nil {
 			session.AbortTransaction(sc)
-			return fmt.Errorf("failed to find existing wordSet: %w", err)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return versionConflictOr(ctx, request.WordSet.ID, *request.Version, Type.NotFound("查無此單字集"))
+			}
+			return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to find existing wordSet: %w", err))
 		}
 
 		// ----------------------------
@@ -1114,14 +1344,14 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 			// 檢查單字
 			if len(v) == 0 || len(v) > Consts.MaxVocabularyLen {
 				session.AbortTransaction(sc)
-				return fmt.Errorf("單字字數不得為0或超過100")
+				return Type.BadRequest("單字字數不得為0或超過100")
 			}
 			// 檢查註釋
 			d := strings.TrimSpace(request.AddWords[i].Definition)
 			request.AddWords[i].Definition = d
-			if len(d) == 0 || len(d) > Consts.MaxDefinitionLen {
+			if !validDefinition(d) {
 				session.AbortTransaction(sc)
-				return fmt.Errorf("註釋字數不得為0或超過300")
+				return Type.BadRequest("註釋字數不得為0或超過300")
 			}
 			// 檢查單字聲音格式
 			if err := utils.IsValidSound(request.AddWords[i].VocabularySound); err != nil {
@@ -1133,14 +1363,26 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 				session.AbortTransaction(sc)
 				return err
 			}
+			// 檢查音標、詞性、意思以及例句
+			if err := validateWordDetails(request.AddWords[i].Pronunciation, request.AddWords[i].PartOfSpeech, request.AddWords[i].Meanings, request.AddWords[i].Examples); err != nil {
+				session.AbortTransaction(sc)
+				return err
+			}
+			// 檢查卡片模板的自訂欄位
+			if err := validateWordFields(existingWordSet.Template, request.AddWords[i].Fields); err != nil {
+				session.AbortTransaction(sc)
+				return err
+			}
+			request.AddWords[i].Fields = mergeWordFields(nil, request.AddWords[i].Fields)
 		}
+		stripWordMedia(request.AddWords)
 		if len(request.AddWords) > 0 {
 			addUpdate := bson.M{
 				"$push": bson.M{"words": bson.M{"$each": request.AddWords}},
 			}
 			if _, err := wordSetColl.UpdateOne(sc, filter, addUpdate); err != nil {
 				session.AbortTransaction(sc)
-				return fmt.Errorf("failed to update added words: %w", err)
+				return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to update added words: %w", err))
 			}
 		}
 
@@ -1154,19 +1396,20 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 			if title != "" {
 				if len(title) > Consts.MaxTitleLen {
 					session.AbortTransaction(sc)
-					return errors.New("標題字數不得為0或超過50字元")
+					return Type.BadRequest("標題字數不得為0或超過50字元")
 				}
 				setFields["title"] = title
 			}
 		}
 		if description, ok := request.WordSet.Description.(string); ok {
-			if len(description) > Consts.MaxDescriptionLen {
+			if !markdownLenOK(description, Consts.MaxDescriptionLen) {
 				session.AbortTransaction(sc)
-				return errors.New("敘述字數不得超過150字元")
+				return Type.BadRequest("敘述字數不得超過150字元")
 			}
 			setFields["description"] = description
 		}
 		setFields["updatedAt"] = utils.GetNow()
+		setFields["updatedBy"] = userIDFromContext(ctx)
 		setFields["shouldSwap"] = request.WordSet.ShouldSwap
 
 		// Prepare array filters for updating existing words
@@ -1182,14 +1425,26 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 				updated = true
 			}
 			if word.Definition != "" {
+				if !validDefinition(word.Definition) {
+					session.AbortTransaction(sc)
+					return Type.BadRequest("註釋字數不得為0或超過300")
+				}
 				setFields[fmt.Sprintf("words.$[%s].definition", elemIdentifier)] = word.Definition
 				updated = true
 			}
 			if word.VocabularySound != "" {
+				if err := utils.IsValidSound(word.VocabularySound); err != nil {
+					session.AbortTransaction(sc)
+					return err
+				}
 				setFields[fmt.Sprintf("words.$[%s].vocabularySound", elemIdentifier)] = word.VocabularySound
 				updated = true
 			}
 			if word.DefinitionSound != "" {
+				if err := utils.IsValidSound(word.DefinitionSound); err != nil {
+					session.AbortTransaction(sc)
+					return err
+				}
 				setFields[fmt.Sprintf("words.$[%s].definitionSound", elemIdentifier)] = word.DefinitionSound
 				updated = true
 			}
@@ -1197,6 +1452,41 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 				setFields[fmt.Sprintf("words.$[%s].order", elemIdentifier)] = word.Order
 				updated = true
 			}
+			// 音標、詞性、意思以及例句有給才更新，給空的代表清除
+			if err := validateEditWordDetails(word); err != nil {
+				session.AbortTransaction(sc)
+				return err
+			}
+			if word.Pronunciation != nil {
+				setFields[fmt.Sprintf("words.$[%s].pronunciation", elemIdentifier)] = strings.TrimSpace(*word.Pronunciation)
+				updated = true
+			}
+			if word.PartOfSpeech != nil {
+				setFields[fmt.Sprintf("words.$[%s].partOfSpeech", elemIdentifier)] = *word.PartOfSpeech
+				updated = true
+			}
+			if word.Meanings != nil {
+				setFields[fmt.Sprintf("words.$[%s].meanings", elemIdentifier)] = *word.Meanings
+				updated = true
+			}
+			if word.Examples != nil {
+				setFields[fmt.Sprintf("words.$[%s].examples", elemIdentifier)] = *word.Examples
+				updated = true
+			}
+			// 自訂欄位只更新有給的key，跟原本的值合併後整個寫回
+			if word.Fields != nil {
+				if err := validateWordFields(existingWordSet.Template, word.Fields); err != nil {
+					session.AbortTransaction(sc)
+					return err
+				}
+				index := slices.IndexFunc(existingWordSet.Words, func(w Type.Word) bool { return w.ID == word.ID })
+				if index == -1 {
+					session.AbortTransaction(sc)
+					return Type.NotFound("查無此單字或單字集")
+				}
+				setFields[fmt.Sprintf("words.$[%s].fields", elemIdentifier)] = mergeWordFields(existingWordSet.Words[index].Fields, word.Fields)
+				updated = true
+			}
 
 			// ✅ Only add array filter if a field was updated
 			if updated {
@@ -1212,11 +1502,11 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 			res, err := wordSetColl.UpdateOne(sc, filter, editUpdate, updateOpts)
 			if err != nil {
 				session.AbortTransaction(sc)
-				return fmt.Errorf("failed to update edited fields: %w", err)
+				return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to update edited fields: %w", err))
 			}
 			if res.MatchedCount == 0 {
 				session.AbortTransaction(sc)
-				return errors.New("no matched wordSet for editing")
+				return Type.NotFound("查無此單字集")
 			}
 		}
 
@@ -1230,158 +1520,173 @@ func UpdateWordSet(request Type.EditWordSetRequest) (string, error) {
 			}
 			if _, err := wordSetColl.UpdateOne(sc, filter, removeUpdate); err != nil {
 				session.AbortTransaction(sc)
-				return fmt.Errorf("failed to update removed words: %w", err)
+				return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to update removed words: %w", err))
+			}
+		}
+
+		// ----------------------------
+		// Step 3.5: 檢查重複單字(新增的以及改過單字的)
+		// ----------------------------
+		changed := map[string]bool{}
+		for _, word := range request.AddWords {
+			changed[word.ID] = true
+		}
+		for _, word := range request.WordSet.Words {
+			if word.Vocabulary != "" {
+				changed[word.ID] = true
+			}
+		}
+		if len(changed) > 0 {
+			var current Type.WordSet
+			if err := wordSetColl.FindOne(sc, filter).Decode(&current); err != nil {
+				session.AbortTransaction(sc)
+				return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to find edited wordSet: %w", err))
+			}
+			if err := checkDuplicateWords(ctx, current.DuplicatePolicy, current.Words, changed); err != nil {
+				session.AbortTransaction(sc)
+				return err
 			}
 		}
 
 		// ----------------------------
 		// Step 4: Update wordCnt
 		// ----------------------------
-		newWordCnt := len(existingWordSet.Words) + len(request.AddWords) - len(request.RemoveWords)
-		wordCntUpdate := bson.M{"$set": bson.M{"wordCnt": newWordCnt}}
+		// 用DB裡實際的words長度，不依賴先前讀到的資料(removeWords可能包含不存在的id)
+		if _, err := wordSetColl.UpdateOne(sc, filter, syncWordCntUpdate); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to update word count: %w", err))
+		}
 
-		if _, err := wordSetColl.UpdateOne(sc, filter, wordCntUpdate); err != nil {
+		// ----------------------------
+		// Step 5: 記錄版本
+		// ----------------------------
+		if err := recordWordSetRevision(sc, request.WordSet.ID, &existingWordSet, Consts.RevisionActionEdit, 0); err != nil {
 			session.AbortTransaction(sc)
-			return fmt.Errorf("failed to update word count: %w", err)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(fmt.Errorf("failed to record revision: %w", err))
 		}
 
-		return session.CommitTransaction(sc)
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to update word set: %w", err)
+		return "", err
 	}
+
+	reportWordSetVersion(ctx, *request.Version+1)
+	title := existingWordSet.Title
+	if newTitle, ok := request.WordSet.Title.(string); ok {
+		title = newTitle
+	}
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: existingWordSet.ID, Title: title, AuthorID: existingWordSet.AuthorID, ActorID: userIDFromContext(ctx),
+	})
 	return "", nil
 }
 
 // 處裡刪除wordSet
-func deleteWordSet(request Type.DeleteWordSetRequest) (string, error) {
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	// Correct filter
-	filter := bson.M{"id": request.WordSetID}
-	deletingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	res, err := coll.DeleteOne(deletingContext, filter)
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤")
-		}
-		log.Println("deleteWordSet error", err.Error())
-		return "", fmt.Errorf("伺服器出錯 請重試")
-	}
-	if res.DeletedCount == 0 {
-		return "", errors.New("查無此單字集")
+func deleteWordSet(ctx context.Context, request Type.DeleteWordSetRequest) (string, error) {
+	// 先移到垃圾桶，保留期限過後才會被永久刪除
+	if err := moveWordSetToTrash(ctx, request.WordSetID); err != nil {
+		return "", err
 	}
 	return "", nil
 }
 
 // 處理新增wordSet中的一個word
-func addWord(request Type.AddWordRequest) (string, error) {
+func addWord(ctx context.Context, request Type.AddWordRequest) (string, error) {
+	if err := validateWord(request.Word); err != nil {
+		return "", err
+	}
 	request.Word.ID = utils.GenerateID()
+	before, err := getWordSetByID(request.WordSetID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkWordSetVersion(before, *request.Version); err != nil {
+		return "", err
+	}
+	if err := validateWordFields(before.Template, request.Word.Fields); err != nil {
+		return "", err
+	}
+	request.Word.Fields = mergeWordFields(nil, request.Word.Fields)
+	stripWordMedia([]Type.Word{request.Word})
+	if err := checkDuplicateWords(ctx, before.DuplicatePolicy, append(slices.Clone(before.Words), request.Word), map[string]bool{request.Word.ID: true}); err != nil {
+		return "", err
+	}
 
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	filter := bson.M{"id":request.WordSetID}
 	update := bson.M{
 		"$push": bson.M{"words": request.Word},
+		"$set": bson.M{"updatedBy": userIDFromContext(ctx)},
 		"$inc": bson.M{"wordCnt": 1},
 	}
-	// Context and timeout for the update operation
-	updateContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	res, err := coll.UpdateOne(updateContext, filter, update)
+	after, err := updateWordSetWithRevision(ctx, before, *request.Version, bson.M{"id": request.WordSetID}, update, Type.NotFound("查無此單字集"))
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤")
-		}
-		log.Println("addWord error", err.Error())
-		return "", fmt.Errorf("伺服器出錯 請重試")
-	}
-
-	if res.ModifiedCount == 0 {
-		return "", errors.New("查無此單字集")
+		return "", err
 	}
-
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
 	return request.Word.ID, nil
 }
 
 // 處裡刪除wordSet中的一個word
-func deleteWord(request Type.DeleteWordRequest) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func deleteWord(ctx context.Context, request Type.DeleteWordRequest) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		return "", errors.New("無法啟動資料庫會話，請重試")
+		return "", Type.Internal("無法啟動資料庫會話，請重試")
 	}
 	defer session.EndSession(ctx)
 
 	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("無法啟動交易 請重試")
+		}
 		coll := DB.Client.Database("go-quizlet").Collection("wordSets")
 		// Correct filter
 		filter := bson.M{"id": request.WordSetID}
 
-		// First, pull the element
+		// First, pull the element，同時用version搶下這次編輯，拿回變更前的資料給版本紀錄用
 		pullUpdate := bson.M{
 			"$pull": bson.M{"words": bson.M{"id": request.WordID}},
+			"$set": bson.M{"updatedBy": userIDFromContext(ctx)},
+			"$inc": bson.M{"version": 1},
 		}
-
-
-		// Execute the pull operation
-		res, err := coll.UpdateOne(sc, filter, pullUpdate)
+		var before Type.WordSet
+		err := coll.FindOneAndUpdate(sc, versionFilter(bson.M{"id": request.WordSetID}, *request.Version), pullUpdate).Decode(&before)
 		if err != nil {
+			session.AbortTransaction(sc)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return versionConflictOr(ctx, request.WordSetID, *request.Version, Type.NotFound("查無此單字集"))
+			}
 			if errors.Is(err, context.DeadlineExceeded) {
-				session.AbortTransaction(sc)
-				return errors.New("超時錯誤")
+				return Type.Timeout("超時錯誤")
 			}
-			log.Println("deleteWord error in deleting", err.Error())
-			session.AbortTransaction(sc)
-			return errors.New("伺服器錯誤 請重試")
+			loggerFromContext(ctx).Error("deleteWord error in deleting", "wordSetID", request.WordSetID, "error", err)
+			return Type.Internal("伺服器錯誤 請重試")
 		}
 
-		// 就算沒有對應的wordID 他一樣不會變0，所以如果是0就代表是沒有對應的wordSetID
-		if res.MatchedCount == 0 {
+		// 用DB裡實際的words長度更新wordCnt
+		if _, err := coll.UpdateOne(sc, filter, syncWordCntUpdate); err != nil {
 			session.AbortTransaction(sc)
-			return errors.New("查無此單字集")
-		}
-
-		// Then fetch the document to get the words array length
-		var doc bson.M
-		err = coll.FindOne(sc, filter).Decode(&doc)
-		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				session.AbortTransaction(sc)
-				return errors.New("超時錯誤")
+				return Type.Timeout("超時錯誤")
 			}
-			log.Println("deleteWord error in querying Words array", err.Error())
-			session.AbortTransaction(sc)
-			return errors.New("伺服器錯誤 請重試")
+			loggerFromContext(ctx).Error("deleteWord error in updating wordCnt", "wordSetID", request.WordSetID, "error", err)
+			return Type.Internal("伺服器錯誤 請重試")
 		}
 
-		// Calculate the new count
-		words, ok := doc["words"].(primitive.A)
-		wordCount := 0
-		if ok {
-			wordCount = len(words)
-		}
-
-		// Update the count
-		countUpdate := bson.M{
-			"$set": bson.M{"wordCnt": wordCount},
-		}
-		_, err = coll.UpdateOne(sc, filter, countUpdate)
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
-				session.AbortTransaction(sc)
-				return errors.New("超時錯誤")
-			}
-			log.Println("deleteWord error in updating wordCnt", err.Error())
+		if err := recordWordSetRevision(sc, request.WordSetID, &before, Consts.RevisionActionEdit, 0); err != nil {
+			loggerFromContext(ctx).Error("deleteWord error in recording revision", "wordSetID", request.WordSetID, "error", err)
 			session.AbortTransaction(sc)
-			return errors.New("伺服器錯誤 請重試")
+			return Type.Internal("伺服器錯誤 請重試")
 		}
 
 		// Commit transaction
 		if err := session.CommitTransaction(sc); err != nil {
-			fmt.Println(err)
-			return errors.New("交易提交失敗 請重試")
+			loggerFromContext(ctx).Error("deleteWord commit failed", "wordSetID", request.WordSetID, "error", err)
+			return Type.Internal("交易提交失敗 請重試")
 		}
 
 		return nil
@@ -1391,124 +1696,75 @@ func deleteWord(request Type.DeleteWordRequest) (string, error) {
 		return "", err
 	}
 
+	reportWordSetVersion(ctx, *request.Version+1)
 	return "", nil
 }
 
 // 處理toggle word中的star
-func toggleWordStar(request Type.ToggleWordStarRequest) (string, error) {
-	wordSet, err := getWordSetByID(request.WordSetID)
+func toggleWordStar(ctx context.Context, request Type.ToggleWordStarRequest) (string, error) {
+	wordSet, err := getReadableWordSet(ctx, request.WordSetID, request.UserID, request.ShareToken)
 	if err != nil {
 		return "", err
 	}
-	var newStar bool 
-	for _, word := range wordSet.Words {
-		if word.ID == request.WordID {
-			newStar = !word.Star
-		}
-	} 
-
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	updateContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	filter := bson.M{"id": request.WordSetID, "words.id": request.WordID}
-	update := bson.M{
-		"$set": bson.M{
-			"words.$.star": newStar,
-		},
-	}
-	res, err := coll.UpdateOne(updateContext, filter, update)
+	starred, err := loadStarredWords(ctx, request.UserID, wordSet)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤")
-		}
-		log.Println("toggleWordStar error", err.Error())
-		return "", fmt.Errorf("伺服器錯誤 請重試")
-	}
-
-	if res.ModifiedCount == 0 {
-		return "", errors.New("查無此單字或單字集")
+		return "", err
 	}
-
-	return "", nil
+	return "", setWordStars(ctx, request.UserID, wordSet, []string{request.WordID}, !starred[request.WordID])
 }
 
-func toggleAllWordStar(request Type.ToggleAllWordStarRequest) (string, error) {
-	wordSetColl := DB.Client.Database("go-quizlet").Collection("wordSets")
-	filter := bson.M{"id": request.WordSetID}
-	update := bson.M{
-		"$set": bson.M{
-			"words.$[].star": request.NewStar,
-		},
-	}
-
-	writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	res, err := wordSetColl.UpdateMany(writingContext, filter, update)
+// 所有單字一起加/取消星號
+func toggleAllWordStar(ctx context.Context, request Type.ToggleAllWordStarRequest) (string, error) {
+	wordSet, err := getReadableWordSet(ctx, request.WordSetID, request.UserID, request.ShareToken)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
-		}
 		return "", err
 	}
-
-	if res.MatchedCount == 0 {
-		return "", errors.New("找不到單字集或單字")
-	}
-
-	log.Println("Successfully toggled star for all words!")
-	return "", nil
+	return "", setWordStars(ctx, request.UserID, wordSet, wordIDsOf(wordSet.Words), request.NewStar)
 }
 
 // 處理inline/bigWordCard編輯單字和註釋
-func inlineUpdateWord(request Type.InlineUpdateWordRequest) (string, error) {
+func inlineUpdateWord(ctx context.Context, request Type.InlineUpdateWordRequest) (string, error) {
 	if request.NewVocabulary == "" || len(request.NewVocabulary) > Consts.MaxVocabularyLen {
-		return "", errors.New("單字長度不得為空且不得超過100字元")
+		return "", Type.BadRequest("單字長度不得為空且不得超過100字元")
 	}
-	if request.NewDefinition == "" || len(request.NewDefinition) > Consts.MaxDefinitionLen {
-		return "", errors.New("註釋長度不得為空且不得超過300字元")
+	if !validDefinition(request.NewDefinition) {
+		return "", Type.BadRequest("註釋長度不得為空且不得超過300字元")
+	}
+	before, err := getWordSetByID(request.WordSetID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkWordSetVersion(before, *request.Version); err != nil {
+		return "", err
+	}
+	if err := checkEditedVocabulary(ctx, before, request.WordID, request.NewVocabulary); err != nil {
+		return "", err
 	}
-    collection := DB.Client.Database("go-quizlet").Collection("wordSets")
-    
-    // Now perform the update
-    updateFilter := bson.M{
-        "id": request.WordSetID,
-        "words.id": request.WordID,
-    }
-    
     update := bson.M{
         "$set": bson.M{
             "words.$.vocabulary": request.NewVocabulary,
             "words.$.definition": request.NewDefinition,
+            "updatedBy": userIDFromContext(ctx),
         },
     }
     
-    writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    
-    res, err := collection.UpdateOne(writingContext, updateFilter, update)
+    after, err := updateWordSetWithRevision(ctx, before, *request.Version, bson.M{"id": request.WordSetID, "words.id": request.WordID}, update, Type.NotFound("查無此單字或單字集"))
     if err != nil {
-		log.Println("inlineUpdateWord error", err.Error())
-        return "", fmt.Errorf("伺服器錯誤 請重試")
-    }
-    
-    if res.MatchedCount == 0 {
-        return "", fmt.Errorf("查無此單字或單字集")
+        return "", err
     }
 
-	if res.ModifiedCount == 0 {
-		fmt.Println("no update made, same word!")
-	}
+	// version一定會+1，內容沒變的話不會產生新的版本紀錄
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
     
     return "", nil
 }
 // bigWordCard編輯單字和註釋
-func bigWordCardUpdateWord(request Type.BigWordCardUpdateWordRequest) (string, error) {
+func bigWordCardUpdateWord(ctx context.Context, request Type.BigWordCardUpdateWordRequest) (string, error) {
 	if request.NewVocabulary == "" || len(request.NewVocabulary) > Consts.MaxVocabularyLen {
-		return "", errors.New("單字長度不得為空且不得超過100字元")
+		return "", Type.BadRequest("單字長度不得為空且不得超過100字元")
 	}
-	if request.NewDefinition == "" || len(request.NewDefinition) > Consts.MaxDefinitionLen {
-		return "", errors.New("註釋長度不得為空且不得超過300字元")
+	if !validDefinition(request.NewDefinition) {
+		return "", Type.BadRequest("註釋長度不得為空且不得超過300字元")
 	}
 	if err := utils.IsValidSound(request.NewVocabularySound); err != nil {
 		return "", err
@@ -1516,56 +1772,77 @@ func bigWordCardUpdateWord(request Type.BigWordCardUpdateWordRequest) (string, e
 	if err := utils.IsValidSound(request.NewDefinitionSound); err != nil {
 		return "", err
 	}
-    collection := DB.Client.Database("go-quizlet").Collection("wordSets")
-    
-    // Now perform the update
-    updateFilter := bson.M{
-        "id": request.WordSetID,
-        "words.id": request.WordID,
+	details := Type.EditWord{Pronunciation: request.NewPronunciation, PartOfSpeech: request.NewPartOfSpeech, Meanings: request.NewMeanings, Examples: request.NewExamples}
+	if err := validateEditWordDetails(details); err != nil {
+		return "", err
+	}
+	before, err := getWordSetByID(request.WordSetID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkWordSetVersion(before, *request.Version); err != nil {
+		return "", err
+	}
+	if err := checkEditedVocabulary(ctx, before, request.WordID, request.NewVocabulary); err != nil {
+		return "", err
+	}
+	if err := validateWordFields(before.Template, request.NewFields); err != nil {
+		return "", err
+	}
+    setFields := bson.M{
+        "words.$.vocabulary": request.NewVocabulary,
+        "words.$.definition": request.NewDefinition,
+        "words.$.vocabularySound": request.NewVocabularySound,
+        "words.$.definitionSound": request.NewDefinitionSound,
+        "updatedBy": userIDFromContext(ctx),
+    }
+    if details.Pronunciation != nil {
+        setFields["words.$.pronunciation"] = strings.TrimSpace(*details.Pronunciation)
+    }
+    if details.PartOfSpeech != nil {
+        setFields["words.$.partOfSpeech"] = *details.PartOfSpeech
+    }
+    if details.Meanings != nil {
+        setFields["words.$.meanings"] = *details.Meanings
+    }
+    if details.Examples != nil {
+        setFields["words.$.examples"] = *details.Examples
+    }
+    if request.NewFields != nil {
+        index := slices.IndexFunc(before.Words, func(word Type.Word) bool { return word.ID == request.WordID })
+        if index == -1 {
+            return "", Type.NotFound("查無此單字或單字集")
+        }
+        setFields["words.$.fields"] = mergeWordFields(before.Words[index].Fields, request.NewFields)
     }
-    
     update := bson.M{
-        "$set": bson.M{
-            "words.$.vocabulary": request.NewVocabulary,
-            "words.$.definition": request.NewDefinition,
-            "words.$.vocabularySound": request.NewVocabularySound,
-            "words.$.definitionSound": request.NewDefinitionSound,
-        },
+        "$set": setFields,
     }
     
-    writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    
-    res, err := collection.UpdateOne(writingContext, updateFilter, update)
+    after, err := updateWordSetWithRevision(ctx, before, *request.Version, bson.M{"id": request.WordSetID, "words.id": request.WordID}, update, Type.NotFound("查無此單字或單字集"))
     if err != nil {
-		log.Println("inlineUpdateWord error", err.Error())
-        return "", fmt.Errorf("伺服器錯誤 請重試")
-    }
-    
-    if res.MatchedCount == 0 {
-        return "", fmt.Errorf("查無此單字或單字集")
+        return "", err
     }
 
-	if res.ModifiedCount == 0 {
-		fmt.Println("no update made, same word!")
-	}
+	// version一定會+1，內容沒變的話不會產生新的版本紀錄
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
     
     return "", nil
 }
 
 // 處理儲存wordSet(給wordSet加星號)
-func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
+func toggleLikeWordSet(ctx context.Context, request Type.ToggleLikeWordSetRequest) (string, error) {
 	// 先比對是否使用者為wordSet Author
-	wordSet, err := getWordSetByID(request.WordSetID)
+	wordSet, err := getReadableWordSet(ctx, request.WordSetID, request.UserID, request.ShareToken)
 	if err != nil {
 		return "", err
 	}
 	if wordSet.AuthorID == request.UserID {
-		return "", errors.New("操作錯誤 你為此單字集作者")
+		return "", Type.BadRequest("操作錯誤 你為此單字集作者")
 	}
 
 	// Create a single timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Get user & author
@@ -1574,7 +1851,7 @@ func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
 
 	cursor, err := userColl.Find(ctx, filter)
 	if err != nil {
-		return "", errors.New("未知錯誤 請重試")
+		return "", Type.Internal("未知錯誤 請重試")
 	}
 	defer cursor.Close(ctx)
 
@@ -1582,10 +1859,10 @@ func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
 	var users []Type.User
 	err = cursor.All(ctx, &users)
 	if err != nil {
-		return "", errors.New("未知錯誤 請重試")
+		return "", Type.Internal("未知錯誤 請重試")
 	}
 	if len(users) < 2 {
-		return "", errors.New("未找到所有用戶")
+		return "", Type.NotFound("未找到所有用戶")
 	}
 
 	// Fix: Correctly map user IDs
@@ -1598,14 +1875,14 @@ func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
 	// Start a transaction session
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		return "", errors.New("無法啟動資料庫會話，請重試")
+		return "", Type.Internal("無法啟動資料庫會話，請重試")
 	}
 	defer session.EndSession(ctx)
 
 	// Execute transaction
 	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
 		if err := session.StartTransaction(); err != nil {
-			return errors.New("無法啟動交易 請重試")
+			return Type.Internal("無法啟動交易 請重試")
 		}
 
 		var userUpdates []mongo.WriteModel
@@ -1657,31 +1934,31 @@ func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
 		res, err := userColl.BulkWrite(sc, userUpdates, options.BulkWrite().SetOrdered(false))
 		if err != nil {
 			session.AbortTransaction(sc)
-			return errors.New("資料寫入錯誤 請重試")
+			return Type.Internal("資料寫入錯誤 請重試")
 		}
 		if res.MatchedCount == 0 {
 			session.AbortTransaction(sc)
-			return errors.New("查無使用者")
+			return Type.NotFound("查無使用者")
 		}
 
 		// Update word set
 		wordSetRes, err := wordSetColl.BulkWrite(sc, wordSetUpdates, options.BulkWrite().SetOrdered(false))
 		if err != nil {
 			session.AbortTransaction(sc)
-			return errors.New("資料寫入錯誤 請重試")
+			return Type.Internal("資料寫入錯誤 請重試")
 		}
 		if wordSetRes.MatchedCount == 0 {
 			session.AbortTransaction(sc)
-			return errors.New("查無單字集")
+			return Type.NotFound("查無單字集")
 		}
 
 		// Commit transaction
 		if err := session.CommitTransaction(sc); err != nil {
-			fmt.Println(err)
-			return errors.New("交易提交失敗 請重試")
+			loggerFromContext(ctx).Error("toggleLikeWordSet commit failed", "wordSetID", request.WordSetID, "error", err)
+			return Type.Internal("交易提交失敗 請重試")
 		}
 
-		log.Println("toggle wordSet star succeeds!")
+		loggerFromContext(ctx).Info("toggle wordSet like succeeds", "wordSetID", request.WordSetID)
 		return nil
 	})
 
@@ -1689,16 +1966,27 @@ func toggleLikeWordSet(request Type.ToggleLikeWordSetRequest) (string, error) {
 		return "", err
 	}
 
+	event := Consts.WebhookEventWordSetLiked
+	if slices.Contains(user.LikedWordSets, request.WordSetID) {
+		event = Consts.WebhookEventWordSetUnliked
+	}
+	emitWordSetEvent(ctx, event, Type.WebhookWordSetData{
+		WordSetID: wordSet.ID, Title: wordSet.Title, AuthorID: wordSet.AuthorID, ActorID: request.UserID,
+	})
+
 	return "", nil
 }
 
 
-func ForkWordSet(request Type.ForkWordSetRequest) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func ForkWordSet(ctx context.Context, request Type.ForkWordSetRequest) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+	newWordSetID := utils.GenerateID()
+	var forkedWordSet *Type.WordSet
+	var sourceAuthorID string
 	session, err := DB.Client.StartSession()
 	if err != nil {
-		return "", errors.New("資料庫錯誤 請重試")
+		return "", Type.Internal("資料庫錯誤 請重試").Wrap(err)
 	}
 	defer session.EndSession(ctx)
 
@@ -1706,18 +1994,19 @@ func ForkWordSet(request Type.ForkWordSetRequest) (string, error) {
 	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
 		// Start transaction
 		if err := session.StartTransaction(); err != nil {
-			return errors.New("資料庫錯誤 請重試")
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
 		}
 
-		wordSet, err := getWordSetByID(request.WordSetID)
+		wordSet, err := getReadableWordSet(ctx, request.WordSetID, request.UserID, request.ShareToken)
 		if err != nil {
 			session.AbortTransaction(sc)
 			return err
 		}
+		sourceAuthorID = wordSet.AuthorID
 
 		if wordSet.AllowCopy == false {
 			session.AbortTransaction(sc)
-			return errors.New("此單字集拒絕複製")
+			return Type.Forbidden("此單字集拒絕複製")
 		}
 
 		// 確認是否是作者本人，否就給原作者credit
@@ -1730,40 +2019,68 @@ func ForkWordSet(request Type.ForkWordSetRequest) (string, error) {
 			}
 	
 			filter := bson.M{"id": author.ID}
-			update := bson.M{"$inc": bson.M{"forkCnt": 1}}
+			update := bson.M{"$inc": bson.M{"forkedCnt": 1}}
 	
 			res, err := userColl.UpdateOne(sc, filter, update)
 			if err != nil {
 				session.AbortTransaction(sc)
-				return errors.New("寫入錯誤 請重試")
+				return Type.Internal("寫入錯誤 請重試").Wrap(err)
 			}
 			if res.MatchedCount == 0 {
 				session.AbortTransaction(sc)
-				return errors.New("找不到使用者 請重試")
+				return Type.NotFound("找不到使用者 請重試")
 			}
 		}
 
 		// 重新產生一個wordSet 並替換createdAt, updatedAt以及AuthorID和LikeUsers和Likes
-		var newWordSet Type.WordSet
-		data, _ := json.Marshal(wordSet)     // Convert to JSON
-		json.Unmarshal(data, &newWordSet)    // Convert back to struct
-		
+		// 直接複製struct，圖片跟音檔的key才會留著(JSON不會帶key)，複製出來的單字集共用同一份檔案
+		newWordSet := *wordSet
+		newWordSet.Words = cloneWords(wordSet.Words)
+
 		// Overwrite fields
-		newWordSetID := utils.GenerateID()
 		newWordSet.ID = newWordSetID
 		newWordSet.AuthorID = request.UserID
 		newWordSet.CreatedAt = utils.GetTodayFormatted()
 		newWordSet.UpdatedAt = utils.GetNow()
 		newWordSet.LikedUsers = []string{}
 		newWordSet.Likes = 0
+		newWordSet.Version = 0
+		newWordSet.UpdatedBy = ""
+		newWordSet.Collaborators = nil // 協作者不會跟著複製
+		newWordSet.RevisionCnt = 0
+		newWordSet.DeletedAt, newWordSet.PurgeAt = 0, 0
+		// 星號換成複製的人自己在來源加的星號
+		starred, err := loadStarredWords(ctx, request.UserID, wordSet)
+		if err != nil {
+			session.AbortTransaction(sc)
+			return err
+		}
+		for i := range newWordSet.Words {
+			newWordSet.Words[i].Star = starred[newWordSet.Words[i].ID]
+		}
+		// 記錄來源，之後可以從來源同步變更
+		newWordSet.ForkedFrom = &Type.ForkSource{
+			WordSetID: wordSet.ID,
+			AuthorID:  wordSet.AuthorID,
+			Title:     wordSet.Title,
+			Version:   wordSet.Version,
+			ForkedAt:  newWordSet.UpdatedAt,
+			SyncedAt:  newWordSet.UpdatedAt,
+			Base:      wordSet.Words,
+		}
 		newWordSet.AllowCopy = true
-		newWordSet.IsPublic = true
+		// 只有public的單字集複製後才是public，避免透過分享連結複製後公開出去
+		newWordSet.Visibility = Consts.VisibilityPrivate
+		if wordSetVisibility(wordSet) == Consts.VisibilityPublic {
+			newWordSet.Visibility = Consts.VisibilityPublic
+		}
+		newWordSet.IsPublic = newWordSet.Visibility == Consts.VisibilityPublic
 
 		wordSetColl := DB.Client.Database("go-quizlet").Collection("wordSets")
 		_, err = wordSetColl.InsertOne(sc, newWordSet)
 		if err != nil {
 			session.AbortTransaction(sc)
-			return errors.New("寫入錯誤 請重試")
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
 		}
 
 		// wordSetID 寫入到 user 的 CreatedWordSets 陣列
@@ -1774,21 +2091,27 @@ func ForkWordSet(request Type.ForkWordSetRequest) (string, error) {
 		if err != nil {
 			session.AbortTransaction(sc) // 交易失敗時回滾
 			if errors.Is(err, context.DeadlineExceeded) {
-				return errors.New("超時錯誤 請重試")
+				return Type.Timeout("超時錯誤 請重試")
 			}
-			return err
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
 		}
 		if res.MatchedCount == 0 {
 			session.AbortTransaction(sc)
-			return errors.New("查無使用者")
+			return Type.NotFound("查無使用者")
+		}
+
+		if err := recordWordSetRevision(sc, newWordSetID, nil, Consts.RevisionActionFork, 0); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
 		}
 
 		// Commit transaction if all operations succeed
 		if err := session.CommitTransaction(sc); err != nil {
-			return errors.New("交易提交失敗 請重試")
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
 		}
 
-		log.Println("fork succeeds!")
+		loggerFromContext(ctx).Info("fork succeeds", "sourceWordSetID", request.WordSetID, "wordSetID", newWordSetID)
+		forkedWordSet = &newWordSet
 		return nil
 	})
 
@@ -1796,75 +2119,84 @@ func ForkWordSet(request Type.ForkWordSetRequest) (string, error) {
 		return "", err
 	}
 
-	return "", nil
+	// 原作者跟複製的人都會收到
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetForked, Type.WebhookWordSetData{
+		WordSetID: newWordSetID, Title: forkedWordSet.Title, AuthorID: request.UserID, ActorID: request.UserID,
+		SourceWordSetID: request.WordSetID,
+	}, sourceAuthorID)
+
+	return newWordSetID, nil
 }
 
 
 // 處理Lib Page
 func getWordSetsInLib(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("userID")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	response, err := getLibPage(r.Context(), r.PathValue("userID"), requestUserID(r))
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	writeDataJson(w, response)
+}
+
+// 查詢某個使用者的自創以及收藏的wordSet，只列出viewerID(未登入為空字串)看得到的
+func getLibPage(ctx context.Context, userID string, viewerID string) (*Type.LibPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	
 	// Step 1: Find user
 	user, err := getUserByID(userID)
 	if err != nil {
-		writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusNotFound, Message: err.Error()})
-		return
+		return nil, err
 	}
 	
 	// Step 2: Find created word sets
-	wordSetsColl := DB.Client.Database("go-quizlet").Collection("wordSets")
-	created := []Type.WordSet{}
-	if len(user.CreatedWordSets) > 0 {
-		createdFilter := bson.M{"id": bson.M{"$in": user.CreatedWordSets}}
-		cursor, err := wordSetsColl.Find(ctx, createdFilter)
-		if err != nil {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "查詢錯誤"})
-			return
-		}
-		defer cursor.Close(ctx)
-
-		if err := cursor.All(ctx, &created); err != nil {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "解碼錯誤"})
-			return
-		}
+	createdWordSets, err := findLibWordSets(ctx, user.CreatedWordSets, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert to display format
-	createdWordSets := make([]Type.LibWordSetDisplay, len(created))
-	for i, wordSet := range created {
-		createdWordSets[i] = Type.LibWordSetDisplay{
-			ID:        wordSet.ID,
-			Title:     wordSet.Title,
-			AuthorID:  wordSet.AuthorID,
-			CreatedAt: wordSet.CreatedAt,
-			UpdatedAt: wordSet.UpdatedAt,
-			WordCnt:   wordSet.WordCnt,
-		}
+	// Step 3: Find liked word sets
+	likedWordSets, err := findLibWordSets(ctx, user.LikedWordSets, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Step 3: Find liked word sets
-	liked := []Type.WordSet{}
-	if len(user.LikedWordSets) > 0 {
-		likedFilter := bson.M{"id": bson.M{"$in": user.LikedWordSets}}
-		cursor, err := wordSetsColl.Find(ctx, likedFilter)
+	return &Type.LibPage{
+		User: Type.LibUser{
+			ID:userID,
+			Role:user.Role,
+			Name:user.Name,
+			Img:user.Img,
+			CreatedAt: user.CreatedAt,
+			LikeCnt: user.LikedCnt,
+			ForkCnt: user.ForkedCnt,
+		},
+		CreatedWordSets: createdWordSets,
+		LikedWordSets: likedWordSets,
+	}, nil
+}
+
+// 用wordSetIDs查詢wordSet並轉成Lib page顯示的格式
+func findLibWordSets(ctx context.Context, wordSetIDs []string, viewerID string) ([]Type.LibWordSetDisplay, error) {
+	found := []Type.WordSet{}
+	if len(wordSetIDs) > 0 {
+		wordSetsColl := DB.Client.Database("go-quizlet").Collection("wordSets")
+		cursor, err := wordSetsColl.Find(ctx, readableWordSetFilter(liveWordSetFilter(bson.M{"id": bson.M{"$in": wordSetIDs}}), viewerID))
 		if err != nil {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "查詢錯誤"})
-			return
+			return nil, Type.Internal("查詢錯誤").Wrap(err)
 		}
 		defer cursor.Close(ctx)
 
-		if err := cursor.All(ctx, &liked); err != nil {
-			writeErrorJson(w, Type.PageDisplayError{StatusCode: http.StatusInternalServerError, Message: "解碼錯誤"})
-			return
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, Type.Internal("解碼錯誤").Wrap(err)
 		}
 	}
 
 	// Convert to display format
-	likedWordSets := make([]Type.LibWordSetDisplay, len(liked))
-	for i, wordSet := range liked {
-		likedWordSets[i] = Type.LibWordSetDisplay{
+	display := make([]Type.LibWordSetDisplay, len(found))
+	for i, wordSet := range found {
+		display[i] = Type.LibWordSetDisplay{
 			ID:        wordSet.ID,
 			Title:     wordSet.Title,
 			AuthorID:  wordSet.AuthorID,
@@ -1873,22 +2205,7 @@ func getWordSetsInLib(w http.ResponseWriter, r *http.Request) {
 			WordCnt:   wordSet.WordCnt,
 		}
 	}
-
-	response := Type.LibPage{
-		User: Type.LibUser{
-			ID:userID,
-			Role:user.Role,
-			Name:user.Name,
-			Img:user.Img,
-			CreatedAt: user.CreatedAt,
-			LikeCnt: user.LikedCnt,
-			ForkCnt: user.ForkedCnt,
-		},
-		CreatedWordSets: createdWordSets,
-		LikedWordSets: likedWordSets,
-	}
-
-	writeDataJson(w, response)
+	return display, nil
 }
 
 // Change User Image
@@ -1896,20 +2213,20 @@ func changeUserImage(w http.ResponseWriter, r *http.Request) {
 	// 先檢查使用者是否登入以及JWT是否過期了
 	token, err := utils.CheckLogIn(w, r)
 	if err != nil {
-		fmt.Println("User not log in! Exit the post handler")
-		CallToLogInJson(w, Type.MessageDisplayError{Message: "使用者未登入! 或憑證已過期!"})
+		loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
 		return
 	}
 	
 	// 從JWT中取出userID
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+		writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 		return
 	}
 	userID, ok := claims["userID"].(string)
 	if !ok {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "憑證錯誤"})
+		writeErrorJson(w, r, Type.Unauthorized("憑證錯誤"))
 		return
 	}
 	
@@ -1920,21 +2237,21 @@ func changeUserImage(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	err = r.ParseMultipartForm(int64(Consts.MaxUploadSize))
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "檔案超過上限(最多10MB)"})
+		writeErrorJson(w, r, Type.BadRequest("檔案超過上限(最多10MB)"))
 		return
 	}
 
 	// 比對request的userID是否一致
 	id := r.FormValue("userID")
 	if id != userID {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "使用者無權限變更"})
+		writeErrorJson(w, r, Type.Forbidden("使用者無權限變更"))
 		return
 	}
 
 	// Get the file from form data
 	file, _, err := r.FormFile("image")
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "檔案格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("檔案格式錯誤"))
 		return
 	}
 	defer file.Close()
@@ -1942,12 +2259,12 @@ func changeUserImage(w http.ResponseWriter, r *http.Request) {
 	// Upload to Imgur
 	imgurResp, err := utils.UploadToImgur(file, "profile image")
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "圖片上傳錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("圖片上傳錯誤 請重試"))
 		return
 	}
-	fmt.Println(imgurResp)
 	if !imgurResp.Success {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "圖片上傳遭拒 請重試"})
+		loggerFromContext(r.Context()).Warn("imgur rejected upload", "userID", userID, "status", imgurResp.Status)
+		writeErrorJson(w, r, Type.Internal("圖片上傳遭拒 請重試"))
 		return
 	}
 
@@ -1960,34 +2277,34 @@ func changeUserImage(w http.ResponseWriter, r *http.Request) {
 	res, err := coll.UpdateOne(writingContext, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "寫入超時 請重試"})
+			writeErrorJson(w, r, Type.Timeout("寫入超時 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 	if res.MatchedCount == 0 {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查無使用者"})
+		writeErrorJson(w, r, Type.NotFound("查無使用者"))
 		return
 	}
 	writeDataJson(w, imgurResp.Data.Link)
 }
 
 // Change User Name
-func changeUserName(request Type.ChangeUserNameRequest) (string, error) {
+func changeUserName(ctx context.Context, request Type.ChangeUserNameRequest) (string, error) {
 	// 判斷名稱是否合法
 	newName := strings.TrimSpace(request.NewName)
 	if len(newName) == 0 {
-		return "", errors.New("名稱不得為空")
+		return "", Type.BadRequest("名稱不得為空")
 	}
 	if len(newName) > 12 {
-		return "", errors.New("名稱不得超過12個字元")
+		return "", Type.BadRequest("名稱不得超過12個字元")
 	}
 	if newName == Consts.ADMINNAME {
-		return "", errors.New(fmt.Sprintf("使用者名稱不得為%s", Consts.ADMINNAME))
+		return "", Type.BadRequest("使用者名稱不得為%s").WithArgs(Consts.ADMINNAME)
 	}
 	if !utils.IsValidName(newName) {
-		return "", errors.New("使用者名稱只能包含英文、數字、底線")
+		return "", Type.BadRequest("使用者名稱只能包含英文、數字、底線")
 	}
 
 	coll := DB.Client.Database("go-quizlet").Collection("users")
@@ -1998,27 +2315,62 @@ func changeUserName(request Type.ChangeUserNameRequest) (string, error) {
 	res, err := coll.UpdateOne(writingContext, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return "", errors.New("查無使用者")
+			return "", Type.NotFound("查無使用者")
 		}
-		log.Println("changeUserName error", err.Error())
-		return "", errors.New("伺服器錯誤 請重試")
+		loggerFromContext(ctx).Error("changeUserName error", "error", err)
+		return "", Type.Internal("伺服器錯誤 請重試")
 	}
 	if res.ModifiedCount == 0 {
-		log.Println("no update made, the same user name!")
+		loggerFromContext(ctx).Debug("no update made, the same user name")
 	}
 
 	return "", nil
 }
 
+// Change User Locale，空字串代表清除設定，改回依瀏覽器的Accept-Language
+func changeUserLocale(ctx context.Context, request Type.ChangeUserLocaleRequest) (string, error) {
+	var locale i18n.Locale
+	if strings.TrimSpace(request.Locale) != "" {
+		parsed, ok := i18n.Parse(request.Locale)
+		if !ok {
+			return "", Type.BadRequest("語系格式錯誤(zh-TW, en)")
+		}
+		locale = parsed
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("users")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := bson.M{"id":request.UserID}
+	update := bson.M{"$set":bson.M{"locale":string(locale)}}
+	if locale == "" {
+		update = bson.M{"$unset":bson.M{"locale":""}}
+	}
+	res, err := coll.UpdateOne(writingContext, filter, update)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", Type.Timeout("超時錯誤 請重試")
+		}
+		loggerFromContext(ctx).Error("changeUserLocale error", "error", err)
+		return "", Type.Internal("伺服器錯誤 請重試")
+	}
+	if res.MatchedCount == 0 {
+		return "", Type.NotFound("查無使用者")
+	}
+	userLocaleCache.Store(request.UserID, locale)
+
+	return "", nil
+}
+
 // Change User Email
-func changeUserEmail(request Type.ChangeUserEmailRequest) (string, error) {
+func changeUserEmail(ctx context.Context, request Type.ChangeUserEmailRequest) (string, error) {
 	// 判斷email是否合法
 	newEmail := strings.TrimSpace(request.NewEmail)
 	if !utils.IsValidEmail(newEmail) {
-		return "", errors.New("email格式不合法")
+		return "", Type.BadRequest("email格式不合法")
 	}
 
 	// 找出是否有驗證過
@@ -2030,22 +2382,22 @@ func changeUserEmail(request Type.ChangeUserEmailRequest) (string, error) {
 	err := resetAccountColl.FindOne(findingContext, filter).Decode(&record)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return "", errors.New("該電子郵件尚未驗證")
+			return "", Type.BadRequest("該電子郵件尚未驗證")
 		}
-		log.Println("changeUserEmail error 1", err.Error())
-		return "", errors.New("伺服器錯誤 請重試")
+		loggerFromContext(ctx).Error("changeUserEmail error in finding record", "error", err)
+		return "", Type.Internal("伺服器錯誤 請重試")
 	}
 
 	// 檢查驗證碼是否正確
 	if !utils.CheckHashedPassword(request.ValidateCode, record.ValidateCode) {
-		return "", errors.New("驗證碼錯誤")
+		return "", Type.BadRequest("驗證碼錯誤")
 	}
 	// 檢查是否過期
 	if utils.GetNow() > record.Expire {
-		return "", errors.New("驗證碼過期 請重新申請")
+		return "", Type.BadRequest("驗證碼過期 請重新申請")
 	}
 
 	coll := DB.Client.Database("go-quizlet").Collection("users")
@@ -2056,30 +2408,30 @@ func changeUserEmail(request Type.ChangeUserEmailRequest) (string, error) {
 	res, err := coll.UpdateOne(writingContext, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return "", errors.New("查無使用者")
+			return "", Type.NotFound("查無使用者")
 		}
-		log.Println("changeUserEmail error 2", err.Error())
-		return "", errors.New("伺服器錯誤 請重試")
+		loggerFromContext(ctx).Error("changeUserEmail error in updating user", "error", err)
+		return "", Type.Internal("伺服器錯誤 請重試")
 	}
 	if res.ModifiedCount == 0 {
-		log.Println("no update made, the same user email!")
+		loggerFromContext(ctx).Debug("no update made, the same user email")
 	}
 
 	// 刪除修改的請求紀錄
 	result, err := resetAccountColl.DeleteOne(writingContext, bson.M{"email": request.NewEmail})
 	if err != nil {
-		log.Println("error when deleting record from resetAccount:", err)
+		loggerFromContext(ctx).Error("error when deleting record from resetAccount", "error", err)
 	} else if result.DeletedCount == 0 {
-		log.Println("no document found with that email")
+		loggerFromContext(ctx).Warn("no resetAccount record found for the new email")
 	}
 
 	return "", nil
 }
 
-func getMails(userID string) ([]Type.MailViewType, error) {
+func getMails(ctx context.Context, userID string) ([]Type.MailViewType, error) {
 	user, err := getUserByID(userID)	
 	if err != nil {
 		return nil, err
@@ -2093,20 +2445,20 @@ func getMails(userID string) ([]Type.MailViewType, error) {
 	cursor, err := mailColl.Find(findingContext, filter)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, errors.New("超時錯誤 請重試")
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
-		return nil, errors.New("郵件查詢錯誤 請重試")
+		return nil, Type.Internal("郵件查詢錯誤 請重試")
 	}
 	if err := cursor.All(findingContext, &mails); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, errors.New("超時錯誤 請重試")
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
-		return nil, errors.New("轉換錯誤 請重試")
+		return nil, Type.Internal("轉換錯誤 請重試")
 	}
 	return mails, nil
 }
 
-func getUnreadMailsCnt(userID string) (int, error) {
+func getUnreadMailsCnt(ctx context.Context, userID string) (int, error) {
 	user, err := getUserByID(userID)	
 	if err != nil {
 		return 0, err
@@ -2120,15 +2472,15 @@ func getUnreadMailsCnt(userID string) (int, error) {
 	cursor, err := mailColl.Find(findingContext, filter)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return 0, errors.New("超時錯誤 請重試")
+			return 0, Type.Timeout("超時錯誤 請重試")
 		}
-		return 0, errors.New("查詢錯誤 請重試")
+		return 0, Type.Internal("查詢錯誤 請重試")
 	}
 	if err := cursor.All(findingContext, &mails); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return 0, errors.New("超時錯誤 請重試")
+			return 0, Type.Timeout("超時錯誤 請重試")
 		}
-		return 0, errors.New("轉換錯誤 請重試")
+		return 0, Type.Internal("轉換錯誤 請重試")
 	}
 	// 算未讀信件數量
 	var cnt int
@@ -2140,7 +2492,7 @@ func getUnreadMailsCnt(userID string) (int, error) {
 	return cnt, nil
 }
 
-func readMail(request Type.ReadMailRequest) (string, error) {
+func readMail(ctx context.Context, request Type.ReadMailRequest) (string, error) {
 	coll := DB.Client.Database("go-quizlet").Collection("mails")
 	filter := bson.M{"id":request.MailID}
 	update := bson.M{"$set":bson.M{"read":true}}
@@ -2149,18 +2501,18 @@ func readMail(request Type.ReadMailRequest) (string, error) {
 	res, err := coll.UpdateOne(writingContext, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
-		return "", errors.New("資料庫錯誤 請重試")
+		return "", Type.Internal("資料庫錯誤 請重試")
 	}
 	if res.MatchedCount == 0 {
-		return "", errors.New("查無該信件")
+		return "", Type.NotFound("查無該信件")
 	}
 	
 	return "", nil
 }
 
-func addRecentVisit(request Type.AddRecentVisitRequest) (string, error) {
+func addRecentVisit(ctx context.Context, request Type.AddRecentVisitRequest) (string, error) {
 	recentVisit, err := getRecentVisitByID(request.UserID)
 	if err != nil  {
 		return "", err
@@ -2188,37 +2540,48 @@ func addRecentVisit(request Type.AddRecentVisitRequest) (string, error) {
 	_, err = coll.UpdateOne(writingContext, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
-		return "", errors.New("未知錯誤 請重試")
+		return "", Type.Internal("未知錯誤 請重試")
 	}
 	return "", nil
 }
 
 func getRecentVisit(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("userID")
-	recentVisit, err := getRecentVisitByID(userID)
+	record, err := getRecentVisitWordSets(r.Context(), r.PathValue("userID"), requestUserID(r))
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return 
 	}
+	response := Type.RecentVisitResponse{
+		Record: record,
+	}
+	err = writeDataJson(w, response)
+	if err != nil {
+		writeErrorJson(w, r, err)
+	}
+}
+
+// 查詢使用者最近看過的wordSet，依照瀏覽順序排列，只列出viewerID看得到的
+func getRecentVisitWordSets(ctx context.Context, userID string, viewerID string) ([]Type.HomePageWordSet, error) {
+	recentVisit, err := getRecentVisitByID(userID)
+	if err != nil {
+		return nil, err
+	}
 	record := make([]Type.HomePageWordSet, 0, 4)
 	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	filter := bson.M{"id":bson.M{"$in":recentVisit.Record}}
-	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	filter := readableWordSetFilter(liveWordSetFilter(bson.M{"id":bson.M{"$in":recentVisit.Record}}), viewerID)
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	cursor, err := coll.Find(findingContext, filter)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
-			return
+			return nil, Type.Timeout("超時錯誤 請重試")
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "近期查看查詢錯誤 請重試"})
-		return
+		return nil, Type.Internal("近期查看查詢錯誤 請重試").Wrap(err)
 	}
 	if err = cursor.All(findingContext, &record); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "資料轉換錯誤 請重試"})
-		return
+		return nil, Type.Internal("資料轉換錯誤 請重試").Wrap(err)
 	}
 	// 依照recentVisit的record裡的id進行排序
 	// 先創造對應順序的map
@@ -2230,94 +2593,79 @@ func getRecentVisit(w http.ResponseWriter, r *http.Request) {
 	sort.Slice(record, func(i, j int) bool {
 		return indexMap[record[i].ID] < indexMap[record[j].ID]
 	})
-
-	response := Type.RecentVisitResponse{
-		Record: record,
-	}
-	err = writeDataJson(w, response)
-	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
-	}
+	return record, nil
 }
 
 func getNewWordSet(w http.ResponseWriter, r *http.Request) {
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// 找出最新的前6
-	filter := bson.M{"isPublic":true}
-	newOption := options.Find()
-	// 因為comparison rule的順序是重要的，所以用bson.D而不是bson.M
-	newOption.SetSort(bson.D{{Key: "createdAt",Value: -1}, {Key:"updatedAt",Value:-1}}) // 如果createdAt一樣，就比updatedAt
-	newOption.SetLimit(6)
-	cursor, err := coll.Find(findingContext, filter, newOption)
+	newWordSet, err := getHomePageWordSets(r.Context(), "new")
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
-			return
-		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "最新單字集查詢錯誤 請重試"})
-		return
-	}
-	newWordSet := make([]Type.HomePageWordSet, 0, 6)
-	if err = cursor.All(findingContext, &newWordSet); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "轉換錯誤 請重試"})
+		writeErrorJson(w, r, err)
 		return
 	}
-	
 	response := Type.NewWordSetResponse{
 		NewWordSet: newWordSet,
 	}
 	err = writeDataJson(w, response)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
 func getPopularWordSet(w http.ResponseWriter, r *http.Request) {
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// 找出最熱門的(喜歡)前6
-	filter := bson.M{"isPublic":true}
-	popularOption := options.Find()
-	popularOption.SetSort(bson.M{"likes":-1})
-	popularOption.SetLimit(6)
-	cursor, err := coll.Find(findingContext, filter, popularOption)
+	popularWordSet, err := getHomePageWordSets(r.Context(), "popular")
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
-			return
-		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "熱門單字集查詢錯誤 請重試"})
+		writeErrorJson(w, r, err)
 		return
 	}
-	popularWordSet := make([]Type.HomePageWordSet, 0, 6)
-	if err = cursor.All(findingContext, &popularWordSet); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "轉換錯誤 請重試"})
-		return
-	}
-
 	response := Type.PopularWordSetResponse{
 		PopularWordSet: popularWordSet,
 	}
 	err = writeDataJson(w, response)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
+// 首頁的公開wordSet列表，kind為new(最新的前6)或popular(最熱門(喜歡)的前6)
+func getHomePageWordSets(ctx context.Context, kind string) ([]Type.HomePageWordSet, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := publicWordSetFilter(liveWordSetFilter(bson.M{}))
+	findOption := options.Find()
+	failedMessage := "最新單字集查詢錯誤 請重試"
+	if kind == "popular" {
+		findOption.SetSort(bson.M{"likes":-1})
+		failedMessage = "熱門單字集查詢錯誤 請重試"
+	} else {
+		// 因為comparison rule的順序是重要的，所以用bson.D而不是bson.M
+		findOption.SetSort(bson.D{{Key: "createdAt",Value: -1}, {Key:"updatedAt",Value:-1}}) // 如果createdAt一樣，就比updatedAt
+	}
+	findOption.SetLimit(6)
+	cursor, err := coll.Find(findingContext, filter, findOption)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal(failedMessage).Wrap(err)
+	}
+	wordSets := make([]Type.HomePageWordSet, 0, 6)
+	if err = cursor.All(findingContext, &wordSets); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return wordSets, nil
+}
+
 func getFeedback(w http.ResponseWriter, r *http.Request) {
 	curNumber := r.URL.Query().Get("curNumber")
 	if curNumber == "" {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "搜尋值為空"})
+		writeErrorJson(w, r, Type.BadRequest("搜尋值為空"))
 		return
 	}
 	start, err := strconv.Atoi(curNumber)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "搜尋值錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("搜尋值錯誤"))
 		return
 	}
 	// 雖然一次最多拿MaxDataFetch的數量，但要更有效率的察看是否還有多的資料
@@ -2333,14 +2681,14 @@ func getFeedback(w http.ResponseWriter, r *http.Request) {
 	cursor, err := coll.Find(findingContext, bson.M{}, feedbackOption)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤"))
 		return
 	}
 	if err = cursor.All(findingContext, &feedbacks); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "格式轉換錯誤"})
+		writeErrorJson(w, r, Type.Internal("格式轉換錯誤"))
 		return
 	}
 	haveMore := len(feedbacks) > Consts.MaxDataFetch
@@ -2353,23 +2701,23 @@ func getFeedback(w http.ResponseWriter, r *http.Request) {
 	}
 	err = writeDataJson(w, response)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 }
 
-func createFeedback(request Type.CreateFeedbackRequest) (string, error) {
+func createFeedback(ctx context.Context, request Type.CreateFeedbackRequest) (string, error) {
 	if len(request.Title) == 0 {
-		return "", errors.New("回饋建議的標題不得為空")
+		return "", Type.BadRequest("回饋建議的標題不得為空")
 	}
 	if len(request.Title) > Consts.MaxTitleLen {
-		return "", errors.New(fmt.Sprintf("回饋建議的標題不得超過%d個字", Consts.MaxTitleLen))
+		return "", Type.BadRequest("回饋建議的標題不得超過%d個字").WithArgs(Consts.MaxTitleLen)
 	}
 
 	if len(request.Content) == 0 {
-		return "", errors.New("回饋建議的內容不得為空")
+		return "", Type.BadRequest("回饋建議的內容不得為空")
 	}
 	if len(request.Content) > Consts.MaxContentLen {
-		return "", errors.New(fmt.Sprintf("回饋建議的內容不得超過%d個字", Consts.MaxContentLen))
+		return "", Type.BadRequest("回饋建議的內容不得超過%d個字").WithArgs(Consts.MaxContentLen)
 	}
 	
 	// 對feedback加上ID和日期
@@ -2387,15 +2735,15 @@ func createFeedback(request Type.CreateFeedbackRequest) (string, error) {
 	_, err := coll.InsertOne(writingContext, feedback)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-		return "", errors.New("超時錯誤 請重試")
+		return "", Type.Timeout("超時錯誤 請重試")
 		}
-		return "", errors.New("未知錯誤 請重試")
+		return "", Type.Internal("未知錯誤 請重試")
 	}
 
 	return "", nil
 }
 
-func toggleAllowCopy(request Type.ToggleAllowCopyRequest) (string, error) {
+func toggleAllowCopy(ctx context.Context, request Type.ToggleAllowCopyRequest) (string, error) {
 	wordSet, err := getWordSetByID(request.WordSetID)
 	if err != nil {
 		return "", err
@@ -2406,31 +2754,29 @@ func toggleAllowCopy(request Type.ToggleAllowCopyRequest) (string, error) {
 	writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	res, err := coll.UpdateOne(writingContext, filter, update)
+	if err != nil {
+		return "", Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
 	if res.MatchedCount == 0 {
-		return "", errors.New("查無單字集")
+		return "", Type.NotFound("查無單字集")
 	} 
 	
 	return "", nil
 }
-func toggleIsPublic(request Type.ToggleIsPublicRequest) (string, error) {
+// 舊的開關只在public跟private之間切換
+func toggleIsPublic(ctx context.Context, request Type.ToggleIsPublicRequest) (string, error) {
 	wordSet, err := getWordSetByID(request.WordSetID)
 	if err != nil {
 		return "", err
 	}
-	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
-	filter := bson.M{"id":request.WordSetID}
-	update := bson.M{"$set":bson.M{"isPublic":!wordSet.IsPublic}}
-	writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	res, err := coll.UpdateOne(writingContext, filter, update)
-	if res.MatchedCount == 0 {
-		return "", errors.New("查無單字集")
-	} 
-
-	return "", nil
+	visibility := Consts.VisibilityPublic
+	if wordSetVisibility(wordSet) == Consts.VisibilityPublic {
+		visibility = Consts.VisibilityPrivate
+	}
+	return "", updateWordSetVisibility(ctx, request.WordSetID, visibility)
 }
 
-func logError(request Type.LogErrorRequest) (string, error) {
+func logError(ctx context.Context, request Type.LogErrorRequest) (string, error) {
 	coll := DB.Client.Database("go-quizlet").Collection("errors")
 	writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -2442,13 +2788,13 @@ func logError(request Type.LogErrorRequest) (string, error) {
 		ErrorInfo: request.ErrorInfo,
 		Time: request.Time,
 	}
-	fmt.Println("errorID:", errorData)
+	loggerFromContext(ctx).Warn("frontend error reported", "errorID", errorData.ErrorID, "error", errorData.Error)
 	_, err := coll.InsertOne(writingContext, errorData)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return "", errors.New("超時錯誤 請重試")
+			return "", Type.Timeout("超時錯誤 請重試")
 		}
-		return "", errors.New("寫入錯誤")
+		return "", Type.Internal("寫入錯誤")
 	}
 
 	return "", nil
@@ -2457,24 +2803,24 @@ func logError(request Type.LogErrorRequest) (string, error) {
 func requestValidateCode(w http.ResponseWriter, r *http.Request) {
 	mode := r.URL.Query().Get("changeMode")
 	if mode != "account" && mode != "password" {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
 		return
 	}
 	var request Type.RequestValidateCodeRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	defer r.Body.Close()
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
 		return
 	}
 	if err = validate.Struct(request); err != nil {
         // Handle validation error
-        writeErrorJson(w, Type.MessageDisplayError{Message: "格式錯誤 缺少必要欄位"})
+        writeErrorJson(w, r, Type.BadRequest("格式錯誤 缺少必要欄位"))
         return
     }
 	// 檢查帳號格式
 	if !utils.IsValidEmail(request.Email) {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "電子郵件格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("電子郵件格式錯誤"))
         return
 	}
 	// 檢查帳號是否存在
@@ -2487,14 +2833,14 @@ func requestValidateCode(w http.ResponseWriter, r *http.Request) {
 	err = userColl.FindOne(findingContext, userFilter).Decode(&existingUser)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號不存在"})
+			writeErrorJson(w, r, Type.NotFound("此帳號不存在"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤 請重試"))
 		return 
 	}
 	if existingUser.IsGoogle {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "第三方登入帳戶不得更改密碼喔!"})
+		writeErrorJson(w, r, Type.Forbidden("第三方登入帳戶不得更改密碼喔!"))
 		return
 	}
 	// 檢查是否在驗證碼時效內有請求過
@@ -2508,37 +2854,41 @@ func requestValidateCode(w http.ResponseWriter, r *http.Request) {
 	var record Type.ResetAccountORPassword 
 	err = coll.FindOne(findingContext, filter).Decode(&record)
 	if err != nil && err != mongo.ErrNoDocuments {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "資料庫查詢錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("資料庫查詢錯誤 請重試"))
 		return 
 	}
 	if record.Expire - int64(Consts.ResetPasswordValidateCodeExpire) + int64(Consts.MinResendTimeBuffer) > utils.GetNow() {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請勿在3分鐘內重複申請驗證碼"})
+		writeErrorJson(w, r, Type.TooManyRequests("請勿在3分鐘內重複申請驗證碼"))
 		return 
 	}
 	// 產生驗證碼(明碼)
 	validateCode, err := utils.GenerateSixDigitCode()
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "驗證碼產生錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("驗證碼產生錯誤 請重試"))
 		return 
 	}
-	// 寄出驗證碼至信箱
+	// 寄出驗證碼至信箱，使用者有設定語系就用設定的語系
+	locale := i18n.FromContext(r.Context())
+	if preferred, ok := i18n.Parse(existingUser.Locale); ok {
+		locale = preferred
+	}
 	var emailTitle string
 	if mode == "account" {
-		emailTitle = "更改帳號-驗證碼"
+		emailTitle = i18n.T(locale, "更改帳號-驗證碼")
 	} else {
-		emailTitle = "更改密碼-驗證碼"
+		emailTitle = i18n.T(locale, "更改密碼-驗證碼")
 	}
 	cwd, _ := os.Getwd()
-	path := filepath.Join(cwd, "template/ResetPassword.html")
+	path := i18n.TemplatePath(filepath.Join(cwd, "template"), locale, "ResetPassword.html")
 	err = utils.SendEmailWithTimeout(path, emailTitle, request.Email, strconv.Itoa(validateCode), 10*time.Second)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return
 	}
 	// 把相關請求寫入DB或更新已存在的請求
 	hashedValidateCode, err := utils.HashPassword(strconv.Itoa(validateCode))
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "驗證碼加密錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("驗證碼加密錯誤 請重試"))
 		return 
 	}
 	data := Type.ResetAccountORPassword{
@@ -2554,26 +2904,26 @@ func requestValidateCode(w http.ResponseWriter, r *http.Request) {
 	_, err = coll.ReplaceOne(writingContext, filter, &data, opts)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "寫入錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("寫入錯誤 請重試"))
 		return 
 	}
 	
-	writeDataJson(w, Type.MessageDisplaySuccess{Message: "驗證碼已寄出!"})
+	writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "驗證碼已寄出!")})
 }
 
 func resetPassword(w http.ResponseWriter, r *http.Request) {
 	var resetPasswordRequest Type.ResetPasswordRequest
 	err := json.NewDecoder(r.Body).Decode(&resetPasswordRequest) 
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "密碼更新請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("密碼更新請求格式錯誤"))
 		return
 	}
 	if err = validate.Struct(resetPasswordRequest); err != nil {
         // Handle validation error
-        writeErrorJson(w, Type.MessageDisplayError{Message: "格式錯誤 缺少必要欄位"})
+        writeErrorJson(w, r, Type.BadRequest("格式錯誤 缺少必要欄位"))
         return
     }
 	var existingUser Type.User
@@ -2585,14 +2935,14 @@ func resetPassword(w http.ResponseWriter, r *http.Request) {
 	err = userColl.FindOne(findingContext, userFilter).Decode(&existingUser)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "此帳號不存在"})
+			writeErrorJson(w, r, Type.NotFound("此帳號不存在"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤 請重試"))
 		return 
 	}
 	if existingUser.IsGoogle {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "第三方登入帳戶不得更改密碼喔!"})
+		writeErrorJson(w, r, Type.Forbidden("第三方登入帳戶不得更改密碼喔!"))
 		return
 	}
 	// 用驗證碼找出更改密碼的請求
@@ -2602,39 +2952,39 @@ func resetPassword(w http.ResponseWriter, r *http.Request) {
 	err = resetPasswordColl.FindOne(findingContext, resetPasswordFilter).Decode(&resetPassword)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return 
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "查無驗證碼"})
+			writeErrorJson(w, r, Type.NotFound("查無驗證碼"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤 請重試"))
 		return 
 	}
 	
 	if same := utils.CheckHashedPassword(resetPasswordRequest.ValidateCode, resetPassword.ValidateCode); !same {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "驗證碼錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("驗證碼錯誤"))
 		return 
 	}
 	if resetPassword.Expire < utils.GetNow() {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "驗證碼失效"})
+		writeErrorJson(w, r, Type.BadRequest("驗證碼失效"))
 		return 
 	}
 	// 檢查密碼、確認密碼
 	if err := utils.IsValidPassword(resetPasswordRequest.Password); err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: err.Error()})
+		writeErrorJson(w, r, err)
 		return 
 	}
 	if resetPasswordRequest.Password != resetPasswordRequest.RePassword {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "兩次密碼輸入不一致"})
+		writeErrorJson(w, r, Type.BadRequest("兩次密碼輸入不一致"))
 		return 
 	}
 
 	// 更改密碼
 	hashedPassword, err := utils.HashPassword(resetPasswordRequest.Password)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "密碼轉換錯誤"})
+		writeErrorJson(w, r, Type.Internal("密碼轉換錯誤"))
 		return 
 	}
 	writingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -2643,22 +2993,22 @@ func resetPassword(w http.ResponseWriter, r *http.Request) {
 	_, err = userColl.UpdateOne(writingContext, userFilter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return 
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤 請重試"))
 		return 
 	}
 
-	err = writeDataJson(w, Type.MessageDisplaySuccess{Message: "更改密碼成功"})
+	err = writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "更改密碼成功")})
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 	}
 
 	// 刪除驗證碼紀錄
 	_, err = resetPasswordColl.DeleteOne(writingContext, resetPasswordFilter)
 	if err != nil {
-		log.Println("resetPassword deletion error", err)
+		loggerFromContext(r.Context()).Error("resetPassword deletion error", "error", err)
 	}
 }
 
@@ -2666,12 +3016,12 @@ func sendActivationEmail(w http.ResponseWriter, r *http.Request) {
 	var request Type.SendActivateEmailRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
 		return
 	}
 	defer r.Body.Close()
 	if !utils.IsValidEmail(request.Email) {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "電子郵件格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("電子郵件格式錯誤"))
 		return
 	}
 	// 查找是否該電子郵件被註冊過
@@ -2681,15 +3031,15 @@ func sendActivationEmail(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	res := coll.FindOne(ctx, filter)
 	if res.Err() == nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "該電子郵件已被註冊"})
+		writeErrorJson(w, r, Type.Conflict("該電子郵件已被註冊"))
 		return
 	}
 	if res.Err() != nil && res.Err() != mongo.ErrNoDocuments {
 		if res.Err() == context.DeadlineExceeded {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤"))
 		return
 	}	
 
@@ -2699,26 +3049,27 @@ func sendActivationEmail(w http.ResponseWriter, r *http.Request) {
 	err = activateEmailColl.FindOne(ctx, filter).Decode(&record)
 	if err == nil {
 		if record.Expire - int64(Consts.ActivateEmailExpire) + int64(Consts.MinActivateEmailExpire) > utils.GetNow() {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "請勿在3分鐘內重複請求"})
+			writeErrorJson(w, r, Type.TooManyRequests("請勿在3分鐘內重複請求"))
 			return
 		}
 	} 
 	if err != nil && err != mongo.ErrNoDocuments {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "查詢錯誤"})
+		writeErrorJson(w, r, Type.Internal("查詢錯誤"))
 	}
 
 	// sending email activation link, with 10s timeout
 	token := utils.GenerateID()
 	frontendPath := os.Getenv("FrontendPATH")
 	cwd, _ := os.Getwd()
-	path := filepath.Join(cwd, "template/ActivateEmail.html")
-	sendingEmailErr := utils.SendEmailWithTimeout(path, "電子郵件開通驗證", request.Email, fmt.Sprintf("%s/activateEmail/%s", frontendPath, token), 10*time.Second)
+	locale := i18n.FromContext(r.Context())
+	path := i18n.TemplatePath(filepath.Join(cwd, "template"), locale, "ActivateEmail.html")
+	sendingEmailErr := utils.SendEmailWithTimeout(path, i18n.T(locale, "電子郵件開通驗證"), request.Email, fmt.Sprintf("%s/activateEmail/%s", frontendPath, token), 10*time.Second)
 	if sendingEmailErr != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: sendingEmailErr.Error()})
+		writeErrorJson(w, r, sendingEmailErr)
 		return
 	}
 	// save record to the DB
@@ -2734,14 +3085,14 @@ func sendActivationEmail(w http.ResponseWriter, r *http.Request) {
 	_, err = activateColl.ReplaceOne(ctx, filter, &data, opts)
 	if err != nil {
 		if res.Err() == context.DeadlineExceeded {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "寫入錯誤"})
+		writeErrorJson(w, r, Type.Internal("寫入錯誤"))
 		return
 	}
 
-	writeDataJson(w, Type.MessageDisplaySuccess{Message: "開通郵件已寄出"})
+	writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "開通郵件已寄出")})
 }
 
 func activateEmail(w http.ResponseWriter, r *http.Request) {
@@ -2749,10 +3100,10 @@ func activateEmail(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&request)
 	defer r.Body.Close()
 	if err != nil {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請求格式錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
 	}
 	if len(request.Token) != 36 { // length of uuid is 36
-		writeErrorJson(w, Type.MessageDisplayError{Message: "權證錯誤"})
+		writeErrorJson(w, r, Type.BadRequest("權證錯誤"))
 		return
 	}
 
@@ -2764,23 +3115,23 @@ func activateEmail(w http.ResponseWriter, r *http.Request) {
 	err = coll.FindOne(ctx, filter).Decode(&record)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "權證錯誤"})
+			writeErrorJson(w, r, Type.BadRequest("權證錯誤"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "未知錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
 		return
 	}
 	// 查看是否過期
 	if utils.GetNow() > record.Expire {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "權證過期"})
+		writeErrorJson(w, r, Type.BadRequest("權證過期"))
 		return
 	}
 	if record.Activated {
-		writeErrorJson(w, Type.MessageDisplayError{Message: "請勿重複驗證"})
+		writeErrorJson(w, r, Type.Conflict("請勿重複驗證"))
 		return
 	}
 	// 把該email變成activated，並修改expire限制5分鐘內完成註冊
@@ -2788,14 +3139,14 @@ func activateEmail(w http.ResponseWriter, r *http.Request) {
 	_, err = coll.UpdateOne(ctx, filter, update)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "超時錯誤 請重試"})
+			writeErrorJson(w, r, Type.Timeout("超時錯誤 請重試"))
 			return
 		}
-		writeErrorJson(w, Type.MessageDisplayError{Message: "寫入錯誤 請重試"})
+		writeErrorJson(w, r, Type.Internal("寫入錯誤 請重試"))
 		return
 	}
 
-	writeDataJson(w, Type.MessageDisplaySuccess{Message: "郵件開通成功 請關閉頁面~"})
+	writeDataJson(w, Type.MessageDisplaySuccess{Message: translate(r, "郵件開通成功 請關閉頁面~")})
 }
 
 /*
@@ -2867,15 +3218,8 @@ func updateWordElement(wordSetID string, wordID string, newValue any, field stri
 		if !ok {
 			return errors.New("invalid type: 'vocabularySound' or 'definitionSound' field must be a string")
 		}
-		flag := false
-		for _, value := range []string{"en-US", "en-GB", "en-AU", "zh-TW", "zh-CN"} {
-			if value == strings.TrimSpace(strValue) {
-				flag = true
-				break
-			}
-		} 
-		if !flag {
-			return errors.New("invalid value, accepted value are en-US, en-GB, en-AU, zh-TW, zh-CN")
+		if err := utils.IsValidSound(strValue); err != nil {
+			return err
 		}
 	case "star":
 		// Ensure newValue is of type bool
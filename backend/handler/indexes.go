@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"go-quizlet/DB"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+啟動時建立需要的索引
+unique索引用來擋下同時進行的寫入產生的重複資料，已經存在的索引不會重建
+--------------------------------------------------------------
+*/
+
+var collectionIndexes = map[string][]mongo.IndexModel{
+	// 每個API請求都用tokenHash查token
+	"personalAccessTokens": {
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// 下面都是每個使用者一筆的紀錄，用upsert建立，同時建立時靠unique索引擋下重複的
+	"wordStars": {
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "wordSetID", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"wordReviews": {
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "wordSetID", Value: 1}, {Key: "wordID", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"learnSessions": {
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "wordSetID", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// 版本編號由revisionCnt配發，這裡是最後一道防線
+	"wordSetRevisions": {
+		{Keys: bson.D{{Key: "wordSetID", Value: 1}, {Key: "number", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+}
+
+func EnsureIndexes(ctx context.Context) {
+	database := DB.Client.Database("go-quizlet")
+	indexingContext, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	for collection, indexes := range collectionIndexes {
+		names, err := database.Collection(collection).Indexes().CreateMany(indexingContext, indexes)
+		if err != nil {
+			// 通常是舊資料已經有重複，先記log，清掉重複資料後重啟就會建立
+			slog.Error("create indexes failed", "collection", collection, "error", err)
+			continue
+		}
+		slog.Info("indexes ensured", "collection", collection, "indexes", names)
+	}
+}
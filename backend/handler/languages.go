@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"go-quizlet/language"
+	"net/http"
+)
+
+// GET /languages，單字、註釋以及模板欄位可以選的語言(sound)，啟動後不會變所以可以快取
+func handleGetLanguages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeDataJson(w, language.All())
+}
+
+func v1ListLanguages(r *http.Request) (any, error) {
+	return language.All(), nil
+}
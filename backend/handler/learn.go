@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+Learn模式，由後端決定下一題
+每個單字先考選擇題(recognition)，答對升到打字作答(recall)，再答對就算學會(mastered)
+答錯的單字留在原本的階段，隔LearnRequeueGap題再考一次
+進度存在learnSessions collection(每個使用者每個單字集一筆)，換裝置也能接著學
+單字集內容變了(新增/刪除單字)，下次讀取時會同步進度
+--------------------------------------------------------------
+*/
+
+// 題目跟答案，跟其他練習模式一樣題目是註釋、答案是單字，單字集shouldSwap時相反
+func learnPromptAnswer(word Type.Word, shouldSwap bool) (prompt string, promptSound string, answer string, answerSound string) {
+	if shouldSwap {
+		return word.Vocabulary, word.VocabularySound, word.Definition, word.DefinitionSound
+	}
+	return word.Definition, word.DefinitionSound, word.Vocabulary, word.VocabularySound
+}
+
+// 出目前佇列第一個單字的題目，佇列空了代表全部學會
+func nextLearnQuestion(session *Type.LearnSession, wordSet *Type.WordSet) {
+	if len(session.Queue) == 0 {
+		session.Current = nil
+		if session.CompletedAt == 0 {
+			session.CompletedAt = utils.GetNow()
+		}
+		return
+	}
+	session.CompletedAt = 0
+	words := make(map[string]Type.Word, len(wordSet.Words))
+	for _, word := range wordSet.Words {
+		words[word.ID] = word
+	}
+	word := words[session.Queue[0]]
+	stage := Consts.LearnStageRecognition
+	for _, learnWord := range session.Words {
+		if learnWord.WordID == word.ID {
+			stage = learnWord.Stage
+		}
+	}
+	prompt, promptSound, answer, answerSound := learnPromptAnswer(word, wordSet.ShouldSwap)
+	question := &Type.LearnQuestion{
+		ID:          utils.GenerateID(),
+		WordID:      word.ID,
+		Stage:       stage,
+		Prompt:      prompt,
+		PromptSound: promptSound,
+		AnswerSound: answerSound,
+	}
+	if stage == Consts.LearnStageRecognition {
+		// 其他單字的答案當作錯誤選項，正規化後一樣的不重複出現
+		seen := map[string]bool{utils.NormalizeAnswer(answer): true}
+		distractors := []string{}
+		for _, i := range rand.Perm(len(wordSet.Words)) {
+			if len(distractors) >= Consts.LearnChoices-1 {
+				break
+			}
+			_, _, other, _ := learnPromptAnswer(wordSet.Words[i], wordSet.ShouldSwap)
+			if key := utils.NormalizeAnswer(other); !seen[key] {
+				seen[key] = true
+				distractors = append(distractors, other)
+			}
+		}
+		question.AnswerIndex = rand.IntN(len(distractors) + 1)
+		question.Choices = slices.Insert(distractors, question.AnswerIndex, answer)
+	}
+	session.Current = question
+}
+
+// 依單字集目前的內容同步進度：刪掉的單字移除，新的單字從選擇題開始排在最後面，回傳是否有變動
+func syncLearnSession(session *Type.LearnSession, wordSet *Type.WordSet) bool {
+	exists := make(map[string]bool, len(wordSet.Words))
+	for _, word := range wordSet.Words {
+		exists[word.ID] = true
+	}
+	changed := false
+	tracked := make(map[string]bool, len(session.Words))
+	session.Words = slices.DeleteFunc(session.Words, func(learnWord Type.LearnWord) bool {
+		tracked[learnWord.WordID] = true
+		if !exists[learnWord.WordID] {
+			changed = true
+			return true
+		}
+		return false
+	})
+	session.Queue = slices.DeleteFunc(session.Queue, func(wordID string) bool {
+		return !exists[wordID]
+	})
+	for _, word := range sortedByOrder(wordSet.Words) {
+		if !tracked[word.ID] {
+			session.Words = append(session.Words, Type.LearnWord{WordID: word.ID, Stage: Consts.LearnStageRecognition})
+			session.Queue = append(session.Queue, word.ID)
+			changed = true
+		}
+	}
+	if (session.Current == nil && len(session.Queue) > 0) || (session.Current != nil && (len(session.Queue) == 0 || session.Current.WordID != session.Queue[0])) {
+		nextLearnQuestion(session, wordSet)
+		changed = true
+	}
+	return changed
+}
+
+// 新的進度，單字隨機排序
+func newLearnSession(userID string, wordSet *Type.WordSet) *Type.LearnSession {
+	now := utils.GetNow()
+	session := &Type.LearnSession{
+		ID:        utils.GenerateID(),
+		UserID:    userID,
+		WordSetID: wordSet.ID,
+		Words:     []Type.LearnWord{},
+		Queue:     []string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, word := range sortedByOrder(wordSet.Words) {
+		session.Words = append(session.Words, Type.LearnWord{WordID: word.ID, Stage: Consts.LearnStageRecognition})
+		session.Queue = append(session.Queue, word.ID)
+	}
+	rand.Shuffle(len(session.Queue), func(i int, j int) {
+		session.Queue[i], session.Queue[j] = session.Queue[j], session.Queue[i]
+	})
+	nextLearnQuestion(session, wordSet)
+	return session
+}
+
+// 各階段的單字數
+func fillLearnProgress(session *Type.LearnSession) {
+	progress := Type.LearnProgress{Total: len(session.Words)}
+	for _, learnWord := range session.Words {
+		switch learnWord.Stage {
+		case Consts.LearnStageRecognition:
+			progress.Recognition++
+		case Consts.LearnStageRecall:
+			progress.Recall++
+		case Consts.LearnStageMastered:
+			progress.Mastered++
+		}
+	}
+	progress.Completed = session.Current == nil
+	session.Progress = progress
+}
+
+// 使用者在單字集的進度，還沒開始過回傳nil
+func findLearnSession(ctx context.Context, userID string, wordSetID string) (*Type.LearnSession, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("learnSessions")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var session Type.LearnSession
+	if err := coll.FindOne(findingContext, bson.M{"userID": userID, "wordSetID": wordSetID}).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	return &session, nil
+}
+
+// 寫回進度，previousVersion是讀出來時的版本，期間被其他裝置改過就回衝突
+func saveLearnSession(ctx context.Context, session *Type.LearnSession, previousVersion int, isNew bool) error {
+	coll := DB.Client.Database("go-quizlet").Collection("learnSessions")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	session.Version = previousVersion + 1
+	session.UpdatedAt = utils.GetNow()
+	filter := bson.M{"userID": session.UserID, "wordSetID": session.WordSetID}
+	if !isNew {
+		filter["id"] = session.ID
+		filter["version"] = previousVersion
+	}
+	res, err := coll.ReplaceOne(writingContext, filter, session, options.Replace().SetUpsert(isNew))
+	if err != nil {
+		// 兩個裝置同時開始新的進度，後寫的被unique索引擋下
+		if mongo.IsDuplicateKeyError(err) {
+			return Type.Conflict("學習進度已在其他裝置更新 請重新整理")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if !isNew && res.MatchedCount == 0 {
+		return Type.Conflict("學習進度已在其他裝置更新 請重新整理")
+	}
+	fillLearnProgress(session)
+	return nil
+}
+
+// 拿目前的進度(順便跟單字集同步)，還沒開始過回404
+func getLearnSession(ctx context.Context, userID string, wordSetID string, shareToken string) (*Type.LearnSession, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	session, err := findLearnSession(ctx, userID, wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, Type.NotFound("尚未開始學習這個單字集")
+	}
+	if syncLearnSession(session, wordSet) {
+		if err := saveLearnSession(ctx, session, session.Version, false); err != nil {
+			return nil, err
+		}
+	}
+	fillLearnProgress(session)
+	return session, nil
+}
+
+// 接著上次的進度，沒有進度或restart時重新開始
+func startLearnSession(ctx context.Context, userID string, wordSetID string, shareToken string, restart bool) (*Type.LearnSession, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	session, err := findLearnSession(ctx, userID, wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || restart {
+		session = newLearnSession(userID, wordSet)
+		if err := saveLearnSession(ctx, session, 0, true); err != nil {
+			return nil, err
+		}
+		loggerFromContext(ctx).Info("learn session started", "wordSetID", wordSetID, "userID", userID)
+		return session, nil
+	}
+	if syncLearnSession(session, wordSet) {
+		if err := saveLearnSession(ctx, session, session.Version, false); err != nil {
+			return nil, err
+		}
+	}
+	fillLearnProgress(session)
+	return session, nil
+}
+
+// 回答目前的題目，questionID要是目前的題目(避免兩個裝置重複作答同一題)
+func answerLearnQuestion(ctx context.Context, userID string, wordSetID string, shareToken string, questionID string, choiceIndex *int, answer string) (*Type.LearnAnswerResponse, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	session, err := findLearnSession(ctx, userID, wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, Type.NotFound("尚未開始學習這個單字集")
+	}
+	// 單字集改過的話先存同步後的進度，題目可能跟著換掉
+	if syncLearnSession(session, wordSet) {
+		if err := saveLearnSession(ctx, session, session.Version, false); err != nil {
+			return nil, err
+		}
+	}
+	if session.Current == nil {
+		return nil, Type.BadRequest("已經學會所有單字 請重新開始")
+	}
+	if session.Current.ID != questionID {
+		fillLearnProgress(session)
+		return nil, Type.Conflict("題目已經更新 請重新作答").WithDetails(session)
+	}
+
+	question := session.Current
+	var word Type.Word
+	for _, w := range wordSet.Words {
+		if w.ID == question.WordID {
+			word = w
+		}
+	}
+	_, _, expected, _ := learnPromptAnswer(word, wordSet.ShouldSwap)
+	response := &Type.LearnAnswerResponse{Answer: expected}
+	if question.Stage == Consts.LearnStageRecognition {
+		if choiceIndex == nil || *choiceIndex < 0 || *choiceIndex >= len(question.Choices) {
+			return nil, Type.BadRequest("請選擇答案")
+		}
+		response.Correct = *choiceIndex == question.AnswerIndex
+	} else {
+		grade := gradeTypedAnswer(expected, Consts.GradeLeniencyNormal, answer)
+		response.Grade = &grade
+		response.Correct = grade.Result != Consts.GradeWrong
+	}
+
+	// 答對升一個階段，還沒學會的排到最後面；答錯留在原本的階段，隔幾題再考
+	session.Queue = session.Queue[1:]
+	for i := range session.Words {
+		learnWord := &session.Words[i]
+		if learnWord.WordID != word.ID {
+			continue
+		}
+		if !response.Correct {
+			learnWord.MissedCnt++
+			session.Queue = slices.Insert(session.Queue, min(Consts.LearnRequeueGap, len(session.Queue)), word.ID)
+			break
+		}
+		if learnWord.Stage == Consts.LearnStageRecognition {
+			learnWord.Stage = Consts.LearnStageRecall
+			session.Queue = append(session.Queue, word.ID)
+		} else {
+			learnWord.Stage = Consts.LearnStageMastered
+		}
+	}
+	session.Answered++
+	if response.Correct {
+		session.CorrectCnt++
+	}
+	nextLearnQuestion(session, wordSet)
+	if err := saveLearnSession(ctx, session, session.Version, false); err != nil {
+		return nil, err
+	}
+	response.Session = *session
+
+	// 順便記錄作答結果給複習牌組用，失敗不影響作答
+	if err := recordStudyResults(ctx, userID, shareToken, []Type.StudyResult{{WordSetID: wordSetID, WordID: word.ID, Correct: response.Correct}}); err != nil {
+		loggerFromContext(ctx).Warn("record learn result failed", "wordSetID", wordSetID, "error", err)
+	}
+	return response, nil
+}
+
+// 放棄進度
+func deleteLearnSession(ctx context.Context, userID string, wordSetID string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("learnSessions")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := coll.DeleteOne(writingContext, bson.M{"userID": userID, "wordSetID": wordSetID})
+	if err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.DeletedCount == 0 {
+		return Type.NotFound("尚未開始學習這個單字集")
+	}
+	return nil
+}
+
+/* ---------------- handlers ---------------- */
+
+// Learn模式要回傳進度，不能用只回訊息的PostValidateUser，自己檢查登入
+func decodeLearnRequest[T any](w http.ResponseWriter, r *http.Request) (string, T, bool) {
+	var request T
+	userID := requestUserID(r)
+	if userID == "" {
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
+		return "", request, false
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	defer r.Body.Close()
+	if err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求格式錯誤"))
+		return "", request, false
+	}
+	if err := validate.Struct(request); err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位"))
+		return "", request, false
+	}
+	return userID, request, true
+}
+
+func handleStartLearnSession(w http.ResponseWriter, r *http.Request) {
+	userID, request, ok := decodeLearnRequest[Type.StartLearnSessionRequest](w, r)
+	if !ok {
+		return
+	}
+	session, err := startLearnSession(r.Context(), userID, request.WordSetID, request.ShareToken, request.Restart)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, session); err != nil {
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func handleAnswerLearnQuestion(w http.ResponseWriter, r *http.Request) {
+	userID, request, ok := decodeLearnRequest[Type.AnswerLearnQuestionRequest](w, r)
+	if !ok {
+		return
+	}
+	response, err := answerLearnQuestion(r.Context(), userID, request.WordSetID, request.ShareToken, request.QuestionID, request.ChoiceIndex, request.Answer)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, response); err != nil {
+		writeErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func v1GetLearnSession(r *http.Request) (any, error) {
+	return getLearnSession(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"), shareTokenFromRequest(r))
+}
+
+func v1StartLearnSession(r *http.Request) (any, error) {
+	restart := r.URL.Query().Get("restart") == "true"
+	return startLearnSession(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"), shareTokenFromRequest(r), restart)
+}
+
+func v1AnswerLearnQuestion(r *http.Request) (any, error) {
+	input, err := decodeAPIBody[Type.V1AnswerLearnQuestionRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return answerLearnQuestion(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"), shareTokenFromRequest(r),
+		input.QuestionID, input.ChoiceIndex, input.Answer)
+}
+
+func v1DeleteLearnSession(r *http.Request) (any, error) {
+	return nil, deleteLearnSession(r.Context(), userIDFromContext(r.Context()), r.PathValue("wordSetID"))
+}
@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/utils"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/net/websocket"
+	"golang.org/x/time/rate"
+)
+
+/*
+--------------------------------------------------------------
+單字集即時協作 GET /api/v1/wordsets/{wordSetID}/live (WebSocket)
+每個單字集一個room，單字的add/update/remove/reorder透過原本的更新邏輯寫進DB後廣播給room裡所有連線
+op的seq就是寫入後的單字集版本號，同一個room的op一次只處理一個，所以seq會照順序送出
+presence(正在編輯哪個單字、還沒送出的輸入內容)只廣播不寫DB
+room存在記憶體中，只支援單一後端instance
+--------------------------------------------------------------
+*/
+
+// client送來的訊息類型
+const (
+	liveMessageAdd      = "add"
+	liveMessageUpdate   = "update"
+	liveMessageRemove   = "remove"
+	liveMessageReorder  = "reorder"
+	liveMessagePresence = "presence"
+	liveMessageSync     = "sync"
+	liveMessagePing     = "ping"
+)
+
+type liveRoom struct {
+	wordSetID string
+	mu        sync.Mutex // 保護clients以及每個client的presence
+	clients   map[string]*liveClient
+	opMu      sync.Mutex // 一次只處理一個op，廣播的seq才會照順序
+}
+
+type liveClient struct {
+	conn      *websocket.Conn
+	send      chan Type.LiveServerMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	locale    i18n.Locale
+	limiter   *rate.Limiter
+	presence  Type.LivePresence
+}
+
+var liveRooms = struct {
+	sync.Mutex
+	rooms map[string]*liveRoom
+}{rooms: map[string]*liveRoom{}}
+
+// 加入單字集的room，人數已滿就回傳錯誤
+func joinLiveRoom(wordSetID string, client *liveClient) (*liveRoom, error) {
+	liveRooms.Lock()
+	defer liveRooms.Unlock()
+	room, ok := liveRooms.rooms[wordSetID]
+	if !ok {
+		room = &liveRoom{wordSetID: wordSetID, clients: map[string]*liveClient{}}
+		liveRooms.rooms[wordSetID] = room
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if len(room.clients) >= Consts.LiveMaxConnections {
+		return nil, Type.TooManyRequests("目前同時編輯的人數已滿 請稍後再試")
+	}
+	room.clients[client.presence.ConnID] = client
+	return room, nil
+}
+
+// 離開room，沒人的room直接移除
+func (room *liveRoom) leave(client *liveClient) {
+	liveRooms.Lock()
+	room.mu.Lock()
+	delete(room.clients, client.presence.ConnID)
+	empty := len(room.clients) == 0
+	if empty && liveRooms.rooms[room.wordSetID] == room {
+		delete(liveRooms.rooms, room.wordSetID)
+	}
+	room.mu.Unlock()
+	liveRooms.Unlock()
+	if !empty {
+		room.broadcastPresence()
+	}
+}
+
+func (room *liveRoom) broadcast(message Type.LiveServerMessage) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	for _, client := range room.clients {
+		client.deliver(message)
+	}
+}
+
+func (room *liveRoom) presenceList() []Type.LivePresence {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	presence := make([]Type.LivePresence, 0, len(room.clients))
+	for _, client := range room.clients {
+		presence = append(presence, client.presence)
+	}
+	slices.SortFunc(presence, func(a, b Type.LivePresence) int {
+		if a.ConnID < b.ConnID {
+			return -1
+		}
+		if a.ConnID > b.ConnID {
+			return 1
+		}
+		return 0
+	})
+	return presence
+}
+
+func (room *liveRoom) broadcastPresence() {
+	room.broadcast(Type.LiveServerMessage{Type: "presence", Presence: room.presenceList()})
+}
+
+// REST API改了單字集內容之後通知room裡的連線，直接送新的快照
+func notifyLiveRoom(ctx context.Context, wordSetID string) {
+	liveRooms.Lock()
+	room, ok := liveRooms.rooms[wordSetID]
+	liveRooms.Unlock()
+	if !ok {
+		return
+	}
+	logger := loggerFromContext(ctx)
+	go func() {
+		room.opMu.Lock()
+		defer room.opMu.Unlock()
+		wordSet, err := getWordSetByID(wordSetID)
+		if err != nil {
+			logger.Warn("load wordSet for live snapshot failed", "wordSetID", wordSetID, "error", err)
+			return
+		}
+		room.broadcast(Type.LiveServerMessage{Type: "snapshot", Seq: wordSet.Version, WordSet: wordSet})
+	}()
+}
+
+// 放進送出佇列，佇列滿了代表client跟不上，直接斷線讓他重連後重新同步
+func (client *liveClient) deliver(message Type.LiveServerMessage) {
+	select {
+	case <-client.done:
+	case client.send <- message:
+	default:
+		client.close()
+	}
+}
+
+func (client *liveClient) deliverError(clientOpID string, err error) {
+	appErr := toAppError(err)
+	client.deliver(Type.LiveServerMessage{
+		Type:       "error",
+		ClientOpID: clientOpID,
+		Error: &Type.MessageDisplayError{
+			Message: i18n.T(client.locale, appErr.Message, appErr.Args...),
+			Code:    appErr.Code,
+			Details: appErr.Details,
+		},
+	})
+}
+
+func (client *liveClient) close() {
+	client.closeOnce.Do(func() {
+		close(client.done)
+		client.conn.Close()
+	})
+}
+
+func (client *liveClient) writeLoop() {
+	for {
+		select {
+		case <-client.done:
+			return
+		case message := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(Consts.LiveWriteTimeout))
+			if err := websocket.JSON.Send(client.conn, message); err != nil {
+				client.close()
+				return
+			}
+		}
+	}
+}
+
+// 先用一般的HTTP request驗證身分跟權限，通過才升級成WebSocket
+// 瀏覽器的WebSocket不能帶Authorization header，所以網頁用JWT cookie，其他client可以用API token
+func serveWordSetLive(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateAPIRequest(r, apiRoute{Scope: Consts.ScopeWordSetsWrite})
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+	ctx := contextWithUserID(r.Context(), userID)
+	r = r.WithContext(ctx)
+	wordSet, err := checkWordSetRole(ctx, r.PathValue("wordSetID"), userID, Consts.WordSetRoleViewer)
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+	user, err := getUserByID(userID)
+	if err != nil {
+		writeAPIError(w, r, err)
+		return
+	}
+
+	client := &liveClient{
+		send:    make(chan Type.LiveServerMessage, 64),
+		done:    make(chan struct{}),
+		locale:  i18n.FromContext(ctx),
+		limiter: rate.NewLimiter(Consts.LiveMessageLimit, Consts.LiveMessageBurst),
+		presence: Type.LivePresence{
+			ConnID: utils.GenerateID(),
+			UserID: userID,
+			Name:   user.Name,
+			Role:   wordSetRoleOf(wordSet, userID),
+		},
+	}
+	server := websocket.Server{
+		// Origin已經在EnableCORS檢查過
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = Consts.LiveMaxMessageSize
+			client.conn = conn
+			room, err := joinLiveRoom(wordSet.ID, client)
+			if err != nil {
+				client.deliverError("", err)
+				conn.SetWriteDeadline(time.Now().Add(Consts.LiveWriteTimeout))
+				websocket.JSON.Send(conn, <-client.send)
+				conn.Close()
+				return
+			}
+			room.serve(ctx, client)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (room *liveRoom) serve(ctx context.Context, client *liveClient) {
+	defer room.leave(client)
+	defer client.close()
+	go client.writeLoop()
+	logger := loggerFromContext(ctx).With("wordSetID", room.wordSetID, "connID", client.presence.ConnID)
+	logger.Info("live session started")
+
+	// 拿快照跟廣播op都在opMu裡，welcome之後收到的op一定接在快照的版本之後
+	room.opMu.Lock()
+	wordSet, err := getWordSetByID(room.wordSetID)
+	if err != nil {
+		room.opMu.Unlock()
+		client.deliverError("", err)
+		return
+	}
+	client.deliver(Type.LiveServerMessage{
+		Type:     "welcome",
+		Seq:      wordSet.Version,
+		ConnID:   client.presence.ConnID,
+		WordSet:  wordSet,
+		Presence: room.presenceList(),
+	})
+	room.opMu.Unlock()
+	room.broadcastPresence()
+
+	for {
+		client.conn.SetReadDeadline(time.Now().Add(Consts.LiveReadTimeout))
+		var message Type.LiveClientMessage
+		if err := websocket.JSON.Receive(client.conn, &message); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				client.deliverError("", Type.BadRequest("請求格式錯誤").Wrap(err))
+				continue
+			}
+			if !errors.Is(err, io.EOF) {
+				logger.Debug("live session read failed", "error", err)
+			}
+			break
+		}
+		if !client.limiter.Allow() {
+			client.deliverError(message.ClientOpID, Type.TooManyRequests("太多請求 請稍後"))
+			continue
+		}
+		room.handle(ctx, client, message)
+	}
+	logger.Info("live session ended")
+}
+
+func (room *liveRoom) handle(ctx context.Context, client *liveClient, message Type.LiveClientMessage) {
+	switch message.Type {
+	case liveMessagePing:
+		client.deliver(Type.LiveServerMessage{Type: "pong"})
+	case liveMessagePresence:
+		draft := message.Draft
+		if utf8.RuneCountInString(draft) > Consts.MaxDefinitionLen {
+			draft = string([]rune(draft)[:Consts.MaxDefinitionLen])
+		}
+		room.mu.Lock()
+		client.presence.FocusWordID = message.FocusWordID
+		client.presence.Field = message.Field
+		client.presence.Draft = draft
+		room.mu.Unlock()
+		room.broadcastPresence()
+	case liveMessageSync:
+		room.opMu.Lock()
+		defer room.opMu.Unlock()
+		wordSet, err := getWordSetByID(room.wordSetID)
+		if err != nil {
+			client.deliverError(message.ClientOpID, err)
+			return
+		}
+		client.deliver(Type.LiveServerMessage{Type: "snapshot", Seq: wordSet.Version, ClientOpID: message.ClientOpID, WordSet: wordSet})
+	case liveMessageAdd, liveMessageUpdate, liveMessageRemove, liveMessageReorder:
+		room.applyOp(ctx, client, message)
+	default:
+		client.deliverError(message.ClientOpID, Type.BadRequest("不支援的訊息類型"))
+	}
+}
+
+// 寫進DB後把op連同新的版本號廣播出去
+func (room *liveRoom) applyOp(ctx context.Context, client *liveClient, message Type.LiveClientMessage) {
+	room.opMu.Lock()
+	defer room.opMu.Unlock()
+
+	opCtx, seq := contextWithVersionSink(ctx)
+	var op *Type.LiveOp
+	var err error
+	// 每個op都重新檢查權限(可能中途被移除協作者)，版本衝突代表有人從其他地方改過，拿最新版本再試一次
+	for attempt := 0; attempt < 2; attempt++ {
+		var wordSet *Type.WordSet
+		wordSet, err = checkWordSetEditor(ctx, room.wordSetID, client.presence.UserID)
+		if err != nil {
+			break
+		}
+		op, err = applyLiveOp(opCtx, wordSet, message)
+		if err == nil || toAppError(err).Code != Type.CodeConflict {
+			break
+		}
+	}
+	if err != nil {
+		client.deliverError(message.ClientOpID, err)
+		return
+	}
+	room.broadcast(Type.LiveServerMessage{
+		Type:       "op",
+		Seq:        *seq,
+		ClientOpID: message.ClientOpID,
+		UserID:     client.presence.UserID,
+		Op:         op,
+	})
+}
+
+// 用wordSet目前的版本號套用op，單字的新增/修改/刪除走跟REST API一樣的function
+func applyLiveOp(ctx context.Context, wordSet *Type.WordSet, message Type.LiveClientMessage) (*Type.LiveOp, error) {
+	version := wordSet.Version
+	switch message.Type {
+	case liveMessageAdd:
+		if message.Word == nil {
+			return nil, Type.BadRequest("請求缺少必要欄位")
+		}
+		if err := validate.Struct(*message.Word); err != nil {
+			return nil, Type.BadRequest("請求缺少必要欄位").Wrap(err)
+		}
+		word := wordFromInput(*message.Word, len(wordSet.Words)+1)
+		id, err := addWord(ctx, Type.AddWordRequest{WordSetID: wordSet.ID, Version: &version, Word: word})
+		if err != nil {
+			return nil, err
+		}
+		word.ID = id
+		return &Type.LiveOp{Type: liveMessageAdd, WordID: id, Word: &word}, nil
+	case liveMessageUpdate:
+		if message.Fields == nil {
+			return nil, Type.BadRequest("請求缺少必要欄位")
+		}
+		word, err := applyWordUpdate(ctx, wordSet, message.WordID, version, *message.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return &Type.LiveOp{Type: liveMessageUpdate, WordID: word.ID, Word: &word}, nil
+	case liveMessageRemove:
+		if !slices.ContainsFunc(wordSet.Words, func(word Type.Word) bool { return word.ID == message.WordID }) {
+			return nil, Type.NotFound("查無此單字或單字集")
+		}
+		if _, err := deleteWord(ctx, Type.DeleteWordRequest{WordSetID: wordSet.ID, Version: &version, WordID: message.WordID}); err != nil {
+			return nil, err
+		}
+		return &Type.LiveOp{Type: liveMessageRemove, WordID: message.WordID}, nil
+	case liveMessageReorder:
+		if err := reorderWords(ctx, wordSet, version, message.Order); err != nil {
+			return nil, err
+		}
+		return &Type.LiveOp{Type: liveMessageReorder, Order: message.Order}, nil
+	}
+	return nil, Type.BadRequest("不支援的訊息類型")
+}
+
+// 依order(所有單字ID)重新編排單字的order
+func reorderWords(ctx context.Context, wordSet *Type.WordSet, version int, order []string) error {
+	if err := checkWordSetVersion(wordSet, version); err != nil {
+		return err
+	}
+	if len(order) != len(wordSet.Words) {
+		return Type.BadRequest("單字順序必須包含所有單字且不能重複")
+	}
+	position := make(map[string]int, len(order))
+	for i, wordID := range order {
+		position[wordID] = i + 1
+	}
+	words := slices.Clone(wordSet.Words)
+	for i := range words {
+		newOrder, ok := position[words[i].ID]
+		if !ok {
+			return Type.BadRequest("單字順序必須包含所有單字且不能重複")
+		}
+		words[i].Order = newOrder
+	}
+	if len(position) != len(words) {
+		return Type.BadRequest("單字順序必須包含所有單字且不能重複")
+	}
+	setFields := bson.M{"words": words, "updatedAt": utils.GetNow()}
+	after, err := updateWordSetFields(ctx, wordSet, version, bson.M{"id": wordSet.ID}, setFields)
+	if err != nil {
+		return err
+	}
+	recordWordSetRevisionOrLog(ctx, wordSet, after, Consts.RevisionActionEdit)
+	return nil
+}
@@ -0,0 +1,542 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Storage"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+單字圖片與發音音檔
+檔案透過Storage.Client存，DB只記key跟網址，圖片原圖以外另外存一張縮圖
+音檔每個單字的vocabulary、definition兩面各一個，播放時優先於TTS
+複製/合併/拆分單字集時單字共用同一份檔案，所以換檔或刪檔時要確認沒有其他單字集(以及版本紀錄)還在用才刪檔案
+key裡有隨機ID，知道網址就看得到檔案(跟原本放Imgur的大頭貼一樣)
+版本紀錄超過上限被刪掉時不會回頭清檔案，只用在舊版本的圖片會留在Storage
+單字集被永久刪除時，單字以及版本紀錄裡用到的檔案在交易提交後一樣走release，其他單字集沒在用才刪
+--------------------------------------------------------------
+*/
+
+// 把上傳的內容讀進記憶體，超過limit回傳錯誤
+func readUpload(body io.Reader, limit int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if err != nil {
+		return nil, Type.BadRequest("檔案讀取錯誤 請重試").Wrap(err)
+	}
+	if len(data) > limit {
+		return nil, Type.BadRequest("檔案超過上限(最多%dMB)").WithArgs(limit >> 20)
+	}
+	if len(data) == 0 {
+		return nil, Type.BadRequest("檔案格式錯誤")
+	}
+	return data, nil
+}
+
+// 存原圖跟縮圖並更新單字，成功後舊的圖片沒人用的話會刪掉
+func setWordImage(ctx context.Context, wordSetID string, wordID string, version int, data []byte) (*Type.WordImage, error) {
+	processed, err := utils.ProcessImage(data)
+	if err != nil {
+		return nil, err
+	}
+	before, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWordSetVersion(before, version); err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(before.Words, func(word Type.Word) bool { return word.ID == wordID })
+	if index == -1 {
+		return nil, Type.NotFound("查無此單字或單字集")
+	}
+
+	id := utils.GenerateID()
+	image := Type.WordImage{
+		Key:          "words/" + wordSetID + "/" + id + processed.Ext,
+		ThumbnailKey: "words/" + wordSetID + "/" + id + "_thumb" + processed.ThumbnailExt,
+		Width:        processed.Width,
+		Height:       processed.Height,
+	}
+	image.URL = Storage.Client.URL(image.Key)
+	image.ThumbnailURL = Storage.Client.URL(image.ThumbnailKey)
+	if err := putMedia(ctx, image.Key, processed.ContentType, data); err != nil {
+		return nil, err
+	}
+	if err := putMedia(ctx, image.ThumbnailKey, processed.ThumbnailContentType, processed.Thumbnail); err != nil {
+		deleteMedia(ctx, image.Key)
+		return nil, err
+	}
+
+	setFields := bson.M{"words.$.image": image, "updatedAt": utils.GetNow()}
+	after, err := updateWordSetFields(ctx, before, version, bson.M{"id": wordSetID, "words.id": wordID}, setFields)
+	if err != nil {
+		deleteMedia(ctx, image.Key, image.ThumbnailKey)
+		return nil, err
+	}
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: before.Title, AuthorID: before.AuthorID, ActorID: userIDFromContext(ctx),
+	})
+	releaseWordImage(ctx, before.Words[index].Image)
+	loggerFromContext(ctx).Info("word image uploaded", "wordSetID", wordSetID, "wordID", wordID, "size", len(data))
+	return &image, nil
+}
+
+// 拿掉單字的圖片
+func removeWordImage(ctx context.Context, wordSetID string, wordID string, version int) error {
+	before, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return err
+	}
+	if err := checkWordSetVersion(before, version); err != nil {
+		return err
+	}
+	index := slices.IndexFunc(before.Words, func(word Type.Word) bool { return word.ID == wordID })
+	if index == -1 {
+		return Type.NotFound("查無此單字或單字集")
+	}
+	if before.Words[index].Image == nil {
+		return Type.BadRequest("單字沒有圖片")
+	}
+
+	setFields := bson.M{"words.$.image": nil, "updatedAt": utils.GetNow()}
+	after, err := updateWordSetFields(ctx, before, version, bson.M{"id": wordSetID, "words.id": wordID}, setFields)
+	if err != nil {
+		return err
+	}
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: before.Title, AuthorID: before.AuthorID, ActorID: userIDFromContext(ctx),
+	})
+	releaseWordImage(ctx, before.Words[index].Image)
+	return nil
+}
+
+// 音檔存在單字的哪個欄位
+func wordAudioField(side string) (string, error) {
+	switch side {
+	case Consts.AudioSideVocabulary:
+		return "vocabularyAudio", nil
+	case Consts.AudioSideDefinition:
+		return "definitionAudio", nil
+	}
+	return "", Type.BadRequest("發音只能設定在vocabulary或definition")
+}
+
+func wordAudioOf(word Type.Word, side string) *Type.WordAudio {
+	if side == Consts.AudioSideVocabulary {
+		return word.VocabularyAudio
+	}
+	return word.DefinitionAudio
+}
+
+// 存音檔並更新單字某一面的發音，成功後舊的音檔沒人用的話會刪掉
+func setWordAudio(ctx context.Context, wordSetID string, wordID string, side string, version int, data []byte) (*Type.WordAudio, error) {
+	field, err := wordAudioField(side)
+	if err != nil {
+		return nil, err
+	}
+	probed, err := utils.ProbeAudio(data)
+	if err != nil {
+		return nil, err
+	}
+	before, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWordSetVersion(before, version); err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(before.Words, func(word Type.Word) bool { return word.ID == wordID })
+	if index == -1 {
+		return nil, Type.NotFound("查無此單字或單字集")
+	}
+
+	audio := Type.WordAudio{
+		Key:         "words/" + wordSetID + "/" + utils.GenerateID() + probed.Ext,
+		ContentType: probed.ContentType,
+		Duration:    probed.Duration,
+	}
+	audio.URL = Storage.Client.URL(audio.Key)
+	if err := putMedia(ctx, audio.Key, probed.ContentType, data); err != nil {
+		return nil, err
+	}
+
+	setFields := bson.M{"words.$." + field: audio, "updatedAt": utils.GetNow()}
+	after, err := updateWordSetFields(ctx, before, version, bson.M{"id": wordSetID, "words.id": wordID}, setFields)
+	if err != nil {
+		deleteMedia(ctx, audio.Key)
+		return nil, err
+	}
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: before.Title, AuthorID: before.AuthorID, ActorID: userIDFromContext(ctx),
+	})
+	releaseWordAudio(ctx, wordAudioOf(before.Words[index], side))
+	loggerFromContext(ctx).Info("word audio uploaded", "wordSetID", wordSetID, "wordID", wordID, "side", side, "size", len(data))
+	return &audio, nil
+}
+
+// 拿掉單字某一面的發音，之後回到用TTS
+func removeWordAudio(ctx context.Context, wordSetID string, wordID string, side string, version int) error {
+	field, err := wordAudioField(side)
+	if err != nil {
+		return err
+	}
+	before, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return err
+	}
+	if err := checkWordSetVersion(before, version); err != nil {
+		return err
+	}
+	index := slices.IndexFunc(before.Words, func(word Type.Word) bool { return word.ID == wordID })
+	if index == -1 {
+		return Type.NotFound("查無此單字或單字集")
+	}
+	audio := wordAudioOf(before.Words[index], side)
+	if audio == nil {
+		return Type.BadRequest("單字沒有音檔")
+	}
+
+	setFields := bson.M{"words.$." + field: nil, "updatedAt": utils.GetNow()}
+	after, err := updateWordSetFields(ctx, before, version, bson.M{"id": wordSetID, "words.id": wordID}, setFields)
+	if err != nil {
+		return err
+	}
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: before.Title, AuthorID: before.AuthorID, ActorID: userIDFromContext(ctx),
+	})
+	releaseWordAudio(ctx, audio)
+	return nil
+}
+
+func putMedia(ctx context.Context, key string, contentType string, data []byte) error {
+	writingContext, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := Storage.Client.Put(writingContext, key, contentType, bytes.NewReader(data)); err != nil {
+		return Type.Internal("檔案儲存錯誤 請重試").Wrap(err)
+	}
+	return nil
+}
+
+// 刪檔案失敗只記log，頂多留下沒人用的檔案
+func deleteMedia(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := Storage.Client.Delete(ctx, key); err != nil {
+			loggerFromContext(ctx).Warn("delete media failed", "key", key, "error", err)
+		}
+	}
+}
+
+// 單字裡存檔案key的欄位
+var wordMediaKeyFields = []string{"image.key", "vocabularyAudio.key", "definitionAudio.key"}
+
+func mediaKeyFilter(prefix string, key string) bson.M {
+	conditions := bson.A{}
+	for _, field := range wordMediaKeyFields {
+		conditions = append(conditions, bson.M{prefix + field: key})
+	}
+	return bson.M{"$or": conditions}
+}
+
+// 單字集或版本紀錄裡還有沒有單字在用這個檔案
+func isWordMediaReferenced(ctx context.Context, key string) (bool, error) {
+	database := DB.Client.Database("go-quizlet")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	count, err := database.Collection("wordSets").CountDocuments(findingContext, mediaKeyFilter("words.", key))
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	count, err = database.Collection("wordSetRevisions").CountDocuments(findingContext, mediaKeyFilter("snapshot.words.", key))
+	return count > 0, err
+}
+
+// 換掉或刪掉圖片之後，沒有其他地方在用就刪檔案
+func releaseWordImage(ctx context.Context, image *Type.WordImage) {
+	if image == nil {
+		return
+	}
+	referenced, err := isWordMediaReferenced(ctx, image.Key)
+	if err != nil {
+		loggerFromContext(ctx).Warn("check image reference failed", "key", image.Key, "error", err)
+		return
+	}
+	if !referenced {
+		deleteMedia(ctx, image.Key, image.ThumbnailKey)
+	}
+}
+
+// 換掉或刪掉音檔之後，沒有其他地方在用就刪檔案
+func releaseWordAudio(ctx context.Context, audio *Type.WordAudio) {
+	if audio == nil {
+		return
+	}
+	referenced, err := isWordMediaReferenced(ctx, audio.Key)
+	if err != nil {
+		loggerFromContext(ctx).Warn("check audio reference failed", "key", audio.Key, "error", err)
+		return
+	}
+	if !referenced {
+		deleteMedia(ctx, audio.Key)
+	}
+}
+
+func sameWordImage(a *Type.WordImage, b *Type.WordImage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Key == b.Key
+}
+
+func sameWordAudio(a *Type.WordAudio, b *Type.WordAudio) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Key == b.Key
+}
+
+// 單字集本身以及版本紀錄裡用到的所有圖片跟音檔，同一個key只留一份
+func collectWordSetMedia(ctx context.Context, wordSet *Type.WordSet) ([]*Type.WordImage, []*Type.WordAudio, error) {
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetProjection(bson.M{"snapshot.words": 1})
+	cursor, err := DB.Client.Database("go-quizlet").Collection("wordSetRevisions").Find(findingContext, bson.M{"wordSetID": wordSet.ID}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var revisions []Type.WordSetRevision
+	if err := cursor.All(findingContext, &revisions); err != nil {
+		return nil, nil, err
+	}
+
+	words := slices.Clone(wordSet.Words)
+	for _, revision := range revisions {
+		if revision.Snapshot != nil {
+			words = append(words, revision.Snapshot.Words...)
+		}
+	}
+	var images []*Type.WordImage
+	var audios []*Type.WordAudio
+	seen := map[string]bool{}
+	for _, word := range words {
+		if word.Image != nil && !seen[word.Image.Key] {
+			seen[word.Image.Key] = true
+			images = append(images, word.Image)
+		}
+		for _, audio := range []*Type.WordAudio{word.VocabularyAudio, word.DefinitionAudio} {
+			if audio != nil && !seen[audio.Key] {
+				seen[audio.Key] = true
+				audios = append(audios, audio)
+			}
+		}
+	}
+	return images, audios, nil
+}
+
+// 新增單字時圖片跟音檔只能之後另外上傳，不接受client帶進來的
+func stripWordMedia(words []Type.Word) {
+	for i := range words {
+		words[i].Image = nil
+		words[i].VocabularyAudio = nil
+		words[i].DefinitionAudio = nil
+	}
+}
+
+/* ---------------- handlers ---------------- */
+
+// GET /media/{key...}，檔案的key不會重複所以可以一直快取，Range由http.ServeContent處理
+func serveMedia(w http.ResponseWriter, r *http.Request) {
+	file, object, err := Storage.Client.Open(r.Context(), r.PathValue("key"))
+	if err != nil {
+		if errors.Is(err, Storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		loggerFromContext(r.Context()).Error("open media failed", "key", r.PathValue("key"), "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, object.Key, object.ModTime, file)
+}
+
+// POST /uploadWordImage，multipart form: wordSetID、wordID、version、image
+func handleUploadWordImage(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
+		return
+	}
+	// 多留1MB給其他form欄位
+	r.Body = http.MaxBytesReader(w, r.Body, int64(Consts.MaxWordImageSize+1<<20))
+	defer r.Body.Close()
+	if err := r.ParseMultipartForm(int64(Consts.MaxWordImageSize)); err != nil {
+		writeErrorJson(w, r, Type.BadRequest("檔案超過上限(最多%dMB)").WithArgs(Consts.MaxWordImageSize>>20))
+		return
+	}
+	wordSetID := r.FormValue("wordSetID")
+	version, err := strconv.Atoi(r.FormValue("version"))
+	if wordSetID == "" || r.FormValue("wordID") == "" || err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位"))
+		return
+	}
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userID); err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		writeErrorJson(w, r, Type.BadRequest("檔案格式錯誤"))
+		return
+	}
+	defer file.Close()
+	data, err := readUpload(file, Consts.MaxWordImageSize)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+
+	ctx, newVersion := contextWithVersionSink(contextWithUserID(r.Context(), userID))
+	image, err := setWordImage(ctx, wordSetID, r.FormValue("wordID"), version, data)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	notifyLiveRoom(ctx, wordSetID)
+	writeDataJson(w, Type.UploadWordImageResponse{Image: *image, Version: *newVersion})
+}
+
+func handleRemoveWordImage(ctx context.Context, request Type.RemoveWordImageRequest) (string, error) {
+	return "", removeWordImage(ctx, request.WordSetID, request.WordID, *request.Version)
+}
+
+// PUT /api/v1/wordsets/{wordSetID}/words/{wordID}/image，body直接是圖片檔
+func v1PutWordImage(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	data, err := readUpload(r.Body, Consts.MaxWordImageSize)
+	if err != nil {
+		return nil, err
+	}
+	return setWordImage(r.Context(), wordSetID, r.PathValue("wordID"), version, data)
+}
+
+func v1DeleteWordImage(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, removeWordImage(r.Context(), wordSetID, r.PathValue("wordID"), version)
+}
+
+// POST /uploadWordAudio，multipart form: wordSetID、wordID、side、version、audio
+func handleUploadWordAudio(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		loggerFromContext(r.Context()).Info("user not logged in", "route", routePattern(r))
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, int64(Consts.MaxWordAudioSize+1<<20))
+	defer r.Body.Close()
+	if err := r.ParseMultipartForm(int64(Consts.MaxWordAudioSize)); err != nil {
+		writeErrorJson(w, r, Type.BadRequest("檔案超過上限(最多%dMB)").WithArgs(Consts.MaxWordAudioSize>>20))
+		return
+	}
+	wordSetID := r.FormValue("wordSetID")
+	version, err := strconv.Atoi(r.FormValue("version"))
+	if wordSetID == "" || r.FormValue("wordID") == "" || r.FormValue("side") == "" || err != nil {
+		writeErrorJson(w, r, Type.BadRequest("請求缺少必要欄位"))
+		return
+	}
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userID); err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	file, _, err := r.FormFile("audio")
+	if err != nil {
+		writeErrorJson(w, r, Type.BadRequest("檔案格式錯誤"))
+		return
+	}
+	defer file.Close()
+	data, err := readUpload(file, Consts.MaxWordAudioSize)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+
+	ctx, newVersion := contextWithVersionSink(contextWithUserID(r.Context(), userID))
+	audio, err := setWordAudio(ctx, wordSetID, r.FormValue("wordID"), r.FormValue("side"), version, data)
+	if err != nil {
+		writeErrorJson(w, r, err)
+		return
+	}
+	notifyLiveRoom(ctx, wordSetID)
+	writeDataJson(w, Type.UploadWordAudioResponse{Audio: *audio, Version: *newVersion})
+}
+
+func handleRemoveWordAudio(ctx context.Context, request Type.RemoveWordAudioRequest) (string, error) {
+	return "", removeWordAudio(ctx, request.WordSetID, request.WordID, request.Side, *request.Version)
+}
+
+// PUT /api/v1/wordsets/{wordSetID}/words/{wordID}/audio/{side}，body直接是音檔
+func v1PutWordAudio(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	data, err := readUpload(r.Body, Consts.MaxWordAudioSize)
+	if err != nil {
+		return nil, err
+	}
+	return setWordAudio(r.Context(), wordSetID, r.PathValue("wordID"), r.PathValue("side"), version, data)
+}
+
+func v1DeleteWordAudio(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, removeWordAudio(r.Context(), wordSetID, r.PathValue("wordID"), r.PathValue("side"), version)
+}
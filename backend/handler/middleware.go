@@ -1,16 +1,29 @@
 package handler
 
 import (
-	"fmt"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"go-quizlet/Consts"
+	"go-quizlet/DB"
 	"go-quizlet/Type"
-	"log"
+	"go-quizlet/i18n"
+	"go-quizlet/utils"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/time/rate"
 )
 
@@ -35,15 +48,19 @@ func EnableCORS(next http.Handler) http.Handler {
 		}
 		allowedOrigins := strings.Split(allowedOrigin, " ") // 用空格區分不同origin
 		origin := r.Header.Get("Origin")
-		fmt.Println(origin)
+		// 沒有Origin的request不是瀏覽器的跨域請求(例如手機app、第三方整合)，/api/v1允許直接呼叫
+		if origin == "" && strings.HasPrefix(r.URL.Path, apiV1Prefix+"/") {
+			next.ServeHTTP(w, r)
+			return
+		}
 		if slices.Contains(allowedOrigins, origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Requested-With")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Requested-With, X-Request-ID, If-Match, X-Share-Token")
+			w.Header().Set("Access-Control-Expose-Headers", requestIDHeader+", ETag, "+duplicateWordsHeader)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		} else {
-			w.WriteHeader(http.StatusForbidden) // 403 Forbidden
-			writeErrorJson(w, Type.MessageDisplayError{Message: fmt.Sprintf("%s CORS violated", origin)})
+			writeErrorJson(w, r, Type.Forbidden("%s CORS violated").WithArgs(origin)) // 403 Forbidden
 			return
 		}
 		// Handle preflight requests
@@ -63,18 +80,217 @@ func RateLimit(next http.Handler) http.Handler {
 	return http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
 		IP, err := getIP(r)
 		if err != nil {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "IP出錯"})
-			log.Println("取得IP出錯", err.Error())
+			writeErrorJson(w, r, Type.Internal("IP出錯"))
+			loggerFromContext(r.Context()).Error("取得IP出錯", "error", err)
 			return
 		}
 		limiterAny, _ := limiterIPMap.LoadOrStore(IP, rate.NewLimiter(Consts.APILimit, Consts.APIBurst))
 		limiter := limiterAny.(*rate.Limiter)
 
 		if !limiter.Allow() {
-			writeErrorJson(w, Type.MessageDisplayError{Message: "太多請求 請稍後"})
-			log.Println("IP", IP, "短時間送出太多請求")
+			writeErrorJson(w, r, Type.TooManyRequests("太多請求 請稍後"))
+			loggerFromContext(r.Context()).Warn("短時間送出太多請求", "ip", IP)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// request scope的context key
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+	wordSetVersionKey
+	duplicateWordsKey
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// 超過這個大小的request body就不印到log裡
+const maxLoggedBodySize = 16 << 10
+
+// 從context拿request ID
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// 從context拿已驗證的userID(要經過validate wrapper之後才會有)
+func userIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+// 把驗證過的userID放進context
+func contextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// 回傳帶有requestID(以及userID)欄位的logger，handler內的log都要經過這裡
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		logger = logger.With("requestID", requestID)
+	}
+	if userID := userIDFromContext(ctx); userID != "" {
+		logger = logger.With("userID", userID)
+	}
+	return logger
+}
+
+// 嘗試從JWT cookie取出userID，只給log用，沒登入或憑證無效就回傳空字串
+func requestUserID(r *http.Request) string {
+	token, err := utils.CheckLogIn(nil, r)
+	if err != nil {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	userID, _ := claims["userID"].(string)
+	return userID
+}
+
+// 包住ResponseWriter，記錄最後回覆的status code和大小
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.size += n
+	return n, err
+}
+
+// 讓http.ResponseController可以拿到原本的ResponseWriter
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// WebSocket升級時要接管底層連線，之後的status一律記成101
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(s.ResponseWriter).Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// 產生request ID並記錄每個request的route、userID、status、耗時
+// 必須放在最外層，CORS或rate limit擋下的request才會被記錄到
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = utils.GenerateID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		r = r.WithContext(ctx)
+
+		logger := slog.Default().With("requestID", requestID)
+		userID := requestUserID(r)
+
+		// debug level才讀取並印出遮蔽過的JSON body，讀完要放回去給handler用
+		// chunked的body長度未知(ContentLength為-1)，不讀避免被截斷
+		if logger.Enabled(ctx, slog.LevelDebug) && r.Body != nil &&
+			strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") &&
+			r.ContentLength >= 0 && r.ContentLength <= maxLoggedBodySize {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedBodySize))
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if err == nil {
+				logger.Debug("request body", "method", r.Method, "path", r.URL.Path, "body", utils.RedactJSON(body))
+			}
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if recorder.status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "request",
+			"method", r.Method,
+			"route", routePattern(r),
+			"path", r.URL.Path,
+			"userID", userID,
+			"status", recorder.status,
+			"size", recorder.size,
+			"latencyMs", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// 已登入使用者的語系設定 userID -> i18n.Locale(沒設定就存空字串)，更改語系時要一起更新
+var userLocaleCache sync.Map
+
+// 從DB拿使用者的語系設定，查詢失敗就當作沒設定
+func userLocale(ctx context.Context, userID string) (i18n.Locale, bool) {
+	if cached, ok := userLocaleCache.Load(userID); ok {
+		locale := cached.(i18n.Locale)
+		return locale, locale != ""
+	}
+	findingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	var user struct {
+		Locale string `bson:"locale"`
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("users")
+	err := coll.FindOne(findingCtx, bson.M{"id": userID}, options.FindOne().SetProjection(bson.M{"locale": 1})).Decode(&user)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			loggerFromContext(ctx).Warn("load user locale failed", "error", err)
+			return "", false
+		}
+	}
+	locale, ok := i18n.Parse(user.Locale)
+	userLocaleCache.Store(userID, locale)
+	return locale, ok
+}
+
+// 決定這個request要用的語系: 已登入且有設定語系就用設定，否則看Accept-Language，預設繁中
+// 要放在RequestLogger之後、其他middleware之前，CORS或rate limit的錯誤訊息才會被翻譯
+func DetectLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := i18n.Match(r.Header.Get("Accept-Language"))
+		if userID := requestUserID(r); userID != "" {
+			if preferred, ok := userLocale(r.Context(), userID); ok {
+				locale = preferred
+			}
+		}
+		w.Header().Set("Content-Language", string(locale))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+	})
+}
+
+// mux配對到的route pattern(例如 GET /getWordSet/{wordSetID})，沒配對到就用原本的path
+func routePattern(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 從apiRoute table產生OpenAPI 3文件，schema由request/response type的json tag反射而來
+// validate:"required"的欄位會標成required
+
+var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+type openAPIBuilder struct {
+	schemas map[string]any // components.schemas，key是Type裡的struct名稱
+}
+
+func buildOpenAPI(routes []apiRoute) map[string]any {
+	builder := &openAPIBuilder{schemas: map[string]any{}}
+	errorSchema := builder.schemaOf(reflect.TypeFor[Type.MessageDisplayError]())
+
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		operation := map[string]any{
+			"operationId": route.Name,
+			"summary":     route.Summary,
+			"tags":        []string{route.Tag},
+		}
+
+		parameters := []any{}
+		for _, match := range pathParamRegex.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		for _, param := range route.Query {
+			parameters = append(parameters, map[string]any{
+				"name":        param.Name,
+				"in":          "query",
+				"required":    param.Required,
+				"description": param.Description,
+				"schema":      map[string]any{"type": param.Type},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if route.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(builder.schemaOf(reflect.TypeOf(route.Request))),
+			}
+		}
+		if route.Upload != "" {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{route.Upload: map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}},
+			}
+		}
+
+		responses := map[string]any{
+			"default": map[string]any{"description": "錯誤", "content": jsonContent(errorSchema)},
+		}
+		if route.Response == nil {
+			responses[strconv.Itoa(http.StatusNoContent)] = map[string]any{"description": http.StatusText(http.StatusNoContent)}
+		} else {
+			status := route.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     jsonContent(builder.schemaOf(reflect.TypeOf(route.Response))),
+			}
+		}
+		operation["responses"] = responses
+
+		if route.Auth || route.OptionalAuth {
+			security := []any{map[string]any{"cookieAuth": []string{}}}
+			if !route.SessionOnly {
+				security = append(security, map[string]any{"bearerAuth": []string{}})
+				operation["x-required-scope"] = route.Scope
+			}
+			// 空的security requirement代表也可以不帶憑證
+			if route.OptionalAuth {
+				security = append(security, map[string]any{})
+			}
+			operation["security"] = security
+		}
+
+		if paths[route.Path] == nil {
+			paths[route.Path] = map[string]any{}
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "go-quizlet API",
+			"version": "1.0.0",
+		},
+		"servers": []any{map[string]any{"url": apiV1Prefix}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": builder.schemas,
+			"securitySchemes": map[string]any{
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": "JWT"},
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "個人API token(" + Consts.AccessTokenPrefix + "...)，需要具備x-required-scope的權限範圍",
+				},
+			},
+		},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// 有名字的struct放進components.schemas並回傳$ref，其他type直接回傳inline schema
+func (b *openAPIBuilder) schemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.objectSchema(t)
+		}
+		if _, ok := b.schemas[t.Name()]; !ok {
+			b.schemas[t.Name()] = map[string]any{} // 先佔位，避免遞迴的type無限展開
+			b.schemas[t.Name()] = b.objectSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		// any等無法判斷的type不限制格式
+		return map[string]any{}
+	}
+}
+
+func (b *openAPIBuilder) objectSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaOf(field.Type)
+		if slices.Contains(strings.Split(field.Tag.Get("validate"), ","), "required") {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,604 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+整理單字集：在單字集之間複製/移動單字、把單字集依order拆成好幾份、合併好幾個單字集
+每個操作的所有寫入(包含版本紀錄)都在同一個transaction裡，任何一步失敗或版本對不上都不會留下寫一半的結果
+搬進來的單字一律產生新的wordID，避免跟目標單字集(例如自己的fork)裡的單字撞ID
+--------------------------------------------------------------
+*/
+
+const (
+	transferModeCopy = "copy"
+	transferModeMove = "move"
+)
+
+// 在同一個transaction裡執行fn，fn回傳錯誤就回滾
+func withTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	session, err := DB.Client.StartSession()
+	if err != nil {
+		return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	defer session.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if err := fn(sc); err != nil {
+			session.AbortTransaction(sc)
+			return err
+		}
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Type.Timeout("超時錯誤 請重試")
+	}
+	return err
+}
+
+// 依order排序的複本，不改到原本的slice
+func sortedByOrder(words []Type.Word) []Type.Word {
+	sorted := slices.Clone(words)
+	slices.SortStableFunc(sorted, func(a Type.Word, b Type.Word) int {
+		return a.Order - b.Order
+	})
+	return sorted
+}
+
+func maxWordOrder(words []Type.Word) int {
+	order := 0
+	for _, word := range words {
+		order = max(order, word.Order)
+	}
+	return order
+}
+
+// 記著結果中已經有的單字，用來偵測重複
+type duplicateDetector struct {
+	seen       map[string]string // 正規化後的單字 -> 結果中的wordID
+	skip       bool
+	duplicates []Type.DuplicateWord
+}
+
+func newDuplicateDetector(existing []Type.Word, skip bool) *duplicateDetector {
+	detector := &duplicateDetector{seen: map[string]string{}, skip: skip, duplicates: []Type.DuplicateWord{}}
+	for _, word := range existing {
+		key := utils.NormalizeVocabulary(word.Vocabulary)
+		if _, ok := detector.seen[key]; !ok {
+			detector.seen[key] = word.ID
+		}
+	}
+	return detector
+}
+
+// 回傳這個單字要不要加進結果，重複的單字會記錄下來，skip時不加
+func (detector *duplicateDetector) accept(sourceID string, word Type.Word, newWordID string) bool {
+	key := utils.NormalizeVocabulary(word.Vocabulary)
+	if existingID, ok := detector.seen[key]; ok {
+		detector.duplicates = append(detector.duplicates, Type.DuplicateWord{
+			WordSetID:   sourceID,
+			WordID:      word.ID,
+			Vocabulary:  word.Vocabulary,
+			DuplicateOf: existingID,
+			Skipped:     detector.skip,
+		})
+		return !detector.skip
+	}
+	detector.seen[key] = newWordID
+	return true
+}
+
+// 在transaction中一次拿多個單字集，有任何一個不存在就回NotFound
+func findWordSetsInSession(sc mongo.SessionContext, wordSetIDs []string) (map[string]*Type.WordSet, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	cursor, err := coll.Find(sc, liveWordSetFilter(bson.M{"id": bson.M{"$in": wordSetIDs}}))
+	if err != nil {
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	var found []Type.WordSet
+	if err := cursor.All(sc, &found); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	wordSets := make(map[string]*Type.WordSet, len(found))
+	for i := range found {
+		wordSets[found[i].ID] = &found[i]
+	}
+	for _, wordSetID := range wordSetIDs {
+		if _, ok := wordSets[wordSetID]; !ok {
+			return nil, Type.NotFound("查無單字集")
+		}
+	}
+	return wordSets, nil
+}
+
+// 作者或editor協作者可以直接拿單字，別人的單字集要讀得到而且允許複製
+func canCopyWordsFrom(ctx context.Context, wordSet *Type.WordSet, userID string) error {
+	if hasWordSetRole(wordSet, userID, Consts.WordSetRoleEditor) {
+		return nil
+	}
+	if err := canReadWordSet(ctx, wordSet, userID, ""); err != nil {
+		return err
+	}
+	if !wordSet.AllowCopy {
+		return Type.Forbidden("此單字集拒絕複製")
+	}
+	return nil
+}
+
+// 依order拿出指定的單字，有不存在的wordID就整個失敗
+func pickWords(wordSet *Type.WordSet, wordIDs []string) ([]Type.Word, error) {
+	wanted := make(map[string]bool, len(wordIDs))
+	for _, wordID := range wordIDs {
+		wanted[wordID] = true
+	}
+	picked := []Type.Word{}
+	for _, word := range sortedByOrder(wordSet.Words) {
+		if wanted[word.ID] {
+			picked = append(picked, word)
+			delete(wanted, word.ID)
+		}
+	}
+	if len(wanted) > 0 {
+		missing := slices.DeleteFunc(slices.Clone(wordIDs), func(wordID string) bool {
+			return !wanted[wordID]
+		})
+		return nil, Type.NotFound("單字集中查無%d個指定的單字").WithArgs(len(missing)).WithDetails(missing)
+	}
+	return picked, nil
+}
+
+// 在transaction中用version當filter換掉整個單字列表並記錄版本，回傳更新後的單字集
+func replaceWordsInSession(sc mongo.SessionContext, before *Type.WordSet, version int, words []Type.Word, action string) (*Type.WordSet, error) {
+	if len(words) == 0 {
+		return nil, Type.BadRequest("單字集至少要有一個單字")
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	filter := versionFilter(liveWordSetFilter(bson.M{"id": before.ID}), version)
+	update := bson.M{"$set": bson.M{
+		"words":     words,
+		"wordCnt":   len(words),
+		"updatedAt": utils.GetNow(),
+		"updatedBy": userIDFromContext(sc),
+	}, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var after Type.WordSet
+	if err := coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&after); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, versionConflictOr(sc, before.ID, version, Type.NotFound("查無此單字集"))
+		}
+		return nil, Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	if err := recordWordSetRevision(sc, before.ID, before, action, 0); err != nil {
+		return nil, Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	return &after, nil
+}
+
+// 在transaction中新建單字集，放進使用者的自創單字集並記錄第一個版本
+func insertWordSetInSession(sc mongo.SessionContext, wordSet *Type.WordSet, action string) error {
+	wordSetColl := DB.Client.Database("go-quizlet").Collection("wordSets")
+	userColl := DB.Client.Database("go-quizlet").Collection("users")
+
+	if _, err := wordSetColl.InsertOne(sc, wordSet); err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	res, err := userColl.UpdateOne(sc, bson.M{"id": wordSet.AuthorID}, bson.M{"$push": bson.M{"createdWordSets": wordSet.ID}})
+	if err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return Type.NotFound("查無使用者")
+	}
+	if err := recordWordSetRevision(sc, wordSet.ID, nil, action, 0); err != nil {
+		return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	return nil
+}
+
+// 從sources複製或移動單字接到目標單字集的最後面
+// 目標以及move的來源需要editor權限並且版本號要對，copy別人的單字集要允許複製
+func transferWords(ctx context.Context, targetID string, version int, request Type.V1TransferWordsRequest) (*Type.V1TransferWordsResponse, error) {
+	userID := userIDFromContext(ctx)
+	if len(request.Sources) > Consts.MaxReorganizeWordSets {
+		return nil, Type.BadRequest("一次最多從%d個單字集搬移單字").WithArgs(Consts.MaxReorganizeWordSets)
+	}
+	wordSetIDs := []string{targetID}
+	for _, source := range request.Sources {
+		if slices.Contains(wordSetIDs, source.WordSetID) {
+			return nil, Type.BadRequest("來源單字集重複或跟目標相同")
+		}
+		if request.Mode == transferModeMove && source.Version == nil {
+			return nil, Type.BadRequest("移動單字需要帶上來源單字集的版本號")
+		}
+		wordSetIDs = append(wordSetIDs, source.WordSetID)
+	}
+
+	response := Type.V1TransferWordsResponse{}
+	var movedFrom []*Type.WordSet
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		wordSets, err := findWordSetsInSession(sc, wordSetIDs)
+		if err != nil {
+			return err
+		}
+		target := wordSets[targetID]
+		if !hasWordSetRole(target, userID, Consts.WordSetRoleEditor) {
+			return Type.Forbidden("使用者無權限更改!")
+		}
+		if target.Version != version {
+			return versionConflictOr(sc, targetID, version, Type.NotFound("查無此單字集"))
+		}
+
+		detector := newDuplicateDetector(target.Words, request.SkipDuplicates)
+		nextOrder := maxWordOrder(target.Words) + 1
+		added := []Type.Word{}
+		starredAdded := []string{}
+		movedFrom = nil
+		for _, source := range request.Sources {
+			wordSet := wordSets[source.WordSetID]
+			if request.Mode == transferModeMove {
+				if !hasWordSetRole(wordSet, userID, Consts.WordSetRoleEditor) {
+					return Type.Forbidden("使用者無權限更改!")
+				}
+			} else if err := canCopyWordsFrom(sc, wordSet, userID); err != nil {
+				return err
+			}
+			picked, err := pickWords(wordSet, source.WordIDs)
+			if err != nil {
+				return err
+			}
+			// 呼叫者自己在來源加的星號跟著單字過去
+			starred, err := loadStarredWords(sc, userID, wordSet)
+			if err != nil {
+				return err
+			}
+
+			taken := map[string]bool{}
+			for _, word := range picked {
+				newWord := word
+				newWord.ID = utils.GenerateID()
+				newWord.Order = nextOrder
+				newWord.Star = false
+				newWord = fitWordFields(target.Template, newWord)
+				if !detector.accept(wordSet.ID, word, newWord.ID) {
+					continue
+				}
+				nextOrder++
+				added = append(added, newWord)
+				taken[word.ID] = true
+				if starred[word.ID] {
+					starredAdded = append(starredAdded, newWord.ID)
+				}
+			}
+
+			if request.Mode == transferModeMove && len(taken) > 0 {
+				remaining := slices.DeleteFunc(slices.Clone(wordSet.Words), func(word Type.Word) bool {
+					return taken[word.ID]
+				})
+				if _, err := replaceWordsInSession(sc, wordSet, *source.Version, remaining, Consts.RevisionActionTransfer); err != nil {
+					return err
+				}
+				movedFrom = append(movedFrom, wordSet)
+			}
+		}
+
+		// 不允許重複的單字集，沒有選擇跳過就整個拒絕
+		if target.DuplicatePolicy == Consts.DuplicatePolicyReject && !request.SkipDuplicates && len(detector.duplicates) > 0 {
+			return Type.DuplicateWords("有%d組重複的單字 此單字集不允許重複").WithArgs(len(detector.duplicates)).WithDetails(detector.duplicates)
+		}
+		response.Transferred = len(added)
+		response.Duplicates = detector.duplicates
+		if len(added) == 0 {
+			response.WordSet = *target
+			return nil
+		}
+		after, err := replaceWordsInSession(sc, target, version, append(slices.Clone(target.Words), added...), Consts.RevisionActionTransfer)
+		if err != nil {
+			return err
+		}
+		if len(starredAdded) > 0 {
+			if err := setWordStars(sc, userID, after, starredAdded, true); err != nil {
+				return err
+			}
+		}
+		if err := applyWordStars(sc, userID, after); err != nil {
+			return err
+		}
+		response.WordSet = *after
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Transferred > 0 {
+		reportWordSetVersion(ctx, response.WordSet.Version)
+		emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+			WordSetID: targetID, Title: response.WordSet.Title, AuthorID: response.WordSet.AuthorID, ActorID: userID,
+		})
+	}
+	for _, wordSet := range movedFrom {
+		notifyLiveRoom(ctx, wordSet.ID)
+		emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+			WordSetID: wordSet.ID, Title: wordSet.Title, AuthorID: wordSet.AuthorID, ActorID: userID,
+		})
+	}
+	loggerFromContext(ctx).Info("words transferred", "wordSetID", targetID, "mode", request.Mode,
+		"sources", len(request.Sources), "words", response.Transferred, "duplicates", len(response.Duplicates))
+	return &response, nil
+}
+
+// 依order把單字平均切成parts份，前面幾份多分一個
+func splitWordsByOrder(words []Type.Word, parts int) [][]Type.Word {
+	sorted := sortedByOrder(words)
+	chunks := make([][]Type.Word, 0, parts)
+	size, extra := len(sorted)/parts, len(sorted)%parts
+	start := 0
+	for i := 0; i < parts; i++ {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		chunks = append(chunks, sorted[start:end])
+		start = end
+	}
+	return chunks
+}
+
+// 拆出來的單字集標題，加上(第幾份/共幾份)，太長就截掉原標題
+func splitPartTitle(title string, part int, parts int) string {
+	suffix := fmt.Sprintf(" (%d/%d)", part, parts)
+	runes := []rune(title)
+	for len(string(runes))+len(suffix) > Consts.MaxTitleLen && len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + suffix
+}
+
+// 把單字集依order拆成parts份，原本的單字集留下第一份，其他份變成新的單字集(僅限作者)
+// 新單字集沿用原本的敘述、公開範圍以及複製設定，協作者不會跟著過去
+func splitWordSet(ctx context.Context, wordSetID string, version int, parts int) (*Type.V1SplitWordSetResponse, error) {
+	userID := userIDFromContext(ctx)
+	if parts > Consts.MaxSplitParts {
+		return nil, Type.BadRequest("一個單字集最多拆成%d份").WithArgs(Consts.MaxSplitParts)
+	}
+
+	response := Type.V1SplitWordSetResponse{}
+	var created []Type.WordSet
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		wordSets, err := findWordSetsInSession(sc, []string{wordSetID})
+		if err != nil {
+			return err
+		}
+		source := wordSets[wordSetID]
+		if !hasWordSetRole(source, userID, Consts.WordSetRoleOwner) {
+			return Type.Forbidden("使用者無權限更改!")
+		}
+		if source.Version != version {
+			return versionConflictOr(sc, wordSetID, version, Type.NotFound("查無此單字集"))
+		}
+		if len(source.Words) < parts {
+			return Type.BadRequest("單字數量不足以拆成%d份").WithArgs(parts)
+		}
+
+		chunks := splitWordsByOrder(source.Words, parts)
+		kept, err := replaceWordsInSession(sc, source, version, chunks[0], Consts.RevisionActionSplit)
+		if err != nil {
+			return err
+		}
+		response.WordSet = *kept
+
+		// 新的單字集沒有星號紀錄的人(作者)沿用單字裡的star，先換成作者目前的星號
+		starred, err := loadStarredWords(sc, userID, source)
+		if err != nil {
+			return err
+		}
+		visibility := wordSetVisibility(source)
+		created = make([]Type.WordSet, 0, parts-1)
+		response.Parts = make([]string, 0, parts-1)
+		for i, chunk := range chunks[1:] {
+			words := slices.Clone(chunk)
+			for j := range words {
+				words[j].Order = j + 1
+				words[j].Star = starred[words[j].ID]
+			}
+			part := Type.WordSet{
+				ID:          utils.GenerateID(),
+				Title:       splitPartTitle(source.Title, i+2, parts),
+				Description: source.Description,
+				AuthorID:    userID,
+				CreatedAt:   utils.GetTodayFormatted(),
+				UpdatedAt:   utils.GetNow(),
+				Words:       words,
+				ShouldSwap:  source.ShouldSwap,
+				LikedUsers:  []string{},
+				WordCnt:     len(words),
+				AllowCopy:   source.AllowCopy,
+				IsPublic:    visibility == Consts.VisibilityPublic,
+				Visibility:  visibility,
+				Template:    source.Template,
+			}
+			if err := insertWordSetInSession(sc, &part, Consts.RevisionActionSplit); err != nil {
+				return err
+			}
+			if err := moveWordStarsInSession(sc, wordSetID, part.ID, wordIDsOf(words)); err != nil {
+				return err
+			}
+			if err := moveWordReviewsInSession(sc, wordSetID, part.ID, wordIDsOf(words)); err != nil {
+				return err
+			}
+			created = append(created, part)
+			response.Parts = append(response.Parts, part.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reportWordSetVersion(ctx, response.WordSet.Version)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: response.WordSet.Title, AuthorID: userID, ActorID: userID,
+	})
+	for _, part := range created {
+		emitWordSetEvent(ctx, Consts.WebhookEventWordSetCreated, Type.WebhookWordSetData{
+			WordSetID: part.ID, Title: part.Title, AuthorID: userID, ActorID: userID,
+		})
+	}
+	loggerFromContext(ctx).Info("wordSet split", "wordSetID", wordSetID, "parts", parts)
+	return &response, nil
+}
+
+// 依wordSetIDs的順序把單字接成一個新的單字集，來源單字集本身不變，除非trashSources
+// 自己是作者的來源保留星號，其他來源的星號清掉
+func mergeWordSets(ctx context.Context, request Type.V1MergeWordSetsRequest) (*Type.V1MergeWordSetsResponse, error) {
+	userID := userIDFromContext(ctx)
+	if err := validateWordSetText(request.Title, request.Description); err != nil {
+		return nil, err
+	}
+	if len(request.WordSetIDs) > Consts.MaxReorganizeWordSets {
+		return nil, Type.BadRequest("一次最多合併%d個單字集").WithArgs(Consts.MaxReorganizeWordSets)
+	}
+	visibility, err := resolveVisibility(request.Visibility, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged Type.WordSet
+	var duplicates []Type.DuplicateWord
+	err = withTransaction(ctx, func(sc mongo.SessionContext) error {
+		wordSets, err := findWordSetsInSession(sc, request.WordSetIDs)
+		if err != nil {
+			return err
+		}
+
+		detector := newDuplicateDetector(nil, request.SkipDuplicates)
+		words := []Type.Word{}
+		// 合併後用第一個單字集的卡片模板，其他單字集多的自訂欄位會拿掉
+		template := wordSets[request.WordSetIDs[0]].Template
+		for _, wordSetID := range request.WordSetIDs {
+			source := wordSets[wordSetID]
+			isOwner := hasWordSetRole(source, userID, Consts.WordSetRoleOwner)
+			if request.TrashSources && !isOwner {
+				return Type.Forbidden("只有作者可以把單字集移到垃圾桶")
+			}
+			if err := canCopyWordsFrom(sc, source, userID); err != nil {
+				return err
+			}
+			// 合併後的單字集還沒有星號紀錄，作者(呼叫者)會沿用單字裡的star
+			starred, err := loadStarredWords(sc, userID, source)
+			if err != nil {
+				return err
+			}
+			for _, word := range sortedByOrder(source.Words) {
+				newWord := word
+				newWord.ID = utils.GenerateID()
+				newWord.Order = len(words) + 1
+				newWord.Star = starred[word.ID]
+				newWord = fitWordFields(template, newWord)
+				if detector.accept(wordSetID, word, newWord.ID) {
+					words = append(words, newWord)
+				}
+			}
+		}
+		if len(words) == 0 {
+			return Type.BadRequest("單字集至少要有一個單字")
+		}
+
+		merged = Type.WordSet{
+			ID:          utils.GenerateID(),
+			Title:       strings.TrimSpace(request.Title),
+			Description: request.Description,
+			AuthorID:    userID,
+			CreatedAt:   utils.GetTodayFormatted(),
+			UpdatedAt:   utils.GetNow(),
+			Words:       words,
+			LikedUsers:  []string{},
+			WordCnt:     len(words),
+			AllowCopy:   request.AllowCopy,
+			IsPublic:    visibility == Consts.VisibilityPublic,
+			Visibility:  visibility,
+			Template:    template,
+		}
+		if err := insertWordSetInSession(sc, &merged, Consts.RevisionActionMerge); err != nil {
+			return err
+		}
+		if request.TrashSources {
+			for _, wordSetID := range request.WordSetIDs {
+				if err := moveWordSetToTrash(sc, wordSetID); err != nil {
+					return err
+				}
+			}
+		}
+		duplicates = detector.duplicates
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetCreated, Type.WebhookWordSetData{
+		WordSetID: merged.ID, Title: merged.Title, AuthorID: userID, ActorID: userID,
+	})
+	loggerFromContext(ctx).Info("wordSets merged", "wordSetID", merged.ID, "sources", len(request.WordSetIDs),
+		"wordCnt", merged.WordCnt, "duplicates", len(duplicates), "trashSources", request.TrashSources)
+	return &Type.V1MergeWordSetsResponse{ID: merged.ID, WordCnt: merged.WordCnt, Duplicates: duplicates}, nil
+}
+
+/* ---------------- handlers ---------------- */
+
+func v1TransferWords(r *http.Request) (any, error) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1TransferWordsRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return transferWords(r.Context(), r.PathValue("wordSetID"), version, request)
+}
+
+func v1SplitWordSet(r *http.Request) (any, error) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1SplitWordSetRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return splitWordSet(r.Context(), r.PathValue("wordSetID"), version, request.Parts)
+}
+
+func v1MergeWordSets(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1MergeWordSetsRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return mergeWordSets(r.Context(), request)
+}
@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+跨單字集的複習牌組，不存在DB，每次依規則從使用者看得到的單字集組出來
+starred：所有加星號的單字
+missed：最近幾天答錯的單字，答錯越多次越前面
+due：作答過且到了該複習時間的單字(Leitner盒子，答對往後延，答錯馬上要再複習)
+作答結果存在wordReviews collection(每個使用者每個單字一筆)
+回傳FullWordCardType，單字已經依各自單字集的shouldSwap換好，前端不用再swap
+--------------------------------------------------------------
+*/
+
+// 記錄作答結果，單字集要看得到、單字要存在
+func recordStudyResults(ctx context.Context, userID string, shareToken string, results []Type.StudyResult) error {
+	if len(results) > Consts.MaxStudyResults {
+		return Type.BadRequest("一次最多回報%d個作答結果").WithArgs(Consts.MaxStudyResults)
+	}
+	wordSetIDs := []string{}
+	wordIDs := []string{}
+	for _, result := range results {
+		if !slices.Contains(wordSetIDs, result.WordSetID) {
+			wordSetIDs = append(wordSetIDs, result.WordSetID)
+		}
+		wordIDs = append(wordIDs, result.WordID)
+	}
+	if len(wordSetIDs) > Consts.MaxReviewDeckWordSets {
+		return Type.BadRequest("最多指定%d個單字集").WithArgs(Consts.MaxReviewDeckWordSets)
+	}
+	for _, wordSetID := range wordSetIDs {
+		wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+		if err != nil {
+			return err
+		}
+		exists := make(map[string]bool, len(wordSet.Words))
+		for _, word := range wordSet.Words {
+			exists[word.ID] = true
+		}
+		for _, result := range results {
+			if result.WordSetID == wordSetID && !exists[result.WordID] {
+				return Type.NotFound("查無此單字或單字集")
+			}
+		}
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("wordReviews")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	cursor, err := coll.Find(writingContext, bson.M{"userID": userID, "wordSetID": bson.M{"$in": wordSetIDs}, "wordID": bson.M{"$in": wordIDs}})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	var existing []Type.WordReview
+	if err := cursor.All(writingContext, &existing); err != nil {
+		return Type.Internal("解析失敗").Wrap(err)
+	}
+	reviews := map[string]*Type.WordReview{}
+	for i := range existing {
+		reviews[existing[i].WordSetID+"/"+existing[i].WordID] = &existing[i]
+	}
+
+	// 同一個單字回報多次就依序套用
+	now := time.Now()
+	changed := []string{}
+	for _, result := range results {
+		key := result.WordSetID + "/" + result.WordID
+		review, ok := reviews[key]
+		if !ok {
+			review = &Type.WordReview{UserID: userID, WordSetID: result.WordSetID, WordID: result.WordID}
+			reviews[key] = review
+		}
+		applyStudyResult(review, result.Correct, now)
+		if !slices.Contains(changed, key) {
+			changed = append(changed, key)
+		}
+	}
+	models := make([]mongo.WriteModel, 0, len(changed))
+	for _, key := range changed {
+		review := reviews[key]
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"userID": userID, "wordSetID": review.WordSetID, "wordID": review.WordID}).
+			SetReplacement(review).
+			SetUpsert(true))
+	}
+	if _, err := coll.BulkWrite(writingContext, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	loggerFromContext(ctx).Info("study results recorded", "userID", userID, "results", len(results))
+	return nil
+}
+
+// 答對移到下一盒並延後複習時間，答錯回到第0盒馬上要再複習
+func applyStudyResult(review *Type.WordReview, correct bool, now time.Time) {
+	review.LastReviewedAt = now.Unix()
+	if correct {
+		review.CorrectCnt++
+		review.Box = min(review.Box+1, len(Consts.ReviewIntervals)-1)
+	} else {
+		review.MissedCnt++
+		review.Box = 0
+		review.LastMissedAt = now.Unix()
+	}
+	review.DueAt = now.Add(Consts.ReviewIntervals[review.Box]).Unix()
+}
+
+// 單字從一個單字集搬到另一個單字集但wordID不變(拆分)，所有人的作答紀錄跟著搬過去
+func moveWordReviewsInSession(sc mongo.SessionContext, fromWordSetID string, toWordSetID string, wordIDs []string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordReviews")
+	filter := bson.M{"wordSetID": fromWordSetID, "wordID": bson.M{"$in": wordIDs}}
+	if _, err := coll.UpdateMany(sc, filter, bson.M{"$set": bson.M{"wordSetID": toWordSetID}}); err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	return nil
+}
+
+// 依規則組出複習牌組，wordSetIDs不是空的話只從這些單字集挑
+func buildReviewDeck(ctx context.Context, userID string, rule string, days int, wordSetIDs []string) (*Type.FullWordCardType, error) {
+	if !slices.Contains(Consts.ReviewDeckRules, rule) {
+		return nil, Type.BadRequest("複習方式錯誤(starred, missed, due)")
+	}
+	if rule == Consts.ReviewDeckMissed && (days < 1 || days > Consts.MaxReviewMissedDays) {
+		return nil, Type.BadRequest("天數需介於1到%d之間").WithArgs(Consts.MaxReviewMissedDays)
+	}
+	if len(wordSetIDs) > Consts.MaxReviewDeckWordSets {
+		return nil, Type.BadRequest("最多指定%d個單字集").WithArgs(Consts.MaxReviewDeckWordSets)
+	}
+	database := DB.Client.Database("go-quizlet")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 使用者自己的星號，每種牌組都要套到單字上
+	starFilter := bson.M{"userID": userID}
+	if len(wordSetIDs) > 0 {
+		starFilter["wordSetID"] = bson.M{"$in": wordSetIDs}
+	}
+	var allStars []Type.WordStars
+	if err := findAll(findingContext, database.Collection("wordStars"), starFilter, &allStars); err != nil {
+		return nil, err
+	}
+	stars := make(map[string]*Type.WordStars, len(allStars))
+	for i := range allStars {
+		stars[allStars[i].WordSetID] = &allStars[i]
+	}
+
+	now := time.Now().Unix()
+	reviews := map[string]Type.WordReview{}
+	wordSetFilter := bson.M{}
+	if rule == Consts.ReviewDeckStarred {
+		starredWordSetIDs := make([]string, 0, len(stars))
+		for wordSetID := range stars {
+			starredWordSetIDs = append(starredWordSetIDs, wordSetID)
+		}
+		// 自己的單字集還沒有wordStars紀錄時沿用單字集裡的星號
+		wordSetFilter["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"id": bson.M{"$in": starredWordSetIDs}},
+			bson.M{"authorID": userID, "words.star": true},
+		}}}
+		if len(wordSetIDs) > 0 {
+			wordSetFilter["id"] = bson.M{"$in": wordSetIDs}
+		}
+	} else {
+		reviewFilter := bson.M{"userID": userID}
+		if rule == Consts.ReviewDeckMissed {
+			reviewFilter["lastMissedAt"] = bson.M{"$gte": now - int64(days)*24*60*60}
+		} else {
+			reviewFilter["dueAt"] = bson.M{"$lte": now}
+		}
+		if len(wordSetIDs) > 0 {
+			reviewFilter["wordSetID"] = bson.M{"$in": wordSetIDs}
+		}
+		var allReviews []Type.WordReview
+		if err := findAll(findingContext, database.Collection("wordReviews"), reviewFilter, &allReviews); err != nil {
+			return nil, err
+		}
+		reviewedWordSetIDs := []string{}
+		for _, review := range allReviews {
+			reviews[review.WordSetID+"/"+review.WordID] = review
+			if !slices.Contains(reviewedWordSetIDs, review.WordSetID) {
+				reviewedWordSetIDs = append(reviewedWordSetIDs, review.WordSetID)
+			}
+		}
+		wordSetFilter["id"] = bson.M{"$in": reviewedWordSetIDs}
+	}
+
+	var wordSets []Type.WordSet
+	if err := findAll(findingContext, database.Collection("wordSets"), readableWordSetFilter(liveWordSetFilter(wordSetFilter), userID), &wordSets); err != nil {
+		return nil, err
+	}
+	// 有指定單字集就照指定的順序，沒有就照標題
+	slices.SortStableFunc(wordSets, func(a Type.WordSet, b Type.WordSet) int {
+		if len(wordSetIDs) > 0 {
+			return slices.Index(wordSetIDs, a.ID) - slices.Index(wordSetIDs, b.ID)
+		}
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	type deckWord struct {
+		word      Type.Word
+		wordSetID string
+		review    Type.WordReview
+	}
+	entries := []deckWord{}
+	for i := range wordSets {
+		wordSet := &wordSets[i]
+		starred := starredWordsOf(userID, wordSet, stars[wordSet.ID])
+		for _, word := range sortedByOrder(wordSet.Words) {
+			review, reviewed := reviews[wordSet.ID+"/"+word.ID]
+			if (rule == Consts.ReviewDeckStarred && !starred[word.ID]) || (rule != Consts.ReviewDeckStarred && !reviewed) {
+				continue
+			}
+			word.Star = starred[word.ID]
+			if wordSet.ShouldSwap {
+				word.Vocabulary, word.Definition = word.Definition, word.Vocabulary
+				word.VocabularySound, word.DefinitionSound = word.DefinitionSound, word.VocabularySound
+			}
+			entries = append(entries, deckWord{word: word, wordSetID: wordSet.ID, review: review})
+		}
+	}
+	switch rule {
+	case Consts.ReviewDeckMissed:
+		slices.SortStableFunc(entries, func(a deckWord, b deckWord) int {
+			return cmp.Or(b.review.MissedCnt-a.review.MissedCnt, cmp.Compare(b.review.LastMissedAt, a.review.LastMissedAt))
+		})
+	case Consts.ReviewDeckDue:
+		slices.SortStableFunc(entries, func(a deckWord, b deckWord) int {
+			return cmp.Compare(a.review.DueAt, b.review.DueAt)
+		})
+	}
+
+	locale := i18n.FromContext(ctx)
+	// 單字來自不同單字集而且已經換好，只用預設模板
+	deck := &Type.FullWordCardType{ID: "review-" + rule, Words: []Type.Word{}, WordSetIDs: map[string]string{}, Template: defaultCardTemplate(ctx, false)}
+	switch rule {
+	case Consts.ReviewDeckStarred:
+		deck.Title = i18n.T(locale, "星號單字")
+	case Consts.ReviewDeckMissed:
+		deck.ID = deck.ID + "-" + strconv.Itoa(days)
+		deck.Title = i18n.T(locale, "最近%d天答錯的單字", days)
+	case Consts.ReviewDeckDue:
+		deck.Title = i18n.T(locale, "該複習的單字")
+	}
+	for _, entry := range entries {
+		if len(deck.Words) >= Consts.MaxReviewDeckWords {
+			break
+		}
+		// 複製來的單字集wordID跟原本的一樣，同一個單字只放一次
+		if _, ok := deck.WordSetIDs[entry.word.ID]; ok {
+			continue
+		}
+		entry.word.Order = len(deck.Words) + 1
+		deck.Words = append(deck.Words, entry.word)
+		deck.WordSetIDs[entry.word.ID] = entry.wordSetID
+	}
+	return deck, nil
+}
+
+// 把查詢結果全部decode進result
+func findAll(ctx context.Context, coll *mongo.Collection, filter bson.M, result any) error {
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if err := cursor.All(ctx, result); err != nil {
+		return Type.Internal("解析失敗").Wrap(err)
+	}
+	return nil
+}
+
+// query param的wordSetIDs用逗號分隔
+func splitWordSetIDs(value string) []string {
+	wordSetIDs := []string{}
+	for _, wordSetID := range strings.Split(value, ",") {
+		if wordSetID = strings.TrimSpace(wordSetID); wordSetID != "" && !slices.Contains(wordSetIDs, wordSetID) {
+			wordSetIDs = append(wordSetIDs, wordSetID)
+		}
+	}
+	return wordSetIDs
+}
+
+/* ---------------- handlers ---------------- */
+
+func handleRecordStudyResults(ctx context.Context, request Type.RecordStudyResultsRequest) (string, error) {
+	return "", recordStudyResults(ctx, request.UserID, request.ShareToken, request.Results)
+}
+
+func handleGetReviewDeck(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r)
+	if userID == "" {
+		CallToLogInJson(w, r, Type.Unauthorized("使用者未登入! 或憑證已過期!"))
+		return
+	}
+	days, err := queryInt(r, "days", Consts.DefaultReviewMissedDays)
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	deck, err := buildReviewDeck(r.Context(), userID, r.PathValue("rule"), days, splitWordSetIDs(r.URL.Query().Get("wordSetIDs")))
+	if err != nil {
+		writePageErrorJson(w, r, err)
+		return
+	}
+	if err := writeDataJson(w, deck); err != nil {
+		writePageErrorJson(w, r, Type.Internal("未知錯誤 請重試"))
+	}
+}
+
+func v1RecordStudyResults(r *http.Request) (any, error) {
+	input, err := decodeAPIBody[Type.V1RecordStudyResultsRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, recordStudyResults(r.Context(), userIDFromContext(r.Context()), shareTokenFromRequest(r), input.Results)
+}
+
+func v1GetReviewDeck(r *http.Request) (any, error) {
+	days, err := queryInt(r, "days", Consts.DefaultReviewMissedDays)
+	if err != nil {
+		return nil, err
+	}
+	return buildReviewDeck(r.Context(), userIDFromContext(r.Context()), r.URL.Query().Get("rule"), days, splitWordSetIDs(r.URL.Query().Get("wordSetIDs")))
+}
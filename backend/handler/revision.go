@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+單字集版本紀錄
+每次變更都存一份變更後的快照以及跟前一版的差異(新增/刪除/修改的單字)，可以還原到任何一個版本
+--------------------------------------------------------------
+*/
+
+const revisionPageSize = 20
+
+func snapshotOf(wordSet *Type.WordSet) *Type.WordSetSnapshot {
+	return &Type.WordSetSnapshot{
+		Title:       wordSet.Title,
+		Description: wordSet.Description,
+		ShouldSwap:  wordSet.ShouldSwap,
+		Words:       wordSet.Words,
+		Template:    wordSet.Template,
+	}
+}
+
+// 算出before到after的差異，before是nil代表新建的單字集
+// 星號是個人複習用的標記，不算在差異裡
+func diffWordSets(before *Type.WordSetSnapshot, after *Type.WordSetSnapshot) Type.WordSetChanges {
+	changes := Type.WordSetChanges{
+		AddedWords:   []Type.Word{},
+		RemovedWords: []Type.Word{},
+		ChangedWords: []Type.WordChange{},
+	}
+	if before == nil {
+		before = &Type.WordSetSnapshot{}
+	}
+	if before.Title != after.Title {
+		changes.Title = &Type.FieldChange{Before: before.Title, After: after.Title}
+	}
+	if before.Description != after.Description {
+		changes.Description = &Type.FieldChange{Before: before.Description, After: after.Description}
+	}
+	changes.Template = !sameCardTemplate(before.Template, after.Template)
+
+	beforeWords := make(map[string]Type.Word, len(before.Words))
+	for _, word := range before.Words {
+		beforeWords[word.ID] = word
+	}
+	for _, word := range after.Words {
+		old, ok := beforeWords[word.ID]
+		if !ok {
+			changes.AddedWords = append(changes.AddedWords, word)
+			continue
+		}
+		delete(beforeWords, word.ID)
+		if fields := changedWordFields(old, word); len(fields) > 0 {
+			changes.ChangedWords = append(changes.ChangedWords, Type.WordChange{WordID: word.ID, Fields: fields, Before: old, After: word})
+		}
+	}
+	// 保持原本的順序
+	for _, word := range before.Words {
+		if _, ok := beforeWords[word.ID]; ok {
+			changes.RemovedWords = append(changes.RemovedWords, word)
+		}
+	}
+	return changes
+}
+
+func changedWordFields(before Type.Word, after Type.Word) []string {
+	fields := []string{}
+	if before.Vocabulary != after.Vocabulary {
+		fields = append(fields, "vocabulary")
+	}
+	if before.Definition != after.Definition {
+		fields = append(fields, "definition")
+	}
+	if before.VocabularySound != after.VocabularySound {
+		fields = append(fields, "vocabularySound")
+	}
+	if before.DefinitionSound != after.DefinitionSound {
+		fields = append(fields, "definitionSound")
+	}
+	if before.Pronunciation != after.Pronunciation {
+		fields = append(fields, "pronunciation")
+	}
+	if before.PartOfSpeech != after.PartOfSpeech {
+		fields = append(fields, "partOfSpeech")
+	}
+	if !slices.Equal(before.Meanings, after.Meanings) {
+		fields = append(fields, "meanings")
+	}
+	if !slices.Equal(before.Examples, after.Examples) {
+		fields = append(fields, "examples")
+	}
+	if !maps.Equal(before.Fields, after.Fields) {
+		fields = append(fields, "fields")
+	}
+	if !sameWordImage(before.Image, after.Image) {
+		fields = append(fields, "image")
+	}
+	if !sameWordAudio(before.VocabularyAudio, after.VocabularyAudio) {
+		fields = append(fields, "vocabularyAudio")
+	}
+	if !sameWordAudio(before.DefinitionAudio, after.DefinitionAudio) {
+		fields = append(fields, "definitionAudio")
+	}
+	if before.Order != after.Order {
+		fields = append(fields, "order")
+	}
+	return fields
+}
+
+func isEmptyChanges(changes Type.WordSetChanges) bool {
+	return changes.Title == nil && changes.Description == nil && !changes.Template &&
+		len(changes.AddedWords) == 0 && len(changes.RemovedWords) == 0 && len(changes.ChangedWords) == 0
+}
+
+// 還沒有任何版本紀錄的舊單字集，第一次變更時要多配一個編號給baseline
+func revisionCntIncrement(before *Type.WordSet) int {
+	if before != nil && before.RevisionCnt == 0 {
+		return 2
+	}
+	return 1
+}
+
+// 在transaction中呼叫，用$inc revisionCnt配發版本編號並拿回變更後的單字集，再寫入新版本
+// 因為會寫到wordSet本身，同時進行的transaction會互相衝突，編號不會重複
+// before是nil代表新建的單字集；沒有任何差異的edit不會產生新版本
+func recordWordSetRevision(sc mongo.SessionContext, wordSetID string, before *Type.WordSet, action string, restoredFrom int) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	update := bson.M{"$inc": bson.M{"revisionCnt": revisionCntIncrement(before)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var after Type.WordSet
+	if err := coll.FindOneAndUpdate(sc, bson.M{"id": wordSetID}, update, opts).Decode(&after); err != nil {
+		return err
+	}
+	return writeWordSetRevision(sc, before, &after, action, restoredFrom)
+}
+
+// 跟before比較並寫入新版本，after必須是配發編號的那次寫入回傳的結果，版本編號是after.RevisionCnt
+func writeWordSetRevision(ctx context.Context, before *Type.WordSet, after *Type.WordSet, action string, restoredFrom int) error {
+	revisionColl := DB.Client.Database("go-quizlet").Collection("wordSetRevisions")
+
+	var beforeSnapshot *Type.WordSetSnapshot
+	if before != nil {
+		beforeSnapshot = snapshotOf(before)
+	}
+	changes := diffWordSets(beforeSnapshot, snapshotOf(after))
+
+	number := after.RevisionCnt
+	now := utils.GetNow()
+	revisions := []any{}
+	if revisionCntIncrement(before) == 2 {
+		revisions = append(revisions, Type.WordSetRevision{
+			ID:        utils.GenerateID(),
+			WordSetID: after.ID,
+			Number:    number - 1,
+			EditorID:  before.AuthorID,
+			Action:    Consts.RevisionActionBaseline,
+			CreatedAt: now,
+			Changes:   diffWordSets(nil, beforeSnapshot),
+			Snapshot:  beforeSnapshot,
+		})
+	}
+	// 沒有差異的edit會留下沒用到的編號，編號只保證遞增不保證連續
+	if action != Consts.RevisionActionEdit || !isEmptyChanges(changes) {
+		revisions = append(revisions, Type.WordSetRevision{
+			ID:           utils.GenerateID(),
+			WordSetID:    after.ID,
+			Number:       number,
+			EditorID:     userIDFromContext(ctx),
+			Action:       action,
+			RestoredFrom: restoredFrom,
+			CreatedAt:    now,
+			Changes:      changes,
+			Snapshot:     snapshotOf(after),
+		})
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	if _, err := revisionColl.InsertMany(ctx, revisions); err != nil {
+		return err
+	}
+
+	// 只保留最近的MaxWordSetRevisions個版本
+	if number > Consts.MaxWordSetRevisions {
+		filter := bson.M{"wordSetID": after.ID, "number": bson.M{"$lte": number - Consts.MaxWordSetRevisions}}
+		if _, err := revisionColl.DeleteMany(ctx, filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 沒有transaction的變更用這個，after是updateWordSetWithRevision的回傳值，版本紀錄失敗不影響已經寫入的變更
+func recordWordSetRevisionOrLog(ctx context.Context, before *Type.WordSet, after *Type.WordSet, action string) {
+	recordingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := writeWordSetRevision(recordingContext, before, after, action, 0); err != nil {
+		loggerFromContext(ctx).Error("record wordSet revision failed", "wordSetID", after.ID, "action", action, "error", err)
+	}
+}
+
+// 沒有transaction的變更用這個：用version搶下這次編輯，同一個寫入裡配發版本編號，回傳寫入後的單字集
+// before必須是version那一版的內容(先用checkWordSetVersion確認)，版本紀錄的差異以及baseline都靠它
+func updateWordSetWithRevision(ctx context.Context, before *Type.WordSet, version int, filter bson.M, update bson.M, notFound error) (*Type.WordSet, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	inc, _ := update["$inc"].(bson.M)
+	if inc == nil {
+		inc = bson.M{}
+	}
+	inc["version"] = 1
+	inc["revisionCnt"] = revisionCntIncrement(before)
+	update["$inc"] = inc
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var after Type.WordSet
+	if err := coll.FindOneAndUpdate(writingContext, versionFilter(filter, version), update, opts).Decode(&after); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, versionConflictOr(ctx, before.ID, version, notFound)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		loggerFromContext(ctx).Error("update wordSet failed", "wordSetID", before.ID, "error", err)
+		return nil, Type.Internal("伺服器錯誤 請重試")
+	}
+	reportWordSetVersion(ctx, after.Version)
+	return &after, nil
+}
+
+// 啟動時幫已經有版本紀錄的單字集補上revisionCnt(目前最大的版本編號)
+func MigrateRevisionCounts(ctx context.Context) {
+	database := DB.Client.Database("go-quizlet")
+	migratingContext, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	missing, err := database.Collection("wordSets").Distinct(migratingContext, "id", bson.M{"revisionCnt": bson.M{"$exists": false}})
+	if err != nil {
+		slog.Error("find wordSets without revisionCnt failed", "error", err)
+		return
+	}
+	if len(missing) == 0 {
+		return
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"wordSetID": bson.M{"$in": missing}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$wordSetID", "latest": bson.M{"$max": "$number"}}}},
+	}
+	cursor, err := database.Collection("wordSetRevisions").Aggregate(migratingContext, pipeline)
+	if err != nil {
+		slog.Error("aggregate revision numbers failed", "error", err)
+		return
+	}
+	var latest []struct {
+		WordSetID string `bson:"_id"`
+		Latest    int    `bson:"latest"`
+	}
+	if err := cursor.All(migratingContext, &latest); err != nil {
+		slog.Error("decode revision numbers failed", "error", err)
+		return
+	}
+	models := make([]mongo.WriteModel, 0, len(latest))
+	for _, row := range latest {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": row.WordSetID, "revisionCnt": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{"revisionCnt": row.Latest}}))
+	}
+	if len(models) > 0 {
+		if _, err := database.Collection("wordSets").BulkWrite(migratingContext, models); err != nil {
+			slog.Error("migrate revision counts failed", "error", err)
+			return
+		}
+	}
+	// 剩下的是還沒有任何版本紀錄的單字集
+	if _, err := database.Collection("wordSets").UpdateMany(migratingContext,
+		bson.M{"revisionCnt": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"revisionCnt": 0}}); err != nil {
+		slog.Error("migrate revision counts failed", "error", err)
+		return
+	}
+	slog.Info("revision counts migrated", "wordSets", len(missing), "withRevisions", len(latest))
+}
+
+func findWordSetRevision(ctx context.Context, wordSetID string, numberParam string) (*Type.WordSetRevision, error) {
+	number, err := strconv.Atoi(numberParam)
+	if err != nil || number <= 0 {
+		return nil, Type.BadRequest("版本編號錯誤")
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetRevisions")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var revision Type.WordSetRevision
+	err = coll.FindOne(findingContext, bson.M{"wordSetID": wordSetID, "number": number}).Decode(&revision)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("查無此版本")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	return &revision, nil
+}
+
+func v1ListWordSetRevisions(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetRole(r.Context(), wordSetID, userIDFromContext(r.Context()), Consts.WordSetRoleViewer); err != nil {
+		return nil, err
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetRevisions")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().
+		SetSort(bson.M{"number": -1}).
+		SetSkip(int64(offset)).
+		SetLimit(revisionPageSize).
+		SetProjection(bson.M{"snapshot": 0})
+	cursor, err := coll.Find(findingContext, bson.M{"wordSetID": wordSetID}, opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	revisions := make([]Type.WordSetRevision, 0)
+	if err := cursor.All(findingContext, &revisions); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return revisions, nil
+}
+
+func v1GetWordSetRevision(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetRole(r.Context(), wordSetID, userIDFromContext(r.Context()), Consts.WordSetRoleViewer); err != nil {
+		return nil, err
+	}
+	return findWordSetRevision(r.Context(), wordSetID, r.PathValue("number"))
+}
+
+// 把單字集內容蓋回某個版本的快照，還原本身也會產生一個新版本，所以可以再還原回來
+func v1RestoreWordSetRevision(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	userID := userIDFromContext(r.Context())
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userID); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := findWordSetRevision(r.Context(), wordSetID, r.PathValue("number"))
+	if err != nil {
+		return nil, err
+	}
+	if revision.Snapshot == nil {
+		return nil, Type.NotFound("查無此版本")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	session, err := DB.Client.StartSession()
+	if err != nil {
+		return nil, Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	defer session.EndSession(ctx)
+
+	var restored Type.WordSet
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+		filter := versionFilter(bson.M{"id": wordSetID}, version)
+
+		var before Type.WordSet
+		if err := coll.FindOne(sc, filter).Decode(&before); err != nil {
+			session.AbortTransaction(sc)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return versionConflictOr(ctx, wordSetID, version, Type.NotFound("查無此單字集"))
+			}
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+
+		update := bson.M{"$set": bson.M{
+			"title":       revision.Snapshot.Title,
+			"description": revision.Snapshot.Description,
+			"shouldSwap":  revision.Snapshot.ShouldSwap,
+			"template":    revision.Snapshot.Template,
+			"words":       revision.Snapshot.Words,
+			"wordCnt":     len(revision.Snapshot.Words),
+			"updatedAt":   utils.GetNow(),
+			"updatedBy":   userID,
+		}, "$inc": bson.M{"version": 1}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		if err := coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&restored); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if err := recordWordSetRevision(sc, wordSetID, &before, Consts.RevisionActionRestore, revision.Number); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, err
+	}
+
+	reportWordSetVersion(r.Context(), restored.Version)
+	loggerFromContext(r.Context()).Info("wordSet restored", "wordSetID", wordSetID, "revision", revision.Number)
+	emitWordSetEvent(r.Context(), Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: restored.Title, AuthorID: restored.AuthorID, ActorID: userID,
+	})
+	return restored, nil
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+單字星號是每個使用者自己的，存在wordStars collection(每個使用者每個單字集一筆)
+讀取單字時把檢視者的星號套到Word.Star上，沒登入的一律沒有星號
+以前星號存在單字集裡，作者還沒有wordStars紀錄時沿用單字集裡的star，第一次加星號時先用$setOnInsert寫進去
+加減星號用$addToSet/$pull，不會讀出整個列表再寫回
+--------------------------------------------------------------
+*/
+
+// 使用者在單字集中加星號的wordID，只保留單字集中還存在的單字
+func loadStarredWords(ctx context.Context, userID string, wordSet *Type.WordSet) (map[string]bool, error) {
+	if userID == "" {
+		return map[string]bool{}, nil
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordStars")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var stars Type.WordStars
+	err := coll.FindOne(findingContext, bson.M{"userID": userID, "wordSetID": wordSet.ID}).Decode(&stars)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return starredWordsOf(userID, wordSet, nil), nil
+	}
+	return starredWordsOf(userID, wordSet, &stars), nil
+}
+
+// 從使用者的wordStars紀錄算出加星號的單字，stars為nil代表還沒有紀錄
+func starredWordsOf(userID string, wordSet *Type.WordSet, stars *Type.WordStars) map[string]bool {
+	starred := map[string]bool{}
+	if stars == nil {
+		// 作者沿用以前存在單字集裡的星號
+		if userID == wordSet.AuthorID {
+			for _, word := range wordSet.Words {
+				if word.Star {
+					starred[word.ID] = true
+				}
+			}
+		}
+		return starred
+	}
+	exists := make(map[string]bool, len(wordSet.Words))
+	for _, word := range wordSet.Words {
+		exists[word.ID] = true
+	}
+	for _, wordID := range stars.WordIDs {
+		if exists[wordID] {
+			starred[wordID] = true
+		}
+	}
+	return starred
+}
+
+// 還沒有wordStars紀錄時先建立一筆，作者沿用以前存在單字集裡的星號
+// 已經有紀錄的話$setOnInsert不會動到它，同時建立被unique索引擋下的那一方也當成已經有紀錄
+func seedWordStars(ctx context.Context, userID string, wordSet *Type.WordSet) error {
+	legacy := starredWordsOf(userID, wordSet, nil)
+	wordIDs := []string{}
+	for _, word := range sortedByOrder(wordSet.Words) {
+		if legacy[word.ID] {
+			wordIDs = append(wordIDs, word.ID)
+		}
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordStars")
+	filter := bson.M{"userID": userID, "wordSetID": wordSet.ID}
+	update := bson.M{"$setOnInsert": bson.M{"wordIDs": wordIDs, "updatedAt": utils.GetNow()}}
+	if _, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
+
+// 把檢視者的星號套到單字上
+func applyWordStars(ctx context.Context, userID string, wordSet *Type.WordSet) error {
+	starred, err := loadStarredWords(ctx, userID, wordSet)
+	if err != nil {
+		return err
+	}
+	for i := range wordSet.Words {
+		wordSet.Words[i].Star = starred[wordSet.Words[i].ID]
+	}
+	return nil
+}
+
+// 取得看得到的單字集，單字的star換成檢視者自己的星號
+func getStarredWordSet(ctx context.Context, wordSetID string, userID string, shareToken string) (*Type.WordSet, error) {
+	wordSet, err := getReadableWordSet(ctx, wordSetID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWordStars(ctx, userID, wordSet); err != nil {
+		return nil, err
+	}
+	return wordSet, nil
+}
+
+// 設定使用者在單字集中一些單字的星號，wordIDs要是單字集中的單字
+func setWordStars(ctx context.Context, userID string, wordSet *Type.WordSet, wordIDs []string, star bool) error {
+	exists := make(map[string]bool, len(wordSet.Words))
+	for _, word := range wordSet.Words {
+		exists[word.ID] = true
+	}
+	for _, wordID := range wordIDs {
+		if !exists[wordID] {
+			return Type.NotFound("查無此單字或單字集")
+		}
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordStars")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	// 只加減這次的單字，同時進行的其他星號變更不會被蓋掉
+	if err := seedWordStars(writingContext, userID, wordSet); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	filter := bson.M{"userID": userID, "wordSetID": wordSet.ID}
+	update := bson.M{"$set": bson.M{"updatedAt": utils.GetNow()}}
+	if star {
+		update["$addToSet"] = bson.M{"wordIDs": bson.M{"$each": wordIDs}}
+	} else {
+		update["$pull"] = bson.M{"wordIDs": bson.M{"$in": wordIDs}}
+	}
+	if _, err := coll.UpdateOne(writingContext, filter, update, options.Update().SetUpsert(true)); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	return nil
+}
+
+// 單字從一個單字集搬到另一個單字集但wordID不變(拆分)，所有人的星號跟著搬過去
+func moveWordStarsInSession(sc mongo.SessionContext, fromWordSetID string, toWordSetID string, wordIDs []string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordStars")
+	cursor, err := coll.Find(sc, bson.M{"wordSetID": fromWordSetID, "wordIDs": bson.M{"$in": wordIDs}})
+	if err != nil {
+		return Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	var allStars []Type.WordStars
+	if err := cursor.All(sc, &allStars); err != nil {
+		return Type.Internal("解析失敗").Wrap(err)
+	}
+	moved := make(map[string]bool, len(wordIDs))
+	for _, wordID := range wordIDs {
+		moved[wordID] = true
+	}
+	for _, stars := range allStars {
+		kept := []string{}
+		for _, wordID := range stars.WordIDs {
+			if moved[wordID] {
+				kept = append(kept, wordID)
+			}
+		}
+		filter := bson.M{"userID": stars.UserID, "wordSetID": toWordSetID}
+		update := bson.M{"$set": bson.M{"wordIDs": kept, "updatedAt": utils.GetNow()}}
+		if _, err := coll.UpdateOne(sc, filter, update, options.Update().SetUpsert(true)); err != nil {
+			return Type.Internal("寫入錯誤 請重試").Wrap(err)
+		}
+	}
+	if _, err := coll.UpdateMany(sc, bson.M{"wordSetID": fromWordSetID}, bson.M{"$pull": bson.M{"wordIDs": bson.M{"$in": wordIDs}}}); err != nil {
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	return nil
+}
+
+// 單字集中所有單字的wordID
+func wordIDsOf(words []Type.Word) []string {
+	wordIDs := make([]string, len(words))
+	for i, word := range words {
+		wordIDs[i] = word.ID
+	}
+	return wordIDs
+}
+
+/* ---------------- handlers ---------------- */
+
+func v1StarWord(r *http.Request) (any, error) {
+	return nil, setWordStarFromPath(r, true)
+}
+
+func v1UnstarWord(r *http.Request) (any, error) {
+	return nil, setWordStarFromPath(r, false)
+}
+
+func setWordStarFromPath(r *http.Request, star bool) error {
+	userID := userIDFromContext(r.Context())
+	wordSet, err := getReadableWordSet(r.Context(), r.PathValue("wordSetID"), userID, shareTokenFromRequest(r))
+	if err != nil {
+		return err
+	}
+	return setWordStars(r.Context(), userID, wordSet, []string{r.PathValue("wordID")}, star)
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/utils"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+--------------------------------------------------------------
+卡片模板
+單字集可以自訂欄位(例如漢字/假名/意思/例句)、自訂欄位的發音，以及卡片正反面要顯示哪些欄位
+vocabulary跟definition是內建欄位，每個模板都一定要有(名稱可以改)，選擇題/填充題/Learn還是考這兩個欄位
+沒有設定模板(nil)等同預設模板: 跟原本的字卡一樣正面definition、背面vocabulary，shouldSwap時相反
+有設定模板的話正反面以模板為準，shouldSwap只影響練習模式
+--------------------------------------------------------------
+*/
+
+var templateFieldKeyRegex = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]{0,29}$`)
+
+func isBuiltinTemplateField(key string) bool {
+	return key == Consts.TemplateFieldVocabulary || key == Consts.TemplateFieldDefinition
+}
+
+// 預設模板，欄位名稱依使用者的語言
+func defaultCardTemplate(ctx context.Context, shouldSwap bool) Type.CardTemplate {
+	locale := i18n.FromContext(ctx)
+	template := Type.CardTemplate{
+		Fields: []Type.TemplateField{
+			{Key: Consts.TemplateFieldVocabulary, Label: i18n.T(locale, "單字")},
+			{Key: Consts.TemplateFieldDefinition, Label: i18n.T(locale, "註釋")},
+		},
+		Front: []string{Consts.TemplateFieldDefinition},
+		Back:  []string{Consts.TemplateFieldVocabulary},
+	}
+	if shouldSwap {
+		template.Front, template.Back = template.Back, template.Front
+	}
+	return template
+}
+
+// 單字集實際使用的模板
+func effectiveCardTemplate(ctx context.Context, wordSet *Type.WordSet) Type.CardTemplate {
+	if wordSet.Template == nil {
+		return defaultCardTemplate(ctx, wordSet.ShouldSwap)
+	}
+	return *wordSet.Template
+}
+
+// 模板的自訂欄位key
+func customFieldKeys(template *Type.CardTemplate) []string {
+	keys := []string{}
+	if template == nil {
+		return keys
+	}
+	for _, field := range template.Fields {
+		if !isBuiltinTemplateField(field.Key) {
+			keys = append(keys, field.Key)
+		}
+	}
+	return keys
+}
+
+// 驗證模板，欄位名稱會trim
+func validateCardTemplate(template *Type.CardTemplate) error {
+	if len(template.Fields) < 2 || len(template.Fields) > Consts.MaxTemplateFields {
+		return Type.BadRequest("模板欄位數須為2至%d個").WithArgs(Consts.MaxTemplateFields)
+	}
+	keys := map[string]bool{}
+	for i, field := range template.Fields {
+		if !templateFieldKeyRegex.MatchString(field.Key) {
+			return Type.BadRequest("欄位代號格式錯誤(%s)").WithArgs(field.Key)
+		}
+		if keys[field.Key] {
+			return Type.BadRequest("欄位代號重複(%s)").WithArgs(field.Key)
+		}
+		keys[field.Key] = true
+		label := strings.TrimSpace(field.Label)
+		if label == "" || utf8.RuneCountInString(label) > Consts.MaxTemplateLabelLen {
+			return Type.BadRequest("欄位名稱不得為空或超過%d字元").WithArgs(Consts.MaxTemplateLabelLen)
+		}
+		template.Fields[i].Label = label
+		if isBuiltinTemplateField(field.Key) {
+			if field.Sound != "" {
+				return Type.BadRequest("內建欄位的發音由每個單字設定")
+			}
+		} else if field.Sound != "" {
+			if err := utils.IsValidSound(field.Sound); err != nil {
+				return err
+			}
+		}
+	}
+	if !keys[Consts.TemplateFieldVocabulary] || !keys[Consts.TemplateFieldDefinition] {
+		return Type.BadRequest("模板必須包含vocabulary跟definition欄位")
+	}
+	if len(template.Front) == 0 || len(template.Back) == 0 {
+		return Type.BadRequest("卡片正面跟背面至少要有一個欄位")
+	}
+	shown := map[string]bool{}
+	for _, key := range slices.Concat(template.Front, template.Back) {
+		if !keys[key] {
+			return Type.BadRequest("卡片欄位不存在(%s)").WithArgs(key)
+		}
+		if shown[key] {
+			return Type.BadRequest("同一個欄位不能重複出現在卡片上(%s)").WithArgs(key)
+		}
+		shown[key] = true
+	}
+	return nil
+}
+
+// 驗證單字自訂欄位的值，key要是模板的自訂欄位
+func validateWordFields(template *Type.CardTemplate, fields map[string]string) error {
+	custom := customFieldKeys(template)
+	for key, value := range fields {
+		if !slices.Contains(custom, key) {
+			return Type.BadRequest("單字集沒有這個欄位(%s)").WithArgs(key)
+		}
+		if utf8.RuneCountInString(value) > Consts.MaxFieldValueLen {
+			return Type.BadRequest("欄位內容不得超過%d字元").WithArgs(Consts.MaxFieldValueLen)
+		}
+	}
+	return nil
+}
+
+// 把更新套用到單字原本的自訂欄位上，空字串代表清除，都沒有值的話回傳nil
+func mergeWordFields(fields map[string]string, updates map[string]string) map[string]string {
+	merged := maps.Clone(fields)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	for key, value := range updates {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// 單字搬到別的單字集時，拿掉目標模板沒有的自訂欄位
+func fitWordFields(template *Type.CardTemplate, word Type.Word) Type.Word {
+	custom := customFieldKeys(template)
+	word.Fields = maps.Clone(word.Fields)
+	maps.DeleteFunc(word.Fields, func(key string, _ string) bool {
+		return !slices.Contains(custom, key)
+	})
+	if len(word.Fields) == 0 {
+		word.Fields = nil
+	}
+	return word
+}
+
+func sameCardTemplate(a *Type.CardTemplate, b *Type.CardTemplate) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return slices.Equal(a.Fields, b.Fields) && slices.Equal(a.Front, b.Front) && slices.Equal(a.Back, b.Back)
+}
+
+// 設定或清除(template是nil)單字集的卡片模板，模板拿掉的自訂欄位會從所有單字中刪除
+func setCardTemplate(ctx context.Context, wordSetID string, version int, template *Type.CardTemplate) (*Type.WordSet, error) {
+	if template != nil {
+		if err := validateCardTemplate(template); err != nil {
+			return nil, err
+		}
+	}
+	before, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	// 要刪掉哪些自訂欄位是用before的模板算的，before一定要是version那一版
+	if err := checkWordSetVersion(before, version); err != nil {
+		return nil, err
+	}
+
+	setFields := bson.M{"updatedAt": utils.GetNow(), "updatedBy": userIDFromContext(ctx)}
+	unsetFields := bson.M{}
+	if template != nil {
+		setFields["template"] = template
+	} else {
+		unsetFields["template"] = ""
+	}
+	kept := customFieldKeys(template)
+	for _, key := range customFieldKeys(before.Template) {
+		if !slices.Contains(kept, key) {
+			unsetFields["words.$[].fields."+key] = ""
+		}
+	}
+	update := bson.M{"$set": setFields}
+	if len(unsetFields) > 0 {
+		update["$unset"] = unsetFields
+	}
+	after, err := updateWordSetWithRevision(ctx, before, version, bson.M{"id": wordSetID}, update, Type.NotFound("查無此單字集"))
+	if err != nil {
+		return nil, err
+	}
+	recordWordSetRevisionOrLog(ctx, before, after, Consts.RevisionActionEdit)
+	emitWordSetEvent(ctx, Consts.WebhookEventWordSetUpdated, Type.WebhookWordSetData{
+		WordSetID: wordSetID, Title: before.Title, AuthorID: before.AuthorID, ActorID: userIDFromContext(ctx),
+	})
+	loggerFromContext(ctx).Info("card template updated", "wordSetID", wordSetID, "custom", template != nil)
+	return after, nil
+}
+
+/* ---------------- handlers ---------------- */
+
+func handleSetCardTemplate(ctx context.Context, request Type.SetCardTemplateRequest) (string, error) {
+	_, err := setCardTemplate(ctx, request.WordSetID, *request.Version, request.Template)
+	return "", err
+}
+
+func v1GetCardTemplate(r *http.Request) (any, error) {
+	wordSet, err := getReadableWordSet(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()), shareTokenFromRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return effectiveCardTemplate(r.Context(), wordSet), nil
+}
+
+func v1PutCardTemplate(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	template, err := decodeAPIBody[Type.CardTemplate](r)
+	if err != nil {
+		return nil, err
+	}
+	return setCardTemplate(r.Context(), wordSetID, version, &template)
+}
+
+func v1DeleteCardTemplate(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetEditor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	return setCardTemplate(r.Context(), wordSetID, version, nil)
+}
@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+垃圾桶
+刪除單字集只會標記deletedAt，保留Consts.TrashRetentionDays天後才由背景工作永久刪除
+永久刪除時一併清掉users、recentVisit、wordSetRevisions裡指向它的引用
+單字以及版本紀錄用到的圖片、音檔在刪除前先收集起來，提交後沒有其他單字集在用就刪檔案
+--------------------------------------------------------------
+*/
+
+// 在查詢wordSet的filter加上「沒有在垃圾桶」的條件
+func liveWordSetFilter(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+// 把單字集移到垃圾桶，引用先保留，還原時才不用補回去
+func moveWordSetToTrash(ctx context.Context, wordSetID string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := utils.GetNow()
+	update := bson.M{"$set": bson.M{
+		"deletedAt": now,
+		"purgeAt":   now + int64(Consts.TrashRetentionDays)*24*60*60,
+	}}
+	res, err := coll.UpdateOne(writingContext, liveWordSetFilter(bson.M{"id": wordSetID}), update)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤")
+		}
+		loggerFromContext(ctx).Error("deleteWordSet error", "wordSetID", wordSetID, "error", err)
+		return Type.Internal("伺服器出錯 請重試")
+	}
+	if res.MatchedCount == 0 {
+		return Type.NotFound("查無此單字集")
+	}
+	loggerFromContext(ctx).Info("wordSet moved to trash", "wordSetID", wordSetID)
+	return nil
+}
+
+// 找出自己垃圾桶裡的單字集
+func findTrashedWordSet(ctx context.Context, wordSetID string, userID string) (*Type.WordSet, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var wordSet Type.WordSet
+	filter := bson.M{"id": wordSetID, "authorID": userID, "deletedAt": bson.M{"$exists": true}}
+	if err := coll.FindOne(findingContext, filter).Decode(&wordSet); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("垃圾桶中查無此單字集")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	return &wordSet, nil
+}
+
+// 永久刪除單字集以及所有指向它的引用
+func purgeWordSet(ctx context.Context, wordSet *Type.WordSet) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	// 刪掉之後就查不到版本紀錄了，要先收集
+	images, audios, err := collectWordSetMedia(ctx, wordSet)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	session, err := DB.Client.StartSession()
+	if err != nil {
+		return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+	}
+	defer session.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		database := DB.Client.Database("go-quizlet")
+
+		res, err := database.Collection("wordSets").DeleteOne(sc, bson.M{"id": wordSet.ID, "deletedAt": bson.M{"$exists": true}})
+		if err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if res.DeletedCount == 0 {
+			session.AbortTransaction(sc)
+			return Type.NotFound("垃圾桶中查無此單字集")
+		}
+
+		userColl := database.Collection("users")
+		userFilter := bson.M{"$or": []bson.M{{"createdWordSets": wordSet.ID}, {"likedWordSets": wordSet.ID}}}
+		userUpdate := bson.M{"$pull": bson.M{"createdWordSets": wordSet.ID, "likedWordSets": wordSet.ID}}
+		if _, err := userColl.UpdateMany(sc, userFilter, userUpdate); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		// 作者被收藏的次數扣掉這個單字集的讚數
+		if wordSet.Likes > 0 {
+			if _, err := userColl.UpdateOne(sc, bson.M{"id": wordSet.AuthorID}, bson.M{"$inc": bson.M{"likedCnt": -wordSet.Likes}}); err != nil {
+				session.AbortTransaction(sc)
+				return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+			}
+		}
+		if _, err := database.Collection("recentVisit").UpdateMany(sc, bson.M{"record": wordSet.ID}, bson.M{"$pull": bson.M{"record": wordSet.ID}}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if _, err := database.Collection("wordSetRevisions").DeleteMany(sc, bson.M{"wordSetID": wordSet.ID}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if _, err := database.Collection("wordSetShareLinks").DeleteMany(sc, bson.M{"wordSetID": wordSet.ID}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if _, err := database.Collection("wordStars").DeleteMany(sc, bson.M{"wordSetID": wordSet.ID}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if _, err := database.Collection("wordReviews").DeleteMany(sc, bson.M{"wordSetID": wordSet.ID}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+		if _, err := database.Collection("learnSessions").DeleteMany(sc, bson.M{"wordSetID": wordSet.ID}); err != nil {
+			session.AbortTransaction(sc)
+			return Type.Internal("資料庫錯誤 請重試").Wrap(err)
+		}
+
+		if err := session.CommitTransaction(sc); err != nil {
+			return Type.Internal("交易提交失敗 請重試").Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return err
+	}
+	for _, image := range images {
+		releaseWordImage(ctx, image)
+	}
+	for _, audio := range audios {
+		releaseWordAudio(ctx, audio)
+	}
+	loggerFromContext(ctx).Info("wordSet purged", "wordSetID", wordSet.ID)
+	return nil
+}
+
+// 背景工作，定期永久刪除過期的單字集，並清掉以前直接刪除留下的失效引用
+func RunTrashPurger(ctx context.Context) {
+	ticker := time.NewTicker(Consts.TrashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		purgeExpiredWordSets(ctx)
+		sweepDanglingWordSetRefs(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeExpiredWordSets(ctx context.Context) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"purgeAt": bson.M{"$lte": utils.GetNow()}, "deletedAt": bson.M{"$exists": true}}
+	opts := options.Find().SetProjection(bson.M{"id": 1, "authorID": 1, "likes": 1, "words": 1})
+	cursor, err := coll.Find(findingContext, filter, opts)
+	if err != nil {
+		slog.Error("find expired wordSets failed", "error", err)
+		return
+	}
+	var expired []Type.WordSet
+	if err := cursor.All(findingContext, &expired); err != nil {
+		slog.Error("decode expired wordSets failed", "error", err)
+		return
+	}
+	for _, wordSet := range expired {
+		if err := purgeWordSet(ctx, &wordSet); err != nil {
+			slog.Error("purge expired wordSet failed", "wordSetID", wordSet.ID, "error", err)
+		}
+	}
+	if len(expired) > 0 {
+		slog.Info("expired wordSets purged", "count", len(expired))
+	}
+}
+
+// 先收集被引用的wordSetID，再查哪些已經不存在，只清掉那些
+// 順序不能反過來，否則剛建立的單字集可能被當成失效引用
+func sweepDanglingWordSetRefs(ctx context.Context) {
+	sweepContext, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	database := DB.Client.Database("go-quizlet")
+
+	referenced := map[string]bool{}
+	for _, source := range []struct{ coll, field string }{
+		{"users", "createdWordSets"},
+		{"users", "likedWordSets"},
+		{"recentVisit", "record"},
+		{"wordSetRevisions", "wordSetID"},
+		{"wordSetShareLinks", "wordSetID"},
+	} {
+		values, err := database.Collection(source.coll).Distinct(sweepContext, source.field, bson.M{})
+		if err != nil {
+			slog.Error("collect wordSet references failed", "collection", source.coll, "error", err)
+			return
+		}
+		for _, value := range values {
+			if id, ok := value.(string); ok {
+				referenced[id] = true
+			}
+		}
+	}
+	if len(referenced) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(referenced))
+	for id := range referenced {
+		ids = append(ids, id)
+	}
+	existing, err := database.Collection("wordSets").Distinct(sweepContext, "id", bson.M{"id": bson.M{"$in": ids}})
+	if err != nil {
+		slog.Error("find existing wordSets failed", "error", err)
+		return
+	}
+	for _, value := range existing {
+		if id, ok := value.(string); ok {
+			delete(referenced, id)
+		}
+	}
+	if len(referenced) == 0 {
+		return
+	}
+
+	dangling := make([]string, 0, len(referenced))
+	for id := range referenced {
+		dangling = append(dangling, id)
+	}
+	slices.Sort(dangling)
+	if _, err := database.Collection("users").UpdateMany(sweepContext, bson.M{},
+		bson.M{"$pull": bson.M{"createdWordSets": bson.M{"$in": dangling}, "likedWordSets": bson.M{"$in": dangling}}}); err != nil {
+		slog.Error("pull dangling user references failed", "error", err)
+	}
+	if _, err := database.Collection("recentVisit").UpdateMany(sweepContext, bson.M{},
+		bson.M{"$pull": bson.M{"record": bson.M{"$in": dangling}}}); err != nil {
+		slog.Error("pull dangling recentVisit references failed", "error", err)
+	}
+	if _, err := database.Collection("wordSetRevisions").DeleteMany(sweepContext, bson.M{"wordSetID": bson.M{"$in": dangling}}); err != nil {
+		slog.Error("delete dangling revisions failed", "error", err)
+	}
+	if _, err := database.Collection("wordSetShareLinks").DeleteMany(sweepContext, bson.M{"wordSetID": bson.M{"$in": dangling}}); err != nil {
+		slog.Error("delete dangling share links failed", "error", err)
+	}
+	slog.Info("dangling wordSet references removed", "count", len(dangling))
+}
+
+func v1ListTrash(r *http.Request) (any, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"authorID": userIDFromContext(r.Context()), "deletedAt": bson.M{"$exists": true}}
+	opts := options.Find().
+		SetSort(bson.M{"deletedAt": -1}).
+		SetProjection(bson.M{"id": 1, "title": 1, "wordCnt": 1, "deletedAt": 1, "purgeAt": 1})
+	cursor, err := coll.Find(findingContext, filter, opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	trash := make([]Type.V1TrashWordSet, 0)
+	if err := cursor.All(findingContext, &trash); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return trash, nil
+}
+
+func v1RestoreFromTrash(r *http.Request) (any, error) {
+	wordSet, err := findTrashedWordSet(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": wordSet.ID, "deletedAt": bson.M{"$exists": true}}
+	res, err := coll.UpdateOne(writingContext, filter, bson.M{"$unset": bson.M{"deletedAt": "", "purgeAt": ""}})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, Type.NotFound("垃圾桶中查無此單字集")
+	}
+	loggerFromContext(r.Context()).Info("wordSet restored from trash", "wordSetID", wordSet.ID)
+	wordSet.DeletedAt, wordSet.PurgeAt = 0, 0
+	return wordSet, nil
+}
+
+func v1PurgeFromTrash(r *http.Request) (any, error) {
+	wordSet, err := findTrashedWordSet(r.Context(), r.PathValue("wordSetID"), userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return nil, purgeWordSet(r.Context(), wordSet)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+--------------------------------------------------------------
+單字集的樂觀鎖
+每次內容變更都要帶上編輯前的version，寫入時用version當filter並$inc，對不上就回409並附上目前的單字集
+星號、收藏這種不改內容的操作不需要version，也不會改變version
+--------------------------------------------------------------
+*/
+
+// 在filter加上version條件，舊資料沒有version欄位，當成0
+func versionFilter(filter bson.M, version int) bson.M {
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// 用version當filter的寫入沒有match時呼叫，判斷是版本衝突還是真的找不到
+// 版本衝突回409並附上目前的單字集，讓前端可以合併或重新載入
+func versionConflictOr(ctx context.Context, wordSetID string, version int, notFound error) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var current Type.WordSet
+	err := coll.FindOne(findingContext, liveWordSetFilter(bson.M{"id": wordSetID})).Decode(&current)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Type.NotFound("查無此單字集")
+		}
+		return Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if current.Version != version {
+		return Type.Conflict("單字集已被其他人修改(目前版本%d) 請重新整理後再編輯").WithArgs(current.Version).WithDetails(current)
+	}
+	return notFound
+}
+
+// 先讀出來的單字集要跟client帶的版本號一致，之後的寫入跟版本紀錄才能用它當變更前的內容
+func checkWordSetVersion(wordSet *Type.WordSet, version int) error {
+	if wordSet.Version != version {
+		return Type.Conflict("單字集已被其他人修改(目前版本%d) 請重新整理後再編輯").WithArgs(wordSet.Version).WithDetails(wordSet)
+	}
+	return nil
+}
+
+// 讓wrapper可以拿到邏輯function變更後的版本號，放進回應
+func contextWithVersionSink(ctx context.Context) (context.Context, *int) {
+	version := new(int)
+	return context.WithValue(ctx, wordSetVersionKey, version), version
+}
+
+// 回報變更後的版本號，沒有sink(例如背景工作)就忽略
+func reportWordSetVersion(ctx context.Context, version int) {
+	if sink, ok := ctx.Value(wordSetVersionKey).(*int); ok {
+		*sink = version
+	}
+}
+
+// v1用If-Match帶版本號，接受"3"或3，沒給回428
+func ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, Type.PreconditionRequired("請在If-Match帶上單字集目前的版本號")
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version < 0 {
+		return 0, Type.BadRequest("版本號格式錯誤")
+	}
+	return version, nil
+}
+
+// 用DB裡words的實際長度更新wordCnt(aggregation pipeline update)
+var syncWordCntUpdate = bson.A{bson.M{"$set": bson.M{"wordCnt": bson.M{"$size": "$words"}}}}
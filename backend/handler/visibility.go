@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+單字集公開範圍以及分享連結
+private: 只有作者跟協作者
+unlisted: 作者、協作者以及帶著有效分享連結token的人
+public: 任何人，會出現在搜尋以及首頁
+讀取單字集的地方都要經過canReadWordSet，沒有權限一律回404，不透露單字集是否存在
+--------------------------------------------------------------
+*/
+
+// 還沒遷移的舊資料沒有visibility，isPublic為true的是public，其他都當成private
+func wordSetVisibility(wordSet *Type.WordSet) string {
+	if wordSet.Visibility != "" {
+		return wordSet.Visibility
+	}
+	if wordSet.IsPublic {
+		return Consts.VisibilityPublic
+	}
+	return Consts.VisibilityPrivate
+}
+
+// 新建單字集時決定公開範圍，沒指定visibility就看舊的isPublic
+func resolveVisibility(visibility string, isPublic bool) (string, error) {
+	if visibility == "" {
+		if isPublic {
+			return Consts.VisibilityPublic, nil
+		}
+		return Consts.VisibilityPrivate, nil
+	}
+	if !slices.Contains(Consts.Visibilities, visibility) {
+		return "", Type.BadRequest("公開範圍錯誤(private, unlisted, public)")
+	}
+	return visibility, nil
+}
+
+// 分享連結的token放在?share=，API也可以用X-Share-Token header
+func shareTokenFromRequest(r *http.Request) string {
+	if token := r.Header.Get("X-Share-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("share")
+}
+
+// 確認userID(未登入為空字串)可以讀取這個單字集
+func canReadWordSet(ctx context.Context, wordSet *Type.WordSet, userID string, shareToken string) error {
+	visibility := wordSetVisibility(wordSet)
+	if visibility == Consts.VisibilityPublic || hasWordSetRole(wordSet, userID, Consts.WordSetRoleViewer) {
+		return nil
+	}
+	if visibility == Consts.VisibilityUnlisted && shareToken != "" {
+		ok, err := isValidShareToken(ctx, wordSet.ID, shareToken)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return Type.NotFound("查無單字集")
+}
+
+// 拿單字集並確認讀取權限
+func getReadableWordSet(ctx context.Context, wordSetID string, userID string, shareToken string) (*Type.WordSet, error) {
+	wordSet, err := getWordSetByID(wordSetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := canReadWordSet(ctx, wordSet, userID, shareToken); err != nil {
+		return nil, err
+	}
+	return wordSet, nil
+}
+
+// 搜尋、首頁這類給所有人看的列表只列public
+func publicWordSetFilter(filter bson.M) bson.M {
+	filter["visibility"] = Consts.VisibilityPublic
+	return filter
+}
+
+// userID看得到的單字集：public，或自己是作者/協作者
+// 分享連結只對單一單字集有效，不會讓單字集出現在列表裡
+func readableWordSetFilter(filter bson.M, userID string) bson.M {
+	if userID == "" {
+		return publicWordSetFilter(filter)
+	}
+	filter["$or"] = bson.A{
+		bson.M{"visibility": Consts.VisibilityPublic},
+		bson.M{"authorID": userID},
+		bson.M{"collaborators.userID": userID},
+	}
+	return filter
+}
+
+// 啟動時把舊資料補上visibility：isPublic為true的是public，其他都是private
+// 以前「不公開」的單字集其實知道ID就看得到，改成private才符合作者原本的預期
+func MigrateWordSetVisibility(ctx context.Context) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	migratingContext, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	public, err := coll.UpdateMany(migratingContext,
+		bson.M{"visibility": bson.M{"$exists": false}, "isPublic": true},
+		bson.M{"$set": bson.M{"visibility": Consts.VisibilityPublic}})
+	if err != nil {
+		slog.Error("migrate public wordSets failed", "error", err)
+		return
+	}
+	private, err := coll.UpdateMany(migratingContext,
+		bson.M{"visibility": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"visibility": Consts.VisibilityPrivate, "isPublic": false}})
+	if err != nil {
+		slog.Error("migrate private wordSets failed", "error", err)
+		return
+	}
+	if public.ModifiedCount+private.ModifiedCount > 0 {
+		slog.Info("wordSet visibility migrated", "public", public.ModifiedCount, "private", private.ModifiedCount)
+	}
+}
+
+// 更改公開範圍，isPublic跟著visibility一起改，舊的前端才不會顯示錯
+func updateWordSetVisibility(ctx context.Context, wordSetID string, visibility string) error {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSets")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"visibility": visibility, "isPublic": visibility == Consts.VisibilityPublic}}
+	res, err := coll.UpdateOne(writingContext, liveWordSetFilter(bson.M{"id": wordSetID}), update)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Type.Timeout("超時錯誤 請重試")
+		}
+		return Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return Type.NotFound("查無單字集")
+	}
+	loggerFromContext(ctx).Info("wordSet visibility changed", "wordSetID", wordSetID, "visibility", visibility)
+	return nil
+}
+
+func setWordSetVisibility(ctx context.Context, request Type.SetWordSetVisibilityRequest) (string, error) {
+	return "", updateWordSetVisibility(ctx, request.WordSetID, request.Visibility)
+}
+
+/* ---------------- share links ---------------- */
+
+// token只在unlisted時有效，改成private時連結先失效，改回unlisted又可以用
+func isValidShareToken(ctx context.Context, wordSetID string, token string) (bool, error) {
+	if !strings.HasPrefix(token, Consts.ShareTokenPrefix) {
+		return false, nil
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetShareLinks")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var link Type.WordSetShareLink
+	err := coll.FindOne(findingContext, bson.M{"wordSetID": wordSetID, "tokenHash": utils.HashAccessToken(token)}).Decode(&link)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return false, Type.Timeout("超時錯誤 請重試")
+		}
+		return false, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	return link.ExpiresAt == 0 || link.ExpiresAt > utils.GetNow(), nil
+}
+
+// 前端單字集頁面帶著token的網址
+func shareLinkURL(wordSetID string, token string) string {
+	return Consts.FrontendPATH + "/wordSet/" + wordSetID + "?share=" + url.QueryEscape(token)
+}
+
+func createShareLink(ctx context.Context, wordSetID string, userID string, expiresInDays int) (*Type.V1CreateShareLinkResponse, error) {
+	if expiresInDays < 0 || expiresInDays > Consts.MaxShareLinkDays {
+		return nil, Type.BadRequest("有效天數須為0至%d天").WithArgs(Consts.MaxShareLinkDays)
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetShareLinks")
+	writingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cnt, err := coll.CountDocuments(writingContext, bson.M{"wordSetID": wordSetID})
+	if err != nil {
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if cnt >= int64(Consts.MaxShareLinks) {
+		return nil, Type.Conflict("分享連結數量已達上限(%d個)").WithArgs(Consts.MaxShareLinks)
+	}
+
+	token, err := utils.GenerateShareToken()
+	if err != nil {
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	now := utils.GetNow()
+	link := Type.WordSetShareLink{
+		ID:        utils.GenerateID(),
+		WordSetID: wordSetID,
+		TokenHash: utils.HashAccessToken(token),
+		Prefix:    token[:len(Consts.ShareTokenPrefix)+6],
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	if expiresInDays > 0 {
+		link.ExpiresAt = now + int64(expiresInDays)*24*60*60
+	}
+	if _, err := coll.InsertOne(writingContext, link); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+
+	loggerFromContext(ctx).Info("share link created", "wordSetID", wordSetID, "shareLinkID", link.ID)
+	return &Type.V1CreateShareLinkResponse{Token: token, URL: shareLinkURL(wordSetID, token), ShareLink: link}, nil
+}
+
+// 撤銷就直接刪掉，linkID為空字串代表撤銷該單字集所有的連結
+func revokeShareLinks(ctx context.Context, wordSetID string, linkID string) (int64, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetShareLinks")
+	deletingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"wordSetID": wordSetID}
+	if linkID != "" {
+		filter["id"] = linkID
+	}
+	res, err := coll.DeleteMany(deletingContext, filter)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, Type.Timeout("超時錯誤 請重試")
+		}
+		return 0, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	loggerFromContext(ctx).Info("share links revoked", "wordSetID", wordSetID, "shareLinkID", linkID, "count", res.DeletedCount)
+	return res.DeletedCount, nil
+}
+
+// 舊的前端用，成功時回傳完整的連結
+func handleCreateShareLink(ctx context.Context, request Type.CreateShareLinkRequest) (string, error) {
+	response, err := createShareLink(ctx, request.WordSetID, userIDFromContext(ctx), 0)
+	if err != nil {
+		return "", err
+	}
+	return response.URL, nil
+}
+
+func handleRevokeShareLinks(ctx context.Context, request Type.RevokeShareLinksRequest) (string, error) {
+	_, err := revokeShareLinks(ctx, request.WordSetID, "")
+	return "", err
+}
+
+func v1ListShareLinks(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetAuthor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("wordSetShareLinks")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := coll.Find(findingContext, bson.M{"wordSetID": wordSetID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	links := make([]Type.WordSetShareLink, 0)
+	if err := cursor.All(findingContext, &links); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return links, nil
+}
+
+func v1CreateShareLink(r *http.Request) (any, error) {
+	wordSetID, userID := r.PathValue("wordSetID"), userIDFromContext(r.Context())
+	if _, err := checkWordSetAuthor(r.Context(), wordSetID, userID); err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1CreateShareLinkRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	return createShareLink(r.Context(), wordSetID, userID, request.ExpiresInDays)
+}
+
+func v1RevokeShareLink(r *http.Request) (any, error) {
+	wordSetID := r.PathValue("wordSetID")
+	if _, err := checkWordSetAuthor(r.Context(), wordSetID, userIDFromContext(r.Context())); err != nil {
+		return nil, err
+	}
+	cnt, err := revokeShareLinks(r.Context(), wordSetID, r.PathValue("linkID"))
+	if err != nil {
+		return nil, err
+	}
+	if cnt == 0 {
+		return nil, Type.NotFound("查無此分享連結")
+	}
+	return nil, nil
+}
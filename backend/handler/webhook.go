@@ -0,0 +1,463 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-quizlet/Consts"
+	"go-quizlet/DB"
+	"go-quizlet/Type"
+	"go-quizlet/utils"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+--------------------------------------------------------------
+Outgoing webhooks
+使用者可以訂閱自己單字集的事件，管理員的webhook會收到所有人的事件(包含user.registered)
+payload用HMAC-SHA256簽章，失敗會用exponential backoff重送，每次嘗試都記在webhookDeliveries
+--------------------------------------------------------------
+*/
+
+const (
+	maxWebhookURLLen        = 500
+	webhookDeliveryPageSize = 50
+	maxWebhookResponseBody  = 1 << 10 // 只讀一點點response body，讓連線可以重用
+)
+
+var errWebhookTargetNotAllowed = errors.New("webhook target is not allowed")
+
+// 不跟隨redirect，並且預設不送到localhost/內網，避免被拿來打內部服務
+var webhookClient = &http.Client{
+	Timeout: Consts.WebhookTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, c syscall.RawConn) error {
+				if Consts.WebhookAllowPrivateTarget {
+					return nil
+				}
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+					ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+					return fmt.Errorf("%w: %s", errWebhookTargetNotAllowed, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+func isAdminUser(user *Type.User) bool {
+	return user.Role == "admin" || (Consts.ADMINID != "" && user.ID == Consts.ADMINID)
+}
+
+// 在寫入DB成功後呼叫，ownerIDs是跟這個事件有關的使用者(例如單字集作者)
+// 送出是非同步的，不會拖慢原本的request
+func emitWebhookEvent(ctx context.Context, event string, ownerIDs []string, data any) {
+	payload := Type.WebhookPayload{
+		ID:        utils.GenerateID(),
+		Event:     event,
+		CreatedAt: utils.GetNow(),
+		Data:      data,
+	}
+	logger := loggerFromContext(ctx).With("event", event, "eventID", payload.ID)
+	go dispatchWebhookEvent(logger, payload, ownerIDs)
+}
+
+// wordset.*事件共用，作者一定會收到，ownerIDs是其他也要收到的使用者
+func emitWordSetEvent(ctx context.Context, event string, data Type.WebhookWordSetData, ownerIDs ...string) {
+	emitWebhookEvent(ctx, event, append([]string{data.AuthorID}, ownerIDs...), data)
+}
+
+func dispatchWebhookEvent(logger *slog.Logger, payload Type.WebhookPayload, ownerIDs []string) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Error("webhook dispatch panicked", "error", err)
+		}
+	}()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error("marshal webhook payload failed", "error", err)
+		return
+	}
+
+	findingContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	coll := DB.Client.Database("go-quizlet").Collection("webhooks")
+	filter := bson.M{
+		"active": true,
+		"events": payload.Event,
+		"$or": []bson.M{
+			{"ownerID": bson.M{"$in": ownerIDs}},
+			{"admin": true},
+		},
+	}
+	cursor, err := coll.Find(findingContext, filter)
+	if err != nil {
+		logger.Error("find webhooks failed", "error", err)
+		return
+	}
+	var webhooks []Type.Webhook
+	if err := cursor.All(findingContext, &webhooks); err != nil {
+		logger.Error("decode webhooks failed", "error", err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		go deliverWebhook(logger, webhook, payload.ID, payload.Event, body)
+	}
+}
+
+// 送出並記錄，可以重送的錯誤會等 base * 2^(n-1) 後再送
+func deliverWebhook(logger *slog.Logger, webhook Type.Webhook, eventID string, event string, body []byte) {
+	delivery := Type.WebhookDelivery{
+		ID:        utils.GenerateID(),
+		WebhookID: webhook.ID,
+		OwnerID:   webhook.OwnerID,
+		EventID:   eventID,
+		Event:     event,
+		Payload:   string(body),
+		Status:    "pending",
+		Attempts:  []Type.WebhookAttempt{},
+		CreatedAt: utils.GetNow(),
+	}
+	logger = logger.With("webhookID", webhook.ID, "deliveryID", delivery.ID)
+	coll := DB.Client.Database("go-quizlet").Collection("webhookDeliveries")
+	if err := withDBTimeout(func(ctx context.Context) error {
+		_, err := coll.InsertOne(ctx, delivery)
+		return err
+	}); err != nil {
+		logger.Error("insert webhook delivery failed", "error", err)
+		return
+	}
+
+	status := "failed"
+	for attempt := 1; attempt <= Consts.WebhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(Consts.WebhookRetryBaseDelay << (attempt - 2))
+		}
+		record, retryable := sendWebhook(webhook, delivery.ID, event, body)
+		record.Attempt = attempt
+		if err := withDBTimeout(func(ctx context.Context) error {
+			_, err := coll.UpdateOne(ctx, bson.M{"id": delivery.ID}, bson.M{"$push": bson.M{"attempts": record}})
+			return err
+		}); err != nil {
+			logger.Warn("record webhook attempt failed", "error", err)
+		}
+
+		if record.Error == "" {
+			status = "succeeded"
+			break
+		}
+		logger.Warn("webhook delivery attempt failed", "attempt", attempt, "statusCode", record.StatusCode, "error", record.Error)
+		if !retryable {
+			break
+		}
+	}
+
+	if err := withDBTimeout(func(ctx context.Context) error {
+		_, err := coll.UpdateOne(ctx, bson.M{"id": delivery.ID}, bson.M{"$set": bson.M{"status": status}})
+		return err
+	}); err != nil {
+		logger.Warn("update webhook delivery status failed", "error", err)
+	}
+	logger.Info("webhook delivered", "status", status)
+}
+
+// 送一次，回傳這次的紀錄以及失敗時是否值得重送(網路錯誤、408、429、5xx)
+func sendWebhook(webhook Type.Webhook, deliveryID string, event string, body []byte) (Type.WebhookAttempt, bool) {
+	timestamp := utils.GetNow()
+	record := Type.WebhookAttempt{SentAt: timestamp}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		record.Error = err.Error()
+		return record, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-quizlet-webhook/1.0")
+	req.Header.Set("X-Quizlet-Event", event)
+	req.Header.Set("X-Quizlet-Delivery", deliveryID)
+	req.Header.Set("X-Quizlet-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Quizlet-Signature", "sha256="+utils.SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	start := time.Now()
+	res, err := webhookClient.Do(req)
+	record.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+		return record, !errors.Is(err, errWebhookTargetNotAllowed)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, maxWebhookResponseBody))
+
+	record.StatusCode = res.StatusCode
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return record, false
+	}
+	record.Error = res.Status
+	retryable := res.StatusCode == http.StatusRequestTimeout || res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+	return record, retryable
+}
+
+func withDBTimeout(fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return fn(ctx)
+}
+
+// 驗證webhook URL跟訂閱的事件，非管理員不能訂閱管理員事件
+func validateWebhook(rawURL string, events []string, isAdmin bool) (string, []string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(rawURL) > maxWebhookURLLen {
+		return "", nil, Type.BadRequest("webhook網址須為http或https且不得超過500字元")
+	}
+	if len(events) == 0 {
+		return "", nil, Type.BadRequest("請至少訂閱一個事件")
+	}
+	for _, event := range events {
+		if !slices.Contains(Consts.WebhookEvents, event) {
+			return "", nil, Type.BadRequest("未知的webhook事件(%s)").WithArgs(event)
+		}
+		if !isAdmin && slices.Contains(Consts.AdminWebhookEvents, event) {
+			return "", nil, Type.Forbidden("只有管理員可以訂閱%s事件").WithArgs(event)
+		}
+	}
+	return rawURL, slices.Compact(slices.Sorted(slices.Values(events))), nil
+}
+
+func findOwnWebhook(ctx context.Context, webhookID string, userID string) (*Type.Webhook, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("webhooks")
+	findingContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var webhook Type.Webhook
+	err := coll.FindOne(findingContext, bson.M{"id": webhookID, "ownerID": userID}).Decode(&webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("查無此webhook")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	return &webhook, nil
+}
+
+func v1ListWebhooks(r *http.Request) (any, error) {
+	coll := DB.Client.Database("go-quizlet").Collection("webhooks")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := coll.Find(findingContext, bson.M{"ownerID": userIDFromContext(r.Context())}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	webhooks := make([]Type.Webhook, 0)
+	if err := cursor.All(findingContext, &webhooks); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return webhooks, nil
+}
+
+func v1CreateWebhook(r *http.Request) (any, error) {
+	request, err := decodeAPIBody[Type.V1CreateWebhookRequest](r)
+	if err != nil {
+		return nil, err
+	}
+	user, err := getUserByID(userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	isAdmin := isAdminUser(user)
+	webhookURL, events, err := validateWebhook(request.URL, request.Events, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("webhooks")
+	writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cnt, err := coll.CountDocuments(writingContext, bson.M{"ownerID": user.ID})
+	if err != nil {
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	if cnt >= int64(Consts.MaxWebhooks) {
+		return nil, Type.Conflict("webhook數量已達上限(%d個)").WithArgs(Consts.MaxWebhooks)
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	webhook := Type.Webhook{
+		ID:        utils.GenerateID(),
+		OwnerID:   user.ID,
+		URL:       webhookURL,
+		Secret:    secret,
+		Events:    events,
+		Admin:     isAdmin,
+		Active:    true,
+		CreatedAt: utils.GetNow(),
+	}
+	if _, err := coll.InsertOne(writingContext, webhook); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+
+	loggerFromContext(r.Context()).Info("webhook created", "webhookID", webhook.ID, "events", events)
+	return Type.V1CreateWebhookResponse{Secret: secret, Webhook: webhook}, nil
+}
+
+func v1UpdateWebhook(r *http.Request) (any, error) {
+	userID := userIDFromContext(r.Context())
+	webhook, err := findOwnWebhook(r.Context(), r.PathValue("webhookID"), userID)
+	if err != nil {
+		return nil, err
+	}
+	request, err := decodeAPIBody[Type.V1UpdateWebhookRequest](r)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.URL != nil {
+		webhook.URL = *request.URL
+	}
+	if request.Events != nil {
+		webhook.Events = *request.Events
+	}
+	webhook.URL, webhook.Events, err = validateWebhook(webhook.URL, webhook.Events, webhook.Admin)
+	if err != nil {
+		return nil, err
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("webhooks")
+	writingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"url": webhook.URL, "events": webhook.Events, "active": webhook.Active}}
+	if _, err := coll.UpdateOne(writingContext, bson.M{"id": webhook.ID, "ownerID": userID}, update); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("寫入錯誤 請重試").Wrap(err)
+	}
+	return webhook, nil
+}
+
+// 刪除webhook時一併刪除送出紀錄
+func v1DeleteWebhook(r *http.Request) (any, error) {
+	userID := userIDFromContext(r.Context())
+	webhookID := r.PathValue("webhookID")
+	deletingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	res, err := DB.Client.Database("go-quizlet").Collection("webhooks").DeleteOne(deletingContext, bson.M{"id": webhookID, "ownerID": userID})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("伺服器錯誤 請重試").Wrap(err)
+	}
+	if res.DeletedCount == 0 {
+		return nil, Type.NotFound("查無此webhook")
+	}
+	if _, err := DB.Client.Database("go-quizlet").Collection("webhookDeliveries").DeleteMany(deletingContext, bson.M{"webhookID": webhookID}); err != nil {
+		loggerFromContext(r.Context()).Warn("delete webhook deliveries failed", "webhookID", webhookID, "error", err)
+	}
+	loggerFromContext(r.Context()).Info("webhook deleted", "webhookID", webhookID)
+	return nil, nil
+}
+
+func v1ListWebhookDeliveries(r *http.Request) (any, error) {
+	webhook, err := findOwnWebhook(r.Context(), r.PathValue("webhookID"), userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := DB.Client.Database("go-quizlet").Collection("webhookDeliveries")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetSkip(int64(offset)).SetLimit(webhookDeliveryPageSize)
+	cursor, err := coll.Find(findingContext, bson.M{"webhookID": webhook.ID}, opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+	deliveries := make([]Type.WebhookDelivery, 0)
+	if err := cursor.All(findingContext, &deliveries); err != nil {
+		return nil, Type.Internal("轉換錯誤 請重試").Wrap(err)
+	}
+	return deliveries, nil
+}
+
+// 用原本的payload(同一個事件ID)重送一次，會產生新的送出紀錄
+func v1RedeliverWebhook(r *http.Request) (any, error) {
+	webhook, err := findOwnWebhook(r.Context(), r.PathValue("webhookID"), userIDFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	coll := DB.Client.Database("go-quizlet").Collection("webhookDeliveries")
+	findingContext, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var delivery Type.WebhookDelivery
+	err = coll.FindOne(findingContext, bson.M{"id": r.PathValue("deliveryID"), "webhookID": webhook.ID}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, Type.NotFound("查無此送出紀錄")
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Type.Timeout("超時錯誤 請重試")
+		}
+		return nil, Type.Internal("查詢錯誤 請重試").Wrap(err)
+	}
+
+	logger := loggerFromContext(r.Context()).With("event", delivery.Event, "eventID", delivery.EventID)
+	go deliverWebhook(logger, *webhook, delivery.EventID, delivery.Event, []byte(delivery.Payload))
+	return nil, nil
+}
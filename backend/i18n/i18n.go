@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 支援的語系，訊息的key就是繁體中文原文(zh-TW不需要翻譯檔)
+type Locale string
+
+const (
+	ZhTW Locale = "zh-TW"
+	En   Locale = "en"
+)
+
+const DefaultLocale = ZhTW
+
+var SupportedLocales = []Locale{ZhTW, En}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// locale -> 原文 -> 翻譯
+var catalogs = map[Locale]map[string]string{}
+
+func init() {
+	for _, locale := range SupportedLocales {
+		if locale == DefaultLocale {
+			continue
+		}
+		data, err := localeFiles.ReadFile(fmt.Sprintf("locales/%s.json", locale))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		catalogs[locale] = catalog
+	}
+}
+
+// 翻譯訊息，找不到翻譯就用原文，有args時才做格式化
+func T(locale Locale, message string, args ...any) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// 把使用者設定或header裡的語系轉成支援的Locale(en-US -> en、zh-Hant-TW -> zh-TW)
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", false
+	}
+	primary, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	switch primary {
+	case "en":
+		return En, true
+	case "zh":
+		return ZhTW, true
+	}
+	return "", false
+}
+
+// 依Accept-Language的q值挑出最適合的語系，都不支援就回傳DefaultLocale
+func Match(acceptLanguage string) Locale {
+	best, bestQ := DefaultLocale, -1.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		locale, ok := Parse(tag)
+		if ok && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+type ctxKey struct{}
+
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, ctxKey{}, locale)
+}
+
+func FromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(ctxKey{}).(Locale); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// 回傳對應語系的email template路徑，例如template/en/ResetPassword.html，沒有翻譯版本就用預設的template/ResetPassword.html
+func TemplatePath(templateDir string, locale Locale, name string) string {
+	if locale != DefaultLocale {
+		localized := filepath.Join(templateDir, string(locale), name)
+		if _, err := os.Stat(localized); err == nil {
+			return localized
+		}
+	}
+	return filepath.Join(templateDir, name)
+}
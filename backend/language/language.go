@@ -0,0 +1,107 @@
+package language
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go-quizlet/Consts"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 單字、註釋以及模板欄位可以使用的語言，sound欄位存的就是Tag
+type Language struct {
+	Tag        string `json:"tag"`             // BCP-47，例如en-US、ja-JP
+	Name       string `json:"name"`            // 顯示名稱，例如英語(美式)
+	NativeName string `json:"nativeName"`      // 該語言自己的名稱，例如日本語
+	Script     string `json:"script"`          // ISO 15924，例如Latn、Hant、Jpan
+	Direction  string `json:"direction"`       // ltr或rtl
+	Voice      string `json:"voice,omitempty"` // 預設的TTS聲音名稱，瀏覽器沒有這個聲音時依Tag挑
+}
+
+const (
+	DirectionLTR = "ltr"
+	DirectionRTL = "rtl"
+)
+
+// 語言-文字-地區，例如en、zh-Hant-TW、es-419
+var tagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-([a-zA-Z]{2}|[0-9]{3}))?$`)
+
+//go:embed languages.json
+var defaultLanguages []byte
+
+// 依設定檔的順序，啟動後不會再改
+var languages []Language
+var byTag = map[string]Language{}
+
+func init() {
+	if err := load(defaultLanguages); err != nil {
+		panic(fmt.Sprintf("language: invalid built-in languages: %v", err))
+	}
+}
+
+// 有設定go_quizlet_languages_file的話改用設定檔的語言
+func InitLanguages() {
+	if Consts.LanguagesFile == "" {
+		return
+	}
+	data, err := os.ReadFile(Consts.LanguagesFile)
+	if err != nil {
+		panic(fmt.Sprintf("language: read %s: %v", Consts.LanguagesFile, err))
+	}
+	if err := load(data); err != nil {
+		panic(fmt.Sprintf("language: invalid %s: %v", Consts.LanguagesFile, err))
+	}
+	slog.Info("languages loaded", "file", Consts.LanguagesFile, "count", len(languages))
+}
+
+func load(data []byte) error {
+	var list []Language
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no languages")
+	}
+	tags := make(map[string]Language, len(list))
+	for i, lang := range list {
+		if !tagPattern.MatchString(lang.Tag) {
+			return fmt.Errorf("invalid tag %q", lang.Tag)
+		}
+		if _, ok := tags[lang.Tag]; ok {
+			return fmt.Errorf("duplicate tag %q", lang.Tag)
+		}
+		if strings.TrimSpace(lang.Name) == "" {
+			return fmt.Errorf("missing name for %q", lang.Tag)
+		}
+		if lang.Direction == "" {
+			list[i].Direction = DirectionLTR
+		} else if lang.Direction != DirectionLTR && lang.Direction != DirectionRTL {
+			return fmt.Errorf("invalid direction %q for %q", lang.Direction, lang.Tag)
+		}
+		tags[lang.Tag] = list[i]
+	}
+	languages, byTag = list, tags
+	return nil
+}
+
+// 所有可用的語言，回傳的是複本
+func All() []Language {
+	return append([]Language(nil), languages...)
+}
+
+func Lookup(tag string) (Language, bool) {
+	lang, ok := byTag[tag]
+	return lang, ok
+}
+
+// 所有語言的Tag，錯誤訊息用
+func Tags() []string {
+	tags := make([]string, len(languages))
+	for i, lang := range languages {
+		tags[i] = lang.Tag
+	}
+	return tags
+}
@@ -1,18 +1,34 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"go-quizlet/DB"
+	"go-quizlet/Storage"
+	"go-quizlet/handler"
+	"go-quizlet/language"
 	"go-quizlet/server"
-	"log"
+	"go-quizlet/utils"
+	"log/slog"
+	"os"
 )
 
 
 func main() {
+	utils.InitLogger()
 	DB.InitDB()
 	defer DB.DisconnectDB()
+	Storage.InitStorage()
+	language.InitLanguages()
+	handler.MigrateWordSetVisibility(context.Background())
+	handler.MigrateForkCounts(context.Background())
+	handler.MigrateRevisionCounts(context.Background())
+	handler.EnsureIndexes(context.Background())
+	go handler.RunTrashPurger(context.Background())
 	server := server.CreateServer()
-	fmt.Println("server is running on: 5000")
-	log.Fatal(server.ListenAndServe())
+	slog.Info("server is running", "addr", server.Addr)
+	if err := server.ListenAndServe(); err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
 	
 }
\ No newline at end of file
@@ -0,0 +1,215 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+--------------------------------------------------------------
+註釋以及單字集敘述用的簡單Markdown
+支援 **粗體**、*斜體*(或_斜體_)、`程式碼`、- 或1. 開頭的清單以及換行
+原文裡的HTML一律跳脫，輸出只會有下面幾種不帶屬性的tag，所以不需要另外過濾:
+p、br、strong、em、code、ul、ol、li
+--------------------------------------------------------------
+*/
+
+type inline struct {
+	tag      string // ""是純文字，其他是strong、em、code
+	text     string // 純文字以及code的內容
+	children []inline
+}
+
+type block struct {
+	tag   string     // p、ul、ol
+	lines [][]inline // p的每一行或清單的每個項目
+}
+
+var (
+	unorderedItem = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	orderedItem   = regexp.MustCompile(`^\d{1,9}[.)]\s+(.*)$`)
+)
+
+// 可以用反斜線跳脫的符號
+const escapable = "\\`*_-+.)#"
+
+// 轉成安全的HTML
+func Render(source string) string {
+	var builder strings.Builder
+	for _, b := range parseBlocks(source) {
+		builder.WriteString("<" + b.tag + ">")
+		for i, line := range b.lines {
+			switch {
+			case b.tag != "p":
+				builder.WriteString("<li>")
+				renderInline(&builder, line)
+				builder.WriteString("</li>")
+			default:
+				if i > 0 {
+					builder.WriteString("<br>")
+				}
+				renderInline(&builder, line)
+			}
+		}
+		builder.WriteString("</" + b.tag + ">")
+	}
+	return builder.String()
+}
+
+// 顯示出來的文字(拿掉Markdown符號)，段落、換行以及清單項目之間用換行分隔
+func Text(source string) string {
+	var lines []string
+	for _, b := range parseBlocks(source) {
+		for _, line := range b.lines {
+			var builder strings.Builder
+			writeText(&builder, line)
+			lines = append(lines, builder.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 顯示出來的字數，字數限制用這個算
+func Len(source string) int {
+	return utf8.RuneCountInString(Text(source))
+}
+
+func parseBlocks(source string) []block {
+	var blocks []block
+	open := false // 上一行是不是可以接續的block
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			open = false
+			continue
+		}
+		tag, content := "p", line
+		if match := unorderedItem.FindStringSubmatch(line); match != nil {
+			tag, content = "ul", match[1]
+		} else if match := orderedItem.FindStringSubmatch(line); match != nil {
+			tag, content = "ol", match[1]
+		}
+		if open && blocks[len(blocks)-1].tag == tag {
+			blocks[len(blocks)-1].lines = append(blocks[len(blocks)-1].lines, parseInline(content))
+		} else {
+			blocks = append(blocks, block{tag: tag, lines: [][]inline{parseInline(content)}})
+		}
+		open = true
+	}
+	return blocks
+}
+
+func parseInline(s string) []inline {
+	var nodes []inline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, inline{text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0:
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				flush()
+				nodes = append(nodes, inline{tag: "code", text: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+		case c == '*' || c == '_':
+			delimiter, tag := s[i:i+1], "em"
+			if i+1 < len(s) && s[i+1] == c {
+				delimiter, tag = s[i:i+2], "strong"
+			}
+			if end, ok := findClosing(s, i, delimiter); ok {
+				flush()
+				nodes = append(nodes, inline{tag: tag, children: parseInline(s[i+len(delimiter) : end])})
+				i = end + len(delimiter)
+				continue
+			}
+		}
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// 找強調的結尾，開頭後面以及結尾前面不能是空白，底線不能在單字中間(snake_case不算)
+func findClosing(s string, start int, delimiter string) (int, bool) {
+	contentStart := start + len(delimiter)
+	if contentStart >= len(s) || s[contentStart] == ' ' {
+		return 0, false
+	}
+	underscore := delimiter[0] == '_'
+	if underscore && start > 0 && isWordChar(s, start-1) {
+		return 0, false
+	}
+	for k := contentStart; k < len(s); {
+		switch {
+		case s[k] == '\\':
+			k += 2
+			continue
+		case s[k] == '`':
+			if end := strings.IndexByte(s[k+1:], '`'); end > 0 {
+				k += end + 2
+				continue
+			}
+		case len(delimiter) == 1 && strings.HasPrefix(s[k:], delimiter+delimiter):
+			// 單個*裡面包著**粗體**時，**是粗體的符號
+			k += 2
+			continue
+		case strings.HasPrefix(s[k:], delimiter) && k > contentStart && s[k-1] != ' ':
+			after := k + len(delimiter)
+			if underscore && after < len(s) && isWordChar(s, after) {
+				break
+			}
+			return k, true
+		}
+		k++
+	}
+	return 0, false
+}
+
+func isWordChar(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	if r == utf8.RuneError {
+		// 在多位元組字元中間，往前找字元開頭
+		r, _ = utf8.DecodeLastRuneInString(s[:i+1])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func renderInline(builder *strings.Builder, nodes []inline) {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			builder.WriteString(html.EscapeString(node.text))
+		case "code":
+			builder.WriteString("<code>" + html.EscapeString(node.text) + "</code>")
+		default:
+			builder.WriteString("<" + node.tag + ">")
+			renderInline(builder, node.children)
+			builder.WriteString("</" + node.tag + ">")
+		}
+	}
+}
+
+func writeText(builder *strings.Builder, nodes []inline) {
+	for _, node := range nodes {
+		if node.tag == "" || node.tag == "code" {
+			builder.WriteString(node.text)
+		} else {
+			writeText(builder, node.children)
+		}
+	}
+}
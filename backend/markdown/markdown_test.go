@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"純文字", "apple", "<p>apple</p>"},
+		{"空的", "", ""},
+		{"粗體", "**bold**", "<p><strong>bold</strong></p>"},
+		{"底線粗體", "__bold__", "<p><strong>bold</strong></p>"},
+		{"斜體", "*em* and _em_", "<p><em>em</em> and <em>em</em></p>"},
+		{"斜體裡的粗體", "*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>"},
+		{"粗體裡的斜體", "**a *b* c**", "<p><strong>a <em>b</em> c</strong></p>"},
+		{"程式碼", "use `a*b*c`", "<p>use <code>a*b*c</code></p>"},
+		{"沒有結尾", "**open and *half", "<p>**open and *half</p>"},
+		{"開頭是空白不算", "a * b * c", "<p>a * b * c</p>"},
+		{"snake_case不算", "snake_case_name", "<p>snake_case_name</p>"},
+		{"反斜線跳脫", `\*not em\*`, "<p>*not em*</p>"},
+		{"換行", "line one\nline two", "<p>line one<br>line two</p>"},
+		{"段落", "one\n\ntwo", "<p>one</p><p>two</p>"},
+		{"CRLF", "one\r\ntwo", "<p>one<br>two</p>"},
+		{"清單", "- a\n* b\n+ c", "<ul><li>a</li><li>b</li><li>c</li></ul>"},
+		{"編號清單", "1. a\n2) b", "<ol><li>a</li><li>b</li></ol>"},
+		{"段落接清單", "intro\n- a\n- b", "<p>intro</p><ul><li>a</li><li>b</li></ul>"},
+		{"清單項目的格式", "- **a** `b`", "<ul><li><strong>a</strong> <code>b</code></li></ul>"},
+		{"跳脫清單符號", `\- not a list`, "<p>- not a list</p>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(test.source); got != test.want {
+				t.Errorf("Render(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+// 輸出只能有不帶屬性的白名單tag，原文裡的HTML一律跳脫
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"img onerror", `<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>"},
+		{"粗體裡的HTML", "**<b onclick=x>hi</b>**", "<p><strong>&lt;b onclick=x&gt;hi&lt;/b&gt;</strong></p>"},
+		{"程式碼裡的HTML", "`<iframe>`", "<p><code>&lt;iframe&gt;</code></p>"},
+		{"清單裡的HTML", "- <a href='javascript:x'>x</a>", "<ul><li>&lt;a href=&#39;javascript:x&#39;&gt;x&lt;/a&gt;</li></ul>"},
+		{"entity", "&lt;script&gt;", "<p>&amp;lt;script&amp;gt;</p>"},
+		{"markdown連結不支援", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(test.source); got != test.want {
+				t.Errorf("Render(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+
+	allowed := regexp.MustCompile(`^</?(p|br|strong|em|code|ul|ol|li)>$`)
+	tag := regexp.MustCompile(`<[^>]*>`)
+	sources := []string{
+		"<<script>>", "**<**>**", "*<*", "`<`>`", "- <li onclick=x>", "1. <ol start=1>",
+		"\\<script>", "<p style=x>a</p>", "**a\n<br onload=x>**", "_<_ _>_",
+	}
+	for _, source := range sources {
+		for _, found := range tag.FindAllString(Render(source), -1) {
+			if !allowed.MatchString(found) {
+				t.Errorf("Render(%q) produced tag %q", source, found)
+			}
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	tests := []struct {
+		source string
+		want   int
+	}{
+		{"apple", 5},
+		{"**apple**", 5},
+		{"*蘋果*", 2},
+		{"- a\n- b", 3}, // 項目之間算一個換行
+		{"`code`", 4},
+		{`\*`, 1},
+		{"<b>", 3},
+	}
+	for _, test := range tests {
+		if got := Len(test.source); got != test.want {
+			t.Errorf("Len(%q) = %d, want %d", test.source, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"go-quizlet/Type"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 比對打字作答用的正規化：全形轉半形(NFKC)、不分大小寫、去掉拉丁字母上的重音等符號、忽略標點以及多餘的空白
+// 日文的濁音符號不算重音，會保留
+func NormalizeAnswer(answer string) string {
+	var builder strings.Builder
+	latinBase := false
+	for _, r := range norm.NFD.String(norm.NFKC.String(answer)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			if latinBase {
+				continue
+			}
+		case unicode.IsPunct(r):
+			latinBase = false
+			continue
+		default:
+			latinBase = unicode.Is(unicode.Latin, r)
+		}
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return strings.Join(strings.Fields(norm.NFC.String(builder.String())), " ")
+}
+
+// 答案可以用/或;分隔多個可接受的答案(全形的／；也算)，整串也算一個答案(例如and/or)
+func SplitAnswers(answer string) []string {
+	answers := []string{}
+	add := func(candidate string) {
+		candidate = strings.TrimSpace(candidate)
+		for _, existing := range answers {
+			if existing == candidate {
+				return
+			}
+		}
+		if candidate != "" {
+			answers = append(answers, candidate)
+		}
+	}
+	add(answer)
+	for _, part := range strings.FieldsFunc(norm.NFKC.String(answer), func(r rune) bool { return r == '/' || r == ';' }) {
+		add(part)
+	}
+	return answers
+}
+
+// 答案跟作答以字元為單位的差異(最長共同子序列)
+func DiffAnswer(expected string, actual string) []Type.AnswerDiff {
+	a, b := []rune(expected), []rune(actual)
+	// lcs[i][j]為a[i:]跟b[j:]的最長共同子序列長度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	diff := []Type.AnswerDiff{}
+	push := func(op string, r rune) {
+		if len(diff) > 0 && diff[len(diff)-1].Op == op {
+			diff[len(diff)-1].Text += string(r)
+			return
+		}
+		diff = append(diff, Type.AnswerDiff{Op: op, Text: string(r)})
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			push("equal", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			push("missing", a[i])
+			i++
+		default:
+			push("extra", b[j])
+			j++
+		}
+	}
+	return diff
+}
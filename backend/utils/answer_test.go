@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"Apple", "apple"},
+		{"  big   apple  ", "big apple"},
+		{"don't!", "dont"},
+		{"café", "cafe"},
+		{"Ｈｅｌｌｏ　Ｗｏｒｌｄ", "hello world"},
+		{"naïve résumé", "naive resume"},
+		{"がっこう", "がっこう"}, // 濁音符號不是重音
+		{"蘋果，香蕉。", "蘋果香蕉"},
+		{"!!", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeAnswer(test.answer); got != test.want {
+			t.Errorf("NormalizeAnswer(%q) = %q, want %q", test.answer, got, test.want)
+		}
+	}
+}
+
+func TestSplitAnswers(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   []string
+	}{
+		{"apple", []string{"apple"}},
+		{"and/or", []string{"and/or", "and", "or"}},
+		{"蘋果; 蘋果樹", []string{"蘋果; 蘋果樹", "蘋果", "蘋果樹"}},
+		{"蘋果／蘋果樹", []string{"蘋果／蘋果樹", "蘋果", "蘋果樹"}},
+		{"a/a", []string{"a/a", "a"}},
+		{" / ", []string{"/"}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		if got := SplitAnswers(test.answer); !slices.Equal(got, test.want) {
+			t.Errorf("SplitAnswers(%q) = %q, want %q", test.answer, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+)
+
+// 使用者上傳的音檔，只讀header算出長度，不解碼
+type ProbedAudio struct {
+	ContentType string
+	Ext         string
+	Duration    float64 // 秒
+}
+
+// 檢查音檔格式(mp3、wav、ogg、m4a)以及長度
+func ProbeAudio(data []byte) (*ProbedAudio, error) {
+	var probed *ProbedAudio
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		probed = probeWAV(data)
+	case len(data) >= 4 && string(data[0:4]) == "OggS":
+		probed = probeOgg(data)
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		probed = probeMP4(data)
+	default:
+		probed = probeMP3(data)
+	}
+	if probed == nil || probed.Duration <= 0 {
+		return nil, Type.BadRequest("不支援的音檔格式(mp3, wav, ogg, m4a)")
+	}
+	if probed.Duration > Consts.MaxAudioDuration {
+		return nil, Type.BadRequest("音檔長度不得超過%d秒").WithArgs(int(Consts.MaxAudioDuration))
+	}
+	return probed, nil
+}
+
+// WAV: data chunk大小除以fmt chunk的byteRate
+func probeWAV(data []byte) *ProbedAudio {
+	var byteRate uint32
+	for pos := 12; pos+8 <= len(data); {
+		chunkID := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		switch chunkID {
+		case "fmt ":
+			if body+12 > len(data) {
+				return nil
+			}
+			byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+		case "data":
+			// 錄音程式沒寫回大小時用剩下的長度
+			if size <= 0 || body+size > len(data) {
+				size = len(data) - body
+			}
+			if byteRate == 0 {
+				return nil
+			}
+			return &ProbedAudio{ContentType: "audio/wav", Ext: ".wav", Duration: float64(size) / float64(byteRate)}
+		}
+		pos = body + size + size%2
+	}
+	return nil
+}
+
+// Ogg(Opus或Vorbis): 最後一頁的granule position除以取樣率
+func probeOgg(data []byte) *ProbedAudio {
+	if len(data) < 27 {
+		return nil
+	}
+	packet := 27 + int(data[26])
+	if packet+19 > len(data) {
+		return nil
+	}
+	var rate, preSkip float64
+	switch {
+	case string(data[packet:packet+8]) == "OpusHead":
+		rate = 48000 // Opus的granule一律是48kHz
+		preSkip = float64(binary.LittleEndian.Uint16(data[packet+10 : packet+12]))
+	case string(data[packet:packet+7]) == "\x01vorbis" && packet+16 <= len(data):
+		rate = float64(binary.LittleEndian.Uint32(data[packet+12 : packet+16]))
+	default:
+		return nil
+	}
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if rate == 0 || last+14 > len(data) {
+		return nil
+	}
+	granule := float64(binary.LittleEndian.Uint64(data[last+6 : last+14]))
+	return &ProbedAudio{ContentType: "audio/ogg", Ext: ".ogg", Duration: (granule - preSkip) / rate}
+}
+
+// MP4/M4A: moov裡mvhd的duration除以timescale
+func probeMP4(data []byte) *ProbedAudio {
+	moov := findMP4Box(data, "moov")
+	if moov == nil {
+		return nil
+	}
+	mvhd := findMP4Box(moov, "mvhd")
+	if len(mvhd) < 20 {
+		return nil
+	}
+	var timescale, duration uint64
+	if mvhd[0] == 1 {
+		if len(mvhd) < 32 {
+			return nil
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+	if timescale == 0 {
+		return nil
+	}
+	return &ProbedAudio{ContentType: "audio/mp4", Ext: ".m4a", Duration: float64(duration) / float64(timescale)}
+}
+
+// 在同一層box裡找指定type的box，回傳box的內容(不含header)
+func findMP4Box(data []byte, boxType string) []byte {
+	for pos := 0; pos+8 <= len(data); {
+		size := uint64(binary.BigEndian.Uint32(data[pos : pos+4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data) - pos)
+		case 1:
+			if pos+16 > len(data) {
+				return nil
+			}
+			size, header = binary.BigEndian.Uint64(data[pos+8:pos+16]), 16
+		}
+		if size < header || size > uint64(len(data)-pos) {
+			return nil
+		}
+		if string(data[pos+4:pos+8]) == boxType {
+			return data[pos+int(header) : pos+int(size)]
+		}
+		pos += int(size)
+	}
+	return nil
+}
+
+var (
+	mp3Bitrates = [2][3][15]int{
+		{ // MPEG1: Layer I, II, III
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		},
+		{ // MPEG2/2.5
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		},
+	}
+	mp3SampleRates = map[int][3]int{3: {44100, 48000, 32000}, 2: {22050, 24000, 16000}, 0: {11025, 12000, 8000}}
+)
+
+// 解析mp3 frame header，回傳frame長度以及這個frame的秒數
+func mp3Frame(header []byte) (int, float64) {
+	if header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return 0, 0
+	}
+	version := int(header[1]>>3) & 3 // 3: MPEG1, 2: MPEG2, 0: MPEG2.5
+	layer := 4 - int(header[1]>>1)&3 // 1: Layer I, 2: Layer II, 3: Layer III
+	bitrateIndex := int(header[2] >> 4)
+	rateIndex := int(header[2]>>2) & 3
+	padding := int(header[2]>>1) & 1
+	if version == 1 || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0, 0
+	}
+	table := 0
+	if version != 3 {
+		table = 1
+	}
+	bitrate := mp3Bitrates[table][layer-1][bitrateIndex] * 1000
+	sampleRate := mp3SampleRates[version][rateIndex]
+	samples := 1152
+	switch {
+	case layer == 1:
+		samples = 384
+	case layer == 3 && version != 3:
+		samples = 576
+	}
+	length := samples/8*bitrate/sampleRate + padding
+	if layer == 1 {
+		length = (12*bitrate/sampleRate + padding) * 4
+	}
+	return length, float64(samples) / float64(sampleRate)
+}
+
+// MP3: 跳過ID3v2 tag後把每個frame的秒數加起來，至少要有連續兩個frame才算mp3
+func probeMP3(data []byte) *ProbedAudio {
+	pos := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		pos = 10 + size
+		if data[5]&0x10 != 0 {
+			pos += 10 // footer
+		}
+	}
+	frames := 0
+	duration := 0.0
+	for pos+4 <= len(data) {
+		length, seconds := mp3Frame(data[pos : pos+4])
+		if length <= 0 {
+			if frames >= 2 {
+				break // 後面是ID3v1之類的tag
+			}
+			// 剛剛可能是剛好長得像header的資料，重新找
+			frames, duration = 0, 0
+			pos++
+			continue
+		}
+		frames++
+		duration += seconds
+		pos += length
+	}
+	if frames < 2 {
+		return nil
+	}
+	return &ProbedAudio{ContentType: "audio/mpeg", Ext: ".mp3", Duration: duration}
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go-quizlet/Consts"
+	"math"
+	"testing"
+)
+
+func wavFile(byteRate uint32, dataSize int) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("RIFF")
+	binary.Write(&buffer, binary.LittleEndian, uint32(36+dataSize))
+	buffer.WriteString("WAVEfmt ")
+	binary.Write(&buffer, binary.LittleEndian, uint32(16))
+	binary.Write(&buffer, binary.LittleEndian, uint16(1))  // PCM
+	binary.Write(&buffer, binary.LittleEndian, uint16(1))  // mono
+	binary.Write(&buffer, binary.LittleEndian, byteRate/2) // sample rate
+	binary.Write(&buffer, binary.LittleEndian, byteRate)   // byte rate
+	binary.Write(&buffer, binary.LittleEndian, uint16(2))  // block align
+	binary.Write(&buffer, binary.LittleEndian, uint16(16)) // bits per sample
+	buffer.WriteString("data")
+	binary.Write(&buffer, binary.LittleEndian, uint32(dataSize))
+	buffer.Write(make([]byte, dataSize))
+	return buffer.Bytes()
+}
+
+func oggPage(granule uint64, packet []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = append(page, make([]byte, 12)...) // serial、sequence、checksum
+	page = append(page, 1, byte(len(packet)))
+	return append(page, packet...)
+}
+
+func opusFile(preSkip uint16, granule uint64) []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, 1)
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+	return append(oggPage(0, head), oggPage(granule, []byte{0})...)
+}
+
+func vorbisFile(rate uint32, granule uint64) []byte {
+	head := []byte("\x01vorbis")
+	head = binary.LittleEndian.AppendUint32(head, 0)
+	head = append(head, 1)
+	head = binary.LittleEndian.AppendUint32(head, rate)
+	head = append(head, make([]byte, 14)...)
+	return append(oggPage(0, head), oggPage(granule, []byte{0})...)
+}
+
+func mp4Box(boxType string, body []byte) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	box = append(box, boxType...)
+	return append(box, body...)
+}
+
+func m4aFile(version byte, timescale uint32, duration uint64) []byte {
+	mvhd := []byte{version, 0, 0, 0}
+	if version == 1 {
+		mvhd = append(mvhd, make([]byte, 16)...)
+		mvhd = binary.BigEndian.AppendUint32(mvhd, timescale)
+		mvhd = binary.BigEndian.AppendUint64(mvhd, duration)
+	} else {
+		mvhd = append(mvhd, make([]byte, 8)...)
+		mvhd = binary.BigEndian.AppendUint32(mvhd, timescale)
+		mvhd = binary.BigEndian.AppendUint32(mvhd, uint32(duration))
+	}
+	mvhd = append(mvhd, make([]byte, 80)...)
+	file := mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	file = append(file, mp4Box("free", make([]byte, 4))...)
+	return append(file, mp4Box("moov", mp4Box("mvhd", mvhd))...)
+}
+
+// MPEG1 Layer III、128kbps、44.1kHz，每個frame 417 byte、1152個sample
+func mp3File(frames int, id3 bool) []byte {
+	var file []byte
+	if id3 {
+		file = append([]byte("ID3\x03\x00\x00\x00\x00\x00\x0A"), make([]byte, 10)...)
+	}
+	for i := 0; i < frames; i++ {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+		file = append(file, frame...)
+	}
+	return append(file, "TAG"...) // ID3v1
+}
+
+func TestProbeAudio(t *testing.T) {
+	mp3Frame := 1152.0 / 44100
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		duration    float64
+	}{
+		{"wav", wavFile(32000, 64000), "audio/wav", 2},
+		{"wav沒寫回data大小", func() []byte {
+			data := wavFile(32000, 32000)
+			binary.LittleEndian.PutUint32(data[40:44], 0)
+			return data
+		}(), "audio/wav", 1},
+		{"opus", opusFile(312, 48000*3+312), "audio/ogg", 3},
+		{"vorbis", vorbisFile(44100, 44100*2), "audio/ogg", 2},
+		{"m4a", m4aFile(0, 1000, 2500), "audio/mp4", 2.5},
+		{"m4a 64位元mvhd", m4aFile(1, 600, 1200), "audio/mp4", 2},
+		{"mp3", mp3File(10, false), "audio/mpeg", 10 * mp3Frame},
+		{"mp3有ID3v2", mp3File(10, true), "audio/mpeg", 10 * mp3Frame},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probed, err := ProbeAudio(test.data)
+			if err != nil {
+				t.Fatalf("ProbeAudio() error = %v", err)
+			}
+			if probed.ContentType != test.contentType || math.Abs(probed.Duration-test.duration) > 1e-6 {
+				t.Errorf("ProbeAudio() = %s %.6fs, want %s %.6fs", probed.ContentType, probed.Duration, test.contentType, test.duration)
+			}
+		})
+	}
+}
+
+func TestProbeAudioRejects(t *testing.T) {
+	tooLong := int(Consts.MaxAudioDuration) + 1
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"空的", []byte{}},
+		{"文字檔", []byte("hello, this is not audio")},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")},
+		{"只有一個mp3 frame", mp3File(1, false)},
+		{"wav沒有fmt", []byte("RIFF\x00\x00\x00\x00WAVEdata\x04\x00\x00\x00\x00\x00\x00\x00")},
+		{"wav太長", wavFile(1000, 1000*tooLong)},
+		{"ogg不是opus或vorbis", oggPage(0, []byte("theora header bytes"))},
+		{"m4a沒有moov", mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00"))},
+		{"m4a box大小超過檔案", append(mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), 0xFF, 0xFF, 0xFF, 0xFF, 'm', 'o', 'o', 'v')},
+		{"m4a timescale為0", m4aFile(0, 0, 100)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if probed, err := ProbeAudio(test.data); err == nil {
+				t.Errorf("ProbeAudio() = %+v, want error", probed)
+			}
+		})
+	}
+}
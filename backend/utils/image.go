@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"go-quizlet/Consts"
+	"go-quizlet/Type"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// 使用者上傳的圖片，原圖照原本的檔案存，另外產生縮圖
+type ProcessedImage struct {
+	ContentType          string
+	Ext                  string
+	Width                int
+	Height               int
+	Thumbnail            []byte
+	ThumbnailContentType string
+	ThumbnailExt         string
+}
+
+var imageFormats = map[string]struct{ contentType, ext string }{
+	"jpeg": {"image/jpeg", ".jpg"},
+	"png":  {"image/png", ".png"},
+	"gif":  {"image/gif", ".gif"},
+}
+
+// 檢查圖片格式跟長寬並產生縮圖，jpeg的縮圖是jpeg，其他(可能有透明)是png
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, Type.BadRequest("不支援的圖片格式(jpeg, png, gif)")
+	}
+	info, ok := imageFormats[format]
+	if !ok {
+		return nil, Type.BadRequest("不支援的圖片格式(jpeg, png, gif)")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > Consts.MaxImageDimension || config.Height > Consts.MaxImageDimension {
+		return nil, Type.BadRequest("圖片長寬不得超過%dpx").WithArgs(Consts.MaxImageDimension)
+	}
+	// 長寬都在上限內，像素總數還是可能太多(6000x6000要144MB)
+	if config.Width*config.Height > Consts.MaxImagePixels {
+		return nil, Type.BadRequest("圖片像素太多(最多%d百萬像素)").WithArgs(Consts.MaxImagePixels / 1_000_000)
+	}
+	var decoded image.Image
+	if format == "gif" {
+		// 動圖只拿第一格做縮圖
+		decoded, err = gif.Decode(bytes.NewReader(data))
+	} else {
+		decoded, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, Type.BadRequest("圖片檔案損毀").Wrap(err)
+	}
+
+	thumbnail := scaleDown(decoded, Consts.ThumbnailSize)
+	var buffer bytes.Buffer
+	processed := &ProcessedImage{ContentType: info.contentType, Ext: info.ext, Width: config.Width, Height: config.Height}
+	if format == "jpeg" {
+		err = jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 80})
+		processed.ThumbnailContentType, processed.ThumbnailExt = "image/jpeg", ".jpg"
+	} else {
+		err = png.Encode(&buffer, thumbnail)
+		processed.ThumbnailContentType, processed.ThumbnailExt = "image/png", ".png"
+	}
+	if err != nil {
+		return nil, Type.Internal("縮圖產生失敗").Wrap(err)
+	}
+	processed.Thumbnail = buffer.Bytes()
+	return processed, nil
+}
+
+// 等比例縮小到最長邊不超過maxSide，每個新像素取原圖對應區塊的平均(box filter)
+// 直接從src取樣，不先複製一份原尺寸的RGBA
+func scaleDown(src image.Image, maxSide int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dstW, dstH := srcW, srcH
+	if srcW > maxSide || srcH > maxSide {
+		if srcW >= srcH {
+			dstW, dstH = maxSide, max(1, srcH*maxSide/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*maxSide/srcH), maxSide
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	if dstW == srcW && dstH == srcH {
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+
+	for y := 0; y < dstH; y++ {
+		y0, y1 := y*srcH/dstH, max((y+1)*srcH/dstH, y*srcH/dstH+1)
+		for x := 0; x < dstW; x++ {
+			x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, a := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					sum[0] += uint64(r)
+					sum[1] += uint64(g)
+					sum[2] += uint64(b)
+					sum[3] += uint64(a)
+				}
+			}
+			count := uint64((y1 - y0) * (x1 - x0))
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				// RGBA()是16位元，轉回8位元
+				dst.Pix[offset+c] = uint8(sum[c] / count >> 8)
+			}
+		}
+	}
+	return dst
+}
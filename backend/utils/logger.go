@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 初始化全域slog logger，LOG_LEVEL可設為debug/info/warn/error(預設info)
+func InitLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+}
+
+// 不可以出現在log裡的欄位(比對時不分大小寫)
+var sensitiveKeys = []string{"password", "validatecode", "code", "token", "credential", "secret", "authorization"}
+
+const redacted = "[REDACTED]"
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// 把JSON body裡的密碼、驗證碼、token等欄位遮蔽後回傳，讓log可以安全印出
+// 如果body不是合法JSON，只回傳長度資訊
+func RedactJSON(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return map[string]any{"nonJSONBytes": len(body)}
+	}
+	return redactValue(data)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, inner := range v {
+			if isSensitiveKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(inner)
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
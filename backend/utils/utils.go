@@ -4,24 +4,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"go-quizlet/Consts"
 	"go-quizlet/Type"
+	"go-quizlet/i18n"
+	"go-quizlet/language"
 	"html/template"
 	"io"
-	"log"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"net/mail"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
-	"slices"
-
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"math/big"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -43,14 +47,14 @@ func GetNow() int64 {
 	return time.Now().Unix()
 }
 
-func GetWelcomeLetter(userName string) string {
-	return fmt.Sprintf("哈囉! <b>%s</b>，誠摯地歡迎您加入Quiz\n這裡多了您一定會變得更好! 也期待在這裡您能有所收穫!<br>讓我們一起努力 一起在學習的路上並肩同行<br>期待您的成長與蛻變，祝福您喔~~~", userName)
+func GetWelcomeLetter(locale i18n.Locale, userName string) string {
+	return i18n.T(locale, "哈囉! <b>%s</b>，誠摯地歡迎您加入Quiz\n這裡多了您一定會變得更好! 也期待在這裡您能有所收穫!<br>讓我們一起努力 一起在學習的路上並肩同行<br>期待您的成長與蛻變，祝福您喔~~~", userName)
 }
 
+// 聲音(語言)必須是language registry裡有的Tag
 func IsValidSound(sound string) error {
-	flag := slices.Contains([]string{"en-US", "en-GB", "en-AU", "zh-TW", "zh-CN"}, strings.TrimSpace(sound)) 
-	if !flag {
-		return errors.New("聲音格式錯誤(en-US, en-GB, en-AU, zh-TW, zh-CN)")
+	if _, ok := language.Lookup(strings.TrimSpace(sound)); !ok {
+		return Type.BadRequest("聲音格式錯誤(%s)").WithArgs(strings.Join(language.Tags(), ", "))
 	}
 	return nil
 }
@@ -95,22 +99,22 @@ var (
 // ValidatePassword checks each condition separately and returns specific error messages
 func IsValidPassword(password string) error {
 	if len(password) < 8 || len(password) > 20 {
-		return errors.New("密碼長度須為8至20")
+		return Type.BadRequest("密碼長度須為8至20")
 	}
 	if !lowercaseRegex.MatchString(password) {
-		return errors.New("密碼至少包含一個小寫英文字母")
+		return Type.BadRequest("密碼至少包含一個小寫英文字母")
 	}
 	if !uppercaseRegex.MatchString(password) {
-		return errors.New("密碼至少包含一個大寫英文字母")
+		return Type.BadRequest("密碼至少包含一個大寫英文字母")
 	}
 	if !numberRegex.MatchString(password) {
-		return errors.New("密碼至少包含一個數字")
+		return Type.BadRequest("密碼至少包含一個數字")
 	}
 	if !specialCharRegex.MatchString(password) {
-		return errors.New("密碼至少包含一個特殊字元(" + allowedSpecialChars + ")")
+		return Type.BadRequest("密碼至少包含一個特殊字元(%s)").WithArgs(allowedSpecialChars)
 	}
 	if invalidCharRegex.MatchString(password) {
-		return errors.New("密碼含有未知字元 合法特殊字元為(" + allowedSpecialChars + ")")
+		return Type.BadRequest("密碼含有未知字元 合法特殊字元為(%s)").WithArgs(allowedSpecialChars)
 	}
 	return nil // Password is valid
 }
@@ -119,18 +123,63 @@ func GenerateSixDigitCode() (int, error) {
 	// Range: 100000 to 999999 inclusive (i.e., 900000 possibilities)
 	nBig, err := rand.Int(rand.Reader, big.NewInt(900000))
 	if err != nil {
-		log.Println(err)
+		slog.Error("generate six digit code failed", "error", err)
 		return 0, err
 	}
 	code := int(nBig.Int64()) + 100000
 	return code, nil
 }
 
+// 產生個人API token的明碼，格式為qzp_加上32 bytes的隨機值
+func GenerateAccessToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		slog.Error("generate access token failed", "error", err)
+		return "", err
+	}
+	return Consts.AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// 產生單字集分享連結的token，格式為qzs_加上24 bytes的隨機值，DB一樣只存HashAccessToken的結果
+func GenerateShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		slog.Error("generate share token failed", "error", err)
+		return "", err
+	}
+	return Consts.ShareTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// API token本身已經是高熵的隨機值，用SHA-256就夠了(bcrypt太慢，每個request都要驗證)
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// 產生webhook的HMAC secret，格式為whsec_加上32 bytes的隨機值
+func GenerateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		slog.Error("generate webhook secret failed", "error", err)
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// webhook簽章：HMAC-SHA256(secret, "<timestamp>.<body>")的hex
+// 接收端用X-Quizlet-Timestamp跟body算一次比對X-Quizlet-Signature，並拒絕太舊的timestamp避免replay
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // HashPassword generates a bcrypt hash of the password
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Println("hashing password error", err.Error())
+		slog.Error("hashing password error", "error", err)
 		return "", err
 	}
 	return string(hashedPassword), nil
@@ -278,7 +327,7 @@ func UploadToImgur(file io.Reader, filename string) (*Type.ImgurResponse, error)
 		return nil, fmt.Errorf("request failed: %v", err)
 	}
 	defer resp.Body.Close()
-	fmt.Println("response", resp)
+	slog.Debug("imgur upload response", "status", resp.StatusCode)
 	// Decode response
 	var imgurResp Type.ImgurResponse
 	if err = json.NewDecoder(resp.Body).Decode(&imgurResp); err != nil {
@@ -290,7 +339,7 @@ func UploadToImgur(file io.Reader, filename string) (*Type.ImgurResponse, error)
 
 func SendEmailWithTimeout(htmlPath, mailTitle, toMail, data string, timeout time.Duration) error {
 	errChan := make(chan error, 1)
-	log.Println("sending to:",toMail)
+	slog.Info("sending email", "subject", mailTitle, "template", filepath.Base(htmlPath))
 	go func() {
 		errChan <- SendEmail(htmlPath, mailTitle, toMail, data)
 	}()
@@ -299,7 +348,7 @@ func SendEmailWithTimeout(htmlPath, mailTitle, toMail, data string, timeout time
 	case err := <-errChan:
 		return err
 	case <-time.After(timeout):
-		return errors.New("寄送郵件逾時，請稍後再試")
+		return Type.Timeout("寄送郵件逾時，請稍後再試")
 	}
 }
 
@@ -307,13 +356,13 @@ func SendEmail(htmlPath string, mailTitle string, toMail string, data string) er
 	var htmlBody bytes.Buffer
 	t, err := template.ParseFiles(htmlPath)
 	if err != nil {
-		log.Println(err)
-		return errors.New("HTML解析錯誤")
+		slog.Error("parse email template failed", "template", htmlPath, "error", err)
+		return Type.Internal("HTML解析錯誤").Wrap(err)
 	}
 	err = t.Execute(&htmlBody, Type.EmailHTMLDate{Email: toMail, Data: data})
 	if err != nil {
-		log.Println(err)
-		return errors.New("HTML執行錯誤")
+		slog.Error("execute email template failed", "template", htmlPath, "error", err)
+		return Type.Internal("HTML執行錯誤").Wrap(err)
 	}
 	m := gomail.NewMessage()
 	m.SetHeader("From", os.Getenv("MAIL_USERNAME"))
@@ -326,7 +375,7 @@ func SendEmail(htmlPath string, mailTitle string, toMail string, data string) er
 
 	// Send the email to Bob, Cora and Dan.
 	if err := d.DialAndSend(m); err != nil {
-		return err
+		return Type.Internal("郵件寄送失敗 請重試").Wrap(err)
 	}
 
 	return nil
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 常用繁體字對應的簡體字，兩個字串一個字對一個字
+// 比對重複單字時把繁體轉成簡體，多對一(例如發、髮都轉成发)只會讓比對更寬鬆
+const (
+	traditionalHanzi = "個們來時國說對會過還這麼後發學點樣經問題開關見長動現實與為無從電車東門間聽氣愛書買賣錢鐵銀錯鐘頭臉腦" +
+		"鳥魚馬龍雞鴨貓豬蟲風雲陽陰歲廣廠廳應慶聯讀寫語詞譯認識記設許論請謝讓議變計訂討調運進遠選達邊遲適遺週" +
+		"場報壞塊壓堅夢夠奮婦孫寶寧專將層屬島師帶幫幣廢強歸當彈復徑態懷戰戶掃換擇擊擔據攝數斷於業極標樓樂機橋" +
+		"權歡歷殺決沒況淚淨測湯溫滿漢潔濃災燈爐爭爺牆獨獎環畫療盡監盤碼礎禮禍種穩窮競筆節範築簡籃類糧紀約紅級" +
+		"紙細終組結給絡統絲綠網線練編緊總績繼續織罰習聞聲職肅腳興舉艦藝蘋蘭處號術衛衝補裝複製規視親覺觀觸訓託" +
+		"證評試詩誌誕誤課談質貝負貨貧責貴費貿資賽趕趨躍軍軟較輕輪輸轉辦農連遊隊險隨際雖雙難離雜靈靜響頁順須領" +
+		"頻顏願顧飛飯飲館餘驗體髮鬥麗黃齊齒鹽麵龜萬億舊義華葉藥蓋蘇夥傳傷價優償儀僅倉傘備兒內兩冊剛創劃劇勞勢" +
+		"務勝勵區協單參員圍圖團園圓執墊壇壯壺夾奪妝娛嬰宮寢審尋導屆屢嶺幾庫廟張彎徵恆惡惱慣憂憶戲撥擁擠擴擾攜" +
+		"敵晉曉曆暫條楊榮槍構樹橫檢櫃歐殘毀氫漁潤濕灣燒熱爛獅獲產畝瘋癢盜眾瞭礙確磚稅稱穀窩竊簽籠紛純紗納紋縣" +
+		"縮繩繪罷羅聖膽膚臟艙莊著蔥蝦蠟蠶襪覽訪診詢該詳誠誰諒諾謀講謊譜護讚豐財販貫貼賞賴賺購贈跡踐蹤軌載輔輩" +
+		"轎辭邏郵鄉鄰醫釋針釣鈴鉛銅鋼錄錦鍋鍵鎖鏡閃閉閱闆闊陣陳陸隱隻霧韓頂項預頓頸顆額顯颱飄餅養餓駕騎騙驚髒" +
+		"鬧鬱鮮鯨鳳鳴鴿鵝鶴鷹麥黨齡臺灑廁廚廂彙裡裏乾幹嗎媽鬆鬍錶"
+	simplifiedHanzi = "个们来时国说对会过还这么后发学点样经问题开关见长动现实与为无从电车东门间听气爱书买卖钱铁银错钟头脸脑" +
+		"鸟鱼马龙鸡鸭猫猪虫风云阳阴岁广厂厅应庆联读写语词译认识记设许论请谢让议变计订讨调运进远选达边迟适遗周" +
+		"场报坏块压坚梦够奋妇孙宝宁专将层属岛师带帮币废强归当弹复径态怀战户扫换择击担据摄数断于业极标楼乐机桥" +
+		"权欢历杀决没况泪净测汤温满汉洁浓灾灯炉争爷墙独奖环画疗尽监盘码础礼祸种稳穷竞笔节范筑简篮类粮纪约红级" +
+		"纸细终组结给络统丝绿网线练编紧总绩继续织罚习闻声职肃脚兴举舰艺苹兰处号术卫冲补装复制规视亲觉观触训托" +
+		"证评试诗志诞误课谈质贝负货贫责贵费贸资赛赶趋跃军软较轻轮输转办农连游队险随际虽双难离杂灵静响页顺须领" +
+		"频颜愿顾飞饭饮馆余验体发斗丽黄齐齿盐面龟万亿旧义华叶药盖苏伙传伤价优偿仪仅仓伞备儿内两册刚创划剧劳势" +
+		"务胜励区协单参员围图团园圆执垫坛壮壶夹夺妆娱婴宫寝审寻导届屡岭几库庙张弯征恒恶恼惯忧忆戏拨拥挤扩扰携" +
+		"敌晋晓历暂条杨荣枪构树横检柜欧残毁氢渔润湿湾烧热烂狮获产亩疯痒盗众了碍确砖税称谷窝窃签笼纷纯纱纳纹县" +
+		"缩绳绘罢罗圣胆肤脏舱庄着葱虾蜡蚕袜览访诊询该详诚谁谅诺谋讲谎谱护赞丰财贩贯贴赏赖赚购赠迹践踪轨载辅辈" +
+		"轿辞逻邮乡邻医释针钓铃铅铜钢录锦锅键锁镜闪闭阅板阔阵陈陆隐只雾韩顶项预顿颈颗额显台飘饼养饿驾骑骗惊脏" +
+		"闹郁鲜鲸凤鸣鸽鹅鹤鹰麦党龄台洒厕厨厢汇里里干干吗妈松胡表"
+)
+
+var hanziFolding = func() map[rune]rune {
+	traditional, simplified := []rune(traditionalHanzi), []rune(simplifiedHanzi)
+	if len(traditional) != len(simplified) {
+		panic("traditional/simplified hanzi table length mismatch")
+	}
+	folding := make(map[rune]rune, len(traditional))
+	for i, r := range traditional {
+		folding[r] = simplified[i]
+	}
+	return folding
+}()
+
+// 比對重複單字用的正規化：全形轉半形(NFKC)、不分大小寫、繁體轉簡體、忽略前後以及連續的空白
+func NormalizeVocabulary(vocabulary string) string {
+	folded := strings.Map(func(r rune) rune {
+		if simplified, ok := hanziFolding[r]; ok {
+			return simplified
+		}
+		return r
+	}, strings.ToLower(norm.NFKC.String(vocabulary)))
+	return strings.Join(strings.Fields(folded), " ")
+}
+
+// 兩個字串的編輯距離(以字元計，相鄰兩字對調算一次)，超過limit就提早回傳limit+1
+func EditDistance(a string, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > limit {
+		return limit + 1
+	}
+	prevPrev := make([]int, len(ra)+1)
+	prev := make([]int, len(ra)+1)
+	curr := make([]int, len(ra)+1)
+	for i := range prev {
+		prev[i] = i
+	}
+	for j := 1; j <= len(rb); j++ {
+		curr[0] = j
+		rowMin := curr[0]
+		for i := 1; i <= len(ra); i++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[i] = min(prev[i]+1, curr[i-1]+1, prev[i-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[i] = min(curr[i], prevPrev[i-2]+1)
+			}
+			rowMin = min(rowMin, curr[i])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+	return prev[len(ra)]
+}
+
+// 正規化後的兩個單字是否拼字相近，短的單字(例如中文詞)只看完全相同
+func IsSimilarVocabulary(a string, b string) bool {
+	length := min(len([]rune(a)), len([]rune(b)))
+	limit := 0
+	switch {
+	case length >= 9:
+		limit = 2
+	case length >= 5:
+		limit = 1
+	}
+	return limit > 0 && EditDistance(a, b, limit) <= limit
+}
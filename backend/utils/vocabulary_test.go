@@ -0,0 +1,95 @@
+package utils
+
+import "testing"
+
+func TestNormalizeVocabulary(t *testing.T) {
+	tests := []struct {
+		vocabulary string
+		want       string
+	}{
+		{"Apple", "apple"},
+		{"  big   apple  ", "big apple"},
+		{"Ｈｅｌｌｏ　Ｗｏｒｌｄ", "hello world"},
+		{"頭髮", "头发"},
+		{"發現", "发现"},
+		{"裡面", "里面"},
+		{"裏面", "里面"},
+		{"头发", "头发"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeVocabulary(test.vocabulary); got != test.want {
+			t.Errorf("NormalizeVocabulary(%q) = %q, want %q", test.vocabulary, got, test.want)
+		}
+	}
+}
+
+// 多對一的繁體字轉成同一個簡體字，正規化後要相同
+func TestNormalizeVocabularyFolding(t *testing.T) {
+	tests := []struct {
+		a string
+		b string
+	}{
+		{"髮", "發"},
+		{"頭髮", "頭发"},
+		{"裡", "裏"},
+		{"乾", "幹"},
+		{"APPLE", "ａｐｐｌｅ"},
+	}
+	for _, test := range tests {
+		if a, b := NormalizeVocabulary(test.a), NormalizeVocabulary(test.b); a != b {
+			t.Errorf("NormalizeVocabulary(%q) = %q, NormalizeVocabulary(%q) = %q, want equal", test.a, a, test.b, b)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a     string
+		b     string
+		limit int
+		want  int
+	}{
+		{"apple", "apple", 0, 0},
+		{"", "", 0, 0},
+		{"", "abc", 3, 3},
+		{"apple", "apply", 2, 1},
+		{"ab", "ba", 2, 1}, // 相鄰對調算一次
+		{"receive", "recieve", 1, 1},
+		{"abcd", "badc", 2, 2},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3}, // 超過limit回傳limit+1
+		{"abc", "abcde", 2, 2},      // 剛好等於limit
+		{"abc", "abcdef", 2, 3},     // 長度差超過limit
+		{"蘋果", "蘋菓", 1, 1},          // 以字元計不是byte
+	}
+	for _, test := range tests {
+		if got := EditDistance(test.a, test.b, test.limit); got != test.want {
+			t.Errorf("EditDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestIsSimilarVocabulary(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{"cat", "cot", false},    // 太短不比對
+		{"cat", "cat", false},    // 完全相同由呼叫端判斷
+		{"蘋果", "蘋菓", false},      // 中文詞只看完全相同
+		{"apple", "apply", true}, // 5個字元容許1
+		{"apple", "maple", false},
+		{"receive", "recieve", true},
+		{"beautiful", "butiful", false},  // 短的只有7個字元，容許1
+		{"beautiful", "beutifull", true}, // 9個字元以上容許2
+		{"strawberry", "strowbarry", true},
+		{"strawberry", "strowbarrie", false},
+	}
+	for _, test := range tests {
+		if got := IsSimilarVocabulary(test.a, test.b); got != test.want {
+			t.Errorf("IsSimilarVocabulary(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
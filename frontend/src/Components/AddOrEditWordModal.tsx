@@ -1,11 +1,20 @@
 import { useEffect, useState } from "react";
-import { Word, NoticeDisplay } from "../Types/types";
+import {
+  CardTemplate,
+  Word,
+  NoticeDisplay,
+  WordExample,
+  WordMeaning,
+} from "../Types/types";
 import { postRequest } from "../Utils/postRequest";
-import { PATH } from "../Consts/consts";
+import { PATH, partsOfSpeech } from "../Consts/consts";
 import { AddWordRequest, BigWordCardUpdateWordRequest } from "../Types/request";
 import { isValidSound } from "../Utils/utils";
 import ClipLoader from "react-spinners/ClipLoader";
+import WordImageUploader from "./WordImageUploader";
+import WordAudioUploader from "./WordAudioUploader";
 import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+import { useLanguages } from "../Hooks/useLanguages";
 
 export default function AddOrEditWordModal({
   wordSetID,
@@ -15,7 +24,11 @@ export default function AddOrEditWordModal({
   mode, // true => Add Word, false => Edit Word
   starMode, // true => Add star word, false => Add non-star word
   setWords,
+  version,
+  setVersion,
   order,
+  template,
+  shouldSwap = false,
 }: {
   wordSetID: string;
   isModalOpen: boolean;
@@ -24,8 +37,13 @@ export default function AddOrEditWordModal({
   mode: boolean;
   starMode: boolean;
   setWords: React.Dispatch<React.SetStateAction<Word[]>>;
+  version: number;
+  setVersion: React.Dispatch<React.SetStateAction<number>>;
   order?: number;
+  template?: CardTemplate; // 單字集的卡片模板，有自訂欄位的話可以填
+  shouldSwap?: boolean; // curWord的vocabulary/definition是否已經交換過
 }) {
+  const languages = useLanguages();
   const { setNotice } = useNoticeDisplayContextProvider();
   const [vocabularySound, setVocabularySound] = useState<string>(
     mode ? "en-US" : (curWord?.vocabularySound ?? ""),
@@ -33,6 +51,45 @@ export default function AddOrEditWordModal({
   const [definitionSound, setDefinitionSound] = useState<string>(
     mode ? "en-US" : (curWord?.definitionSound ?? ""),
   );
+  // 音標、詞性、其他意思以及例句都是選填的，空白的意思/例句送出前拿掉
+  const [pronunciation, setPronunciation] = useState<string>("");
+  const [partOfSpeech, setPartOfSpeech] = useState<string>("");
+  const [meanings, setMeanings] = useState<WordMeaning[]>([]);
+  const [examples, setExamples] = useState<WordExample[]>([]);
+  const [isDetailOpen, setIsDetailOpen] = useState<boolean>(false);
+  // 模板的自訂欄位(vocabulary/definition以外)
+  const customFields = (template?.fields ?? []).filter(
+    (field) => field.key !== "vocabulary" && field.key !== "definition",
+  );
+  const [fields, setFields] = useState<Record<string, string>>({});
+  // 只留下有值的自訂欄位
+  const filledFields = () =>
+    Object.fromEntries(
+      customFields
+        .map((field) => [field.key, (fields[field.key] ?? "").trim()])
+        .filter(([, value]) => value !== ""),
+    ) as Record<string, string>;
+  const wordDetails = () => ({
+    pronunciation: pronunciation.trim(),
+    partOfSpeech: partOfSpeech,
+    meanings: meanings
+      .map((meaning) => ({ ...meaning, definition: meaning.definition.trim() }))
+      .filter((meaning) => meaning.definition !== ""),
+    examples: examples
+      .map((example) => ({
+        sentence: example.sentence.trim(),
+        translation: example.translation?.trim(),
+      }))
+      .filter((example) => example.sentence !== ""),
+  });
+  const resetWordDetails = (word?: Word) => {
+    setPronunciation(word?.pronunciation ?? "");
+    setPartOfSpeech(word?.partOfSpeech ?? "");
+    setMeanings(word?.meanings ?? []);
+    setExamples(word?.examples ?? []);
+    setFields(word?.fields ?? {});
+  };
+
   const addWord = (
     vocabulary: string,
     definition: string,
@@ -77,13 +134,17 @@ export default function AddOrEditWordModal({
       star: starMode,
       vocabularySound: vocabularySound,
       definitionSound: definitionSound,
+      ...wordDetails(),
+      fields: filledFields(),
     } as Word;
     setIsAddWordLoading(true);
     postRequest(`${PATH}/addWord`, {
       wordSetID: wordSetID,
       word: newWord,
+      version: version,
     } as AddWordRequest)
       .then((data) => {
+        setVersion(data.payload.version);
         newWord.id = data.payload.message; // 取得後端回傳的wordID，這樣前端就可以控制他
         setWords((prevWords) => [
           ...prevWords, // Keep existing words
@@ -139,6 +200,8 @@ export default function AddOrEditWordModal({
       setDefinitionError("聲音格式錯誤");
       return;
     }
+    const details = wordDetails();
+    const newFields = filledFields();
     setIsEditWordLoading(true);
     postRequest(`${PATH}/bigWordCardUpdateWord`, {
       wordSetID: wordSetID,
@@ -147,8 +210,18 @@ export default function AddOrEditWordModal({
       newDefinition: newDefinition,
       newVocabularySound: newVocabularySound,
       newDefinitionSound: newDefinitionSound,
+      newPronunciation: details.pronunciation,
+      newPartOfSpeech: details.partOfSpeech,
+      newMeanings: details.meanings,
+      newExamples: details.examples,
+      // 沒填的自訂欄位送空字串，後端會清掉
+      newFields: Object.fromEntries(
+        customFields.map((field) => [field.key, newFields[field.key] ?? ""]),
+      ),
+      version: version,
     } as BigWordCardUpdateWordRequest)
-      .then(() => {
+      .then((data) => {
+        setVersion(data.payload.version);
         setWords((preWords) =>
           preWords.map((word) =>
             word.id === wordID
@@ -158,6 +231,11 @@ export default function AddOrEditWordModal({
                   definition: newDefinition,
                   vocabularySound: newVocabularySound,
                   definitionSound: newDefinitionSound,
+                  // 轉好的HTML已經過時，重新整理前先顯示純文字
+                  vocabularyHtml: undefined,
+                  definitionHtml: undefined,
+                  ...details,
+                  fields: newFields,
                 }
               : word,
           ),
@@ -184,6 +262,7 @@ export default function AddOrEditWordModal({
     setDefinition(curWord?.definition ?? "");
     setVocabularySound(curWord?.vocabularySound ?? ""); // 重製word跟definition
     setDefinitionSound(curWord?.definitionSound ?? "");
+    resetWordDetails(mode ? undefined : curWord);
   };
 
   // Modal目前的vocabulary跟definition
@@ -199,6 +278,7 @@ export default function AddOrEditWordModal({
     setDefinition(mode ? "" : curWord?.definition || "");
     setVocabularySound(mode ? "en-US" : curWord?.vocabularySound || "en-US");
     setDefinitionSound(mode ? "zh-TW" : curWord?.definitionSound || "zh-TW");
+    resetWordDetails(mode ? undefined : curWord);
   }, [curWord, mode, isModalOpen]); // Add isModalOpen dependency
 
   const [vocabularyError, setVocabularyError] = useState<string>("");
@@ -218,7 +298,7 @@ export default function AddOrEditWordModal({
       {/* modal */}
 
       <div
-        className={`${isModalOpen ? "visible top-[50%] opacity-100" : "invisible top-[40%] opacity-0"} fixed left-[50%] z-1000 flex max-h-[350px] w-[90%] max-w-[380px] translate-x-[-50%] translate-y-[-50%] flex-col gap-4 rounded-2xl bg-white p-8 transition-all duration-300 sm:max-h-[450px] sm:max-w-[630px] sm:p-12 lg:max-h-[480px] lg:max-w-[800px]`}
+        className={`${isModalOpen ? "visible top-[50%] opacity-100" : "invisible top-[40%] opacity-0"} fixed left-[50%] z-1000 flex max-h-[350px] overflow-y-auto w-[90%] max-w-[380px] translate-x-[-50%] translate-y-[-50%] flex-col gap-4 rounded-2xl bg-white p-8 transition-all duration-300 sm:max-h-[450px] sm:max-w-[630px] sm:p-12 lg:max-h-[480px] lg:max-w-[800px]`}
       >
         {/* cross button */}
         <button
@@ -259,11 +339,11 @@ export default function AddOrEditWordModal({
                 value={vocabularySound}
                 onChange={(e) => setVocabularySound(e.target.value)}
               >
-                <option value={"en-US"}>英語(美式)</option>
-                <option value={"en-GB"}>英語(英式)</option>
-                <option value={"en-AU"}>英語(澳洲)</option>
-                <option value={"zh-TW"}>中文(繁體)</option>
-                <option value={"zh-CN"}>中文(簡體)</option>
+                {languages.map((language) => (
+                  <option key={language.tag} value={language.tag}>
+                    {language.name}
+                  </option>
+                ))}
               </select>
             </div>
           </div>
@@ -295,15 +375,200 @@ export default function AddOrEditWordModal({
                   setDefinitionSound(e.target.value);
                 }}
               >
-                <option value={"en-US"}>英語(美式)</option>
-                <option value={"en-GB"}>英語(英式)</option>
-                <option value={"en-AU"}>英語(澳洲)</option>
-                <option value={"zh-TW"}>中文(繁體)</option>
-                <option value={"zh-CN"}>中文(簡體)</option>
+                {languages.map((language) => (
+                  <option key={language.tag} value={language.tag}>
+                    {language.name}
+                  </option>
+                ))}
               </select>
             </div>
           </div>
         </div>
+        {/* 圖片跟發音要有wordID才能上傳，新增單字後再編輯加上 */}
+        {!mode && curWord && (
+          <>
+            <WordImageUploader
+              wordSetID={wordSetID}
+              word={curWord}
+              setWords={setWords}
+              version={version}
+              setVersion={setVersion}
+            />
+            {(["vocabulary", "definition"] as const).map((side) => (
+              <WordAudioUploader
+                key={side}
+                wordSetID={wordSetID}
+                word={curWord}
+                side={side}
+                swapped={shouldSwap}
+                setWords={setWords}
+                version={version}
+                setVersion={setVersion}
+              />
+            ))}
+          </>
+        )}
+        {customFields.map((field) => (
+          <div key={field.key} className="flex flex-col gap-1">
+            <input
+              placeholder={field.label}
+              value={fields[field.key] ?? ""}
+              maxLength={300}
+              onChange={(e) =>
+                setFields((prev) => ({ ...prev, [field.key]: e.target.value }))
+              }
+              className="border-b-2 border-black text-xl outline-none focus:border-amber-300"
+            />
+            <span className="text-[.8rem] md:text-[1rem]">{field.label}</span>
+          </div>
+        ))}
+        <button
+          onClick={() => setIsDetailOpen((prev) => !prev)}
+          className="w-max text-[.8rem] font-bold text-[var(--light-theme-color)] hover:cursor-pointer md:text-[1rem]"
+        >
+          {isDetailOpen
+            ? "隱藏其他欄位"
+            : "音標、詞性、其他意思及例句"}
+        </button>
+        {isDetailOpen && (
+          <div className="flex flex-col gap-4 text-[.8rem] md:text-[1rem]">
+            <div className="flex flex-wrap gap-4">
+              <input
+                placeholder="音標或拼音"
+                value={pronunciation}
+                maxLength={100}
+                onChange={(e) => setPronunciation(e.target.value)}
+                className="flex-grow border-b-2 border-black outline-none focus:border-amber-300"
+              />
+              <select
+                className="text-[var(--light-theme-color)]"
+                value={partOfSpeech}
+                onChange={(e) => setPartOfSpeech(e.target.value)}
+              >
+                <option value={""}>詞性</option>
+                {partsOfSpeech.map((pos) => (
+                  <option key={pos.value} value={pos.value}>
+                    {pos.TwName}
+                  </option>
+                ))}
+              </select>
+            </div>
+            {meanings.map((meaning, index) => (
+              <div key={index} className="flex items-center gap-2">
+                <span>{index + 2}.</span>
+                <input
+                  placeholder="其他意思"
+                  value={meaning.definition}
+                  maxLength={300}
+                  onChange={(e) =>
+                    setMeanings((prev) =>
+                      prev.map((cur, i) =>
+                        i === index
+                          ? { ...cur, definition: e.target.value }
+                          : cur,
+                      ),
+                    )
+                  }
+                  className="flex-grow border-b-2 border-black outline-none focus:border-amber-300"
+                />
+                <select
+                  className="text-[var(--light-theme-color)]"
+                  value={meaning.partOfSpeech ?? ""}
+                  onChange={(e) =>
+                    setMeanings((prev) =>
+                      prev.map((cur, i) =>
+                        i === index
+                          ? { ...cur, partOfSpeech: e.target.value }
+                          : cur,
+                      ),
+                    )
+                  }
+                >
+                  <option value={""}>詞性</option>
+                  {partsOfSpeech.map((pos) => (
+                    <option key={pos.value} value={pos.value}>
+                      {pos.TwName}
+                    </option>
+                  ))}
+                </select>
+                <button
+                  onClick={() =>
+                    setMeanings((prev) => prev.filter((_, i) => i !== index))
+                  }
+                  className="hover:cursor-pointer"
+                >
+                  &#x2716;
+                </button>
+              </div>
+            ))}
+            {examples.map((example, index) => (
+              <div key={index} className="flex items-start gap-2">
+                <div className="flex flex-grow flex-col gap-2">
+                  <input
+                    placeholder="例句"
+                    value={example.sentence}
+                    maxLength={300}
+                    onChange={(e) =>
+                      setExamples((prev) =>
+                        prev.map((cur, i) =>
+                          i === index
+                            ? { ...cur, sentence: e.target.value }
+                            : cur,
+                        ),
+                      )
+                    }
+                    className="border-b-2 border-black outline-none focus:border-amber-300"
+                  />
+                  <input
+                    placeholder="例句翻譯(選填)"
+                    value={example.translation ?? ""}
+                    maxLength={300}
+                    onChange={(e) =>
+                      setExamples((prev) =>
+                        prev.map((cur, i) =>
+                          i === index
+                            ? { ...cur, translation: e.target.value }
+                            : cur,
+                        ),
+                      )
+                    }
+                    className="border-b-2 border-black outline-none focus:border-amber-300"
+                  />
+                </div>
+                <button
+                  onClick={() =>
+                    setExamples((prev) => prev.filter((_, i) => i !== index))
+                  }
+                  className="hover:cursor-pointer"
+                >
+                  &#x2716;
+                </button>
+              </div>
+            ))}
+            <div className="flex gap-4 font-bold text-[var(--light-theme-color)]">
+              {meanings.length < 10 && (
+                <button
+                  onClick={() =>
+                    setMeanings((prev) => [...prev, { definition: "" }])
+                  }
+                  className="hover:cursor-pointer"
+                >
+                  + 新增意思
+                </button>
+              )}
+              {examples.length < 5 && (
+                <button
+                  onClick={() =>
+                    setExamples((prev) => [...prev, { sentence: "" }])
+                  }
+                  className="hover:cursor-pointer"
+                >
+                  + 新增例句
+                </button>
+              )}
+            </div>
+          </div>
+        )}
         <div className="mt-auto flex w-full items-center justify-end gap-6">
           <button
             onClick={() => handleClose()}
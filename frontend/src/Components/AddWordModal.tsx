@@ -1,6 +1,7 @@
 import { useState } from "react";
 import { isValidSound } from "../Utils/utils";
 import React from "react";
+import { useLanguages } from "../Hooks/useLanguages";
 
 export default React.memo(function AddWordModal({
   isModalOpen,
@@ -27,6 +28,7 @@ export default React.memo(function AddWordModal({
     definitionSound: string,
   ) => void;
 }) {
+  const languages = useLanguages();
   // Modal目前的vocabulary跟definition
   const [vocabulary, setVocabulary] = useState<string>("");
   const [definition, setDefinition] = useState<string>("");
@@ -162,11 +164,11 @@ export default React.memo(function AddWordModal({
                   setVocabularySoundError("");
                 }}
               >
-                <option value={"en-US"}>英語(美式)</option>
-                <option value={"en-GB"}>英語(英式)</option>
-                <option value={"en-AU"}>英語(澳洲)</option>
-                <option value={"zh-TW"}>中文(繁體)</option>
-                <option value={"zh-CN"}>中文(簡體)</option>
+                {languages.map((language) => (
+                  <option key={language.tag} value={language.tag}>
+                    {language.name}
+                  </option>
+                ))}
               </select>
             </div>
           </div>
@@ -202,11 +204,11 @@ export default React.memo(function AddWordModal({
                   setDefinitionSoundError("");
                 }}
               >
-                <option value={"en-US"}>英語(美式)</option>
-                <option value={"en-GB"}>英語(英式)</option>
-                <option value={"en-AU"}>英語(澳洲)</option>
-                <option value={"zh-TW"}>中文(繁體)</option>
-                <option value={"zh-CN"}>中文(簡體)</option>
+                {languages.map((language) => (
+                  <option key={language.tag} value={language.tag}>
+                    {language.name}
+                  </option>
+                ))}
               </select>
             </div>
           </div>
@@ -7,10 +7,16 @@ import { LiaRandomSolid } from "react-icons/lia";
 import { RiFullscreenFill } from "react-icons/ri";
 import { FaBackwardStep } from "react-icons/fa6";
 import { FaPause } from "react-icons/fa6";
-import { Word } from "../Types/types";
-import { getRandomInt, AutoPlaySpeaker, Speaker } from "../Utils/utils";
+import { CardTemplate, Word } from "../Types/types";
+import {
+  getRandomInt,
+  AutoPlaySpeaker,
+  stopSpeaking,
+  cardFace,
+  speakCardFace,
+} from "../Utils/utils";
+import CardFaceContent from "./CardFaceContent";
 import AddOrEditWordModal from "./AddOrEditWordModal";
-import { useLogInContextProvider } from "../Context/LogInContextProvider";
 import { useNavigate } from "react-router";
 import React from "react";
 
@@ -18,17 +24,26 @@ const BigWordCard = React.memo(
   ({
     wordSetID,
     words,
-    authorID,
+    canEdit,
+    canStar,
     handleStarOne,
     setWords,
+    version,
+    setVersion,
+    template,
+    shouldSwap,
   }: {
     wordSetID: string;
     words: Word[];
-    authorID: string;
+    canEdit: boolean; // 作者或editor協作者
+    canStar: boolean; // 有登入就可以加自己的星號
     handleStarOne: (id: string) => void;
     setWords: React.Dispatch<React.SetStateAction<Word[]>>;
+    version: number;
+    setVersion: React.Dispatch<React.SetStateAction<number>>;
+    template: CardTemplate;
+    shouldSwap: boolean; // words已經交換過vocabulary/definition
   }) => {
-    const { user } = useLogInContextProvider();
     const navigate = useNavigate();
     const [isCardFlip, setIsCardFlip] = useState(false);
     const [isHintOpen, setIsHintOpen] = useState(false);
@@ -108,13 +123,23 @@ const BigWordCard = React.memo(
 
       // Show front side for 3 seconds
       setIsCardFlip(false);
+      const backEntries = cardFace(
+        sortedWords[curWordIndexConst],
+        template,
+        "back",
+        shouldSwap,
+      );
+      const spoken = backEntries.find((e) => e.sound !== "") ?? backEntries[0];
       try {
         soundPlayTimerRef.current = setTimeout(async () => {
-          await AutoPlaySpeaker(
-            sortedWords[curWordIndexConst].vocabulary,
-            sortedWords[curWordIndexConst].vocabularySound,
-            speechRef,
-          );
+          if (spoken !== undefined) {
+            await AutoPlaySpeaker(
+              spoken.text,
+              spoken.sound,
+              speechRef,
+              spoken.audio,
+            );
+          }
           // Flip to back side and show for 2 seconds
           setIsCardFlip(true);
           autoPlayTimerRef.current = setTimeout(() => {
@@ -144,11 +169,9 @@ const BigWordCard = React.memo(
         soundPlayTimerRef.current = null;
       }
 
-      // Stop speech synthesis if it's active
-      if (speechRef.current) {
-        speechSynthesis.cancel(); // Stops the speech immediately
-        speechRef.current = null;
-      }
+      // Stop speech synthesis or uploaded audio if it's active
+      stopSpeaking(); // Stops the speech immediately
+      speechRef.current = null;
 
       setIsAutoPlaying(false);
     };
@@ -191,6 +214,19 @@ const BigWordCard = React.memo(
       }
     };
     console.log("big word re-render");
+    const frontEntries = cardFace(
+      sortedWords[curWordIndex],
+      template,
+      "front",
+      shouldSwap,
+    );
+    const backEntries = cardFace(
+      sortedWords[curWordIndex],
+      template,
+      "back",
+      shouldSwap,
+    );
+    const hintText = backEntries[0]?.text ?? "";
 
     return (
       <>
@@ -202,6 +238,10 @@ const BigWordCard = React.memo(
           starMode={false}
           curWord={sortedWords[curWordIndex]}
           setWords={setWords}
+          version={version}
+          setVersion={setVersion}
+          template={template}
+          shouldSwap={shouldSwap}
         />
         <div className="relative flex max-h-[620px] min-h-[500px] max-w-full min-w-0 flex-col perspective-[1000px]">
           {/* front content area */}
@@ -232,15 +272,13 @@ const BigWordCard = React.memo(
                   <BiBulb className="h-6 w-6" />
                   <span className="text-md sm:text-lg">
                     {isHintOpen
-                      ? sortedWords[curWordIndex].vocabulary[0] +
-                        "_".repeat(
-                          sortedWords[curWordIndex].vocabulary.length - 1,
-                        )
+                      ? hintText.slice(0, 1) +
+                        "_".repeat(Math.max(0, hintText.length - 1))
                       : "顯示提示"}
                   </span>
                 </div>
                 <div className="flex gap-2 sm:gap-4">
-                  {user !== null && user.id === authorID && (
+                  {canEdit && (
                     <button
                       onClick={(e) => {
                         e.stopPropagation();
@@ -255,16 +293,13 @@ const BigWordCard = React.memo(
                   <button
                     onClick={(e) => {
                       e.stopPropagation();
-                      Speaker(
-                        sortedWords[curWordIndex].definition,
-                        sortedWords[curWordIndex].definitionSound,
-                      );
+                      speakCardFace(frontEntries);
                     }}
                     className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
                   >
                     <HiOutlineSpeakerWave className="h-6 w-6" />
                   </button>
-                  {user !== null && user.id === authorID && (
+                  {canStar && (
                     <button
                       onClick={(e) => {
                         e.stopPropagation();
@@ -280,10 +315,12 @@ const BigWordCard = React.memo(
                 </div>
               </div>
 
-              {/* definition */}
-              <div className="break-word flex flex-grow items-center justify-center overflow-scroll text-center text-2xl text-black md:text-4xl lg:text-5xl">
-                {sortedWords[curWordIndex].definition}
-              </div>
+              {/* 正面，預設是definition */}
+              <CardFaceContent
+                entries={frontEntries}
+                image={sortedWords[curWordIndex].image}
+                className="text-2xl md:text-4xl lg:text-5xl"
+              />
             </div>
           </div>
 
@@ -307,7 +344,7 @@ const BigWordCard = React.memo(
               <div className="flex w-full items-center justify-between">
                 <div></div> {/* 移除了提示之後要留著空的div確保排版 */}
                 <div className="flex gap-2 sm:gap-4">
-                  {user !== null && user.id === authorID && (
+                  {canEdit && (
                     <button
                       onClick={(e) => {
                         e.stopPropagation();
@@ -322,16 +359,13 @@ const BigWordCard = React.memo(
                   <button
                     onClick={(e) => {
                       e.stopPropagation();
-                      Speaker(
-                        sortedWords[curWordIndex].vocabulary,
-                        sortedWords[curWordIndex].vocabularySound,
-                      );
+                      speakCardFace(backEntries);
                     }}
                     className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['撥放'] hover:cursor-pointer hover:after:visible"
                   >
                     <HiOutlineSpeakerWave className="h-6 w-6" />
                   </button>
-                  {user !== null && user.id === authorID && (
+                  {canStar && (
                     <button
                       onClick={(e) => {
                         e.stopPropagation();
@@ -347,10 +381,11 @@ const BigWordCard = React.memo(
                 </div>
               </div>
 
-              {/* vocabulary */}
-              <div className="break-word flex flex-grow items-center justify-center overflow-scroll text-center text-3xl text-black md:text-4xl lg:text-5xl">
-                {sortedWords[curWordIndex].vocabulary}
-              </div>
+              {/* 背面，預設是vocabulary */}
+              <CardFaceContent
+                entries={backEntries}
+                className="text-3xl md:text-4xl lg:text-5xl"
+              />
             </div>
           </div>
 
@@ -0,0 +1,61 @@
+import { WordImage } from "../Types/types";
+import { CardFaceEntry, mediaURL, textDirection } from "../Utils/utils";
+import RichText from "./RichText";
+
+// 卡片一面的內容，只有一個欄位時跟原本一樣大字置中，多個欄位時加上欄位名稱
+// className是只有一個欄位時的字體大小，有image的話顯示在文字上方
+export default function CardFaceContent({
+  entries,
+  className,
+  image,
+}: {
+  entries: CardFaceEntry[];
+  className: string;
+  image?: WordImage;
+}) {
+  if (image) {
+    return (
+      <div className="flex min-h-0 flex-grow flex-col items-center justify-center gap-2 overflow-scroll text-center">
+        <img
+          src={mediaURL(image.url)}
+          alt={entries[0]?.text ?? ""}
+          className="max-h-[220px] max-w-full rounded-md object-contain"
+        />
+        {entries.map((entry, index) => (
+          <RichText
+            key={index}
+            html={entry.html}
+            text={entry.text}
+            dir={textDirection(entry.sound)}
+            className={`${index === 0 ? "text-xl md:text-3xl" : "text-lg md:text-xl"} break-words text-black`}
+          />
+        ))}
+      </div>
+    );
+  }
+  if (entries.length <= 1) {
+    return (
+      <div
+        dir={textDirection(entries[0]?.sound ?? "")}
+        className={`break-word flex flex-grow items-center justify-center overflow-scroll text-center text-black ${className}`}
+      >
+        <RichText html={entries[0]?.html} text={entries[0]?.text ?? ""} />
+      </div>
+    );
+  }
+  return (
+    <div className="flex flex-grow flex-col items-center justify-center gap-4 overflow-scroll text-center">
+      {entries.map((entry, index) => (
+        <div key={index} className="flex max-w-full flex-col items-center">
+          <span className="text-[.9rem] text-gray-500">{entry.label}</span>
+          <RichText
+            html={entry.html}
+            text={entry.text}
+            dir={textDirection(entry.sound)}
+            className={`${index === 0 ? "text-2xl md:text-4xl" : "text-xl md:text-2xl"} break-words text-black`}
+          />
+        </div>
+      ))}
+    </div>
+  );
+}
@@ -0,0 +1,240 @@
+import React, { useEffect, useState } from "react";
+import { postRequest } from "../Utils/postRequest";
+import { PATH } from "../Consts/consts";
+import { SetCardTemplateRequest } from "../Types/request";
+import { CardTemplate, NoticeDisplay } from "../Types/types";
+import { defaultCardTemplate } from "../Utils/utils";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+import { useLanguages } from "../Hooks/useLanguages";
+
+type Side = "front" | "back" | "hidden";
+
+const sideLabels: Record<Side, string> = {
+  front: "正面",
+  back: "背面",
+  hidden: "不顯示",
+};
+
+const isBuiltinField = (key: string) =>
+  key === "vocabulary" || key === "definition";
+
+// 欄位在卡片的哪一面
+const sideOf = (template: CardTemplate, key: string): Side =>
+  template.front.includes(key)
+    ? "front"
+    : template.back.includes(key)
+      ? "back"
+      : "hidden";
+
+// 編輯單字集的卡片模板: 欄位名稱、自訂欄位的發音，以及每個欄位顯示在正面或背面
+export default React.memo(function CardTemplateModal({
+  wordSetID,
+  version,
+  setVersion,
+  template,
+  setTemplate,
+  shouldSwap,
+  isModalOpen,
+  handleClose,
+}: {
+  wordSetID: string;
+  version: number;
+  setVersion: React.Dispatch<React.SetStateAction<number>>;
+  template: CardTemplate;
+  setTemplate: React.Dispatch<React.SetStateAction<CardTemplate>>;
+  shouldSwap: boolean;
+  isModalOpen: boolean;
+  handleClose: () => void;
+}) {
+  const languages = useLanguages();
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const [draft, setDraft] = useState<CardTemplate>(template);
+  const [isSaving, setIsSaving] = useState(false);
+
+  useEffect(() => {
+    if (isModalOpen) setDraft(template);
+  }, [isModalOpen, template]);
+
+  const setSide = (key: string, side: Side) => {
+    setDraft((prev) => {
+      const front = prev.front.filter((k) => k !== key);
+      const back = prev.back.filter((k) => k !== key);
+      if (side === "front") front.push(key);
+      if (side === "back") back.push(key);
+      // 正反面的順序跟欄位順序一致
+      const order = (k: string) => prev.fields.findIndex((f) => f.key === k);
+      front.sort((a, b) => order(a) - order(b));
+      back.sort((a, b) => order(a) - order(b));
+      return { ...prev, front, back };
+    });
+  };
+
+  const addField = () => {
+    setDraft((prev) => {
+      let n = 1;
+      while (prev.fields.some((f) => f.key === `field${n}`)) n++;
+      const key = `field${n}`;
+      return {
+        ...prev,
+        fields: [...prev.fields, { key, label: `欄位${n}` }],
+        back: [...prev.back, key],
+      };
+    });
+  };
+
+  const removeField = (key: string) => {
+    setDraft((prev) => ({
+      fields: prev.fields.filter((f) => f.key !== key),
+      front: prev.front.filter((k) => k !== key),
+      back: prev.back.filter((k) => k !== key),
+    }));
+  };
+
+  const save = (newTemplate: CardTemplate | null) => {
+    setIsSaving(true);
+    postRequest(`${PATH}/setCardTemplate`, {
+      wordSetID: wordSetID,
+      version: version,
+      template: newTemplate,
+    } as SetCardTemplateRequest)
+      .then((data) => {
+        setVersion(data.payload.version);
+        setTemplate(newTemplate ?? defaultCardTemplate(shouldSwap));
+        setNotice({
+          type: "Success",
+          payload: { message: "卡片模板已更新" },
+        } as NoticeDisplay);
+        handleClose();
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsSaving(false);
+      });
+  };
+
+  const trimmed: CardTemplate = {
+    ...draft,
+    fields: draft.fields.map((f) => ({ ...f, label: f.label.trim() })),
+  };
+  const error = trimmed.fields.some((f) => f.label === "")
+    ? "欄位名稱不得為空"
+    : trimmed.front.length === 0 || trimmed.back.length === 0
+      ? "正面跟背面至少要有一個欄位"
+      : "";
+
+  return (
+    <>
+      <div
+        onClick={() => handleClose()}
+        className={`${isModalOpen ? "block" : "hidden"} fixed inset-0 z-1000 bg-black opacity-30`}
+      ></div>
+      <div
+        className={`${isModalOpen ? "visible top-[50%] opacity-100" : "invisible top-[40%] opacity-0"} fixed left-[50%] z-1000 flex max-h-[80vh] w-[90%] translate-x-[-50%] translate-y-[-50%] flex-col gap-4 rounded-2xl bg-white p-4 transition-all duration-300 sm:w-[55%] sm:p-8 xl:w-[35%]`}
+      >
+        <span
+          onClick={() => handleClose()}
+          className="absolute top-[12px] right-[15px] text-[1.2rem] font-bold text-black hover:cursor-pointer"
+        >
+          &#10005;
+        </span>
+        <h1 className="text-[1.2rem] font-bold text-black sm:text-[2rem]">
+          卡片模板
+        </h1>
+        <div className="flex flex-col gap-3 overflow-y-auto">
+          {draft.fields.map((field, index) => (
+            <div
+              key={field.key}
+              className="flex flex-wrap items-center gap-2 rounded-lg border-2 border-gray-200 p-2"
+            >
+              <input
+                value={field.label}
+                maxLength={20}
+                onChange={(e) =>
+                  setDraft((prev) => ({
+                    ...prev,
+                    fields: prev.fields.map((f, i) =>
+                      i === index ? { ...f, label: e.target.value } : f,
+                    ),
+                  }))
+                }
+                className="min-w-0 flex-grow border-b-2 border-black outline-none focus:border-amber-300"
+              />
+              {!isBuiltinField(field.key) && (
+                <select
+                  className="text-[var(--light-theme-color)]"
+                  value={field.sound ?? ""}
+                  onChange={(e) =>
+                    setDraft((prev) => ({
+                      ...prev,
+                      fields: prev.fields.map((f, i) =>
+                        i === index
+                          ? { ...f, sound: e.target.value || undefined }
+                          : f,
+                      ),
+                    }))
+                  }
+                >
+                  <option value={""}>不發音</option>
+                  {languages.map((language) => (
+                    <option key={language.tag} value={language.tag}>
+                      {language.name}
+                    </option>
+                  ))}
+                </select>
+              )}
+              <select
+                className="text-[var(--light-theme-color)]"
+                value={sideOf(draft, field.key)}
+                onChange={(e) => setSide(field.key, e.target.value as Side)}
+              >
+                {(["front", "back", "hidden"] as const).map((side) => (
+                  <option key={side} value={side}>
+                    {sideLabels[side]}
+                  </option>
+                ))}
+              </select>
+              {!isBuiltinField(field.key) && (
+                <button
+                  onClick={() => removeField(field.key)}
+                  className="hover:cursor-pointer"
+                >
+                  &#x2716;
+                </button>
+              )}
+            </div>
+          ))}
+          {draft.fields.length < 8 && (
+            <button
+              onClick={() => addField()}
+              className="w-max font-bold text-[var(--light-theme-color)] hover:cursor-pointer"
+            >
+              + 新增欄位
+            </button>
+          )}
+          <span className="text-[.8rem] text-gray-500">
+            刪除的欄位會一併清除所有單字在該欄位的內容
+          </span>
+        </div>
+        {error !== "" && (
+          <span className="text-[.9rem] text-red-500">{error}</span>
+        )}
+        <div className="flex justify-end gap-4">
+          <button
+            onClick={() => save(null)}
+            className={`${isSaving ? "pointer-events-none opacity-50" : ""} rounded-xl px-4 py-2 font-bold text-[var(--light-theme-color)] hover:cursor-pointer hover:bg-gray-200`}
+          >
+            恢復預設
+          </button>
+          <button
+            onClick={() => save(trimmed)}
+            className={`${isSaving || error !== "" ? "pointer-events-none opacity-50" : ""} rounded-xl bg-[var(--light-theme-color)] px-4 py-2 text-white hover:cursor-pointer hover:bg-blue-700`}
+          >
+            儲存
+          </button>
+        </div>
+      </div>
+    </>
+  );
+});
@@ -5,17 +5,26 @@ import { HiOutlineSpeakerWave } from "react-icons/hi2";
 import { PiRanking } from "react-icons/pi";
 import { useNavigate, useOutletContext } from "react-router";
 import { FullWordCardType } from "../Types/response";
-import { shuffleArray, Speaker } from "../Utils/utils";
+import { clozeSentence, shuffleArray, Speaker } from "../Utils/utils";
 import { useLocalStorage } from "../Hooks/useLocalStorage";
 import { useEffect, useState, useRef } from "react";
 import { gradeType, clozeRecordType, Word } from "../Types/types";
 import GradeModal from "./GradeModal";
 import { BiBulb } from "react-icons/bi";
 import { createPortal } from "react-dom";
+import { useLogInContextProvider } from "../Context/LogInContextProvider";
+import { recordStudyResult } from "../Utils/recordStudyResult";
+import { postRequest } from "../Utils/postRequest";
+import { PATH } from "../Consts/consts";
+import { GradeAnswerRequest } from "../Types/request";
+import { GradeAnswerResponseSchema } from "../Types/zod_response";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
 
 export default function Cloze() {
   const [isFirstRender, setIsFirstRender] = useState(true);
   const navigate = useNavigate();
+  const { user } = useLogInContextProvider();
+  const { setNotice } = useNoticeDisplayContextProvider();
   const inputRef = useRef<HTMLInputElement | null>(null);
   const buttonRef = useRef<HTMLButtonElement | null>(null);
   const { wordSet, isRandom, onlyStar } = useOutletContext<{
@@ -31,6 +40,10 @@ export default function Cloze() {
           definition: word.vocabulary,
           vocabularySound: word.definitionSound,
           definitionSound: word.vocabularySound,
+          vocabularyAudio: word.definitionAudio,
+          definitionAudio: word.vocabularyAudio,
+          vocabularyHtml: word.definitionHtml,
+          definitionHtml: word.vocabularyHtml,
         }))
       : wordSet.words,
   );
@@ -73,6 +86,10 @@ export default function Cloze() {
           definition: word.vocabulary,
           vocabularySound: word.definitionSound,
           definitionSound: word.vocabularySound,
+          vocabularyAudio: word.definitionAudio,
+          definitionAudio: word.vocabularyAudio,
+          vocabularyHtml: word.definitionHtml,
+          definitionHtml: word.vocabularyHtml,
         }))
       : wordSet.words;
     if (isRandom) {
@@ -210,6 +227,25 @@ export default function Cloze() {
     : clozeRecordForAll.every((r) => r !== null);
   const [isAnimating, setIsAnimating] = useState<boolean>(false);
   const [answerCorrect, setAnswerCorrect] = useState<boolean>(false);
+  const [isGrading, setIsGrading] = useState<boolean>(false);
+  // 交給後端判定(忽略大小寫、空白、標點、重音，容許少量錯字)，失敗的話就自己比對
+  const gradeAnswer = (ans: string, userAns: string): Promise<boolean> =>
+    postRequest(`${PATH}/gradeAnswer`, {
+      expected: ans,
+      answer: userAns,
+    } as GradeAnswerRequest)
+      .then((response) => {
+        const graded = GradeAnswerResponseSchema.parse(response.payload);
+        if (graded.result === "almost") {
+          setNotice({
+            type: "Success",
+            payload: { message: `差一點! 正確答案是 ${graded.answer}` },
+          });
+        }
+        return graded.result !== "wrong";
+      })
+      .catch(() => ans.trim() === userAns.trim());
+
   const submitAnswer = (
     q: string,
     ans: string,
@@ -218,7 +254,31 @@ export default function Cloze() {
     userAns: string,
     skip: boolean,
   ) => {
-    const isAnswer = ans === userAns;
+    setIsGrading(true);
+    (skip ? Promise.resolve(false) : gradeAnswer(ans, userAns))
+      .then((isAnswer) =>
+        showAnswerResult(q, ans, qSound, ansSound, userAns, skip, isAnswer),
+      )
+      .finally(() => {
+        setIsGrading(false);
+      });
+  };
+
+  const showAnswerResult = (
+    q: string,
+    ans: string,
+    qSound: string,
+    ansSound: string,
+    userAns: string,
+    skip: boolean,
+    isAnswer: boolean,
+  ) => {
+    recordStudyResult(
+      wordSet,
+      user?.id,
+      words[curQuestionIndex]?.id,
+      isAnswer && !skip,
+    );
     setIsAnimating(true);
     setAnswerCorrect(isAnswer);
     setTimeout(() => {
@@ -373,6 +433,17 @@ export default function Cloze() {
     return null;
   }
 
+  // 第一個包含答案的例句，答案挖空
+  const clozeExample = (words[curQuestionIndex]?.examples ?? [])
+    .map((example) => ({
+      sentence: clozeSentence(
+        example.sentence,
+        words[curQuestionIndex].vocabulary,
+      ),
+      translation: example.translation,
+    }))
+    .find((example) => example.sentence !== null);
+
   return (
     <>
       {/* overlay for grade modal */}
@@ -414,7 +485,7 @@ export default function Cloze() {
       )}
 
       {/* 在播放時要放置overlay 以防user亂按 */}
-      {isAnimating && (
+      {(isAnimating || isGrading) && (
         <div className="absolute z-10 h-full w-full bg-transparent"></div>
       )}
       <div className="relative flex min-h-[500px] max-w-full min-w-0 flex-grow flex-col">
@@ -443,6 +514,7 @@ export default function Cloze() {
                     Speaker(
                       words[curQuestionIndex].definition,
                       words[curQuestionIndex].definitionSound,
+                      words[curQuestionIndex].definitionAudio,
                     );
                   }}
                   className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
@@ -450,6 +522,17 @@ export default function Cloze() {
                   <HiOutlineSpeakerWave className="h-6 w-6" />
                 </button>
               </div>
+              {/* 有例句的話把答案挖空當作提示 */}
+              {clozeExample && (
+                <div className="flex flex-col gap-1 rounded-lg bg-gray-100 px-3 py-2 text-[.8rem] text-gray-700 sm:text-[1rem]">
+                  <span className="break-words">{clozeExample.sentence}</span>
+                  {clozeExample.translation && (
+                    <span className="break-words text-gray-500">
+                      {clozeExample.translation}
+                    </span>
+                  )}
+                </div>
+              )}
               <div
                 onClick={() => {
                   if (onlyStar) {
@@ -12,15 +12,17 @@ import ConfirmModal from "./ConfirmModal";
 import { useNavigate } from "react-router";
 import { ImportWord, NoticeDisplay, Word, WordSetType } from "../Types/types";
 import { postRequest } from "../Utils/postRequest";
-import { PATH, soundArray } from "../Consts/consts";
+import { PATH } from "../Consts/consts";
 import { CreateWordSetRequest } from "../Types/request";
 import { useLogInContextProvider } from "../Context/LogInContextProvider";
 import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
 import SettingWordSetModal from "./SettingWordSetModal";
 import ImportModal from "./ImportModal";
 import ContentEditable from "./ContentEditable";
+import { useLanguages } from "../Hooks/useLanguages";
 
 export default function CreateWordSet() {
+  const languages = useLanguages();
   const oneTimeID = uuid();
   const { user } = useLogInContextProvider();
   const { setNotice } = useNoticeDisplayContextProvider();
@@ -417,6 +419,8 @@ export default function CreateWordSet() {
       wordCnt: words.length,
       allowCopy: allowCopy,
       isPublic: isPublic,
+      visibility: isPublic ? "public" : "private", // 不公開的單字集只有自己看得到
+      version: 0,
     };
     postRequest(`${PATH}/createWordSet`, {
       userID: user?.id || "",
@@ -673,12 +677,12 @@ export default function CreateWordSet() {
                         handleEditWordSound(word.id, e.target.value);
                       }}
                     >
-                      {soundArray.map((sound, index) => (
+                      {languages.map((language, index) => (
                         <option
                           key={`vocabularySound-${index}`}
-                          value={sound.EngName}
+                          value={language.tag}
                         >
-                          {sound.TwName}
+                          {language.name}
                         </option>
                       ))}
                     </select>
@@ -721,12 +725,12 @@ export default function CreateWordSet() {
                         }
                       }}
                     >
-                      {soundArray.map((sound, index) => (
+                      {languages.map((language, index) => (
                         <option
                           key={`definitionSound-${index}`}
-                          value={sound.EngName}
+                          value={language.tag}
                         >
-                          {sound.TwName}
+                          {language.name}
                         </option>
                       ))}
                     </select>
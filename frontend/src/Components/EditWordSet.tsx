@@ -8,6 +8,7 @@ import {
   EditWord,
   EditWordSetType,
   ImportWord,
+  LiveOp,
   NoticeDisplay,
   Word,
 } from "../Types/types";
@@ -20,15 +21,18 @@ import { useNavigate } from "react-router";
 import { WordSetType } from "../Types/types";
 import { EditWordSetRequest } from "../Types/request";
 import { postRequest } from "../Utils/postRequest";
-import { PATH, soundArray } from "../Consts/consts";
+import { PATH } from "../Consts/consts";
 import ContentEditable from "./ContentEditable";
 import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
 import SettingWordSetModal from "./SettingWordSetModal";
 import { useLogInContextProvider } from "../Context/LogInContextProvider";
 import ImportModal from "./ImportModal";
+import { useWordSetLive } from "../Hooks/useWordSetLive";
+import { useLanguages } from "../Hooks/useLanguages";
 
 // true代表fork單字集 false代表編輯單字集
 export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
+  const languages = useLanguages();
   const { user } = useLogInContextProvider();
   const { setNotice } = useNoticeDisplayContextProvider();
   const navigate = useNavigate();
@@ -296,6 +300,73 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
     setShouldSwap((prev) => !prev);
   }, []);
 
+  // 即時協作: 其他人的變更直接套用到本地沒改過的單字，本地改過的單字保留自己的內容，存檔時才覆蓋
+  const [version, setVersion] = useState<number>(() => wordSet.version ?? 0);
+  const toDisplayWord = (word: Word): Word =>
+    shouldSwap
+      ? {
+          ...word,
+          vocabulary: word.definition,
+          definition: word.vocabulary,
+          vocabularySound: word.definitionSound,
+          definitionSound: word.vocabularySound,
+        }
+      : word;
+  const applyRemoteWords = (remoteWords: Word[], removedIDs: string[]) => {
+    const locallyChanged = new Set(
+      Object.keys(oldWords).filter(
+        (id) =>
+          !(id in currentWords) ||
+          JSON.stringify(currentWords[id]) !== JSON.stringify(oldWords[id]),
+      ),
+    );
+    setOldWords((prev) => {
+      const next = { ...prev };
+      removedIDs.forEach((id) => delete next[id]);
+      remoteWords.forEach((word) => (next[word.id] = toDisplayWord(word)));
+      return next;
+    });
+    setCurrentWords((prev) => {
+      const next = { ...prev };
+      removedIDs.forEach((id) => delete next[id]);
+      remoteWords.forEach((word) => {
+        if (!locallyChanged.has(word.id)) next[word.id] = toDisplayWord(word);
+      });
+      return next;
+    });
+  };
+  const { others, sendPresence } = useWordSetLive(wordSetID, {
+    onOp: (op: LiveOp, seq: number) => {
+      setVersion(seq);
+      if ((op.type === "add" || op.type === "update") && op.word) {
+        applyRemoteWords([op.word], []);
+      } else if (op.type === "remove" && op.wordID) {
+        applyRemoteWords([], [op.wordID]);
+      } else if (op.type === "reorder" && op.order) {
+        const position = Object.fromEntries(
+          op.order.map((id, index) => [id, index + 1]),
+        );
+        const reorder = (prev: Record<string, Word>) =>
+          Object.fromEntries(
+            Object.entries(prev).map(([id, word]) => [
+              id,
+              id in position ? { ...word, order: position[id] } : word,
+            ]),
+          );
+        setOldWords(reorder);
+        setCurrentWords(reorder);
+      }
+    },
+    onSnapshot: (latest) => {
+      setVersion(latest.version);
+      const latestIDs = new Set(latest.words.map((word) => word.id));
+      applyRemoteWords(
+        latest.words,
+        Object.keys(oldWords).filter((id) => !latestIDs.has(id)),
+      );
+    },
+  });
+
   // 用order代表是否開啟，-1則關閉
   const [isAddModalOpen, setIsAddModalOpen] = useState<number>(-1);
 
@@ -415,15 +486,23 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
       addWords: addWords,
       wordSet: editWordSet,
       removeWords: removeWords,
+      version: version,
     };
 
     postRequest(`${PATH}/updateWordSet`, request as EditWordSetRequest)
-      .then(() => {
+      .then((data) => {
         cleanUp();
+        // 後端偵測到重複或拼字相近的單字時只提醒，不會擋下
+        const duplicates: unknown[] = data.payload.duplicates ?? [];
         setTimeout(() => {
           setNotice({
             type: "Success",
-            payload: { message: "編輯單字集成功" },
+            payload: {
+              message:
+                duplicates.length > 0
+                  ? `編輯單字集成功，但有${duplicates.length}組重複或相近的單字`
+                  : "編輯單字集成功",
+            },
           });
         }, 300); // Let the navigation settle first
         navigate(`/wordSet/${wordSetID}`);
@@ -476,6 +555,8 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
         handleClose={() => setIsSettingModalOpen(false)}
         allowCopy={wordSet.allowCopy}
         isPublic={wordSet.isPublic}
+        visibility={wordSet.visibility}
+        duplicatePolicy={wordSet.duplicatePolicy ?? "warn"}
       />
 
       <ImportModal
@@ -587,6 +668,17 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
             </button>
           </div>
         </div>
+        {/* 一起編輯的人 */}
+        {others.length > 0 && (
+          <div className="mb-2 flex w-full flex-wrap items-center gap-2 text-[.9rem] text-gray-600">
+            <span>正在一起編輯:</span>
+            {others.map((p) => (
+              <span key={p.connID} className="rounded-lg bg-white px-2 py-1">
+                {p.name}
+              </span>
+            ))}
+          </div>
+        )}
         {/* 單字列表區 */}
         <div className="flex w-full flex-col">
           {/* 白色區域 */}
@@ -609,6 +701,16 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
                   </span>
                 </div>
               </div>
+              {others
+                .filter((p) => p.focusWordID === word.id)
+                .map((p) => (
+                  <span
+                    key={p.connID}
+                    className="bg-white px-5 text-[.8rem] break-all text-[var(--light-theme-color)]"
+                  >
+                    {p.name} 正在輸入: {p.draft}
+                  </span>
+                ))}
               <div className="flex max-h-auto min-h-[110px] w-full flex-col gap-4 rounded-b-lg bg-white p-4 sm:h-[130px] sm:flex-row sm:gap-0">
                 <div className="flex w-full flex-col items-start justify-center gap-2 sm:w-[40%]">
                   <ContentEditable
@@ -616,6 +718,7 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
                     updateContent={(newVocabulary: string) => {
                       if (newVocabulary.length > 100) return;
                       handleEditVocabulary(word.id, newVocabulary);
+                      sendPresence(word.id, "vocabulary", newVocabulary);
                     }}
                     className="w-full resize-none border-b-[3px] border-black text-[1.2rem] break-words break-all text-black outline-none focus:border-amber-300"
                   />
@@ -637,12 +740,12 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
                         handleEditVocabularySound(word.id, e.target.value);
                       }}
                     >
-                      {soundArray.map((sound, index) => (
+                      {languages.map((language, index) => (
                         <option
                           key={`vocabularySound-${index}`}
-                          value={sound.EngName}
+                          value={language.tag}
                         >
-                          {sound.TwName}
+                          {language.name}
                         </option>
                       ))}
                     </select>
@@ -654,6 +757,7 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
                     updateContent={(newDefinition: string) => {
                       if (newDefinition.length > 300) return;
                       handleEditDefinition(word.id, newDefinition);
+                      sendPresence(word.id, "definition", newDefinition);
                     }}
                     className="w-full resize-none border-b-[3px] border-black text-[1.2rem] break-words break-all text-black outline-none focus:border-amber-300"
                   />
@@ -674,12 +778,12 @@ export default function EditWordSet({ wordSet }: { wordSet: WordSetType }) {
                         handleEditDefinitionSound(word.id, e.target.value);
                       }}
                     >
-                      {soundArray.map((sound, index) => (
+                      {languages.map((language, index) => (
                         <option
                           key={`definitionSound-${index}`}
-                          value={sound.EngName}
+                          value={language.tag}
                         >
-                          {sound.TwName}
+                          {language.name}
                         </option>
                       ))}
                     </select>
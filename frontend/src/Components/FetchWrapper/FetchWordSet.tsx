@@ -1,31 +1,48 @@
 import { Suspense, useState, useEffect } from "react";
 import { WordSetType } from "../../Types/types";
-import { Await, useParams } from "react-router";
+import { Await, useParams, useSearchParams } from "react-router";
 import Loader from "../Loader";
 import FetchErrorPage from "../FetchErrorPage";
 import WordSet from "../WordSet";
 import EditWordSet from "../EditWordSet";
 import { getRequest } from "../../Utils/getRequest";
 import { PATH } from "../../Consts/consts";
+import {
+  canEditWordSet,
+  rememberShareToken,
+  withShareToken,
+} from "../../Utils/utils";
 import { useLogInContextProvider } from "../../Context/LogInContextProvider";
 import { z } from "zod";
-import { Word } from "../../Types/zod_response";
+import {
+  CardTemplateSchema,
+  Collaborator,
+  ForkSource,
+  Word,
+} from "../../Types/zod_response";
 import { ErrorBoundary } from "react-error-boundary";
 import ErrorBoundaryFallback from "../ErrorBoundaryFallback";
 
 export default function FetchWordSet({ mode }: { mode: boolean }) {
   // mode => true代表要給WordSet route; mode => false代表editWordSet route
   const params = useParams();
+  const [searchParams] = useSearchParams();
   const { user } = useLogInContextProvider();
+  // 透過分享連結進來的話先記住token，遊戲、預覽等請求也會用到
+  rememberShareToken(params.wordSetID ?? "", searchParams.get("share"));
   const [fetchWordSetPromise, setFetchWordSetPromise] = useState<
     Promise<WordSetType>
   >(
     getRequest<WordSetType>(
-      `${PATH}/getWordSet/${params.wordSetID}`,
+      withShareToken(
+        `${PATH}/getWordSet/${params.wordSetID}`,
+        params.wordSetID ?? "",
+      ),
       z.object({
         id: z.string(),
         title: z.string(),
         description: z.string(),
+        descriptionHtml: z.string().optional(),
         authorID: z.string(), // 原作者
         createdAt: z.string(),
         updatedAt: z.number(),
@@ -36,6 +53,13 @@ export default function FetchWordSet({ mode }: { mode: boolean }) {
         wordCnt: z.number(),
         allowCopy: z.boolean(), // 允許他人複製衍生
         isPublic: z.boolean(), // 是否在首頁發布
+        visibility: z.enum(["private", "unlisted", "public"]), // 公開範圍
+        version: z.number(), // 編輯時要帶上的版本號
+        updatedBy: z.string().optional(), // 最後修改的使用者
+        collaborators: z.array(Collaborator).optional(), // 協作者
+        forkedFrom: ForkSource.optional(), // 複製來源
+        duplicatePolicy: z.enum(["warn", "reject"]).optional(), // 重複單字設定
+        template: CardTemplateSchema.optional(), // 卡片模板
       }),
     ),
   );
@@ -45,11 +69,15 @@ export default function FetchWordSet({ mode }: { mode: boolean }) {
 
     setFetchWordSetPromise(
       getRequest<WordSetType>(
-        `${PATH}/getWordSet/${params.wordSetID}`,
+        withShareToken(
+          `${PATH}/getWordSet/${params.wordSetID}`,
+          params.wordSetID ?? "",
+        ),
         z.object({
           id: z.string(),
           title: z.string(),
           description: z.string(),
+          descriptionHtml: z.string().optional(),
           authorID: z.string(),
           createdAt: z.string(),
           updatedAt: z.number(),
@@ -60,6 +88,13 @@ export default function FetchWordSet({ mode }: { mode: boolean }) {
           wordCnt: z.number(),
           allowCopy: z.boolean(),
           isPublic: z.boolean(),
+          visibility: z.enum(["private", "unlisted", "public"]),
+          version: z.number(),
+          updatedBy: z.string().optional(),
+          collaborators: z.array(Collaborator).optional(),
+          forkedFrom: ForkSource.optional(),
+          duplicatePolicy: z.enum(["warn", "reject"]).optional(),
+          template: CardTemplateSchema.optional(),
         }),
       ),
     );
@@ -73,9 +108,9 @@ export default function FetchWordSet({ mode }: { mode: boolean }) {
             if (resolvedData === undefined || resolvedData === null) {
               throw Error("Data for WordSet/EditWordSet is undefined/null");
             }
-            // 若編輯的人不是作者本人 則不會跳到編輯頁面
+            // 若編輯的人不是作者本人或editor協作者 則不會跳到編輯頁面
             return mode ||
-              (mode === false && resolvedData.authorID !== user?.id) ? (
+              (mode === false && !canEditWordSet(resolvedData, user?.id)) ? (
               <ErrorBoundary FallbackComponent={ErrorBoundaryFallback}>
                 <WordSet wordSet={resolvedData} />
               </ErrorBoundary>
@@ -3,15 +3,24 @@ import { Await, useParams } from "react-router";
 import Loader from "../Loader";
 import FetchErrorPage from "../FetchErrorPage";
 import { getRequest } from "../../Utils/getRequest";
-import { PATH } from "../../Consts/consts";
+import { gameWordsURL } from "../../Utils/utils";
 import Game from "../Game";
 import { FullWordCardType } from "../../Types/response";
 import { useLogInContextProvider } from "../../Context/LogInContextProvider";
 import { z } from "zod";
-import { Word } from "../../Types/zod_response";
+import { CardTemplateSchema, Word } from "../../Types/zod_response";
 import { ErrorBoundary } from "react-error-boundary";
 import ErrorBoundaryFallback from "../ErrorBoundaryFallback";
 
+const FullWordCardSchema = z.object({
+  id: z.string(),
+  title: z.string(),
+  words: z.array(Word),
+  shouldSwap: z.boolean(),
+  wordSetIDs: z.record(z.string(), z.string()).optional(),
+  template: CardTemplateSchema,
+});
+
 export default function FetchWords() {
   const { user } = useLogInContextProvider();
   const params = useParams();
@@ -19,13 +28,8 @@ export default function FetchWords() {
     Promise<FullWordCardType>
   >(
     getRequest<FullWordCardType>(
-      `${PATH}/getWords/${params.wordSetID ?? ""}`,
-      z.object({
-        id: z.string(),
-        title: z.string(),
-        words: z.array(Word),
-        shouldSwap: z.boolean(),
-      }),
+      gameWordsURL(params.wordSetID ?? ""),
+      FullWordCardSchema,
     ),
   );
 
@@ -33,13 +37,8 @@ export default function FetchWords() {
     if (user === undefined) return;
     setFetchWordsPromise(
       getRequest<FullWordCardType>(
-        `${PATH}/getWords/${params.wordSetID ?? ""}`,
-        z.object({
-          id: z.string(),
-          title: z.string(),
-          words: z.array(Word),
-          shouldSwap: z.boolean(),
-        }),
+        gameWordsURL(params.wordSetID ?? ""),
+        FullWordCardSchema,
       ),
     );
   }, [params.userID, user?.id]);
@@ -9,9 +9,12 @@ import { Word } from "../Types/types";
 import {
   getRandomInt,
   AutoPlaySpeaker,
-  Speaker,
+  stopSpeaking,
   shuffleArray,
+  cardFace,
+  speakCardFace,
 } from "../Utils/utils";
+import CardFaceContent from "./CardFaceContent";
 import { useNavigate, useOutletContext } from "react-router";
 import { FullWordCardType } from "../Types/response";
 import { useLocalStorage } from "../Hooks/useLocalStorage";
@@ -58,6 +61,10 @@ const FullWordCard = () => {
           definition: word.vocabulary,
           vocabularySound: word.definitionSound,
           definitionSound: word.vocabularySound,
+          vocabularyAudio: word.definitionAudio,
+          definitionAudio: word.vocabularyAudio,
+          vocabularyHtml: word.definitionHtml,
+          definitionHtml: word.vocabularyHtml,
         }))
       : wordSet.words,
   );
@@ -73,6 +80,10 @@ const FullWordCard = () => {
           definition: word.vocabulary,
           vocabularySound: word.definitionSound,
           definitionSound: word.vocabularySound,
+          vocabularyAudio: word.definitionAudio,
+          definitionAudio: word.vocabularyAudio,
+          vocabularyHtml: word.definitionHtml,
+          definitionHtml: word.vocabularyHtml,
         }))
       : wordSet.words;
     if (isRandom) {
@@ -177,13 +188,23 @@ const FullWordCard = () => {
 
     // Show front side for 3 seconds
     setIsCardFlip(false);
+    const backEntries = cardFace(
+      words[curWordIndexConst],
+      wordSet.template,
+      "back",
+      wordSet.shouldSwap,
+    );
+    const spoken = backEntries.find((e) => e.sound !== "") ?? backEntries[0];
     try {
       soundPlayTimerRef.current = setTimeout(async () => {
-        await AutoPlaySpeaker(
-          words[curWordIndexConst].vocabulary,
-          words[curWordIndexConst].vocabularySound,
-          speechRef,
-        );
+        if (spoken !== undefined) {
+          await AutoPlaySpeaker(
+            spoken.text,
+            spoken.sound,
+            speechRef,
+            spoken.audio,
+          );
+        }
         // Flip to back side and show for 2 seconds
         setIsCardFlip(true);
         autoPlayTimerRef.current = setTimeout(() => {
@@ -213,11 +234,9 @@ const FullWordCard = () => {
       soundPlayTimerRef.current = null;
     }
 
-    // Stop speech synthesis if it's active
-    if (speechRef.current) {
-      speechSynthesis.cancel(); // Stops the speech immediately
-      speechRef.current = null;
-    }
+    // Stop speech synthesis or uploaded audio if it's active
+    stopSpeaking(); // Stops the speech immediately
+    speechRef.current = null;
     setIsCardAutoPlaying(false);
     setIsAutoPlaying(false);
   };
@@ -261,6 +280,19 @@ const FullWordCard = () => {
     }
   };
   console.log("full word card re-render");
+  const frontEntries = cardFace(
+    words[curWordIndex],
+    wordSet.template,
+    "front",
+    wordSet.shouldSwap,
+  );
+  const backEntries = cardFace(
+    words[curWordIndex],
+    wordSet.template,
+    "back",
+    wordSet.shouldSwap,
+  );
+  const hintText = backEntries[0]?.text ?? "";
 
   return (
     <>
@@ -292,8 +324,8 @@ const FullWordCard = () => {
                 <BiBulb className="h-6 w-6" />
                 <span className="text-md sm:text-lg">
                   {isHintOpen
-                    ? words[curWordIndex].vocabulary[0] +
-                      "_".repeat(words[curWordIndex].vocabulary.length - 1)
+                    ? hintText.slice(0, 1) +
+                      "_".repeat(Math.max(0, hintText.length - 1))
                     : "顯示提示"}
                 </span>
               </div>
@@ -301,10 +333,7 @@ const FullWordCard = () => {
                 <button
                   onClick={(e) => {
                     e.stopPropagation();
-                    Speaker(
-                      words[curWordIndex].definition,
-                      words[curWordIndex].definitionSound,
-                    );
+                    speakCardFace(frontEntries);
                   }}
                   className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
                 >
@@ -313,10 +342,12 @@ const FullWordCard = () => {
               </div>
             </div>
 
-            {/* definition */}
-            <div className="break-word flex flex-grow items-center justify-center overflow-scroll text-center text-3xl text-black md:text-4xl lg:text-5xl">
-              {words[curWordIndex].definition}
-            </div>
+            {/* 正面，預設是definition */}
+            <CardFaceContent
+              entries={frontEntries}
+              image={words[curWordIndex].image}
+              className="text-3xl md:text-4xl lg:text-5xl"
+            />
           </div>
         </div>
 
@@ -341,10 +372,7 @@ const FullWordCard = () => {
                 <button
                   onClick={(e) => {
                     e.stopPropagation();
-                    Speaker(
-                      words[curWordIndex].vocabulary,
-                      words[curWordIndex].vocabularySound,
-                    );
+                    speakCardFace(backEntries);
                   }}
                   className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['撥放'] hover:cursor-pointer hover:after:visible"
                 >
@@ -353,10 +381,11 @@ const FullWordCard = () => {
               </div>
             </div>
 
-            {/* vocabulary */}
-            <div className="break-word flex flex-grow items-center justify-center overflow-scroll text-center text-3xl text-black md:text-4xl lg:text-5xl">
-              {words[curWordIndex].vocabulary}
-            </div>
+            {/* 背面，預設是vocabulary */}
+            <CardFaceContent
+              entries={backEntries}
+              className="text-3xl md:text-4xl lg:text-5xl"
+            />
           </div>
         </div>
 
@@ -5,6 +5,7 @@ import { IoClose } from "react-icons/io5";
 import { Outlet, Link, useLocation, NavLink, useNavigate } from "react-router";
 import { FullWordCardType } from "../Types/response";
 import { useEffect, useRef, useState } from "react";
+import { isReviewDeck } from "../Utils/utils";
 
 export default function Game({ wordSet }: { wordSet: FullWordCardType }) {
   /* const [curNumber, setCurNumber] = useState<number>(1); // 目前到第幾題(用於進度條)
@@ -21,8 +22,11 @@ export default function Game({ wordSet }: { wordSet: FullWordCardType }) {
   const [isSettingOpen, setIsSettingOpen] = useState<boolean>(false);
   const settingModalRef = useRef<HTMLDivElement | null>(null);
 
-  const options: string[] = ["單字卡", "選擇題", "填充題"];
-  const paths: string[] = ["wordCard", "multiChoice", "cloze"];
+  // 複習牌組不是真的單字集，沒有Learn模式
+  const options: string[] = isReviewDeck(wordSet.id)
+    ? ["單字卡", "選擇題", "填充題"]
+    : ["單字卡", "選擇題", "填充題", "學習"];
+  const paths: string[] = ["wordCard", "multiChoice", "cloze", "learn"];
 
   const location = useLocation();
   /* const shouldProgressBarDisplay =
@@ -124,7 +128,7 @@ export default function Game({ wordSet }: { wordSet: FullWordCardType }) {
                   </div>
                 </div>
                 <div className="mt-[1.5rem] flex w-full flex-col items-center justify-center gap-[.5rem] text-[1.2rem] text-black">
-                  {Array.from({ length: 3 }, (_, index) => {
+                  {Array.from({ length: options.length }, (_, index) => {
                     return (
                       <NavLink
                         key={index}
@@ -148,7 +152,7 @@ export default function Game({ wordSet }: { wordSet: FullWordCardType }) {
           </div>
           <Link
             className="relative flex h-[2.5rem] w-[2.5rem] items-center justify-center rounded-lg border-2 border-gray-300 p-1 text-black transition-all duration-200 after:invisible after:absolute after:top-[110%] after:left-1/2 after:z-60 after:block after:w-max after:min-w-[50px] after:-translate-x-1/2 after:bg-black after:p-1 after:text-center after:text-white after:content-['返回單字集'] hover:cursor-pointer hover:bg-gray-300 hover:after:visible"
-            to={`/wordSet/${wordSet.id}`}
+            to={isReviewDeck(wordSet.id) ? "/" : `/wordSet/${wordSet.id}`}
           >
             <IoClose className="h-full w-full" />
           </Link>
@@ -1,8 +1,8 @@
 import { useCallback, useState } from "react";
 import ConfirmModal from "./ConfirmModal";
 import React from "react";
-import { soundArray } from "../Consts/consts";
 import { ImportWord } from "../Types/types";
+import { useLanguages } from "../Hooks/useLanguages";
 
 export interface ImportModalProps {
   totalWords: number;
@@ -17,6 +17,7 @@ export interface ImportModalProps {
 }
 
 export default React.memo(function ImportModal(props: ImportModalProps) {
+  const languages = useLanguages();
   const { totalWords, isModalOpen, handleClose, handleImport } = props;
   const [voDelimiter, setVoDelimiter] = useState<string>(`\u0020\u0020`);
   const [defaultCustomForVo, setDefaultCustomForVo] = useState(""); // 讓自訂分隔可以被正確選到所加的額外state
@@ -332,9 +333,9 @@ export default React.memo(function ImportModal(props: ImportModalProps) {
               id="vocabularySound"
               className="font-bold text-black"
             >
-              {soundArray.map((sound, index) => (
-                <option key={`vocabularySound-${index}`} value={sound.EngName}>
-                  {sound.TwName}
+              {languages.map((language, index) => (
+                <option key={`vocabularySound-${index}`} value={language.tag}>
+                  {language.name}
                 </option>
               ))}
             </select>
@@ -347,9 +348,9 @@ export default React.memo(function ImportModal(props: ImportModalProps) {
               onChange={(e) => setDefinitionSound(e.target.value)}
               className="font-bold text-black"
             >
-              {soundArray.map((sound, index) => (
-                <option key={`definitionSound-${index}`} value={sound.EngName}>
-                  {sound.TwName}
+              {languages.map((language, index) => (
+                <option key={`definitionSound-${index}`} value={language.tag}>
+                  {language.name}
                 </option>
               ))}
             </select>
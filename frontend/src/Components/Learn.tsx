@@ -0,0 +1,193 @@
+import { useEffect, useRef, useState } from "react";
+import { useOutletContext } from "react-router";
+import { HiOutlineSpeakerWave } from "react-icons/hi2";
+import { FullWordCardType, LearnSession } from "../Types/response";
+import {
+  AnswerLearnQuestionRequest,
+  StartLearnSessionRequest,
+} from "../Types/request";
+import {
+  LearnAnswerResponseSchema,
+  LearnSessionSchema,
+} from "../Types/zod_response";
+import { NoticeDisplay } from "../Types/types";
+import { postRequest } from "../Utils/postRequest";
+import { getShareToken, Speaker } from "../Utils/utils";
+import { PATH } from "../Consts/consts";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+import Loader from "./Loader";
+
+// 後端決定題目的Learn模式，先選擇題，答對後改成打字作答，答錯的隔幾題再考
+// 進度存在後端，換裝置也能接著學
+export default function Learn() {
+  const { wordSet } = useOutletContext<{ wordSet: FullWordCardType }>();
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const [session, setSession] = useState<LearnSession | null>(null);
+  const [feedback, setFeedback] = useState<{
+    correct: boolean;
+    answer: string;
+    next: LearnSession;
+  } | null>(null);
+  const [isSubmitting, setIsSubmitting] = useState(false);
+  const inputRef = useRef<HTMLInputElement | null>(null);
+
+  const startSession = (restart: boolean) => {
+    postRequest(`${PATH}/startLearnSession`, {
+      wordSetID: wordSet.id,
+      restart: restart,
+      shareToken: getShareToken(wordSet.id),
+    } as StartLearnSessionRequest)
+      .then((response) => {
+        setFeedback(null);
+        setSession(LearnSessionSchema.parse(response.payload));
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      });
+  };
+
+  useEffect(() => {
+    startSession(false);
+  }, [wordSet.id]);
+
+  const submitAnswer = (answer: { choiceIndex?: number; answer?: string }) => {
+    if (!session?.current || isSubmitting) return;
+    setIsSubmitting(true);
+    postRequest(`${PATH}/answerLearnQuestion`, {
+      wordSetID: wordSet.id,
+      questionID: session.current.id,
+      shareToken: getShareToken(wordSet.id),
+      ...answer,
+    } as AnswerLearnQuestionRequest)
+      .then((response) => {
+        const result = LearnAnswerResponseSchema.parse(response.payload);
+        setFeedback({
+          correct: result.correct,
+          answer: result.answer,
+          next: result.session,
+        });
+      })
+      .catch((error) => {
+        // 其他裝置先作答了，拿最新的進度
+        if (error?.payload?.code === "CONFLICT") {
+          startSession(false);
+        }
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsSubmitting(false);
+      });
+  };
+
+  const handleNext = () => {
+    if (!feedback) return;
+    setSession(feedback.next);
+    setFeedback(null);
+    if (inputRef.current) inputRef.current.value = "";
+  };
+
+  if (session === null) {
+    return <Loader />;
+  }
+
+  const { progress, current } = session;
+  return (
+    <div className="flex w-full flex-col gap-4 rounded-xl bg-white px-6 py-4 shadow-2xl ring ring-gray-300">
+      <div className="flex items-center gap-3 text-[.9rem] text-gray-500">
+        <div className="h-2 flex-grow rounded-full bg-gray-200">
+          <div
+            style={{
+              width: `${progress.total === 0 ? 0 : Math.round((progress.mastered / progress.total) * 100)}%`,
+            }}
+            className="h-full rounded-full bg-green-400"
+          ></div>
+        </div>
+        <span>{`已學會 ${progress.mastered}/${progress.total}`}</span>
+      </div>
+
+      {progress.completed || !current ? (
+        <div className="flex flex-col items-center gap-4 py-10">
+          <span className="text-[1.5rem] font-bold text-black">
+            你已經學會所有單字!
+          </span>
+          <span className="text-gray-500">{`共作答 ${session.answered} 題，答對 ${session.correctCnt} 題`}</span>
+          <button
+            onClick={() => startSession(true)}
+            className="rounded-lg bg-[var(--light-theme-color)] px-4 py-2 font-bold text-white hover:cursor-pointer hover:opacity-80"
+          >
+            重新開始
+          </button>
+        </div>
+      ) : (
+        <>
+          <div className="flex items-start justify-between gap-2">
+            <span className="text-[.9rem] text-gray-500">
+              {current.stage === "recognition" ? "選擇正確的答案" : "輸入答案"}
+            </span>
+            <HiOutlineSpeakerWave
+              onClick={() => Speaker(current.prompt, current.promptSound)}
+              className="h-6 w-6 text-gray-500 hover:cursor-pointer hover:text-black"
+            />
+          </div>
+          <p className="min-h-[6rem] text-[1.5rem] break-words whitespace-pre-wrap text-black">
+            {current.prompt}
+          </p>
+
+          {current.stage === "recognition" ? (
+            <div className="grid grid-cols-1 gap-3 md:grid-cols-2">
+              {(current.choices ?? []).map((choice, index) => (
+                <button
+                  key={index}
+                  onClick={() => submitAnswer({ choiceIndex: index })}
+                  className={`${feedback ? "pointer-events-none" : ""} ${feedback && choice === feedback.answer ? "border-green-600" : "border-gray-300"} rounded-lg border-2 p-3 text-left text-black hover:cursor-pointer hover:bg-gray-100`}
+                >
+                  {choice}
+                </button>
+              ))}
+            </div>
+          ) : (
+            <form
+              onSubmit={(e) => {
+                e.preventDefault();
+                submitAnswer({ answer: inputRef.current?.value ?? "" });
+              }}
+              className="flex w-full gap-3"
+            >
+              <input
+                ref={inputRef}
+                disabled={feedback !== null}
+                className="h-[3rem] flex-grow rounded-lg border-2 border-transparent bg-gray-200 px-2 font-bold text-black outline-none focus:border-[var(--light-theme-opacity-color)] focus:bg-white"
+                placeholder="輸入答案"
+              />
+              <button
+                type="submit"
+                disabled={feedback !== null}
+                className="rounded-lg bg-[var(--light-theme-color)] px-4 font-bold text-white hover:cursor-pointer hover:opacity-80"
+              >
+                送出
+              </button>
+            </form>
+          )}
+
+          {feedback && (
+            <div className="flex items-center justify-between gap-3">
+              <span
+                className={`font-bold ${feedback.correct ? "text-green-600" : "text-red-600"}`}
+              >
+                {feedback.correct
+                  ? "正確!"
+                  : `錯誤! 正確答案是 ${feedback.answer}`}
+              </span>
+              <button
+                onClick={handleNext}
+                className="rounded-lg bg-[var(--light-theme-color)] px-4 py-2 font-bold text-white hover:cursor-pointer hover:opacity-80"
+              >
+                繼續
+              </button>
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
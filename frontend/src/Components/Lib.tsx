@@ -4,9 +4,18 @@ import UserLink from "./UserLink";
 import { useState, useRef, useEffect } from "react";
 import { LibPage } from "../Types/response";
 import { formatTime } from "../Utils/utils";
+import { useLogInContextProvider } from "../Context/LogInContextProvider";
+
+// 跨單字集的複習牌組，id對應後端的規則
+const reviewDecks = [
+  { id: "review-starred", title: "星號單字" },
+  { id: "review-missed-7", title: "最近7天答錯的單字" },
+  { id: "review-due", title: "該複習的單字" },
+];
 
 export default function Lib({ fetchedData }: { fetchedData: LibPage }) {
   console.log("Lib");
+  const { user } = useLogInContextProvider();
   const sectionTitles = ["全部學習卡", "已儲存", "創作"];
   const [section, setSection] = useState<string>(sectionTitles[0]);
 
@@ -143,6 +152,20 @@ export default function Lib({ fetchedData }: { fetchedData: LibPage }) {
         {fetchedData.user.role === "admin" ? " #此為唯一認證官方帳號" : ""}
       </div>
 
+      {user?.id === fetchedData.user.id && (
+        <div className="mb-[2rem] flex flex-wrap gap-[1rem]">
+          {reviewDecks.map((deck) => (
+            <Link
+              key={deck.id}
+              to={`/game/${deck.id}`}
+              className="rounded-lg bg-white px-4 py-2 font-bold text-black hover:cursor-pointer hover:text-[var(--light-theme-color)]"
+            >
+              {deck.title}
+            </Link>
+          ))}
+        </div>
+      )}
+
       <div className="mb-[3rem] flex gap-[2rem] border-b-2 border-gray-200 pb-[10px] text-[1rem] font-bold">
         {sectionTitles.map((title) => (
           <span
@@ -17,12 +17,15 @@ import {
   Word,
 } from "../Types/types";
 import Loader from "./Loader";
+import { useLogInContextProvider } from "../Context/LogInContextProvider";
+import { recordStudyResult } from "../Utils/recordStudyResult";
 import GradeModal from "./GradeModal";
 import { createPortal } from "react-dom";
 
 export default function MultiChoice() {
   const onMountRef = useRef<boolean>(true); // 第一次mount時候是true，可以當作一個guard防止useEffect執行
   const navigate = useNavigate();
+  const { user } = useLogInContextProvider();
   const { wordSet, isRandom, onlyStar } = useOutletContext<{
     wordSet: FullWordCardType;
     isRandom: boolean;
@@ -98,7 +101,9 @@ export default function MultiChoice() {
       }
       const question: multiChoiceQuestion = {
         q: [word.definition, word.definitionSound],
+        qAudio: word.definitionAudio,
         choices: choices,
+        wordID: word.id,
       };
       questions.push(question);
     });
@@ -134,6 +139,10 @@ export default function MultiChoice() {
           definition: word.vocabulary,
           vocabularySound: word.definitionSound,
           definitionSound: word.vocabularySound,
+          vocabularyAudio: word.definitionAudio,
+          definitionAudio: word.vocabularyAudio,
+          vocabularyHtml: word.definitionHtml,
+          definitionHtml: word.vocabularyHtml,
         }))
       : wordSet.words;
     const starWords = newWords.filter((word) => word.star);
@@ -280,6 +289,12 @@ export default function MultiChoice() {
   const [answerCorrect, setAnswerCorrect] = useState<boolean>(false);
   const [chosenIndex, setChosenIndex] = useState<number>(-1);
   const clickChoice = (isAnswer: boolean, index: number, skip: boolean) => {
+    recordStudyResult(
+      wordSet,
+      user?.id,
+      questions[curQuestionIndex]?.wordID,
+      isAnswer && !skip,
+    );
     setAnswerCorrect(isAnswer);
     setChosenIndex(index);
     setIsAnimating(true);
@@ -523,6 +538,7 @@ export default function MultiChoice() {
                     Speaker(
                       questions[curQuestionIndex].q[0],
                       questions[curQuestionIndex].q[1],
+                      questions[curQuestionIndex].qAudio,
                     );
                   }}
                   className="relative after:invisible after:absolute after:top-[120%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
@@ -61,7 +61,10 @@ export default function PreviewModal({
       const signal = controllerRef.current?.signal;
       setIsPreviewLoading(true);
       getRequest<WordSetCardPreview>(
-        `${PATH}/getPreviewWords/?wordSetID=${wordSetID}&curNumber=${previewWordsNumber}`,
+        withShareToken(
+          `${PATH}/getPreviewWords/?wordSetID=${wordSetID}&curNumber=${previewWordsNumber}`,
+          wordSetID,
+        ),
         z.object({
           words: z.array(Word_zod),
           haveMore: z.boolean(),
@@ -0,0 +1,28 @@
+// 顯示後端Markdown轉好(已過濾)的HTML，沒有html時顯示原本的文字
+// 後端只會輸出p、br、strong、em、code、ul、ol、li，所以可以直接放進去
+export default function RichText({
+  html,
+  text,
+  className,
+  dir,
+}: {
+  html?: string;
+  text: string;
+  className?: string;
+  dir?: "ltr" | "rtl" | "auto";
+}) {
+  if (!html) {
+    return (
+      <span dir={dir} className={className}>
+        {text}
+      </span>
+    );
+  }
+  return (
+    <div
+      dir={dir}
+      className={`${className ?? ""} [&_code]:rounded [&_code]:bg-gray-100 [&_code]:px-1 [&_ol]:list-decimal [&_ol]:pl-6 [&_ol]:text-left [&_p+p]:mt-2 [&_ul]:list-disc [&_ul]:pl-6 [&_ul]:text-left`}
+      dangerouslySetInnerHTML={{ __html: html }}
+    />
+  );
+}
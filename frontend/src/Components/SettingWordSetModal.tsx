@@ -2,12 +2,23 @@ import { useEffect, useState } from "react";
 import { postRequest } from "../Utils/postRequest";
 import { PATH } from "../Consts/consts";
 import {
+  CreateShareLinkRequest,
+  RevokeShareLinksRequest,
+  SetDuplicatePolicyRequest,
+  SetWordSetVisibilityRequest,
   ToggleAllowCopyRequest,
   ToggleIsPublicRequest,
 } from "../Types/request";
+import { DuplicatePolicy, Visibility } from "../Types/types";
 import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
 import React from "react";
 
+const visibilityOptions: { value: Visibility; label: string }[] = [
+  { value: "private", label: "私人(只有自己跟協作者)" },
+  { value: "unlisted", label: "持有分享連結的人" },
+  { value: "public", label: "公開(會出現在搜尋跟首頁)" },
+];
+
 export default React.memo(function SettingWordSetModal({
   handleToggleAllowCopy,
   handleToggleIsPublic,
@@ -17,6 +28,8 @@ export default React.memo(function SettingWordSetModal({
   handleClose,
   allowCopy,
   isPublic,
+  visibility,
+  duplicatePolicy,
 }: {
   handleToggleAllowCopy?: () => void;
   handleToggleIsPublic?: () => void;
@@ -26,6 +39,8 @@ export default React.memo(function SettingWordSetModal({
   handleClose: () => void;
   allowCopy: boolean;
   isPublic: boolean;
+  visibility?: Visibility; // 有給的話(編輯既有單字集)顯示公開範圍跟分享連結，取代isPublic開關
+  duplicatePolicy?: DuplicatePolicy; // 有給的話(編輯既有單字集)顯示重複單字設定
 }) {
   const { setNotice } = useNoticeDisplayContextProvider();
   const [isAllowCopy, setIsAllowCopy] = useState(allowCopy);
@@ -40,6 +55,93 @@ export default React.memo(function SettingWordSetModal({
     setIsPub(isPublic);
   }, [isPublic]);
 
+  const [curVisibility, setCurVisibility] = useState(visibility);
+  const [isVisibilityLoading, setIsVisibilityLoading] = useState(false);
+  const [shareLink, setShareLink] = useState("");
+  useEffect(() => {
+    setCurVisibility(visibility);
+  }, [visibility]);
+
+  const changeVisibility = (next: Visibility) => {
+    setIsVisibilityLoading(true);
+    postRequest(`${PATH}/setWordSetVisibility`, {
+      userID: userID,
+      wordSetID: wordSetID,
+      visibility: next,
+    } as SetWordSetVisibilityRequest)
+      .then(() => {
+        setCurVisibility(next);
+      })
+      .catch((error) => {
+        setNotice(error);
+      })
+      .finally(() => {
+        setIsVisibilityLoading(false);
+      });
+  };
+
+  const [curDuplicatePolicy, setCurDuplicatePolicy] = useState(duplicatePolicy);
+  const [isDuplicatePolicyLoading, setIsDuplicatePolicyLoading] =
+    useState(false);
+  useEffect(() => {
+    setCurDuplicatePolicy(duplicatePolicy);
+  }, [duplicatePolicy]);
+
+  const toggleDuplicatePolicy = () => {
+    const next: DuplicatePolicy =
+      curDuplicatePolicy === "reject" ? "warn" : "reject";
+    setIsDuplicatePolicyLoading(true);
+    postRequest(`${PATH}/setDuplicatePolicy`, {
+      userID: userID,
+      wordSetID: wordSetID,
+      duplicatePolicy: next,
+    } as SetDuplicatePolicyRequest)
+      .then(() => {
+        setCurDuplicatePolicy(next);
+      })
+      .catch((error) => {
+        setNotice(error);
+      })
+      .finally(() => {
+        setIsDuplicatePolicyLoading(false);
+      });
+  };
+
+  // 建立新的分享連結並複製，連結只會顯示這一次
+  const createShareLink = () => {
+    postRequest(`${PATH}/createShareLink`, {
+      userID: userID,
+      wordSetID: wordSetID,
+    } as CreateShareLinkRequest)
+      .then((data) => {
+        const link: string = data.payload.message;
+        setShareLink(link);
+        return navigator.clipboard.writeText(link).then(() => {
+          setNotice({ type: "Success", payload: { message: "複製連結成功" } });
+        });
+      })
+      .catch((error) => {
+        setNotice(error);
+      });
+  };
+
+  const revokeShareLinks = () => {
+    postRequest(`${PATH}/revokeShareLinks`, {
+      userID: userID,
+      wordSetID: wordSetID,
+    } as RevokeShareLinksRequest)
+      .then(() => {
+        setShareLink("");
+        setNotice({
+          type: "Success",
+          payload: { message: "已撤銷所有分享連結" },
+        });
+      })
+      .catch((error) => {
+        setNotice(error);
+      });
+  };
+
   const toggleAllowCopy =
     handleToggleAllowCopy === undefined
       ? () => {
@@ -118,8 +220,70 @@ export default React.memo(function SettingWordSetModal({
           </label>
         </div>
 
+        {curDuplicatePolicy !== undefined && (
+          <div className="flex items-center justify-between lg:text-[1.2rem]">
+            <span>不允許重複的單字</span>
+            <label
+              htmlFor="isRejectDuplicateToggle"
+              className={`relative inline-block h-6 w-10 cursor-pointer rounded-full transition-colors duration-300 ease-in-out lg:h-8 lg:w-14 ${isDuplicatePolicyLoading ? "pointer-events-none opacity-50" : ""} ${curDuplicatePolicy === "reject" ? "bg-[var(--light-theme-color)]" : "bg-gray-400"}`}
+            >
+              <input
+                type="checkbox"
+                id="isRejectDuplicateToggle"
+                className="sr-only"
+                checked={curDuplicatePolicy === "reject"}
+                onChange={() => toggleDuplicatePolicy()}
+              />
+              <span
+                className={`absolute top-1 left-1 h-4 w-4 transform rounded-full bg-white shadow-md transition-transform duration-300 lg:h-6 lg:w-6 ${curDuplicatePolicy === "reject" ? "translate-x-4 lg:translate-x-6" : "translate-x-0"}`}
+              ></span>
+            </label>
+          </div>
+        )}
+
+        {curVisibility !== undefined && (
+          <div className="flex flex-col gap-2 lg:text-[1.2rem]">
+            <span>誰可以查看</span>
+            {visibilityOptions.map((option) => (
+              <label
+                key={option.value}
+                className={`flex items-center gap-2 ${isVisibilityLoading ? "pointer-events-none opacity-50" : "cursor-pointer"}`}
+              >
+                <input
+                  type="radio"
+                  name="visibility"
+                  checked={curVisibility === option.value}
+                  onChange={() => changeVisibility(option.value)}
+                />
+                <span>{option.label}</span>
+              </label>
+            ))}
+            {curVisibility === "unlisted" && (
+              <div className="flex flex-col gap-2 text-[.9rem]">
+                <div className="flex gap-2">
+                  <button
+                    onClick={() => createShareLink()}
+                    className="rounded-lg bg-[var(--light-theme-color)] px-3 py-1 text-white hover:cursor-pointer"
+                  >
+                    建立並複製分享連結
+                  </button>
+                  <button
+                    onClick={() => revokeShareLinks()}
+                    className="rounded-lg border-2 border-gray-300 px-3 py-1 hover:cursor-pointer hover:bg-gray-300"
+                  >
+                    撤銷所有連結
+                  </button>
+                </div>
+                {shareLink !== "" && (
+                  <span className="break-all text-gray-500">{shareLink}</span>
+                )}
+              </div>
+            )}
+          </div>
+        )}
+
         <div
-          className={`flex items-center justify-between lg:text-[1.2rem] ${isPub === undefined ? "opacity-50" : ""}`}
+          className={`${curVisibility !== undefined ? "hidden" : "flex"} items-center justify-between lg:text-[1.2rem] ${isPub === undefined ? "opacity-50" : ""}`}
         >
           <span>允許發布至首頁(最新/熱門單字集)</span>
           <label
@@ -0,0 +1,191 @@
+import React, { useEffect, useState } from "react";
+import { useNavigate } from "react-router";
+import { getRequest } from "../Utils/getRequest";
+import { postRequest } from "../Utils/postRequest";
+import { PATH } from "../Consts/consts";
+import { PullUpstreamRequest } from "../Types/request";
+import { NoticeDisplay, UpstreamChanges, Word } from "../Types/types";
+import { UpstreamChangesSchema } from "../Types/zod_response";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+
+const kindLabels = {
+  added: "新增",
+  updated: "修改",
+  removed: "刪除",
+};
+
+function WordPreview({ label, word }: { label: string; word?: Word }) {
+  return (
+    <div className="flex flex-1 flex-col rounded-lg bg-gray-100 p-2">
+      <span className="text-[.8rem] text-gray-500">{label}</span>
+      {word === undefined ? (
+        <span className="text-gray-400">(已刪除)</span>
+      ) : (
+        <>
+          <span className="font-bold break-words">{word.vocabulary}</span>
+          <span className="break-words">{word.definition}</span>
+        </>
+      )}
+    </div>
+  );
+}
+
+// 預覽原始單字集的變更，衝突的單字要選擇保留哪一邊後才能同步
+export default React.memo(function UpstreamModal({
+  userID,
+  wordSetID,
+  version,
+  isModalOpen,
+  handleClose,
+}: {
+  userID: string;
+  wordSetID: string;
+  version: number;
+  isModalOpen: boolean;
+  handleClose: () => void;
+}) {
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const navigate = useNavigate();
+  const [upstream, setUpstream] = useState<UpstreamChanges | null>(null);
+  const [resolutions, setResolutions] = useState<
+    Record<string, "upstream" | "local">
+  >({});
+  const [isPulling, setIsPulling] = useState(false);
+
+  useEffect(() => {
+    if (!isModalOpen) return;
+    const controller = new AbortController();
+    setUpstream(null);
+    setResolutions({});
+    getRequest(
+      `${PATH}/getUpstreamChanges/${wordSetID}`,
+      UpstreamChangesSchema,
+      controller.signal,
+    )
+      .then((data) => {
+        setUpstream(data);
+      })
+      .catch((error) => {
+        if (controller.signal.aborted) return;
+        setNotice(error as NoticeDisplay);
+        handleClose();
+      });
+    return () => controller.abort();
+  }, [isModalOpen, wordSetID]);
+
+  const conflicts =
+    upstream?.changes.filter((change) => change.conflict).length ?? 0;
+  const unresolved =
+    upstream?.changes.filter(
+      (change) => change.conflict && resolutions[change.wordID] === undefined,
+    ).length ?? 0;
+
+  const pull = () => {
+    setIsPulling(true);
+    postRequest(`${PATH}/pullUpstream`, {
+      userID: userID,
+      wordSetID: wordSetID,
+      version: version,
+      resolutions: resolutions,
+    } as PullUpstreamRequest)
+      .then(() => {
+        setNotice({
+          type: "Success",
+          payload: { message: "同步原始單字集成功" },
+        } as NoticeDisplay);
+        navigate(0); // 單字跟版本號都變了，直接重整
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsPulling(false);
+      });
+  };
+
+  return (
+    <>
+      <div
+        onClick={() => handleClose()}
+        className={`${isModalOpen ? "block" : "hidden"} fixed inset-0 z-1000 bg-black opacity-30`}
+      ></div>
+      <div
+        className={`${isModalOpen ? "visible top-[50%] opacity-100" : "invisible top-[40%] opacity-0"} fixed left-[50%] z-1000 flex max-h-[80vh] w-[90%] translate-x-[-50%] translate-y-[-50%] flex-col gap-4 rounded-2xl bg-white p-4 transition-all duration-300 sm:w-[55%] sm:p-8 xl:w-[35%]`}
+      >
+        <span
+          onClick={() => handleClose()}
+          className="absolute top-[12px] right-[15px] text-[1.2rem] font-bold text-black hover:cursor-pointer"
+        >
+          &#10005;
+        </span>
+        <h1 className="text-[1.2rem] font-bold text-black sm:text-[2rem]">
+          同步原始單字集
+        </h1>
+        {upstream === null ? (
+          <span className="text-gray-500">載入中...</span>
+        ) : upstream.changes.length === 0 ? (
+          <span className="text-gray-500">原始單字集沒有新的變更</span>
+        ) : (
+          <>
+            <span className="text-[.9rem] text-gray-500">
+              共{upstream.changes.length}個變更
+              {conflicts > 0 && `，其中${conflicts}個與你的修改衝突`}
+            </span>
+            <div className="flex flex-col gap-3 overflow-y-auto">
+              {upstream.changes.map((change) => (
+                <div
+                  key={change.wordID}
+                  className={`flex flex-col gap-2 rounded-lg border-2 p-2 ${change.conflict ? "border-amber-300" : "border-gray-200"}`}
+                >
+                  <span className="text-[.9rem] font-bold">
+                    {kindLabels[change.kind]}
+                    {change.conflict && "(衝突)"}
+                  </span>
+                  <div className="flex gap-2">
+                    <WordPreview label="原始單字集" word={change.upstream} />
+                    {change.conflict && (
+                      <WordPreview label="你的版本" word={change.local} />
+                    )}
+                  </div>
+                  {change.conflict && (
+                    <div className="flex gap-4 text-[.9rem]">
+                      {(["upstream", "local"] as const).map((side) => (
+                        <label
+                          key={side}
+                          className="flex items-center gap-1 hover:cursor-pointer"
+                        >
+                          <input
+                            type="radio"
+                            name={`resolution-${change.wordID}`}
+                            checked={resolutions[change.wordID] === side}
+                            onChange={() =>
+                              setResolutions((prev) => ({
+                                ...prev,
+                                [change.wordID]: side,
+                              }))
+                            }
+                          />
+                          <span>
+                            {side === "upstream" ? "使用原始版本" : "保留我的"}
+                          </span>
+                        </label>
+                      ))}
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+          </>
+        )}
+        <div className="flex justify-end">
+          <button
+            onClick={() => pull()}
+            className={`${upstream === null || unresolved > 0 || isPulling ? "pointer-events-none opacity-50" : ""} rounded-xl bg-[var(--light-theme-color)] px-4 py-2 text-white hover:cursor-pointer hover:bg-blue-700`}
+          >
+            {unresolved > 0 ? `尚有${unresolved}個衝突未選擇` : "同步"}
+          </button>
+        </div>
+      </div>
+    </>
+  );
+});
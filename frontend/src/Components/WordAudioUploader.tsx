@@ -0,0 +1,143 @@
+import { useRef, useState } from "react";
+import ClipLoader from "react-spinners/ClipLoader";
+import { HiOutlineSpeakerWave } from "react-icons/hi2";
+import { postRequest } from "../Utils/postRequest";
+import { PATH } from "../Consts/consts";
+import { RemoveWordAudioRequest } from "../Types/request";
+import { NoticeDisplay, Word, WordAudio } from "../Types/types";
+import { Speaker } from "../Utils/utils";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+
+const maxAudioSize = 3 << 20; // 跟後端Consts.MaxWordAudioSize一致
+
+// 編輯單字時上傳/刪除某一面的發音，選好檔案就直接送出
+// swapped時畫面上的vocabulary其實是後端的definition，送出時要換回來
+export default function WordAudioUploader({
+  wordSetID,
+  word,
+  side,
+  swapped,
+  setWords,
+  version,
+  setVersion,
+}: {
+  wordSetID: string;
+  word: Word;
+  side: "vocabulary" | "definition";
+  swapped: boolean;
+  setWords: React.Dispatch<React.SetStateAction<Word[]>>;
+  version: number;
+  setVersion: React.Dispatch<React.SetStateAction<number>>;
+}) {
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const inputRef = useRef<HTMLInputElement | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const field = side === "vocabulary" ? "vocabularyAudio" : "definitionAudio";
+  const audio = word[field];
+  const serverSide =
+    swapped === (side === "vocabulary") ? "definition" : "vocabulary";
+
+  const updateAudio = (audio: WordAudio | undefined) => {
+    setWords((prev) =>
+      prev.map((cur) => (cur.id === word.id ? { ...cur, [field]: audio } : cur)),
+    );
+  };
+
+  const upload = (file: File) => {
+    if (file.size > maxAudioSize) {
+      setNotice({
+        type: "Error",
+        payload: { message: "音檔不得超過3MB" },
+      } as NoticeDisplay);
+      return;
+    }
+    const formData = new FormData();
+    formData.append("wordSetID", wordSetID);
+    formData.append("wordID", word.id);
+    formData.append("side", serverSide);
+    formData.append("version", String(version));
+    formData.append("audio", file);
+    setIsLoading(true);
+    postRequest(`${PATH}/uploadWordAudio`, formData)
+      .then((data) => {
+        setVersion(data.payload.version);
+        updateAudio(data.payload.audio);
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsLoading(false);
+        if (inputRef.current) inputRef.current.value = "";
+      });
+  };
+
+  const remove = () => {
+    setIsLoading(true);
+    postRequest(`${PATH}/removeWordAudio`, {
+      wordSetID: wordSetID,
+      wordID: word.id,
+      side: serverSide,
+      version: version,
+    } as RemoveWordAudioRequest)
+      .then((data) => {
+        setVersion(data.payload.version);
+        updateAudio(undefined);
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsLoading(false);
+      });
+  };
+
+  const label = side === "vocabulary" ? "單字發音" : "註釋發音";
+  const text = side === "vocabulary" ? word.vocabulary : word.definition;
+  const sound =
+    side === "vocabulary" ? word.vocabularySound : word.definitionSound;
+
+  return (
+    <div className="flex items-center gap-4 text-[.8rem] md:text-[1rem]">
+      <span>{label}</span>
+      {audio && (
+        <button
+          onClick={() => Speaker(text, sound, audio)}
+          className="hover:cursor-pointer"
+        >
+          <HiOutlineSpeakerWave className="h-5 w-5" />
+        </button>
+      )}
+      <input
+        ref={inputRef}
+        type="file"
+        accept="audio/mpeg,audio/wav,audio/ogg,audio/mp4,.m4a"
+        className="hidden"
+        onChange={(e) => {
+          const file = e.target.files?.[0];
+          if (file) upload(file);
+        }}
+      />
+      {isLoading ? (
+        <ClipLoader size={20} />
+      ) : (
+        <>
+          <button
+            onClick={() => inputRef.current?.click()}
+            className="font-bold text-[var(--light-theme-color)] hover:cursor-pointer"
+          >
+            {audio ? "更換音檔" : "+ 上傳音檔"}
+          </button>
+          {audio && (
+            <button
+              onClick={() => remove()}
+              className="font-bold text-red-500 hover:cursor-pointer"
+            >
+              刪除音檔
+            </button>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,84 @@
+import { CardTemplate, Word } from "../Types/types";
+import { mediaURL, partOfSpeechName } from "../Utils/utils";
+
+// 單字列表裡顯示音標、詞性、模板自訂欄位、其他意思以及例句，都沒有的話不顯示
+export default function WordDetails({
+  word,
+  template,
+}: {
+  word: Word;
+  template?: CardTemplate;
+}) {
+  const meanings = word.meanings ?? [];
+  const examples = word.examples ?? [];
+  const customFields = (template?.fields ?? []).filter(
+    (field) => (word.fields?.[field.key] ?? "") !== "",
+  );
+  if (
+    !word.pronunciation &&
+    !word.partOfSpeech &&
+    customFields.length === 0 &&
+    !word.image &&
+    meanings.length === 0 &&
+    examples.length === 0
+  ) {
+    return null;
+  }
+  return (
+    <div className="flex flex-col gap-1 text-[.9rem] text-gray-600">
+      {word.image && (
+        <img
+          src={mediaURL(word.image.thumbnailUrl)}
+          alt={word.vocabulary}
+          loading="lazy"
+          className="h-20 w-max max-w-full rounded-md object-contain"
+        />
+      )}
+      {(word.pronunciation || word.partOfSpeech) && (
+        <div className="flex flex-wrap items-center gap-2">
+          {word.pronunciation && (
+            <span className="break-all">{word.pronunciation}</span>
+          )}
+          {word.partOfSpeech && (
+            <span className="rounded-md bg-gray-200 px-2 text-[.8rem]">
+              {partOfSpeechName(word.partOfSpeech)}
+            </span>
+          )}
+        </div>
+      )}
+      {customFields.map((field) => (
+        <div key={field.key} className="flex gap-2">
+          <span className="text-gray-500">{field.label}</span>
+          <span className="break-words">{word.fields?.[field.key]}</span>
+        </div>
+      ))}
+      {meanings.length > 0 && (
+        <ol className="list-inside list-decimal" start={2}>
+          {meanings.map((meaning, index) => (
+            <li key={index} className="break-words">
+              {meaning.partOfSpeech && (
+                <span className="mr-1 text-gray-500">
+                  {`(${partOfSpeechName(meaning.partOfSpeech)})`}
+                </span>
+              )}
+              {meaning.definition}
+            </li>
+          ))}
+        </ol>
+      )}
+      {examples.map((example, index) => (
+        <div
+          key={index}
+          className="flex flex-col border-l-2 border-gray-300 pl-2"
+        >
+          <span className="break-words italic">{example.sentence}</span>
+          {example.translation && (
+            <span className="break-words text-gray-500">
+              {example.translation}
+            </span>
+          )}
+        </div>
+      ))}
+    </div>
+  );
+}
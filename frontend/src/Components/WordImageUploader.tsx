@@ -0,0 +1,124 @@
+import { useRef, useState } from "react";
+import ClipLoader from "react-spinners/ClipLoader";
+import { postRequest } from "../Utils/postRequest";
+import { PATH } from "../Consts/consts";
+import { RemoveWordImageRequest } from "../Types/request";
+import { NoticeDisplay, Word, WordImage } from "../Types/types";
+import { mediaURL } from "../Utils/utils";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+
+const maxImageSize = 5 << 20; // 跟後端Consts.MaxWordImageSize一致
+
+// 編輯單字時上傳/刪除圖片，選好檔案就直接送出，不用等按確認
+export default function WordImageUploader({
+  wordSetID,
+  word,
+  setWords,
+  version,
+  setVersion,
+}: {
+  wordSetID: string;
+  word: Word;
+  setWords: React.Dispatch<React.SetStateAction<Word[]>>;
+  version: number;
+  setVersion: React.Dispatch<React.SetStateAction<number>>;
+}) {
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const inputRef = useRef<HTMLInputElement | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+
+  const updateImage = (image: WordImage | undefined) => {
+    setWords((prev) =>
+      prev.map((cur) => (cur.id === word.id ? { ...cur, image: image } : cur)),
+    );
+  };
+
+  const upload = (file: File) => {
+    if (file.size > maxImageSize) {
+      setNotice({
+        type: "Error",
+        payload: { message: "圖片不得超過5MB" },
+      } as NoticeDisplay);
+      return;
+    }
+    const formData = new FormData();
+    formData.append("wordSetID", wordSetID);
+    formData.append("wordID", word.id);
+    formData.append("version", String(version));
+    formData.append("image", file);
+    setIsLoading(true);
+    postRequest(`${PATH}/uploadWordImage`, formData)
+      .then((data) => {
+        setVersion(data.payload.version);
+        updateImage(data.payload.image);
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsLoading(false);
+        if (inputRef.current) inputRef.current.value = "";
+      });
+  };
+
+  const remove = () => {
+    setIsLoading(true);
+    postRequest(`${PATH}/removeWordImage`, {
+      wordSetID: wordSetID,
+      wordID: word.id,
+      version: version,
+    } as RemoveWordImageRequest)
+      .then((data) => {
+        setVersion(data.payload.version);
+        updateImage(undefined);
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsLoading(false);
+      });
+  };
+
+  return (
+    <div className="flex items-center gap-4 text-[.8rem] md:text-[1rem]">
+      {word.image && (
+        <img
+          src={mediaURL(word.image.thumbnailUrl)}
+          alt={word.vocabulary}
+          className="h-16 w-16 rounded-md object-cover"
+        />
+      )}
+      <input
+        ref={inputRef}
+        type="file"
+        accept="image/jpeg,image/png,image/gif"
+        className="hidden"
+        onChange={(e) => {
+          const file = e.target.files?.[0];
+          if (file) upload(file);
+        }}
+      />
+      {isLoading ? (
+        <ClipLoader size={20} />
+      ) : (
+        <>
+          <button
+            onClick={() => inputRef.current?.click()}
+            className="font-bold text-[var(--light-theme-color)] hover:cursor-pointer"
+          >
+            {word.image ? "更換圖片" : "+ 新增圖片"}
+          </button>
+          {word.image && (
+            <button
+              onClick={() => remove()}
+              className="font-bold text-red-500 hover:cursor-pointer"
+            >
+              刪除圖片
+            </button>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
@@ -11,7 +11,13 @@ import { PiNotePencilBold } from "react-icons/pi";
 import { HiOutlineSpeakerWave } from "react-icons/hi2";
 import { IoAddCircleOutline } from "react-icons/io5";
 import UserLink from "./UserLink";
-import { NoticeDisplay, sortWordType, Word, WordSetType } from "../Types/types";
+import {
+  CardTemplate,
+  NoticeDisplay,
+  sortWordType,
+  Word,
+  WordSetType,
+} from "../Types/types";
 import {
   AddRecentVisitRequest,
   DeleteWordSetRequest,
@@ -23,7 +29,14 @@ import {
 } from "../Types/request";
 import BigWordCard from "./BigWordCard";
 import AddOrEditWordModal from "./AddOrEditWordModal";
-import { formatTime, Speaker } from "../Utils/utils";
+import WordDetails from "./WordDetails";
+import {
+  canEditWordSet,
+  defaultCardTemplate,
+  formatTime,
+  getShareToken,
+  speakWord,
+} from "../Utils/utils";
 import { useLogInContextProvider } from "../Context/LogInContextProvider";
 import { postRequest } from "../Utils/postRequest";
 import { PATH } from "../Consts/consts";
@@ -31,10 +44,16 @@ import ConfirmModal from "./ConfirmModal";
 import { useNavigate } from "react-router";
 import ContentEditable from "./ContentEditable";
 import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+import UpstreamModal from "./UpstreamModal";
+import CardTemplateModal from "./CardTemplateModal";
+import WordSetForks from "./WordSetForks";
+import RichText from "./RichText";
 
 export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
   const authorID = wordSet.authorID;
   const { user } = useLogInContextProvider();
+  const canEdit = canEditWordSet(wordSet, user?.id); // 作者或editor協作者
+  const canStar = !!user; // 星號是每個人自己的，登入就可以加
   const { setNotice } = useNoticeDisplayContextProvider();
   const [isMenuOpen, setIsMenuOpen] = useState(false);
   const toolKitRef = useRef<HTMLDivElement | null>(null);
@@ -46,6 +65,16 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
   );
   const [wordSetLikes, setWordSetLikes] = useState<number>(() => wordSet.likes);
   const [forkLoading, setForkLoading] = useState<boolean>(false);
+  // 單字集目前的版本號，修改成功後用後端回傳的新版本號更新
+  const [version, setVersion] = useState<number>(() => wordSet.version ?? 0);
+  const [isUpstreamModalOpen, setIsUpstreamModalOpen] =
+    useState<boolean>(false);
+  // 卡片模板，沒設定的話用預設的
+  const [template, setTemplate] = useState<CardTemplate>(
+    () => wordSet.template ?? defaultCardTemplate(wordSet.shouldSwap),
+  );
+  const [isTemplateModalOpen, setIsTemplateModalOpen] =
+    useState<boolean>(false);
   // Close menu when clicking outside
   useEffect(() => {
     const handleClickOutside = (e: MouseEvent) => {
@@ -70,6 +99,10 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
         definition: word.vocabulary,
         vocabularySound: word.definitionSound,
         definitionSound: word.vocabularySound,
+        vocabularyAudio: word.definitionAudio,
+        definitionAudio: word.vocabularyAudio,
+        vocabularyHtml: word.definitionHtml,
+        definitionHtml: word.vocabularyHtml,
       }));
     } else {
       return wordSet.words;
@@ -81,8 +114,10 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
     const currentState = sortedWords.length === sortedStarWords.length;
     if (!currentState) {
       postRequest(`${PATH}/toggleAllWordStar`, {
+        userID: user?.id ?? "",
         wordSetID: wordSet.id,
         newStar: true,
+        shareToken: getShareToken(wordSet.id),
       } as ToggleAllWordStarRequest)
         .then(() => {
           setWords((prev) => prev.map((word) => ({ ...word, star: true })));
@@ -92,8 +127,10 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
         });
     } else {
       postRequest(`${PATH}/toggleAllWordStar`, {
+        userID: user?.id ?? "",
         wordSetID: wordSet.id,
         newStar: false,
+        shareToken: getShareToken(wordSet.id),
       } as ToggleAllWordStarRequest)
         .then(() => {
           setWords((prev) => prev.map((word) => ({ ...word, star: false })));
@@ -105,22 +142,27 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
   };
 
   // 單選星星
-  const handleStarOne = useCallback((id: string) => {
-    postRequest(`${PATH}/toggleWordStar`, {
-      wordSetID: wordSet.id,
-      wordID: id,
-    } as ToggleWordStarRequest)
-      .then(() => {
-        setWords((prev) =>
-          prev.map((word) =>
-            word.id === id ? { ...word, star: !word.star } : word,
-          ),
-        );
-      })
-      .catch((error) => {
-        setNotice(error as NoticeDisplay);
-      });
-  }, []);
+  const handleStarOne = useCallback(
+    (id: string) => {
+      postRequest(`${PATH}/toggleWordStar`, {
+        userID: user?.id ?? "",
+        wordSetID: wordSet.id,
+        wordID: id,
+        shareToken: getShareToken(wordSet.id),
+      } as ToggleWordStarRequest)
+        .then(() => {
+          setWords((prev) =>
+            prev.map((word) =>
+              word.id === id ? { ...word, star: !word.star } : word,
+            ),
+          );
+        })
+        .catch((error) => {
+          setNotice(error as NoticeDisplay);
+        });
+    },
+    [user?.id],
+  );
 
   // 按字母順序(A-Z)且不管大小寫，並用useMemo減少re-render開銷
   const sortedWords = useMemo(() => {
@@ -187,8 +229,10 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
       wordID: wordID,
       newVocabulary: newVocabulary,
       newDefinition: newDefinition,
+      version: version,
     } as InlineUpdateWordRequest)
-      .then(() => {
+      .then((data) => {
+        setVersion(data.payload.version);
         setWords((preWords) =>
           preWords.map((word) =>
             word.id === wordID
@@ -196,6 +240,9 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                   ...word,
                   vocabulary: newVocabulary,
                   definition: newDefinition,
+                  // 轉好的HTML已經過時，重新整理前先顯示純文字
+                  vocabularyHtml: undefined,
+                  definitionHtml: undefined,
                 }
               : word,
           ),
@@ -308,10 +355,34 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
         mode={true}
         starMode={!displayAllWords}
         setWords={setWords}
+        version={version}
+        setVersion={setVersion}
         order={
           words.sort((a, b) => a.order - b.order)[words.length - 1].order + 1
         } // order比最後一位大就好
+        template={template}
       />
+      {canEdit && (
+        <CardTemplateModal
+          wordSetID={wordSet.id}
+          version={version}
+          setVersion={setVersion}
+          template={template}
+          setTemplate={setTemplate}
+          shouldSwap={wordSet.shouldSwap}
+          isModalOpen={isTemplateModalOpen}
+          handleClose={() => setIsTemplateModalOpen(false)}
+        />
+      )}
+      {canEdit && wordSet.forkedFrom !== undefined && (
+        <UpstreamModal
+          userID={user?.id ?? ""}
+          wordSetID={wordSet.id}
+          version={version}
+          isModalOpen={isUpstreamModalOpen}
+          handleClose={() => setIsUpstreamModalOpen(false)}
+        />
+      )}
       <div className="flex h-full w-full flex-col items-center px-[2rem] py-[1rem] sm:py-[2rem] lg:items-start lg:px-[6rem] xl:px-[8rem]">
         <div className="flex h-full w-full flex-col md:max-w-[90%] md:min-w-[80%] xl:max-w-[85%]">
           {/* header */}
@@ -333,6 +404,7 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                     postRequest(`${PATH}/toggleLikeWordSet`, {
                       userID: user.id,
                       wordSetID: wordSet.id,
+                      shareToken: getShareToken(wordSet.id),
                     } as ToggleLikeWordSetRequest)
                       .then(() => {
                         if (likes) {
@@ -358,6 +430,7 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                   postRequest(`${PATH}/forkWordSet`, {
                     userID: user?.id ?? "",
                     wordSetID: wordSet.id,
+                    shareToken: getShareToken(wordSet.id),
                   } as ForkWordSetRequest)
                     .then(() => {
                       setNotice({
@@ -392,15 +465,26 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                 {/* Menu Content */}
                 {isMenuOpen && (
                   <div className="absolute top-[120%] right-0 z-20 flex w-max flex-col items-center bg-gray-300">
+                    {canEdit && (
+                      <div
+                        onClick={() => navigate(`/editWordSet/${wordSet.id}`)}
+                        className="flex h-full w-full flex-grow items-center gap-2 p-2 hover:cursor-pointer hover:bg-gray-400"
+                      >
+                        <GoPencil className="h-[1.5rem] w-[1.5rem]" />
+                        <span>編輯</span>
+                      </div>
+                    )}
+                    {canEdit && (
+                      <div
+                        onClick={() => setIsTemplateModalOpen(true)}
+                        className="flex h-full w-full flex-grow items-center gap-2 p-2 hover:cursor-pointer hover:bg-gray-400"
+                      >
+                        <BsCardText className="h-[1.5rem] w-[1.5rem]" />
+                        <span>卡片模板</span>
+                      </div>
+                    )}
                     {user !== null && user.id === authorID && (
                       <>
-                        <div
-                          onClick={() => navigate(`/editWordSet/${wordSet.id}`)}
-                          className="flex h-full w-full flex-grow items-center gap-2 p-2 hover:cursor-pointer hover:bg-gray-400"
-                        >
-                          <GoPencil className="h-[1.5rem] w-[1.5rem]" />
-                          <span>編輯</span>
-                        </div>
                         <div
                           onClick={() => setIsConfirmModalOpen(true)}
                           className={`${isDeletingLoading ? "pointer-events-none bg-gray-200 text-gray-400" : ""} flex h-full w-full flex-grow items-center gap-2 p-2 hover:cursor-pointer hover:bg-gray-400`}
@@ -464,9 +548,14 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
           <BigWordCard
             wordSetID={wordSet.id}
             words={words}
-            authorID={authorID}
+            canEdit={canEdit}
+            canStar={canStar}
             handleStarOne={handleStarOne}
             setWords={setWords}
+            version={version}
+            setVersion={setVersion}
+            template={template}
+            shouldSwap={wordSet.shouldSwap}
           />
           {/* 字卡作者與日期 */}
           <div className="flex h-[80px] w-full flex-wrap items-center justify-between py-2">
@@ -480,10 +569,40 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
               <span>於</span> <span>{wordSet.createdAt}</span>
             </div>
           </div>
+          {/* 複製來源 */}
+          {wordSet.forkedFrom !== undefined && (
+            <div className="mb-4 flex flex-wrap items-center gap-2 text-[.9rem] text-gray-600">
+              <span>複製自</span>
+              <span
+                onClick={() =>
+                  navigate(`/wordSet/${wordSet.forkedFrom?.wordSetID}`)
+                }
+                className="font-bold hover:cursor-pointer hover:text-[var(--light-theme-color)]"
+              >
+                「{wordSet.forkedFrom.title}」
+              </span>
+              <UserLink userID={wordSet.forkedFrom.authorID} />
+              {canEdit && (
+                <button
+                  onClick={() => setIsUpstreamModalOpen(true)}
+                  className="rounded-lg border-2 border-gray-300 px-2 py-1 hover:cursor-pointer hover:bg-gray-300"
+                >
+                  同步原始單字集
+                </button>
+              )}
+            </div>
+          )}
 
           {/* 單字集敘述 */}
-          <div className="w-full text-[1.1rem] text-black">
-            {wordSet.description}
+          <RichText
+            html={wordSet.descriptionHtml}
+            text={wordSet.description}
+            className="w-full text-[1.1rem] text-black"
+          />
+
+          {/* 複製版本 */}
+          <div className="mt-4 w-full">
+            <WordSetForks wordSetID={wordSet.id} />
           </div>
 
           {/* 單字列表outer div */}
@@ -522,17 +641,17 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
             {/* 灰色區域 */}
             <div className="relative mt-4 flex w-full flex-col gap-4 rounded-xl bg-gray-100 p-4">
               {/* header */}
-              {sortedWords.length > 0 &&
-                user !== null &&
-                user.id === authorID && (
+              {sortedWords.length > 0 && canStar && (
                   <div className="mb-4 flex w-full items-center justify-between">
-                    <div
-                      onClick={() => navigate(`/editWordSet/${wordSet.id}`)}
-                      className="flex gap-2 rounded-xl border-2 border-gray-400 px-4 py-2 hover:cursor-pointer hover:border-[var(--light-theme-color)] hover:text-[var(--light-theme-color)]"
-                    >
-                      <GoPencil className="h-6 w-6" />
-                      <span>編輯單字集</span>
-                    </div>
+                    {canEdit && (
+                      <div
+                        onClick={() => navigate(`/editWordSet/${wordSet.id}`)}
+                        className="flex gap-2 rounded-xl border-2 border-gray-400 px-4 py-2 hover:cursor-pointer hover:border-[var(--light-theme-color)] hover:text-[var(--light-theme-color)]"
+                      >
+                        <GoPencil className="h-6 w-6" />
+                        <span>編輯單字集</span>
+                      </div>
+                    )}
 
                     <div
                       onClick={() => handleStarAll()}
@@ -597,9 +716,11 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                               )}
                             </div>
                           ) : (
-                            <span className="overflow-wrap break-word resize-none break-words break-all text-black">
-                              {word.vocabulary}
-                            </span>
+                            <RichText
+                              html={word.vocabularyHtml}
+                              text={word.vocabulary}
+                              className="overflow-wrap break-word resize-none break-words break-all text-black"
+                            />
                           )}
                         </div>
                         <div className="flex h-auto min-h-3/5 w-full items-center px-4 text-[1.2rem] break-words text-black md:w-4/7">
@@ -637,16 +758,21 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                               )}
                             </div>
                           ) : (
-                            <span className="overflow-wrap break-word resize-none break-words break-all text-black">
-                              {word.definition}
-                            </span>
+                            <div className="flex w-full flex-col gap-2">
+                              <RichText
+                                html={word.definitionHtml}
+                                text={word.definition}
+                                className="overflow-wrap break-word resize-none break-words break-all text-black"
+                              />
+                              <WordDetails word={word} template={template} />
+                            </div>
                           )}
                         </div>
                       </div>
 
                       {/* Button container - explicit dimensions for both modes */}
                       <div className="order-first flex h-max w-full justify-end gap-3 md:order-last md:h-20 md:w-1/6 md:justify-evenly md:gap-2 lg:gap-3">
-                        {user !== null && user.id === authorID && (
+                        {canEdit && (
                           <button
                             onClick={() => {
                               if (editWordID === word.id) {
@@ -679,14 +805,13 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                         )}
                         <button
                           onClick={() => {
-                            Speaker(word.vocabulary, word.vocabularySound);
-                            Speaker(word.definition, word.definitionSound);
+                            speakWord(word);
                           }}
                           className="relative after:invisible after:absolute after:top-[80%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
                         >
                           <HiOutlineSpeakerWave className="h-6 w-6 md:h-5 md:w-5 lg:h-6 lg:w-6" />
                         </button>
-                        {user !== null && user.id === authorID && (
+                        {canStar && (
                           <button
                             onClick={() => handleStarOne(word.id)}
                             className="relative after:invisible after:absolute after:top-[80%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['加入最愛'] hover:cursor-pointer hover:after:visible"
@@ -742,9 +867,11 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                               )}
                             </div>
                           ) : (
-                            <span className="overflow-wrap break-word resize-none break-words break-all text-black">
-                              {word.vocabulary}
-                            </span>
+                            <RichText
+                              html={word.vocabularyHtml}
+                              text={word.vocabulary}
+                              className="overflow-wrap break-word resize-none break-words break-all text-black"
+                            />
                           )}
                         </div>
                         <div className="flex h-auto min-h-4/7 w-full items-center px-4 text-[1.2rem] break-words text-black md:w-3/5">
@@ -782,16 +909,21 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                               )}
                             </div>
                           ) : (
-                            <span className="overflow-wrap break-word resize-none break-words break-all text-black">
-                              {word.definition}
-                            </span>
+                            <div className="flex w-full flex-col gap-2">
+                              <RichText
+                                html={word.definitionHtml}
+                                text={word.definition}
+                                className="overflow-wrap break-word resize-none break-words break-all text-black"
+                              />
+                              <WordDetails word={word} template={template} />
+                            </div>
                           )}
                         </div>
                       </div>
 
                       {/* Button container - explicit dimensions for both modes */}
                       <div className="order-first flex h-8 w-full justify-end gap-3 md:order-last md:h-20 md:w-1/6 md:justify-evenly md:gap-2 lg:gap-3">
-                        {user !== null && user.id === authorID && (
+                        {canEdit && (
                           <button
                             onClick={() => {
                               if (editWordID === word.id) {
@@ -824,14 +956,13 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                         )}
                         <button
                           onClick={() => {
-                            Speaker(word.vocabulary, word.vocabularySound);
-                            Speaker(word.definition, word.definitionSound);
+                            speakWord(word);
                           }}
                           className="relative after:invisible after:absolute after:top-[80%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['播放'] hover:cursor-pointer hover:after:visible"
                         >
                           <HiOutlineSpeakerWave className="h-6 w-6 md:h-5 md:w-5 lg:h-6 lg:w-6" />
                         </button>
-                        {user !== null && user.id === authorID && (
+                        {canStar && (
                           <button
                             onClick={() => handleStarOne(word.id)}
                             className="relative after:invisible after:absolute after:top-[80%] after:left-[50%] after:h-[25px] after:w-max after:translate-x-[-50%] after:rounded-md after:bg-black after:px-2 after:text-white after:content-['加入最愛'] hover:cursor-pointer hover:after:visible"
@@ -844,7 +975,7 @@ export default function WordSet({ wordSet }: { wordSet: WordSetType }) {
                       </div>
                     </div>
                   ))}
-              {user !== null && user.id === authorID && (
+              {canEdit && (
                 <div
                   onClick={() => setIsModalOpen(true)}
                   className="absolute top-full left-[50%] translate-x-[-50%]"
@@ -0,0 +1,86 @@
+import { useState } from "react";
+import { useNavigate } from "react-router";
+import { z } from "zod";
+import { getRequest } from "../Utils/getRequest";
+import { withShareToken } from "../Utils/utils";
+import { PATH } from "../Consts/consts";
+import { LibWordSetDisplay } from "../Types/response";
+import { NoticeDisplay } from "../Types/types";
+import { LibWordSetDisplaySchema } from "../Types/zod_response";
+import { useNoticeDisplayContextProvider } from "../Context/NoticeDisplayContextProvider";
+import UserLink from "./UserLink";
+
+const forkPageSize = 20; // 跟後端一頁的數量一樣
+
+// 列出從這個單字集複製出去的單字集，按下才去抓
+export default function WordSetForks({ wordSetID }: { wordSetID: string }) {
+  const { setNotice } = useNoticeDisplayContextProvider();
+  const navigate = useNavigate();
+  const [forks, setForks] = useState<LibWordSetDisplay[] | null>(null);
+  const [hasMore, setHasMore] = useState(false);
+  const [isLoading, setIsLoading] = useState(false);
+
+  const loadForks = (offset: number) => {
+    setIsLoading(true);
+    getRequest(
+      withShareToken(
+        `${PATH}/getWordSetForks/${wordSetID}?offset=${offset}`,
+        wordSetID,
+      ),
+      z.array(LibWordSetDisplaySchema),
+    )
+      .then((data) => {
+        setForks((prev) => [...(offset === 0 ? [] : (prev ?? [])), ...data]);
+        setHasMore(data.length === forkPageSize);
+      })
+      .catch((error) => {
+        setNotice(error as NoticeDisplay);
+      })
+      .finally(() => {
+        setIsLoading(false);
+      });
+  };
+
+  if (forks === null) {
+    return (
+      <button
+        onClick={() => loadForks(0)}
+        className={`${isLoading ? "pointer-events-none opacity-50" : ""} w-max text-[.9rem] text-[var(--light-theme-color)] hover:cursor-pointer hover:underline`}
+      >
+        查看複製版本
+      </button>
+    );
+  }
+
+  return (
+    <div className="flex flex-col gap-2">
+      <h2 className="font-bold text-black sm:text-[1.2rem]">複製版本</h2>
+      {forks.length === 0 ? (
+        <span className="text-gray-500">還沒有人複製這個單字集</span>
+      ) : (
+        forks.map((fork) => (
+          <div
+            key={fork.id}
+            className="flex items-center justify-between gap-2 rounded-lg bg-gray-100 p-2"
+          >
+            <span
+              onClick={() => navigate(`/wordSet/${fork.id}`)}
+              className="truncate font-bold hover:cursor-pointer hover:text-[var(--light-theme-color)]"
+            >
+              {fork.title}
+            </span>
+            <UserLink userID={fork.authorID} />
+          </div>
+        ))
+      )}
+      {hasMore && (
+        <button
+          onClick={() => loadForks(forks.length)}
+          className={`${isLoading ? "pointer-events-none opacity-50" : ""} w-max text-[.9rem] text-[var(--light-theme-color)] hover:cursor-pointer hover:underline`}
+        >
+          載入更多
+        </button>
+      )}
+    </div>
+  );
+}
@@ -1,4 +1,4 @@
-import { Sound } from "../Types/types";
+import { Language } from "../Types/types";
 
 export const ADMIN = {
   id: import.meta.env.VITE_ADMINID,
@@ -9,12 +9,57 @@ export const ADMIN = {
 export const CURPATH = import.meta.env.VITE_CURPATH;
 export const PATH = import.meta.env.VITE_PATH;
 
-export const soundArray: Sound[] = [
-  { EngName: "en-US", TwName: "英文(美式)" },
-  { EngName: "en-GB", TwName: "英文(英式)" },
-  { EngName: "en-AU", TwName: "英文(澳洲)" },
-  { EngName: "zh-TW", TwName: "中文(繁體)" },
-  { EngName: "zh-CN", TwName: "中文(簡體)" },
+// GET /languages拿到之前(或失敗時)先用的語言
+export const defaultLanguages: Language[] = [
+  {
+    tag: "en-US",
+    name: "英語(美式)",
+    nativeName: "English (US)",
+    script: "Latn",
+    direction: "ltr",
+  },
+  {
+    tag: "en-GB",
+    name: "英語(英式)",
+    nativeName: "English (UK)",
+    script: "Latn",
+    direction: "ltr",
+  },
+  {
+    tag: "en-AU",
+    name: "英語(澳洲)",
+    nativeName: "English (Australia)",
+    script: "Latn",
+    direction: "ltr",
+  },
+  {
+    tag: "zh-TW",
+    name: "中文(繁體)",
+    nativeName: "中文(台灣)",
+    script: "Hant",
+    direction: "ltr",
+  },
+  {
+    tag: "zh-CN",
+    name: "中文(簡體)",
+    nativeName: "中文(中国)",
+    script: "Hans",
+    direction: "ltr",
+  },
+];
+
+// 單字的詞性，跟後端Consts.PartsOfSpeech一致
+export const partsOfSpeech: { value: string; TwName: string }[] = [
+  { value: "noun", TwName: "名詞" },
+  { value: "verb", TwName: "動詞" },
+  { value: "adjective", TwName: "形容詞" },
+  { value: "adverb", TwName: "副詞" },
+  { value: "pronoun", TwName: "代名詞" },
+  { value: "preposition", TwName: "介系詞" },
+  { value: "conjunction", TwName: "連接詞" },
+  { value: "interjection", TwName: "感嘆詞" },
+  { value: "phrase", TwName: "片語" },
+  { value: "other", TwName: "其他" },
 ];
 
 export const resendValidateCodeTime = 3 * 60 * 1000; // 3 minutes
@@ -0,0 +1,43 @@
+import { useEffect, useState } from "react";
+import { z } from "zod";
+import { PATH, defaultLanguages } from "../Consts/consts";
+import { Language } from "../Types/types";
+import { LanguageSchema } from "../Types/zod_response";
+import { getRequest } from "../Utils/getRequest";
+
+// 後端的語言啟動後不會變，整個app只拿一次
+let languages: Language[] = defaultLanguages;
+let languagesPromise: Promise<Language[]> | null = null;
+
+export const loadLanguages = (): Promise<Language[]> => {
+  if (languagesPromise === null) {
+    languagesPromise = getRequest(`${PATH}/languages`, z.array(LanguageSchema))
+      .then((data) => {
+        languages = data;
+        return languages;
+      })
+      .catch(() => {
+        // 失敗的話先用預設的，下次再試
+        languagesPromise = null;
+        return languages;
+      });
+  }
+  return languagesPromise;
+};
+
+// 目前拿到的語言，還沒拿到時是defaultLanguages
+export const getLanguages = (): Language[] => languages;
+
+export const useLanguages = (): Language[] => {
+  const [list, setList] = useState<Language[]>(languages);
+  useEffect(() => {
+    let ignore = false;
+    loadLanguages().then((data) => {
+      if (!ignore) setList(data);
+    });
+    return () => {
+      ignore = true;
+    };
+  }, []);
+  return list;
+};
@@ -0,0 +1,109 @@
+import { useCallback, useEffect, useRef, useState } from "react";
+import { PATH } from "../Consts/consts";
+import {
+  LiveOp,
+  LivePresence,
+  LiveServerMessage,
+  WordSetType,
+} from "../Types/types";
+
+const PING_INTERVAL = 25 * 1000; // 後端60秒沒收到任何訊息就會斷線
+const RECONNECT_DELAY = 3 * 1000;
+
+// 連上單字集的即時協作WebSocket
+// 收到op時呼叫onOp，剛連上、seq跳號(錯過了變更)或別人從其他地方存檔時會收到完整快照並呼叫onSnapshot
+export const useWordSetLive = (
+  wordSetID: string,
+  handlers: {
+    onOp: (op: LiveOp, seq: number) => void;
+    onSnapshot: (wordSet: WordSetType) => void;
+  },
+) => {
+  const [presence, setPresence] = useState<LivePresence[]>([]);
+  const [connID, setConnID] = useState<string>("");
+  const socketRef = useRef<WebSocket | null>(null);
+  const seqRef = useRef<number>(0);
+  const handlersRef = useRef(handlers);
+  handlersRef.current = handlers;
+
+  useEffect(() => {
+    let stopped = false;
+    let pingTimer: ReturnType<typeof setInterval> | undefined;
+    let reconnectTimer: ReturnType<typeof setTimeout> | undefined;
+
+    const connect = () => {
+      const url = new URL(
+        `${PATH}/api/v1/wordsets/${wordSetID}/live`,
+        window.location.href,
+      );
+      url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+      const socket = new WebSocket(url);
+      socketRef.current = socket;
+
+      socket.onopen = () => {
+        pingTimer = setInterval(() => {
+          socket.send(JSON.stringify({ type: "ping" }));
+        }, PING_INTERVAL);
+      };
+      socket.onmessage = (event) => {
+        const message = JSON.parse(event.data) as LiveServerMessage;
+        switch (message.type) {
+          case "welcome":
+          case "snapshot":
+            if (message.connID) setConnID(message.connID);
+            if (message.presence) setPresence(message.presence);
+            seqRef.current = message.seq ?? 0;
+            handlersRef.current.onSnapshot(message.wordSet!);
+            break;
+          case "op":
+            // 跳號代表錯過了變更，要求完整快照
+            if (message.seq !== seqRef.current + 1) {
+              socket.send(JSON.stringify({ type: "sync" }));
+              return;
+            }
+            seqRef.current = message.seq;
+            handlersRef.current.onOp(message.op!, message.seq);
+            break;
+          case "presence":
+            setPresence(message.presence ?? []);
+            break;
+          case "error":
+            console.log("live error", message.error);
+            break;
+        }
+      };
+      socket.onclose = () => {
+        clearInterval(pingTimer);
+        setPresence([]);
+        if (!stopped) {
+          reconnectTimer = setTimeout(connect, RECONNECT_DELAY);
+        }
+      };
+    };
+    connect();
+
+    return () => {
+      stopped = true;
+      clearInterval(pingTimer);
+      clearTimeout(reconnectTimer);
+      socketRef.current?.close();
+    };
+  }, [wordSetID]);
+
+  // 告訴其他人自己正在編輯哪個單字、還沒送出的內容
+  const sendPresence = useCallback(
+    (focusWordID: string, field: string, draft: string) => {
+      const socket = socketRef.current;
+      if (socket?.readyState !== WebSocket.OPEN) return;
+      socket.send(
+        JSON.stringify({ type: "presence", focusWordID, field, draft }),
+      );
+    },
+    [],
+  );
+
+  // 排除自己這條連線
+  const others = presence.filter((p) => p.connID !== connID);
+
+  return { others, sendPresence };
+};
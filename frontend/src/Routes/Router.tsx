@@ -15,6 +15,7 @@ import FetchWords from "../Components/FetchWrapper/FetchWords";
 import FullWordCard from "../Components/FullWordCard";
 import MultiChoice from "../Components/MultiChoice";
 import Cloze from "../Components/Cloze";
+import Learn from "../Components/Learn";
 import FetchWordSetCard from "../Components/FetchWrapper/FetchWordSetCard";
 import FetchRecentVisit from "../Components/FetchWrapper/FetchRecentVisit";
 import CreateFeedback from "../Components/CreateFeedback";
@@ -65,6 +66,7 @@ export const router = createBrowserRouter(
             <Route path="wordCard" element={<FullWordCard />} />
             <Route path="multiChoice" element={<MultiChoice />} />
             <Route path="cloze" element={<Cloze />} />
+            <Route path="learn" element={<Learn />} />
           </Route>
         </Route>
       </Route>
@@ -1,4 +1,13 @@
-import { EditWordSetType, Word, WordSetType } from "./types";
+import {
+  CardTemplate,
+  DuplicatePolicy,
+  EditWordSetType,
+  Visibility,
+  Word,
+  WordExample,
+  WordMeaning,
+  WordSetType,
+} from "./types";
 
 export interface AccountPasswordLogInRequest {
   userEmail: string;
@@ -23,25 +32,68 @@ export interface OAuthRegisterRequest {
 export interface ToggleLikeWordSetRequest {
   userID: string;
   wordSetID: string;
+  shareToken?: string; // 透過分享連結看到的單字集要帶上
 }
 
 export interface ForkWordSetRequest {
   userID: string;
   wordSetID: string;
+  shareToken?: string;
 }
 
 export interface DeleteWordSetRequest {
   wordSetID: string;
 }
 
+// 星號是每個使用者自己的
 export interface ToggleWordStarRequest {
+  userID: string;
   wordSetID: string;
   wordID: string;
+  shareToken?: string;
 }
 
 export interface ToggleAllWordStarRequest {
+  userID: string;
   wordSetID: string;
   newStar: boolean;
+  shareToken?: string;
+}
+
+export interface StudyResult {
+  wordSetID: string;
+  wordID: string;
+  correct: boolean;
+}
+
+// 判定填充題的作答，expected是題目的答案
+export interface GradeAnswerRequest {
+  expected: string;
+  answer: string;
+  leniency?: "strict" | "normal" | "lenient";
+}
+
+// 開始或接著上次的Learn進度
+export interface StartLearnSessionRequest {
+  wordSetID: string;
+  restart: boolean;
+  shareToken?: string;
+}
+
+// 選擇題帶choiceIndex，打字作答帶answer
+export interface AnswerLearnQuestionRequest {
+  wordSetID: string;
+  questionID: string;
+  choiceIndex?: number;
+  answer?: string;
+  shareToken?: string;
+}
+
+// 回報練習時的作答結果，用來組複習牌組
+export interface RecordStudyResultsRequest {
+  userID: string;
+  shareToken?: string;
+  results: StudyResult[];
 }
 
 export interface InlineUpdateWordRequest {
@@ -49,6 +101,7 @@ export interface InlineUpdateWordRequest {
   wordID: string;
   newVocabulary: string;
   newDefinition: string;
+  version: number;
 }
 
 export interface BigWordCardUpdateWordRequest {
@@ -58,11 +111,37 @@ export interface BigWordCardUpdateWordRequest {
   newDefinition: string;
   newVocabularySound: string;
   newDefinitionSound: string;
+  newPronunciation?: string;
+  newPartOfSpeech?: string;
+  newMeanings?: WordMeaning[];
+  newExamples?: WordExample[];
+  newFields?: Record<string, string>; // 空字串代表清除
+  version: number;
+}
+
+export interface RemoveWordImageRequest {
+  wordSetID: string;
+  wordID: string;
+  version: number;
+}
+
+export interface RemoveWordAudioRequest {
+  wordSetID: string;
+  wordID: string;
+  side: "vocabulary" | "definition";
+  version: number;
+}
+
+export interface SetCardTemplateRequest {
+  wordSetID: string;
+  version: number;
+  template: CardTemplate | null; // null代表恢復預設模板
 }
 
 export interface AddWordRequest {
   wordSetID: string;
   word: Word;
+  version: number;
 }
 
 export interface ChangeUserNameRequest {
@@ -85,6 +164,7 @@ export interface EditWordSetRequest {
   addWords: Word[];
   wordSet: EditWordSetType;
   removeWords: string[];
+  version: number; // 編輯前的單字集版本號
 }
 
 export interface ReadMailRequest {
@@ -106,6 +186,36 @@ export interface ToggleIsPublicRequest {
   wordSetID: string;
 }
 
+export interface SetWordSetVisibilityRequest {
+  userID: string;
+  wordSetID: string;
+  visibility: Visibility;
+}
+
+export interface SetDuplicatePolicyRequest {
+  userID: string;
+  wordSetID: string;
+  duplicatePolicy: DuplicatePolicy;
+}
+
+export interface CreateShareLinkRequest {
+  userID: string;
+  wordSetID: string;
+}
+
+export interface RevokeShareLinksRequest {
+  userID: string;
+  wordSetID: string;
+}
+
+export interface PullUpstreamRequest {
+  userID: string;
+  wordSetID: string;
+  version: number;
+  resolutions: Record<string, "upstream" | "local">; // 衝突的wordID要保留哪一邊
+  shareToken?: string; // 來源是透過分享連結看到的要帶上
+}
+
 export interface CreateFeedbackRequest {
   authorID: string;
   title: string;
@@ -1,4 +1,4 @@
-import { FeedBackCard, HomePageWordSet, Word } from "./types";
+import { CardTemplate, FeedBackCard, HomePageWordSet, Word } from "./types";
 
 /*Strict typing for APIResponse*/
 export interface APIResponseSuccess<T = any> {
@@ -59,6 +59,8 @@ export interface FullWordCardType {
   title: string;
   words: Word[];
   shouldSwap: boolean; // 用來給前端展示是否要swap
+  wordSetIDs?: Record<string, string>; // 複習牌組的單字來自不同單字集，wordID對應來源單字集
+  template: CardTemplate; // 實際使用的卡片模板
 }
 
 export interface SearchWordSetCardResponse {
@@ -97,3 +99,37 @@ export interface FeedbackResponse {
   feedbacks: FeedBackCard[];
   haveMore: boolean;
 }
+
+// Learn模式，單字從選擇題(recognition)升到打字作答(recall)，再答對就學會(mastered)
+export type LearnStage = "recognition" | "recall" | "mastered";
+
+export interface LearnQuestion {
+  id: string;
+  wordID: string;
+  stage: LearnStage;
+  prompt: string;
+  promptSound: string;
+  answerSound: string;
+  choices?: string[]; // 選擇題的選項
+}
+
+export interface LearnSession {
+  id: string;
+  wordSetID: string;
+  current?: LearnQuestion; // 全部學會後沒有題目
+  progress: {
+    total: number;
+    recognition: number;
+    recall: number;
+    mastered: number;
+    completed: boolean;
+  };
+  answered: number;
+  correctCnt: number;
+}
+
+export interface LearnAnswerResponse {
+  correct: boolean;
+  answer: string; // 正確答案
+  session: LearnSession;
+}
@@ -45,6 +45,7 @@ export interface WordSetType {
   id: string;
   title: string;
   description: string;
+  descriptionHtml?: string; // 後端把description的Markdown轉好的HTML
   authorID: string; // 原作者
   createdAt: string;
   updatedAt: number;
@@ -55,6 +56,62 @@ export interface WordSetType {
   wordCnt: number;
   allowCopy: boolean; // 允許他人複製衍生
   isPublic: boolean; // 是否在首頁發布
+  visibility: Visibility; // 公開範圍
+  version: number; // 每次修改+1，送出修改時要帶上
+  updatedBy?: string; // 最後修改的使用者
+  collaborators?: Collaborator[]; // 作者邀請的協作者
+  forkedFrom?: ForkSource; // 從哪個單字集複製來的
+  duplicatePolicy?: DuplicatePolicy; // 沒有就是warn
+  template?: CardTemplate; // 卡片模板，沒有就是預設的正反面
+}
+
+// 卡片模板的欄位，vocabulary跟definition是內建欄位
+export interface TemplateField {
+  key: string;
+  label: string;
+  sound?: string; // 自訂欄位的發音語言
+}
+
+// 卡片模板，front/back是正反面要顯示的欄位key
+export interface CardTemplate {
+  fields: TemplateField[];
+  front: string[];
+  back: string[];
+}
+
+// 複製來源，version是上次同步時來源的版本
+export interface ForkSource {
+  wordSetID: string;
+  authorID: string;
+  title: string;
+  version: number;
+  forkedAt: number;
+  syncedAt: number;
+}
+
+// 原始單字集跟自己之間一個單字的差異，conflict代表兩邊都改過
+export interface UpstreamWordChange {
+  wordID: string;
+  kind: "added" | "updated" | "removed";
+  conflict: boolean;
+  base?: Word;
+  upstream?: Word;
+  local?: Word;
+}
+
+export interface UpstreamChanges {
+  sourceWordSetID: string;
+  baseVersion: number;
+  sourceVersion: number;
+  changes: UpstreamWordChange[];
+}
+
+// 單字集協作者，editor可以編輯內容，viewer只能查看
+export interface Collaborator {
+  userID: string;
+  role: "editor" | "viewer";
+  addedBy: string;
+  addedAt: number;
 }
 
 // word, for display words in WordCard
@@ -63,9 +120,44 @@ export interface Word {
   order: number;
   vocabulary: string;
   definition: string;
+  definitionHtml?: string; // 後端把definition的Markdown轉好的HTML
+  vocabularyHtml?: string; // 只有前端交換vocabulary/definition之後才會有
   vocabularySound: string;
   definitionSound: string;
   star: boolean;
+  pronunciation?: string; // 音標或拼音
+  partOfSpeech?: string;
+  meanings?: WordMeaning[]; // 其他意思，definition是主要的意思
+  examples?: WordExample[];
+  fields?: Record<string, string>; // 模板自訂欄位的值
+  image?: WordImage; // 透過uploadWordImage上傳
+  vocabularyAudio?: WordAudio; // 錄好的發音，有的話優先於TTS
+  definitionAudio?: WordAudio;
+}
+
+// 單字的圖片，url可能是相對於後端的路徑，顯示前要經過mediaURL
+export interface WordImage {
+  url: string;
+  thumbnailUrl: string;
+  width: number;
+  height: number;
+}
+
+// 單字某一面上傳的發音，url跟圖片一樣要經過mediaURL
+export interface WordAudio {
+  url: string;
+  contentType: string;
+  duration: number; // 秒
+}
+
+export interface WordMeaning {
+  partOfSpeech?: string;
+  definition: string;
+}
+
+export interface WordExample {
+  sentence: string;
+  translation?: string;
 }
 
 export interface NoticeDisplay {
@@ -107,7 +199,9 @@ export interface multiChoice {
 
 export interface multiChoiceQuestion {
   q: [string, string]; // 一個是題目/另一個是發音
+  qAudio?: WordAudio; // 題目上傳的發音
   choices: multiChoice[];
+  wordID?: string; // 回報作答結果用，舊的localStorage題目沒有
 }
 
 export interface multiChoiceRecordType {
@@ -148,7 +242,50 @@ export interface ImportWord {
   definition: string;
 }
 
-export interface Sound {
-  EngName: string;
-  TwName: string;
+// 後端language registry裡的語言，單字的sound欄位存的就是tag
+export interface Language {
+  tag: string; // BCP-47
+  name: string;
+  nativeName: string;
+  script: string;
+  direction: "ltr" | "rtl";
+  voice?: string; // 預設的TTS聲音名稱
+}
+
+// 即時協作WebSocket已經寫進DB的單字變更
+export interface LiveOp {
+  type: "add" | "update" | "remove" | "reorder";
+  wordID?: string;
+  word?: Word; // add、update之後完整的單字
+  order?: string[]; // reorder
+}
+
+// 即時協作中線上的人以及正在編輯的位置
+export interface LivePresence {
+  connID: string;
+  userID: string;
+  name: string;
+  role: string;
+  focusWordID?: string;
+  field?: string;
+  draft?: string; // 還沒送出的輸入內容
+}
+
+// 即時協作server送來的訊息
+export interface LiveServerMessage {
+  type: "welcome" | "snapshot" | "op" | "presence" | "error" | "pong";
+  seq?: number; // 單字集版本號
+  clientOpID?: string;
+  userID?: string;
+  connID?: string;
+  op?: LiveOp;
+  wordSet?: WordSetType;
+  presence?: LivePresence[];
+  error?: { message: string; code?: string };
 }
+
+// 單字集公開範圍: private只有作者跟協作者、unlisted拿到分享連結的人、public任何人
+export type Visibility = "private" | "unlisted" | "public";
+
+// 重複單字設定，warn只提醒，reject直接拒絕完全相同的單字
+export type DuplicatePolicy = "warn" | "reject";
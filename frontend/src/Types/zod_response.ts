@@ -33,14 +33,94 @@ export const HomePageWordSet_ZOD = z.object({
   wordCnt: z.number(),
 });
 
+const WordAudioSchema = z.object({
+  url: z.string(),
+  contentType: z.string(),
+  duration: z.number(),
+});
+
 export const Word = z.object({
   id: z.string(),
   order: z.number(),
   vocabulary: z.string(),
   definition: z.string(),
+  definitionHtml: z.string().optional(),
   vocabularySound: z.string(),
   definitionSound: z.string(),
   star: z.boolean(),
+  pronunciation: z.string().optional(),
+  partOfSpeech: z.string().optional(),
+  meanings: z
+    .array(
+      z.object({
+        partOfSpeech: z.string().optional(),
+        definition: z.string(),
+      }),
+    )
+    .optional(),
+  examples: z
+    .array(
+      z.object({
+        sentence: z.string(),
+        translation: z.string().optional(),
+      }),
+    )
+    .optional(),
+  fields: z.record(z.string(), z.string()).optional(),
+  image: z
+    .object({
+      url: z.string(),
+      thumbnailUrl: z.string(),
+      width: z.number(),
+      height: z.number(),
+    })
+    .optional(),
+  vocabularyAudio: WordAudioSchema.optional(),
+  definitionAudio: WordAudioSchema.optional(),
+});
+
+export const CardTemplateSchema = z.object({
+  fields: z.array(
+    z.object({
+      key: z.string(),
+      label: z.string(),
+      sound: z.string().optional(),
+    }),
+  ),
+  front: z.array(z.string()),
+  back: z.array(z.string()),
+});
+
+export const Collaborator = z.object({
+  userID: z.string(),
+  role: z.union([z.literal("editor"), z.literal("viewer")]),
+  addedBy: z.string(),
+  addedAt: z.number(),
+});
+
+export const ForkSource = z.object({
+  wordSetID: z.string(),
+  authorID: z.string(),
+  title: z.string(),
+  version: z.number(),
+  forkedAt: z.number(),
+  syncedAt: z.number(),
+});
+
+export const UpstreamChangesSchema = z.object({
+  sourceWordSetID: z.string(),
+  baseVersion: z.number(),
+  sourceVersion: z.number(),
+  changes: z.array(
+    z.object({
+      wordID: z.string(),
+      kind: z.enum(["added", "updated", "removed"]),
+      conflict: z.boolean(),
+      base: Word.optional(),
+      upstream: Word.optional(),
+      local: Word.optional(),
+    }),
+  ),
 });
 
 export const WordSetCard = z.object({
@@ -52,3 +132,56 @@ export const WordSetCard = z.object({
   wordCnt: z.number(),
   likes: z.number(),
 });
+
+export const GradeAnswerResponseSchema = z.object({
+  result: z.enum(["correct", "almost", "wrong"]),
+  answer: z.string(),
+  answers: z.array(z.string()),
+  distance: z.number(),
+  diff: z.array(
+    z.object({
+      op: z.enum(["equal", "missing", "extra"]),
+      text: z.string(),
+    }),
+  ),
+});
+
+const LearnQuestionSchema = z.object({
+  id: z.string(),
+  wordID: z.string(),
+  stage: z.enum(["recognition", "recall", "mastered"]),
+  prompt: z.string(),
+  promptSound: z.string(),
+  answerSound: z.string(),
+  choices: z.array(z.string()).optional(),
+});
+
+export const LearnSessionSchema = z.object({
+  id: z.string(),
+  wordSetID: z.string(),
+  current: LearnQuestionSchema.optional(),
+  progress: z.object({
+    total: z.number(),
+    recognition: z.number(),
+    recall: z.number(),
+    mastered: z.number(),
+    completed: z.boolean(),
+  }),
+  answered: z.number(),
+  correctCnt: z.number(),
+});
+
+export const LearnAnswerResponseSchema = z.object({
+  correct: z.boolean(),
+  answer: z.string(),
+  session: LearnSessionSchema,
+});
+
+export const LanguageSchema = z.object({
+  tag: z.string(),
+  name: z.string(),
+  nativeName: z.string(),
+  script: z.string(),
+  direction: z.enum(["ltr", "rtl"]),
+  voice: z.string().optional(),
+});
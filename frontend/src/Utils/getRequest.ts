@@ -13,22 +13,20 @@ export const getRequest = async <T>(
       credentials: "include",
     });
 
-    if (!response.ok) {
-      // HTTP error (non-2xx)
-      throw {
-        type: "Error",
-        payload: { message: "連線問題! 請重試" },
-      };
-    }
     // 正確做法就是不做type assertion給API date，而是接收到後再進行驗證
+    // 後端的錯誤也會帶有對應的HTTP status(4xx/5xx)，所以先解析JSON再判斷
     let data: unknown;
     try {
       data = await response.json();
     } catch (e) {
-      // Invalid JSON
+      // Invalid JSON，non-2xx多半是連線或proxy出錯
       throw {
         type: "Error",
-        payload: { message: `回應格式錯誤，可能不是 JSON。` },
+        payload: {
+          message: response.ok
+            ? "回應格式錯誤，可能不是 JSON。"
+            : "連線問題! 請重試",
+        },
       };
     }
 
@@ -17,21 +17,19 @@ export const postRequest = async <T>(url: string, request: T): Promise<any> => {
       body: isFormData ? request : JSON.stringify(request),
       credentials: "include",
     });
-    if (!response.ok) {
-      // response.ok is true for status codes 200-299
-      throw {
-        type: "Error",
-        payload: { message: "連線問題! 請重試" },
-      };
-    }
+    // 後端的錯誤也會帶有對應的HTTP status(4xx/5xx)，所以先解析JSON再判斷
     let data: unknown;
     try {
       data = await response.json();
     } catch (e) {
-      // Invalid JSON
+      // Invalid JSON，non-2xx多半是連線或proxy出錯
       throw {
         type: "Error",
-        payload: { message: "回應格式錯誤，可能不是 JSON。" },
+        payload: {
+          message: response.ok
+            ? "回應格式錯誤，可能不是 JSON。"
+            : "連線問題! 請重試",
+        },
       };
     }
     const apiResponse = data as APIResponse;
@@ -0,0 +1,24 @@
+import { PATH } from "../Consts/consts";
+import { RecordStudyResultsRequest } from "../Types/request";
+import { FullWordCardType } from "../Types/response";
+import { postRequest } from "./postRequest";
+import { getShareToken } from "./utils";
+
+// 回報一題的作答結果，給後端組答錯/該複習的複習牌組
+// 沒登入就不回報，失敗也不影響練習
+export const recordStudyResult = (
+  wordSet: FullWordCardType,
+  userID: string | undefined,
+  wordID: string | undefined,
+  correct: boolean,
+) => {
+  if (!userID || !wordID) return;
+  const wordSetID = wordSet.wordSetIDs?.[wordID] ?? wordSet.id;
+  postRequest(`${PATH}/recordStudyResults`, {
+    userID: userID,
+    shareToken: getShareToken(wordSetID),
+    results: [{ wordSetID: wordSetID, wordID: wordID, correct: correct }],
+  } as RecordStudyResultsRequest).catch((error) => {
+    console.log("record study result failed", error);
+  });
+};
@@ -1,4 +1,135 @@
-import { soundArray } from "../Consts/consts";
+import { PATH, partsOfSpeech } from "../Consts/consts";
+import { getLanguages } from "../Hooks/useLanguages";
+import { CardTemplate, Word, WordAudio, WordSetType } from "../Types/types";
+
+// 作者本人或editor協作者可以編輯單字集內容
+export const canEditWordSet = (
+  wordSet: WordSetType,
+  userID: string | undefined,
+): boolean => {
+  if (!userID) return false;
+  return (
+    wordSet.authorID === userID ||
+    (wordSet.collaborators ?? []).some(
+      (c) => c.userID === userID && c.role === "editor",
+    )
+  );
+};
+
+// 透過分享連結(?share=)進來的token，存在sessionStorage，之後同一個單字集的請求都帶上
+const shareTokenKey = (wordSetID: string) => `shareToken:${wordSetID}`;
+
+export const rememberShareToken = (wordSetID: string, token: string | null) => {
+  if (token) window.sessionStorage.setItem(shareTokenKey(wordSetID), token);
+};
+
+export const getShareToken = (wordSetID: string): string | undefined =>
+  window.sessionStorage.getItem(shareTokenKey(wordSetID)) ?? undefined;
+
+// 在url後面加上share query param(沒有token就原樣回傳)
+export const withShareToken = (url: string, wordSetID: string): string => {
+  const token = getShareToken(wordSetID);
+  if (!token) return url;
+  return `${url}${url.includes("?") ? "&" : "?"}share=${encodeURIComponent(token)}`;
+};
+
+// 複習牌組的id開頭是review-，例如review-starred、review-missed-7
+export const isReviewDeck = (id: string): boolean => id.startsWith("review-");
+
+// 練習頁面要抓單字的url，複習牌組由後端依規則組出來
+export const gameWordsURL = (wordSetID: string): string => {
+  if (!isReviewDeck(wordSetID)) {
+    return withShareToken(`${PATH}/getWords/${wordSetID}`, wordSetID);
+  }
+  const [rule, days] = wordSetID.slice("review-".length).split("-");
+  return `${PATH}/getReviewDeck/${rule}${days ? `?days=${days}` : ""}`;
+};
+
+// 上傳檔案的網址，後端回傳的是/media開頭的相對路徑時要接上後端網址
+export const mediaURL = (url: string): string =>
+  url.startsWith("/") ? `${PATH}${url}` : url;
+
+// 詞性的中文名稱，沒對應到的就原樣顯示
+export const partOfSpeechName = (value: string): string =>
+  partsOfSpeech.find((pos) => pos.value === value)?.TwName ?? value;
+
+// 把例句裡的答案挖空當作填充題的題目，例句裡沒有答案就回傳null
+export const clozeSentence = (
+  sentence: string,
+  answer: string,
+): string | null => {
+  const target = answer.trim();
+  if (target === "") return null;
+  const pattern = new RegExp(
+    target.replace(/[.*+?^${}()|[\]\\]/g, "\\$&"),
+    "gi",
+  );
+  if (!pattern.test(sentence)) return null;
+  return sentence.replace(pattern, "_____");
+};
+
+export interface CardFaceEntry {
+  label: string;
+  text: string;
+  sound: string;
+  audio?: WordAudio; // 上傳的發音，有的話不用TTS
+  html?: string; // 後端Markdown轉好的HTML(目前只有definition)
+}
+
+// 卡片某一面要顯示的欄位，空的欄位不顯示
+// swapped是前端已經把vocabulary/definition交換過的單字，內建欄位要換回原本的值
+export const cardFace = (
+  word: Word,
+  template: CardTemplate,
+  side: "front" | "back",
+  swapped: boolean,
+): CardFaceEntry[] => {
+  const entries: CardFaceEntry[] = [];
+  for (const key of template[side]) {
+    const field = template.fields.find((f) => f.key === key);
+    if (field === undefined) continue;
+    let entry: CardFaceEntry;
+    if (key === "vocabulary" || key === "definition") {
+      const isVocabulary = (key === "vocabulary") !== swapped;
+      entry = {
+        label: field.label,
+        text: isVocabulary ? word.vocabulary : word.definition,
+        sound: isVocabulary ? word.vocabularySound : word.definitionSound,
+        audio: isVocabulary ? word.vocabularyAudio : word.definitionAudio,
+        html: isVocabulary ? word.vocabularyHtml : word.definitionHtml,
+      };
+    } else {
+      entry = {
+        label: field.label,
+        text: word.fields?.[key] ?? "",
+        sound: field.sound ?? "",
+      };
+    }
+    if (entry.text !== "") entries.push(entry);
+  }
+  return entries;
+};
+
+// 沒有設定模板時的預設模板，跟後端一樣正面definition、背面vocabulary，shouldSwap時相反
+export const defaultCardTemplate = (shouldSwap: boolean): CardTemplate => {
+  const template: CardTemplate = {
+    fields: [
+      { key: "vocabulary", label: "單字" },
+      { key: "definition", label: "註釋" },
+    ],
+    front: ["definition"],
+    back: ["vocabulary"],
+  };
+  return shouldSwap
+    ? { ...template, front: template.back, back: template.front }
+    : template;
+};
+
+// 播放卡片一面的發音，用第一個有設定發音的欄位
+export const speakCardFace = (entries: CardFaceEntry[]): void => {
+  const entry = entries.find((e) => e.sound !== "") ?? entries[0];
+  if (entry !== undefined) Speaker(entry.text, entry.sound, entry.audio);
+};
 
 // date convert(Unix time to formatted yyyy/mm/dd)
 export const formatTime = (unixTime: number): string => {
@@ -46,13 +177,67 @@ export const getRandomInt = (min: number, max: number): number => {
 };
 
 // for sound
+// 優先用語言設定的預設聲音，瀏覽器沒有的話用第一個同語言的聲音
+const pickVoice = (language: string): SpeechSynthesisVoice | undefined => {
+  const voices = speechSynthesis.getVoices();
+  const preferred = getLanguages().find((l) => l.tag === language)?.voice;
+  return (
+    voices.find((v) => preferred !== undefined && v.name === preferred) ??
+    voices.find((v) => v.lang === language)
+  );
+};
+
+// 正在播放的上傳發音，停止自動播放時要一起停
+let playingAudio: HTMLAudioElement | null = null;
+
+// 播放上傳的發音，載入失敗時reject讓呼叫的人改用TTS
+const playAudio = (audio: WordAudio): Promise<void> => {
+  return new Promise((resolve, reject) => {
+    const element = new Audio(mediaURL(audio.url));
+    playingAudio = element;
+    element.onended = () => {
+      playingAudio = null;
+      resolve();
+    };
+    element.onpause = () => {
+      if (element.ended) return;
+      playingAudio = null;
+      reject(new Error("Audio was paused"));
+    };
+    element.onerror = () => {
+      playingAudio = null;
+      reject(new Error("Audio failed to load"));
+    };
+    element.play().catch(reject);
+  });
+};
+
+// 停止TTS以及上傳的發音
+export const stopSpeaking = (): void => {
+  speechSynthesis.cancel();
+  if (playingAudio) {
+    playingAudio.pause();
+    playingAudio = null;
+  }
+};
+
 // Speaker function returning a Promise
 // Speaker function that supports cancellation
-export const AutoPlaySpeaker = (
+export const AutoPlaySpeaker = async (
   word: string,
   language: string,
   speechRef: React.RefObject<SpeechSynthesisUtterance | null>,
+  audio?: WordAudio,
 ): Promise<void> => {
+  if (audio) {
+    try {
+      await playAudio(audio);
+      return;
+    } catch (error) {
+      // 被停止就不要再念，載入失敗才改用TTS
+      if ((error as Error).message === "Audio was paused") throw error;
+    }
+  }
   return new Promise((resolve, reject) => {
     const utterance = new SpeechSynthesisUtterance(word);
     utterance.lang = language;
@@ -60,9 +245,7 @@ export const AutoPlaySpeaker = (
     // Store the speech instance in a ref
     speechRef.current = utterance;
 
-    // Get available voices
-    const voices = speechSynthesis.getVoices();
-    const voice = voices.find((v) => v.lang === language);
+    const voice = pickVoice(language);
     if (voice) utterance.voice = voice;
 
     // Resolve when speech finishes
@@ -81,18 +264,48 @@ export const AutoPlaySpeaker = (
   });
 };
 
-export const Speaker = (word: string, language: string): void => {
+export const Speaker = (
+  word: string,
+  language: string,
+  audio?: WordAudio,
+): void => {
+  if (audio) {
+    playAudio(audio).catch((error: Error) => {
+      if (error.message !== "Audio was paused") Speaker(word, language);
+    });
+    return;
+  }
   const utterance = new SpeechSynthesisUtterance(word);
   utterance.lang = language;
 
-  // Get available voices
-  const voices = speechSynthesis.getVoices();
-  const voice = voices.find((v) => v.lang === language);
+  const voice = pickVoice(language);
   if (voice) utterance.voice = voice;
 
   speechSynthesis.speak(utterance);
 };
 
+// 依序念單字跟註釋，有上傳的發音就用上傳的
+export const speakWord = (word: Word): void => {
+  const speechRef: React.RefObject<SpeechSynthesisUtterance | null> = {
+    current: null,
+  };
+  AutoPlaySpeaker(
+    word.vocabulary,
+    word.vocabularySound,
+    speechRef,
+    word.vocabularyAudio,
+  )
+    .then(() =>
+      AutoPlaySpeaker(
+        word.definition,
+        word.definitionSound,
+        speechRef,
+        word.definitionAudio,
+      ),
+    )
+    .catch(() => {});
+};
+
 export const textCount = (text: string): number => {
   return text.length;
 };
@@ -116,8 +329,12 @@ export const isValidName = (name: string): boolean => {
   return /^[a-zA-Z0-9_]+$/.test(name);
 };
 
+// 依語言設定決定文字方向，沒有設定發音的欄位交給瀏覽器判斷
+export const textDirection = (sound: string): "ltr" | "rtl" | "auto" =>
+  getLanguages().find((l) => l.tag === sound)?.direction ?? "auto";
+
 export const isValidSound = (sound: string): boolean => {
-  return soundArray.find((s) => s.EngName === sound) !== undefined;
+  return getLanguages().find((l) => l.tag === sound) !== undefined;
 };
 
 export const shuffleArray = <T>(array: T[]): T[] => {